@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/rewrite"
+	"github.com/valerioTomassi/todototum/internal/todo"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	rootCmd.AddCommand(assignCmd)
+	fs := assignCmd.Flags()
+	fs.StringP("path", "p", ".", "Directory path to scan")
+	fs.String("ignore", "", "Comma-separated list of directory names to skip")
+	fs.Bool("from-blame", false, "Take each item's owner from the author git blame reports for its line")
+	fs.String("map", "", "YAML file mapping blame emails to handles; authors missing from it are skipped")
+	fs.Bool("dry-run", false, "Print a diff of the changes instead of writing them")
+	fs.Bool("no-backup", false, "Do not copy the originals of rewritten files to "+todo.BackupDirName+"/<timestamp>/ (revert then has nothing to restore)")
+	fs.Bool("allow-dirty", false, "Rewrite files even when the git working tree has uncommitted changes")
+	fs.String("manifest", "", "Write the JSON manifest of the changes here instead of into "+todo.BackupDirName+"/<timestamp>/")
+}
+
+// assignCmd writes owners into unassigned items, turning "TODO: x" into
+// "TODO(alice): x".
+var assignCmd = &cobra.Command{
+	Use:   "assign",
+	Short: "Add owners to TODOs that have none",
+	Long: `Scans for items without an owner after their tag and inserts one, e.g.
+"TODO: x" becomes "TODO(alice): x". With --from-blame the owner is the author
+git blame reports for the line: the handle --map gives for their email, or
+the part of the email before '@' when no map is given.
+
+Files are rewritten atomically, keeping indentation and line endings. A line
+that changed since the scan is left alone with a warning. Use --dry-run to
+preview the changes as a diff.
+
+Inside a git repository, assign refuses to run on a working tree with
+uncommitted changes unless --allow-dirty is given. Each file is rewritten
+only if its checksum still matches the scan; one edited in the meantime is
+skipped with a warning. Originals are copied to
+.todototum-backup/<timestamp>/ under --path before any change, unless
+--no-backup is given, and a manifest listing every changed line is written
+there either way (or to --manifest). todototum revert --manifest <file>
+restores the originals.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+
+		p, _ := cmd.Flags().GetString("path")
+		i, _ := cmd.Flags().GetString("ignore")
+		fromBlame, _ := cmd.Flags().GetBool("from-blame")
+		mapFile, _ := cmd.Flags().GetString("map")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		noBackup, _ := cmd.Flags().GetBool("no-backup")
+		allowDirty, _ := cmd.Flags().GetBool("allow-dirty")
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+
+		if !fromBlame {
+			return errors.New("assign needs an owner source: --from-blame")
+		}
+		var handles map[string]string
+		if mapFile != "" {
+			var err error
+			if handles, err = loadAuthorMap(mapFile); err != nil {
+				return err
+			}
+		}
+
+		var session *rewrite.Session
+		if !dryRun {
+			if !allowDirty {
+				if err := rewrite.CheckClean(cmd.Context(), gitRunner, p); err != nil {
+					return fmt.Errorf("%w (or pass --allow-dirty)", err)
+				}
+			}
+			var err error
+			if session, err = rewrite.NewSession(p, "assign", now(), !noBackup); err != nil {
+				return err
+			}
+		}
+
+		opts := todo.DefaultScanOptions()
+		opts.IgnoreDirs = buildIgnoreList(i)
+		sums := rewrite.NewChecksumReader(p)
+		items, err := todo.ScanDirWithOptions(p, opts, sums)
+		if err != nil {
+			return err
+		}
+		s, err := todo.NewScanner(opts)
+		if err != nil {
+			return err
+		}
+		if err := assignFromBlame(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), p, items, handles, s, session, sums); err != nil {
+			return err
+		}
+		if session == nil || len(session.Manifest().Files) == 0 {
+			return nil
+		}
+		written, err := session.WriteManifest(manifestPath)
+		if err != nil {
+			return fmt.Errorf("write manifest: %w", err)
+		}
+		if noBackup {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Manifest written to %s (no backup to revert from)\n", written)
+		} else {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Manifest written to %s; undo with: todototum revert --manifest %s\n", written, written)
+		}
+		return nil
+	},
+}
+
+// loadAuthorMap reads a YAML mapping of author emails to handles. Emails are
+// matched ignoring case.
+func loadAuthorMap(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	handles := make(map[string]string, len(raw))
+	for email, handle := range raw {
+		handles[strings.ToLower(strings.TrimSpace(email))] = strings.TrimSpace(handle)
+	}
+	return handles, nil
+}
+
+// blameHandle returns the handle for email: its entry in handles when a map
+// was given, otherwise the part before '@'.
+func blameHandle(email string, handles map[string]string) (string, bool) {
+	if handles != nil {
+		h, ok := handles[strings.ToLower(email)]
+		return h, ok && h != ""
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return local, local != ""
+}
+
+// assignFromBlame inserts the blamed owner into every unassigned item, file
+// by file, through session, checking each file against its checksum in
+// sums. A nil session is a dry run. Changes go to out, as a diff in a dry
+// run; warnings and unmapped authors go to errOut.
+func assignFromBlame(ctx context.Context, out, errOut io.Writer, dir string, items []todo.Todo, handles map[string]string, s *todo.Scanner, session *rewrite.Session, sums *rewrite.ChecksumReader) error {
+	dryRun := session == nil
+	byFile := make(map[string]map[int][]todo.Todo)
+	var files []string
+	for _, t := range items {
+		if todo.HasAssignee(t) {
+			continue
+		}
+		if byFile[t.File] == nil {
+			byFile[t.File] = make(map[int][]todo.Todo)
+			files = append(files, t.File)
+		}
+		byFile[t.File][t.Line] = append(byFile[t.File][t.Line], t)
+	}
+	sort.Strings(files)
+
+	unmapped := make(map[string]int)
+	assigned, changedFiles := 0, 0
+	for _, file := range files {
+		emails, err := todo.BlameEmails(ctx, gitRunner, dir, filepath.FromSlash(file))
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "warning: %s: git blame: %v; skipped\n", file, err)
+			continue
+		}
+		want := byFile[file]
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		edit := func(n int, line string) (string, bool) {
+			lineItems, ok := want[n]
+			if !ok {
+				return "", false
+			}
+			email, ok := emails[n]
+			if !ok {
+				return "", false
+			}
+			handle, ok := blameHandle(email, handles)
+			if !ok {
+				unmapped[email]++
+				return "", false
+			}
+			// Items sharing the line are assigned right to left, so each
+			// insertion leaves the columns of those before it in place.
+			repl := line
+			for i := len(lineItems) - 1; i >= 0; i-- {
+				next, err := todo.InsertAssignee(repl, lineItems[i], handle, s)
+				if err != nil {
+					_, _ = fmt.Fprintf(errOut, "warning: %s:%d: %v; skipped\n", file, n, err)
+					continue
+				}
+				repl = next
+			}
+			return repl, repl != line
+		}
+		var changes map[int][2]string
+		if dryRun {
+			changes, err = todo.RewriteLines(path, edit, true)
+		} else {
+			sum, _ := sums.Sum(file)
+			changes, err = session.Rewrite(file, sum, edit)
+			if errors.Is(err, rewrite.ErrChanged) {
+				_, _ = fmt.Fprintf(errOut, "warning: %s: %v; skipped\n", file, err)
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		changedFiles++
+		assigned += len(changes)
+		writeAssignChanges(out, file, changes, dryRun)
+	}
+
+	if len(unmapped) > 0 {
+		emails := make([]string, 0, len(unmapped))
+		for e := range unmapped {
+			emails = append(emails, e)
+		}
+		sort.Strings(emails)
+		_, _ = fmt.Fprintln(errOut, "Authors missing from --map (items skipped):")
+		for _, e := range emails {
+			_, _ = fmt.Fprintf(errOut, "  %s (%d)\n", e, unmapped[e])
+		}
+	}
+	verb := "Assigned"
+	if dryRun {
+		verb = "Would assign"
+	}
+	_, _ = fmt.Fprintf(out, "%s %d item(s) in %d file(s)\n", verb, assigned, changedFiles)
+	return nil
+}
+
+// writeAssignChanges prints the changed lines of file, as a unified diff
+// when dryRun is set.
+func writeAssignChanges(w io.Writer, file string, changes map[int][2]string, dryRun bool) {
+	lines := make([]int, 0, len(changes))
+	for n := range changes {
+		lines = append(lines, n)
+	}
+	sort.Ints(lines)
+	if !dryRun {
+		for _, n := range lines {
+			_, _ = fmt.Fprintf(w, "%s:%d: %s\n", file, n, strings.TrimSpace(changes[n][1]))
+		}
+		return
+	}
+	_, _ = fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n", file, file)
+	for _, n := range lines {
+		_, _ = fmt.Fprintf(w, "@@ -%d +%d @@\n-%s\n+%s\n", n, n, changes[n][0], changes[n][1])
+	}
+}
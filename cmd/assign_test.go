@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository in a temp dir with files committed by
+// author email. It skips the test when git is not installed.
+func gitRepo(t *testing.T, email string, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=Ada", "-c", "user.email=" + email, "commit", "-q", "-m", "init"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		c.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestAssign_Command_FromBlame(t *testing.T) {
+	src := "package x\r\n\t// TODO: tidy up\r\n// FIXME(bob): keep\r\n"
+	dir := gitRepo(t, "ada@example.com", map[string]string{"a.go": src})
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame", "--dry-run"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("assign --dry-run: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "-\t// TODO: tidy up\n+\t// TODO(ada): tidy up\n") || !strings.Contains(out, "Would assign 1 item(s) in 1 file(s)") {
+		t.Fatalf("unexpected diff:\n%s", out)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(b) != src {
+		t.Fatalf("dry run changed the file: %q", b)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	want := "package x\r\n\t// TODO(ada): tidy up\r\n// FIXME(bob): keep\r\n"
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(b) != want {
+		t.Fatalf("rewritten = %q, want %q", b, want)
+	}
+}
+
+func TestAssign_Command_MapSkipsUnmapped(t *testing.T) {
+	src := "// TODO: one\n"
+	dir := gitRepo(t, "ada@example.com", map[string]string{"a.go": src})
+	mapFile := filepath.Join(t.TempDir(), "authors.yml")
+	if err := os.WriteFile(mapFile, []byte("grace@example.com: grace\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&errOut)
+	t.Cleanup(func() { rootCmd.SetOut(nil); rootCmd.SetErr(nil) })
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame", "--map", mapFile})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "ada@example.com (1)") || !strings.Contains(out.String(), "Assigned 0 item(s)") {
+		t.Fatalf("unexpected output:\n%s\n%s", out.String(), errOut.String())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(b) != src {
+		t.Fatalf("unmapped item was changed: %q", b)
+	}
+}
+
+func TestAssign_Command_RequiresSource(t *testing.T) {
+	rootCmd.SetArgs([]string{"assign", "--path", t.TempDir()})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--from-blame") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestAssign_Command_RefusesDirtyTree(t *testing.T) {
+	src := "// TODO: one\n"
+	dir := gitRepo(t, "ada@example.com", map[string]string{"a.go": src})
+	if err := os.WriteFile(filepath.Join(dir, "wip.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "uncommitted changes (wip.go)") {
+		t.Fatalf("err = %v, want a dirty tree refusal", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(b) != src {
+		t.Fatalf("a dirty tree was rewritten: %q", b)
+	}
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame", "--allow-dirty"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("assign --allow-dirty: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(b) != "// TODO(ada): one\n" {
+		t.Fatalf("rewritten = %q", b)
+	}
+}
+
+func TestAssign_Command_RevertRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"a.go": "package x\r\n\t// TODO: tidy up\r\n// FIXME: no newline at end",
+		"b.py": "# NOTE: keep\n# BUG: crash\n",
+	}
+	dir := gitRepo(t, "ada@example.com", files)
+	manifest := filepath.Join(t.TempDir(), "changes.json")
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame", "--manifest", manifest})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if !strings.Contains(out.String(), "Assigned 4 item(s) in 2 file(s)") || !strings.Contains(out.String(), "revert --manifest "+manifest) {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, ".todototum-backup", "*", "a.go"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want the original of a.go", backups)
+	}
+	if b, _ := os.ReadFile(backups[0]); string(b) != files["a.go"] {
+		t.Fatalf("backup = %q", b)
+	}
+	data, err := os.ReadFile(manifest)
+	if err != nil || !strings.Contains(string(data), `"after": "\t// TODO(ada): tidy up"`) {
+		t.Fatalf("manifest = %s (%v)", data, err)
+	}
+
+	// The backup neither dirties the tree nor shows up in a scan.
+	c := exec.Command("git", "status", "--porcelain", "--untracked-files=all")
+	c.Dir = dir
+	if st, err := c.Output(); err != nil || strings.Contains(string(st), ".todototum-backup") {
+		t.Fatalf("git status = %q (%v)", st, err)
+	}
+
+	out.Reset()
+	rootCmd.SetArgs([]string{"revert", "--manifest", manifest})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	for name, want := range files {
+		if b, _ := os.ReadFile(filepath.Join(dir, name)); string(b) != want {
+			t.Errorf("%s after revert = %q, want %q", name, b, want)
+		}
+	}
+	if !strings.Contains(out.String(), "Restored 2 of 2 file(s)") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestAssign_Command_NoBackupStillWritesManifest(t *testing.T) {
+	dir := gitRepo(t, "ada@example.com", map[string]string{"a.go": "// TODO: tidy up\n"})
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"assign", "--path", dir, "--from-blame", "--no-backup"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	manifests, _ := filepath.Glob(filepath.Join(dir, ".todototum-backup", "*", "manifest.json"))
+	if len(manifests) != 1 {
+		t.Fatalf("manifests = %v, want one for the run", manifests)
+	}
+	if !strings.Contains(out.String(), "Manifest written to "+manifests[0]+" (no backup to revert from)") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	if backups, _ := filepath.Glob(filepath.Join(dir, ".todototum-backup", "*", "a.go")); len(backups) != 0 {
+		t.Fatalf("backups = %v, want none with --no-backup", backups)
+	}
+	data, err := os.ReadFile(manifests[0])
+	if err != nil || !strings.Contains(string(data), `"after": "// TODO(ada): tidy up"`) {
+		t.Fatalf("manifest = %s (%v)", data, err)
+	}
+}
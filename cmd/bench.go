@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/benchgate"
+)
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	fs := benchCmd.Flags()
+	fs.String("baseline", "internal/todo/testdata/bench-baseline.json", "Baseline JSON file to compare against")
+	fs.String("input", "-", "go test -bench -benchmem output to check; - reads stdin")
+	fs.Float64("threshold", 0, "Tolerated slowdown in percent (default: the baseline's, or 20)")
+	fs.Bool("update", false, "Rewrite the baseline from the input instead of comparing")
+}
+
+// benchCmd is the perf regression gate for CI; it is hidden as it only
+// makes sense in a checkout of this repository.
+var benchCmd = &cobra.Command{
+	Use:    "bench",
+	Short:  "Compare benchmark results against the committed baseline",
+	Hidden: true,
+	Long: `Reads the output of go test -bench -benchmem (repeat runs with -count take
+the median) and compares ns/op and allocs/op of each benchmark in the
+baseline. Exits with code 2, listing the regressions, when any figure grew by
+more than the threshold. Benchmarks missing from the input or the baseline
+are reported but do not fail. With --update, the input becomes the new
+baseline, keeping its threshold.
+
+  go test ./internal/todo -run '^$' -bench . -benchmem -count 5 | todototum bench`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+
+		baselinePath, _ := cmd.Flags().GetString("baseline")
+		input, _ := cmd.Flags().GetString("input")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		update, _ := cmd.Flags().GetBool("update")
+		if threshold < 0 {
+			return errors.New("--threshold must not be negative")
+		}
+
+		var in io.Reader = cmd.InOrStdin()
+		if input != "-" {
+			f, err := os.Open(input)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			in = f
+		}
+		results, err := benchgate.Parse(in)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return errors.New("no benchmark results in the input")
+		}
+
+		w := cmd.OutOrStdout()
+		if update {
+			base := &benchgate.Baseline{Threshold: threshold, Benchmarks: results}
+			if old, err := benchgate.LoadBaseline(baselinePath); err == nil && threshold == 0 {
+				base.Threshold = old.Threshold
+			}
+			f, err := os.Create(baselinePath)
+			if err != nil {
+				return err
+			}
+			if err := benchgate.WriteBaseline(f, base); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(w, "Baseline of %d benchmarks written to %s\n", len(results), baselinePath)
+			return nil
+		}
+
+		base, err := benchgate.LoadBaseline(baselinePath)
+		if err != nil {
+			return err
+		}
+		rep := benchgate.Compare(base, results, threshold)
+		for _, c := range rep.Changes {
+			_, _ = fmt.Fprintln(w, c)
+		}
+		for _, name := range rep.Missing {
+			_, _ = fmt.Fprintf(w, "%s: in the baseline but not in the input\n", name)
+		}
+		for _, name := range rep.New {
+			_, _ = fmt.Fprintf(w, "%s: not in the baseline; run with --update to track it\n", name)
+		}
+		if n := len(rep.Regressions()); n > 0 {
+			return gatingError(fmt.Errorf("%d benchmark figure(s) regressed by more than %g%%", n, rep.Threshold))
+		}
+		return nil
+	},
+}
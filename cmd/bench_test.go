@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBench_Command_GateAndUpdate(t *testing.T) {
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "baseline.json")
+	if err := os.WriteFile(baseline, []byte(`{"threshold": 10, "benchmarks": {"BenchmarkA": {"nsPerOp": 100, "allocsPerOp": 4}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(input string, args ...string) (string, error) {
+		t.Helper()
+		var buf bytes.Buffer
+		rootCmd.SetIn(strings.NewReader(input))
+		rootCmd.SetOut(&buf)
+		t.Cleanup(func() { rootCmd.SetIn(nil); rootCmd.SetOut(nil) })
+		rootCmd.SetArgs(append([]string{"bench", "--baseline", baseline}, args...))
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+
+	out, err := run("BenchmarkA-8 10 105 ns/op 64 B/op 4 allocs/op\n")
+	if err != nil || !strings.Contains(out, "BenchmarkA ns/op: 100 -> 105 (+5.0%)") {
+		t.Fatalf("within threshold: %v\n%s", err, out)
+	}
+
+	out, err = run("BenchmarkA-8 10 125 ns/op 64 B/op 4 allocs/op\nBenchmarkB-8 10 1 ns/op\n")
+	if exitCode(err) != ExitGating || !strings.Contains(out, "(+25.0%) REGRESSION") || !strings.Contains(out, "BenchmarkB: not in the baseline") {
+		t.Fatalf("regression: %v (exit %d)\n%s", err, exitCode(err), out)
+	}
+	if _, err := run("BenchmarkA-8 10 125 ns/op\n", "--threshold", "30"); err != nil {
+		t.Errorf("--threshold 30 should accept +25%%: %v", err)
+	}
+
+	if _, err := run("BenchmarkA-8 10 125 ns/op 64 B/op 5 allocs/op\n", "--update"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(baseline)
+	if !strings.Contains(string(data), `"threshold": 10`) || !strings.Contains(string(data), `"nsPerOp": 125`) {
+		t.Errorf("updated baseline:\n%s", data)
+	}
+
+	if _, err := run("PASS\n"); err == nil {
+		t.Error("input without results should fail")
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/config"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	fs := checkCmd.Flags()
+	fs.StringP("path", "p", ".", "Directory path to scan")
+	fs.String("ignore", "", "Comma-separated list of directory names to skip")
+	fs.String("tags", "", "Comma-separated list of tags to scan for (default: the budgeted tags)")
+	fs.String("budget", "", "Comma-separated per-tag item budgets as TAG=N, e.g. FIXME=0,TODO=50")
+}
+
+// checkCmd is the CI gate: it counts items per tag against budgets and
+// writes no report.
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Fail when a tag has more items than its budget",
+	Long: `Scans --path for the budgeted tags in one pass, counts the items of each
+tag and fails when any tag has more items than its budget allows. Nothing is
+printed when every tag is within budget; otherwise one line per tag over
+budget, such as
+
+  over-budget tag=FIXME count=3 limit=0
+
+Budgets are given as TAG=N with --budget or, like every setting, as a
+budget list in .todototum.yml or the TODOTOTUM_BUDGET variable. The
+config file is shared with scan, whose --budget gate fails the same way
+next to a full report; check reads only its path, ignore, tags and budget
+settings.
+
+Exit codes:
+  0  every tag is within budget
+  1  usage error: invalid flags, budgets or configuration
+  2  a tag is over budget
+  3  the scan itself failed, e.g. --path does not exist`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+		if err := resolveCheckConfig(cmd); err != nil {
+			return err
+		}
+
+		p, _ := cmd.Flags().GetString("path")
+		i, _ := cmd.Flags().GetString("ignore")
+		tagList, _ := cmd.Flags().GetString("tags")
+		budgetSpec, _ := cmd.Flags().GetString("budget")
+
+		budgets, err := todo.ParseBudgets(buildIgnoreList(budgetSpec))
+		if err != nil {
+			return fmt.Errorf("--budget: %w", err)
+		}
+		if len(budgets) == 0 {
+			return errors.New("no budgets; pass --budget, e.g. --budget FIXME=0, or set budget in the config file")
+		}
+		opts := todo.DefaultScanOptions()
+		opts.IgnoreDirs = buildIgnoreList(i)
+		opts.Tags = buildIgnoreList(tagList)
+		if len(opts.Tags) == 0 {
+			opts.Tags = budgets.Tags()
+		}
+		for _, tag := range budgets.Tags() {
+			if !slices.ContainsFunc(opts.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+				return fmt.Errorf("budget for %s, which --tags does not scan for", tag)
+			}
+		}
+
+		items, err := todo.ScanDirWithOptions(p, opts, todo.OSFileReader{})
+		if err != nil {
+			return scanFailed(err)
+		}
+		return budgetError(cmd.OutOrStdout(), items, budgets)
+	},
+}
+
+// resolveCheckConfig validates the config file like scan does, since the two
+// commands share it, then applies the settings check has, and the
+// environment, to the flags of cmd not set on the command line.
+func resolveCheckConfig(cmd *cobra.Command) error {
+	file, err := loadConfigFile(cmd)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(scanCmd.Flags(), file, scanConfigRules()); err != nil {
+		return err
+	}
+	if file != nil {
+		own := &config.File{Path: file.Path, Values: map[string]string{}, Lines: file.Lines, Columns: file.Columns}
+		for k, v := range file.Values {
+			if cmd.Flags().Lookup(k) != nil {
+				own.Values[k] = v
+			}
+		}
+		file = own
+	}
+	_, err = config.Apply(cmd.Flags(), file, os.LookupEnv)
+	return err
+}
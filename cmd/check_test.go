@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCheck(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"check"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestCheck_Command(t *testing.T) {
+	tmp := t.TempDir()
+	src := "// TODO: one\n// TODO: two\n// FIXME: three\n// HACK: not scanned\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCheck(t, "--path", tmp, "--budget", "TODO=2,FIXME=1")
+	if err != nil || out != "" {
+		t.Fatalf("within budget: %v\n%s", err, out)
+	}
+
+	out, err = runCheck(t, "--path", tmp, "--budget", "todo=1,FIXME=0,BUG=0")
+	if exitCode(err) != ExitGating || err.Error() != "2 tag(s) over budget" {
+		t.Fatalf("err = %v (exit %d), want a gating error", err, exitCode(err))
+	}
+	if want := "over-budget tag=FIXME count=1 limit=0\nover-budget tag=TODO count=2 limit=1\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	if _, err := runCheck(t, "--path", tmp); exitCode(err) != ExitUsage || !strings.Contains(err.Error(), "no budgets") {
+		t.Errorf("no budget: err = %v", err)
+	}
+	if _, err := runCheck(t, "--path", tmp, "--budget", "TODO"); exitCode(err) != ExitUsage || !strings.Contains(err.Error(), "want TAG=N") {
+		t.Errorf("malformed budget: err = %v", err)
+	}
+	if _, err := runCheck(t, "--path", tmp, "--tags", "TODO", "--budget", "FIXME=0"); err == nil || !strings.Contains(err.Error(), "--tags does not scan for") {
+		t.Errorf("budget outside --tags: err = %v", err)
+	}
+	if _, err := runCheck(t, "--path", filepath.Join(tmp, "missing"), "--budget", "TODO=0"); exitCode(err) != ExitScanFailed {
+		t.Errorf("missing path: err = %v (exit %d)", err, exitCode(err))
+	}
+}
+
+func TestCheck_Command_SharedConfig(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// FIXME: three\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(t.TempDir(), ".todototum.yml")
+	// Scan settings check has no flag for are accepted and ignored.
+	if err := os.WriteFile(cfg, []byte("report: html\nbudget:\n  - FIXME=0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCheck(t, "--path", tmp, "--config", cfg)
+	if exitCode(err) != ExitGating || out != "over-budget tag=FIXME count=1 limit=0\n" {
+		t.Errorf("config budget: %v\n%s", err, out)
+	}
+
+	t.Setenv("TODOTOTUM_BUDGET", "FIXME=1")
+	if out, err := runCheck(t, "--path", tmp, "--config", cfg); err != nil || out != "" {
+		t.Errorf("TODOTOTUM_BUDGET should override the file: %v\n%s", err, out)
+	}
+
+	if err := os.WriteFile(cfg, []byte("budgett: FIXME=0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCheck(t, "--path", tmp, "--config", cfg); err == nil || !strings.Contains(err.Error(), `did you mean "budget"`) {
+		t.Errorf("misspelt setting: err = %v", err)
+	}
+}
+
+func TestScan_Command_Budget(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: one\n// TODO: two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--budget", "TODO=1"})
+	var err error
+	captureStdout(t, func() { err = rootCmd.Execute() })
+	if exitCode(err) != ExitGating || !strings.Contains(err.Error(), "1 tag(s) over budget") {
+		t.Errorf("err = %v (exit %d)", err, exitCode(err))
+	}
+}
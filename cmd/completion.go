@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/config"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completionCmd generates shell completion scripts. It replaces cobra's
+// default command so the script is written to the command's configured
+// output at run time.
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate a shell completion script",
+	Long: `Prints a completion script for the given shell. Flag values with a fixed
+set (such as --report formats) and the configured --tags are completed too.
+
+  source <(todototum completion bash)`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := cmd.OutOrStdout()
+		root := cmd.Root()
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletionV2(w, true)
+		case "zsh":
+			return root.GenZshCompletion(w)
+		case "fish":
+			return root.GenFishCompletion(w, true)
+		case "powershell":
+			return root.GenPowerShellCompletionWithDesc(w)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+// Fixed value sets offered by shell completion.
+var (
+	reportFormats = []string{"table", "console", "html", "json", "jsonl", "md", "treemap", "github", "codequality", "ics", "badge"}
+	sortKeys      = []string{"file", "age"}
+	groupByKeys   = []string{"tag", "symbol"}
+	jsonShapes    = []string{todo.JSONShapeFlat, todo.JSONShapeNested}
+	badgeStyles   = []string{todo.MarkdownBadgesEmoji, todo.MarkdownBadgesShields}
+	pathDefaults  = []string{pathDefaultCwd, pathDefaultRepo}
+)
+
+// allReportFormats returns reportFormats followed by the formats programs
+// embedding todototum registered with todo.RegisterReporter.
+func allReportFormats() []string {
+	formats := slices.Clone(reportFormats)
+	for _, name := range todo.Reporters() {
+		if !slices.Contains(formats, name) {
+			formats = append(formats, name)
+		}
+	}
+	return formats
+}
+
+// registerScanCompletions registers value completions for the scan flags
+// defined on cmd by addScanFlags.
+func registerScanCompletions(cmd *cobra.Command) {
+	fixed := map[string][]string{
+		"sort":         sortKeys,
+		"md-badges":    badgeStyles,
+		"group-by":     groupByKeys,
+		"json-shape":   jsonShapes,
+		"lang":         todo.Languages(),
+		"detect":       todo.Detectors,
+		"compress":     {todo.CompressGzip},
+		"path-default": pathDefaults,
+	}
+	for name, values := range fixed {
+		_ = cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+	}
+	_ = cmd.RegisterFlagCompletionFunc("report", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return allReportFormats(), cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("tags", completeTags)
+}
+
+// completeTags offers the configured tag list (from the config file or the
+// environment) plus the default tags. Values already typed in a
+// comma-separated list are kept as the completion prefix.
+func completeTags(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	known := append([]string{}, todo.DefaultTags...)
+	configured, ok := os.LookupEnv(config.EnvName("tags"))
+	if !ok {
+		if file, err := loadConfigFile(cmd); err == nil && file != nil {
+			configured = file.Values["tags"]
+		}
+	}
+	known = append(buildIgnoreList(configured), known...)
+
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	typed := map[string]bool{}
+	for _, t := range buildIgnoreList(prefix) {
+		typed[strings.ToUpper(t)] = true
+	}
+	var out []string
+	for _, t := range known {
+		if typed[strings.ToUpper(t)] {
+			continue
+		}
+		typed[strings.ToUpper(t)] = true
+		out = append(out, prefix+t)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
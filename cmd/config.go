@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/valerioTomassi/todototum/internal/config"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// configPath is the --config persistent flag.
+var configPath string
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configDumpCmd)
+	addScanFlags(configDumpCmd.Flags())
+	registerScanCompletions(configDumpCmd)
+	configDumpCmd.Flags().Bool("json", false, "Print the configuration as JSON")
+	configDumpCmd.Flags().String("dir", "", "Show the settings for files in this directory below --path, with the nested .todototum.yml files applied")
+	configCmd.AddCommand(configValidateCmd)
+}
+
+// configCmd groups configuration helpers.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect todototum configuration",
+	Long: `Settings can be given as scan flags, as TODOTOTUM_<FLAG> environment
+variables (e.g. TODOTOTUM_OUT_DIR) or in a .todototum.yml file mapping flag
+names to values. Flags take precedence over the environment, which takes
+precedence over the file.`,
+}
+
+// configDumpCmd prints the effective scan configuration.
+var configDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Print the effective scan configuration",
+	Long: `Prints every scan setting after merging defaults, the config file, the
+environment and any scan flags given to this command, along with where each
+value came from. With --dir, the nested .todototum.yml files between --path
+and that directory are applied too, and settings they supply name their
+file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+		asJSON, _ := cmd.Flags().GetBool("json")
+		dir, _ := cmd.Flags().GetString("dir")
+		res, err := resolveConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if dir != "" {
+			root, _ := cmd.Flags().GetString("path")
+			if res, err = overlayDirConfigs(cmd, res, root, dir); err != nil {
+				return err
+			}
+		}
+		// --json and --dir select the output; they are not scan settings.
+		delete(res.Settings, "json")
+		delete(res.Settings, "dir")
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(res)
+		}
+		w := cmd.OutOrStdout()
+		if res.ConfigFile != "" {
+			_, _ = fmt.Fprintf(w, "# config file: %s\n", res.ConfigFile)
+		}
+		for _, l := range res.Layers {
+			_, _ = fmt.Fprintf(w, "# nested config file: %s\n", l.Path)
+		}
+		names := make([]string, 0, len(res.Settings))
+		for name := range res.Settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s := res.Settings[name]
+			if s.Source == config.SourceDir {
+				_, _ = fmt.Fprintf(w, "%s=%v (%s %s)\n", name, s.Value, s.Source, res.File(name))
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "%s=%v (%s)\n", name, s.Value, s.Source)
+		}
+		return nil
+	},
+}
+
+// configValidateCmd checks the config file without scanning.
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for mistakes",
+	Long: `Checks the config file (--config, or .todototum.yml in the current
+directory) and lists every problem with its line and column: unknown
+settings, values of the wrong type, values outside a fixed set such as
+--report formats, and --error-tags, --warning-tags or --require-owner-for
+entries missing from the file's tags list. Scans run the same checks and
+refuse to start on a file that fails them.`,
+	Args: cobra.NoArgs,
+	// Problems are in the file, not in how the command was invoked.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := loadConfigFile(cmd)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			return errors.New("no config file found; pass --config or create .todototum.yml")
+		}
+		if err := config.Validate(scanCmd.Flags(), file, scanConfigRules()); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: ok\n", file.Path)
+		return nil
+	},
+}
+
+// scanConfigRules returns the constraints on config file values of the
+// scan settings.
+func scanConfigRules() config.Rules {
+	return config.Rules{
+		Enums: map[string][]string{
+			"report":       allReportFormats(),
+			"sort":         sortKeys,
+			"md-badges":    badgeStyles,
+			"group-by":     groupByKeys,
+			"json-shape":   jsonShapes,
+			"lang":         todo.Languages(),
+			"detect":       todo.Detectors,
+			"compress":     {todo.CompressGzip},
+			"path-default": pathDefaults,
+		},
+		TagList: "tags",
+		TagRefs: []string{"error-tags", "warning-tags", "require-owner-for"},
+	}
+}
+
+// resolveConfig validates the config file (see scanConfigRules), then
+// applies it and the environment to the flags of cmd that were not set on
+// the command line and returns the effective settings.
+func resolveConfig(cmd *cobra.Command) (*config.Resolved, error) {
+	file, err := loadConfigFile(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(cmd.Flags(), file, scanConfigRules()); err != nil {
+		return nil, err
+	}
+	return config.Apply(cmd.Flags(), file, os.LookupEnv)
+}
+
+// loadConfigFile reads the file named by --config or its environment
+// variable, falling back to a .todototum.yml in the working directory.
+// It returns nil when no file is configured or found.
+func loadConfigFile(cmd *cobra.Command) (*config.File, error) {
+	p, _ := cmd.Flags().GetString(config.FlagName)
+	if p == "" {
+		p = os.Getenv(config.EnvName(config.FlagName))
+	}
+	if p == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, nil
+		}
+		if p = config.Find(wd); p == "" {
+			return nil, nil
+		}
+	}
+	return config.Load(p)
+}
+
+// dirConfigKeys are the settings a nested .todototum.yml may set for its
+// directory's subtree: those deciding what a file yields. Output, gating and
+// severity settings apply to the whole scan and stay with the root config.
+var dirConfigKeys = []string{"detect", "first-match-only", "respect-ignore-comments", "tags"}
+
+// loadDirConfig returns the nested config file of dir, checked against the
+// scan flags fs like the root file and limited to dirConfigKeys. It returns
+// nil when dir has none or when it is rootConfig, the file already applied
+// to the whole scan.
+func loadDirConfig(fs *pflag.FlagSet, dir, rootConfig string) (*config.File, error) {
+	p := config.Find(dir)
+	if p == "" || sameFile(p, rootConfig) {
+		return nil, nil
+	}
+	file, err := config.Load(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(fs, file, scanConfigRules()); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(file.Values))
+	for k := range file.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !slices.Contains(dirConfigKeys, k) {
+			return nil, fmt.Errorf("%s:%d: %s cannot be set per directory; nested config files may set only %s", p, file.Lines[k], k, strings.Join(dirConfigKeys, ", "))
+		}
+	}
+	return file, nil
+}
+
+// sameFile reports whether paths a and b name the same file.
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	sa, errA := os.Stat(a)
+	sb, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(sa, sb)
+}
+
+// dirOverride converts a nested config file to the override of its
+// directory's scan settings. Settings res got from flags or the environment
+// are left out: they win over nested files as over the root file.
+func dirOverride(file *config.File, res *config.Resolved) *todo.Override {
+	o := &todo.Override{Source: file.Path}
+	value := func(key string) (string, bool) {
+		v, ok := file.Values[key]
+		return v, ok && res.FileMaySet(key)
+	}
+	if v, ok := value("tags"); ok {
+		o.Tags = append([]string{}, buildIgnoreList(v)...)
+	}
+	if v, ok := value("detect"); ok {
+		o.Detect = append([]string{}, buildIgnoreList(v)...)
+	}
+	for key, field := range map[string]**bool{"first-match-only": &o.FirstMatchOnly, "respect-ignore-comments": &o.RespectIgnoreComments} {
+		if v, ok := value(key); ok {
+			b, _ := strconv.ParseBool(v) // checked by loadDirConfig
+			*field = &b
+		}
+	}
+	return o
+}
+
+// overlayDirConfigs applies to res the nested config files of the
+// directories below root down to dir, outermost first. The root itself is
+// left to the root config, as in a scan.
+func overlayDirConfigs(cmd *cobra.Command, res *config.Resolved, root, dir string) (*config.Resolved, error) {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("--dir %s is not inside --path %s", dir, root)
+	}
+	if rel == "." {
+		return res, nil
+	}
+	cur := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+		file, err := loadDirConfig(cmd.Flags(), cur, res.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			res = res.Overlay(cmd.Flags(), file)
+		}
+	}
+	return res, nil
+}
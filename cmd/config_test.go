@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/config"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func TestConfigDump_JSON_MergesFileEnvAndFlags(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "todototum.yml")
+	if err := os.WriteFile(cfg, []byte("report: md\nout-dir: from-file\ntags: [HACK, TODO]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TODOTOTUM_OUT_DIR", "from-env")
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"config", "dump", "--json", "--config", cfg, "--report", "json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("config dump: %v", err)
+	}
+
+	var got config.Resolved
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if got.SchemaVersion != config.SchemaVersion || got.ConfigFile != cfg {
+		t.Fatalf("unexpected header: %+v", got)
+	}
+	checks := map[string]config.Setting{
+		"report":  {Value: "json", Source: config.SourceFlag},
+		"out-dir": {Value: "from-env", Source: config.SourceEnv},
+		"tags":    {Value: "HACK,TODO", Source: config.SourceFile},
+		"keep":    {Value: float64(0), Source: config.SourceDefault},
+	}
+	for name, want := range checks {
+		if s := got.Settings[name]; s != want {
+			t.Errorf("%s = %#v, want %#v", name, s, want)
+		}
+	}
+	for _, name := range []string{"json", "help", "config"} {
+		if _, ok := got.Settings[name]; ok {
+			t.Errorf("%s should not be listed as a setting", name)
+		}
+	}
+}
+
+func TestScan_Command_ConfigFileTags(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// HACK: workaround\n// TODO: later\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(t.TempDir(), "todototum.yml")
+	if err := os.WriteFile(cfg, []byte("tags:\n  - HACK\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--config", cfg})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("scan: %v", err)
+		}
+	})
+	if !strings.Contains(out, "HACK: 1") || strings.Contains(out, "TODO: 1") {
+		t.Fatalf("expected only the configured HACK tag:\n%s", out)
+	}
+}
+
+func TestScan_Command_ConfigFileCommentSyntax(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.pony"), []byte("// TODO: own line\nlet x = 1 // FIXME: trailing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(t.TempDir(), "todototum.yml")
+	if err := os.WriteFile(cfg, []byte("comment-syntax:\n  - .pony://\n  - .pony:/*\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--config", cfg})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("scan: %v", err)
+		}
+	})
+	if !strings.Contains(out, "own-line: 1, trailing: 1") {
+		t.Fatalf("expected the configured syntax to classify positions:\n%s", out)
+	}
+}
+
+func TestCompletion_BashScriptAndFlagValues(t *testing.T) {
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	rootCmd.SetArgs([]string{"completion", "bash"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("completion bash: %v", err)
+	}
+	// Cobra's bash script asks the binary for flag values at completion
+	// time via the hidden __complete command.
+	if !strings.Contains(buf.String(), "__start_todototum") || !strings.Contains(buf.String(), "__complete") {
+		t.Fatalf("unexpected bash script:\n%.300s", buf.String())
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"__complete", "scan", "--report", ""})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("__complete: %v", err)
+	}
+	for _, f := range reportFormats {
+		if !strings.Contains(buf.String(), f+"\n") {
+			t.Fatalf("report format %q not offered:\n%s", f, buf.String())
+		}
+	}
+
+	buf.Reset()
+	t.Setenv("TODOTOTUM_TAGS", "HACK,XXX")
+	rootCmd.SetArgs([]string{"__complete", "scan", "--tags", "HACK,"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("__complete tags: %v", err)
+	}
+	if !strings.Contains(buf.String(), "HACK,XXX\n") || strings.Contains(buf.String(), "HACK,HACK") {
+		t.Fatalf("unexpected tag completions:\n%s", buf.String())
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		t.Helper()
+		p := filepath.Join(dir, "todototum.yml")
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	run := func(args ...string) (string, error) {
+		var buf bytes.Buffer
+		rootCmd.SetOut(&buf)
+		t.Cleanup(func() { rootCmd.SetOut(nil) })
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+
+	cfg := write("report: md\ntags: [TODO, HACK]\nerror-tags: HACK\n")
+	if out, err := run("config", "validate", "--config", cfg); err != nil || out != cfg+": ok\n" {
+		t.Fatalf("clean file: %q, %v", out, err)
+	}
+
+	cfg = write("report: pdf\nserverity: high\ntags: [TODO]\nrequire-owner-for: [FIXME]\n")
+	_, err := run("config", "validate", "--config", cfg)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		cfg + `:1:9: report: invalid value "pdf"`,
+		cfg + `:2:1: serverity: unknown setting "serverity"`,
+		cfg + `:4:21: require-owner-for: tag "FIXME" is not in tags`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q:\n%v", want, err)
+		}
+	}
+	if exitCode(err) != ExitUsage {
+		t.Errorf("exit code = %d, want %d", exitCode(err), ExitUsage)
+	}
+
+	// Scans refuse the same file.
+	_, err = run("scan", "--path", dir, "--config", cfg)
+	if err == nil || !strings.Contains(err.Error(), "serverity") || exitCode(err) != ExitUsage {
+		t.Fatalf("scan with an invalid config: %v", err)
+	}
+}
+
+func TestScan_Command_NestedConfig(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		".todototum.yml":                "tags: [TODO, FIXME]\n",
+		"main.go":                       "// TODO: root\n// FIXME: root\n",
+		"legacy/.todototum.yml":         "tags: [FIXME, BUG]\n",
+		"legacy/old.go":                 "// TODO: hopeless\n// BUG: legacy\n",
+		"legacy/keep/.todototum.yml":    "tags: [TODO, FIXME]\n",
+		"legacy/keep/new.go":            "// TODO: restored\n",
+		"services/pay/.todototum.yml":   "first-match-only: true\n",
+		"services/pay/charge.go":        "// TODO: a FIXME: b\n",
+		"services/pay/refund/refund.go": "// FIXME: c TODO: d\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(tmp)
+
+	out := filepath.Join(t.TempDir(), "r.json")
+	rootCmd.SetArgs([]string{"scan", "--report", "json", "--out", out, "--only", "**/*.go"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Todos []struct{ File, Tag string } `json:"todos"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range parsed.Todos {
+		got = append(got, it.File+" "+it.Tag)
+	}
+	sort.Strings(got)
+	want := []string{"legacy/keep/new.go TODO", "legacy/old.go BUG", "main.go FIXME", "main.go TODO", "services/pay/charge.go TODO", "services/pay/refund/refund.go FIXME"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("items:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"config", "dump", "--dir", filepath.Join("legacy", "keep")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# nested config file: " + filepath.Join("legacy", ".todototum.yml"),
+		"tags=TODO,FIXME (dir " + filepath.Join("legacy", "keep", ".todototum.yml") + ")",
+		"first-match-only=false (default)",
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("dump lacks %q:\n%s", line, buf.String())
+		}
+	}
+
+	// Nested files hold matching settings only.
+	if err := os.WriteFile(filepath.Join(tmp, "legacy", ".todototum.yml"), []byte("report: md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"config", "dump", "--dir", "legacy"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "report cannot be set per directory") {
+		t.Errorf("err = %v, want the per-directory restriction", err)
+	}
+}
+
+func TestScan_Command_NestedConfigPrecedence(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		// Not the config of this run: --config names ci.yml.
+		".todototum.yml":     "tags: [BUG]\nreport: json\nout: x.json\n",
+		"ci.yml":             "tags: [TODO, FIXME]\n",
+		"main.go":            "// TODO: root\n// BUG: root\n",
+		"sub/.todototum.yml": "tags: [TODO]\n",
+		"sub/a.go":           "// TODO: sub\n// FIXME: sub\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(tmp)
+
+	scan := func(args ...string) []string {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "r.json")
+		rootCmd.SetArgs(append([]string{"scan", "--report", "json", "--out", out, "--only", "**/*.go"}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed struct {
+			Todos    []struct{ File, Tag string } `json:"todos"`
+			Problems []todo.Problem               `json:"problems"`
+		}
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		if len(parsed.Problems) != 0 {
+			t.Errorf("%v: problems %+v", args, parsed.Problems)
+		}
+		var got []string
+		for _, it := range parsed.Todos {
+			got = append(got, it.File+" "+it.Tag)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	// The root .todototum.yml is not a nested override of the root.
+	got := scan("--config", "ci.yml")
+	if want := []string{"main.go TODO", "sub/a.go TODO"}; !slices.Equal(got, want) {
+		t.Errorf("--config: items %v, want %v", got, want)
+	}
+	// A flag wins over nested files as over the root file.
+	got = scan("--config", "ci.yml", "--tags", "FIXME")
+	if want := []string{"sub/a.go FIXME"}; !slices.Equal(got, want) {
+		t.Errorf("--tags: items %v, want %v", got, want)
+	}
+}
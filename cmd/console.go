@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+	"golang.org/x/term"
+)
+
+const (
+	// defaultConsoleWidth is used when the terminal width cannot be detected.
+	defaultConsoleWidth = 80
+	// minTextWidth is the narrowest the Text column is squeezed to; tables
+	// wider than the terminal are preferred over one word per line.
+	minTextWidth = 20
+	// maxFileWidth caps the File column so long paths wrap instead of
+	// leaving no room for the text.
+	maxFileWidth = 40
+)
+
+// terminalWidth is a package-level function variable so tests can pin the
+// detected width.
+var terminalWidth = detectTerminalWidth
+
+// detectTerminalWidth returns the width of the terminal on stdout, falling
+// back to $COLUMNS and then defaultConsoleWidth when stdout is not a terminal.
+func detectTerminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultConsoleWidth
+}
+
+// renderConsole writes items as a box-drawn table fitted to width columns,
+// wrapping long file paths and text inside their cells.
+func renderConsole(w io.Writer, items []todo.Todo, width int, lang string) {
+	header := []string{todo.Message(lang, "col.file"), todo.Message(lang, "col.line"), todo.Message(lang, "col.tag"), todo.Message(lang, "col.text")}
+	rows := make([][]string, 0, len(items))
+	for _, t := range items {
+		rows = append(rows, []string{t.File, strconv.Itoa(t.Line), t.Tag, t.Text})
+	}
+
+	widths := make([]int, len(header))
+	for c, h := range header {
+		widths[c] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for c, cell := range row[:3] {
+			widths[c] = max(widths[c], utf8.RuneCountInString(cell))
+		}
+	}
+	widths[0] = min(widths[0], maxFileWidth)
+	// Every column takes two padding spaces plus its left border, and the
+	// row ends with a right border.
+	borders := 3*len(header) + 1
+	widths[3] = max(width-borders-widths[0]-widths[1]-widths[2], minTextWidth)
+
+	line := func(left, mid, right string) {
+		var b strings.Builder
+		b.WriteString(left)
+		for c, cw := range widths {
+			if c > 0 {
+				b.WriteString(mid)
+			}
+			b.WriteString(strings.Repeat("─", cw+2))
+		}
+		b.WriteString(right)
+		fmt.Fprintln(w, b.String())
+	}
+	row := func(cells []string, colorTag bool) {
+		wrapped := make([][]string, len(cells))
+		height := 1
+		for c, cell := range cells {
+			wrapped[c] = wrapText(cell, widths[c])
+			height = max(height, len(wrapped[c]))
+		}
+		for l := 0; l < height; l++ {
+			var b strings.Builder
+			b.WriteString("│")
+			for c := range cells {
+				s := ""
+				if l < len(wrapped[c]) {
+					s = wrapped[c][l]
+				}
+				pad := strings.Repeat(" ", widths[c]-utf8.RuneCountInString(s))
+				if c == 1 {
+					s = pad + s // right-align line numbers
+				} else {
+					s += pad
+				}
+				if c == 2 && colorTag {
+					s = tagColor(cells[2]).Sprint(s)
+				}
+				b.WriteString(" " + s + " │")
+			}
+			fmt.Fprintln(w, b.String())
+		}
+	}
+
+	line("┌", "┬", "┐")
+	row(header, false)
+	line("├", "┼", "┤")
+	for _, r := range rows {
+		row(r, true)
+	}
+	line("└", "┴", "┘")
+}
+
+// wrapText breaks s into lines of at most width runes, at spaces where
+// possible and mid-word otherwise. Whitespace runs collapse to one space.
+func wrapText(s string, width int) []string {
+	words := strings.Fields(s)
+	if len(words) == 0 || width <= 0 {
+		return []string{""}
+	}
+	var lines []string
+	cur := ""
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > width {
+			if cur != "" {
+				lines = append(lines, cur)
+				cur = ""
+			}
+			r := []rune(word)
+			lines = append(lines, string(r[:width]))
+			word = string(r[width:])
+		}
+		switch {
+		case cur == "":
+			cur = word
+		case utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(word) <= width:
+			cur += " " + word
+		default:
+			lines = append(lines, cur)
+			cur = word
+		}
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	return lines
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func TestWrapText(t *testing.T) {
+	cases := []struct {
+		in    string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"wrap these  words please", 10, []string{"wrap these", "words", "please"}},
+		{"abcdefghijkl xy", 5, []string{"abcde", "fghij", "kl xy"}},
+		{"ünïcödé wörds", 7, []string{"ünïcödé", "wörds"}},
+	}
+	for _, c := range cases {
+		if got := wrapText(c.in, c.width); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("wrapText(%q, %d) = %q, want %q", c.in, c.width, got, c.want)
+		}
+	}
+}
+
+func TestRenderConsole_WrapsToWidth(t *testing.T) {
+	old := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = old }()
+
+	items := []todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 7}, Tag: "TODO", Text: strings.Repeat("very long description ", 10)},
+		{Location: todo.Location{File: "b.go", Line: 12}, Tag: "FIXME", Text: "short"},
+	}
+	var buf bytes.Buffer
+	renderConsole(&buf, items, 60, todo.DefaultLang)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for _, l := range lines {
+		if n := utf8.RuneCountInString(l); n != 60 {
+			t.Fatalf("line width %d, want 60: %q\n%s", n, l, buf.String())
+		}
+	}
+	if !strings.HasPrefix(lines[0], "┌") || !strings.HasPrefix(lines[len(lines)-1], "└") {
+		t.Fatalf("missing box corners:\n%s", buf.String())
+	}
+	// Header, two separators, two borders, one line for b.go, several for a.go.
+	if len(lines) < 8 {
+		t.Fatalf("expected wrapped text to span several lines:\n%s", buf.String())
+	}
+}
+
+func TestRenderConsole_MinTextWidth(t *testing.T) {
+	items := []todo.Todo{{Location: todo.Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "text that is wrapped"}}
+	var buf bytes.Buffer
+	renderConsole(&buf, items, 10, todo.DefaultLang)
+	if !strings.Contains(buf.String(), "text that is wrapped") {
+		t.Fatalf("text column should keep at least %d columns:\n%s", minTextWidth, buf.String())
+	}
+}
+
+func TestScan_Command_ConsoleReport(t *testing.T) {
+	tmp := t.TempDir()
+	src := "// TODO: " + strings.Repeat("wrap me ", 20) + "\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldWidth := terminalWidth
+	terminalWidth = func() int { return 50 }
+	defer func() { terminalWidth = oldWidth }()
+
+	run := func(args ...string) string {
+		return captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp, "--report", "console"}, args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+		})
+	}
+	detected := run()
+	if !strings.Contains(detected, "┌") || !strings.Contains(detected, "Summary:") {
+		t.Fatalf("unexpected console output:\n%s", detected)
+	}
+	first := strings.SplitN(detected, "\n", 2)[0]
+	if n := utf8.RuneCountInString(first); n != 50 {
+		t.Fatalf("detected width not used: %d", n)
+	}
+	overridden := strings.SplitN(run("--width", "70"), "\n", 2)[0]
+	if n := utf8.RuneCountInString(overridden); n != 70 {
+		t.Fatalf("--width not used: %d", n)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "console", "--width", "-1"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for negative --width")
+	}
+}
+
+func TestScan_Command_RelativeRoot(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "very", "deep", "tree")
+	for _, sub := range []string{"src", "pkg"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, sub, "a.go"), []byte("// TODO: x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "console", "--width", "80", "--relative-root"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	})
+	if strings.Contains(out, "very/deep") || !strings.Contains(out, "│ src/a.go ") || !strings.Contains(out, "│ pkg/a.go ") {
+		t.Fatalf("common prefix not trimmed:\n%s", out)
+	}
+}
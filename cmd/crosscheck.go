@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/todo"
+	"github.com/valerioTomassi/todototum/internal/tracker"
+)
+
+func init() {
+	rootCmd.AddCommand(crosscheckCmd)
+	fs := crosscheckCmd.Flags()
+	fs.StringP("path", "p", ".", "Directory path to scan")
+	fs.String("ignore", "", "Comma-separated list of directory names to skip")
+	fs.String("provider", "github", "Issue tracker to query: github")
+	fs.String("repo", "", "Repository (owner/name) that bare references like #12 belong to")
+	fs.String("token", "", "API token for the tracker (defaults to $GITHUB_TOKEN for github)")
+	fs.String("api-url", tracker.DefaultGitHubURL, "API root URL, e.g. for GitHub Enterprise")
+	fs.Int("batch", tracker.DefaultBatchSize, "Maximum number of ticket lookups in flight at once")
+	fs.Int("retries", tracker.DefaultMaxRetries, "Retries per lookup when rate-limited or on server errors")
+	fs.String("report", "table", "Output format: one of table, json, md")
+	fs.String("out", "", "Write the json/md report to this file instead of stdout")
+	_ = crosscheckCmd.RegisterFlagCompletionFunc("report", cobra.FixedCompletions([]string{"table", "json", "md"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = crosscheckCmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions([]string{"github"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// crosscheckCmd reports items whose referenced ticket is already closed.
+var crosscheckCmd = &cobra.Command{
+	Use:   "crosscheck",
+	Short: "Find TODOs that reference closed tickets",
+	Long: `Scans for items mentioning ticket references (#12, owner/repo#12, issue
+links), looks each distinct ticket up in the issue tracker and lists the
+closed ones with every location still referencing them. Tickets that cannot
+be fetched are listed as unverifiable instead of failing the run.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+
+		p, _ := cmd.Flags().GetString("path")
+		i, _ := cmd.Flags().GetString("ignore")
+		provider, _ := cmd.Flags().GetString("provider")
+		repo, _ := cmd.Flags().GetString("repo")
+		token, _ := cmd.Flags().GetString("token")
+		apiURL, _ := cmd.Flags().GetString("api-url")
+		batch, _ := cmd.Flags().GetInt("batch")
+		retries, _ := cmd.Flags().GetInt("retries")
+		r, _ := cmd.Flags().GetString("report")
+		outName, _ := cmd.Flags().GetString("out")
+
+		r = strings.ToLower(strings.TrimSpace(r))
+		switch r {
+		case "", "table":
+			r = "table"
+		case "json", "md":
+		default:
+			return errors.New("invalid --report value; must be one of: table, json, md")
+		}
+		var prov tracker.Provider
+		switch strings.ToLower(provider) {
+		case "github":
+			if token == "" {
+				token = os.Getenv("GITHUB_TOKEN")
+			}
+			prov = &tracker.GitHub{Repo: repo, Token: token, BaseURL: apiURL, MaxRetries: retries}
+		default:
+			return fmt.Errorf("unsupported --provider %q; must be: github", provider)
+		}
+
+		opts := todo.DefaultScanOptions()
+		opts.IgnoreDirs = buildIgnoreList(i)
+		items, err := todo.ScanDirWithOptions(p, opts, todo.OSFileReader{})
+		if err != nil {
+			return err
+		}
+		res, err := tracker.CrossCheck(cmd.Context(), items, prov, batch)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if outName != "" && r != "table" {
+			if err := ensureParentDir(outName); err != nil {
+				return err
+			}
+			f, err := os.Create(outName)
+			if err != nil {
+				return err
+			}
+			defer todo.SafeClose(f, outName)
+			w = f
+		}
+		switch r {
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(res)
+		case "md":
+			return writeCrosscheckMarkdown(w, res)
+		}
+		writeCrosscheckTable(w, res)
+		return nil
+	},
+}
+
+// writeCrosscheckTable prints closed and unverifiable tickets as tables.
+func writeCrosscheckTable(w io.Writer, res tracker.Result) {
+	if len(res.Closed) == 0 {
+		_, _ = fmt.Fprintf(w, "No TODOs reference closed tickets (%d open).\n", res.Open)
+	} else {
+		_, _ = fmt.Fprintln(w, "Closed tickets still referenced:")
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Ticket", "Title", "Locations"})
+		table.SetAutoWrapText(false)
+		for _, g := range res.Closed {
+			table.Append([]string{g.Ticket.Ref, g.Ticket.Title, formatLocations(g.Locations, "\n")})
+		}
+		table.Render()
+	}
+	if len(res.Unverifiable) > 0 {
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "Unverifiable references:")
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Ticket", "Reason", "Locations"})
+		table.SetAutoWrapText(false)
+		for _, g := range res.Unverifiable {
+			table.Append([]string{g.Ticket.Ref, g.Reason, formatLocations(g.Locations, "\n")})
+		}
+		table.Render()
+	}
+}
+
+// writeCrosscheckMarkdown renders the cross-check result as Markdown.
+func writeCrosscheckMarkdown(w io.Writer, res tracker.Result) error {
+	var b strings.Builder
+	b.WriteString("# todototum cross-check\n\n")
+	b.WriteString(fmt.Sprintf("- Closed: %d\n- Open: %d\n- Unverifiable: %d\n\n", len(res.Closed), res.Open, len(res.Unverifiable)))
+	if len(res.Closed) > 0 {
+		b.WriteString("## Closed tickets\n\n")
+		for _, g := range res.Closed {
+			ref := todo.EscapeMarkdown(g.Ticket.Ref)
+			if g.Ticket.URL != "" {
+				ref = todo.MarkdownLink(g.Ticket.Ref, g.Ticket.URL)
+			}
+			b.WriteString(fmt.Sprintf("### %s %s\n\n", ref, todo.EscapeMarkdown(g.Ticket.Title)))
+			for _, l := range g.Locations {
+				b.WriteString(fmt.Sprintf("- %s:%d\n", todo.EscapeMarkdown(l.File), l.Line))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(res.Unverifiable) > 0 {
+		b.WriteString("## Unverifiable\n\n")
+		b.WriteString("| Ticket | Reason | Locations |\n")
+		b.WriteString("|--------|--------|-----------|\n")
+		for _, g := range res.Unverifiable {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", todo.EscapeMarkdown(g.Ticket.Ref), todo.EscapeMarkdown(g.Reason), todo.EscapeMarkdown(formatLocations(g.Locations, ", "))))
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLocations joins locations as file:line.
+func formatLocations(locs []todo.Location, sep string) string {
+	parts := make([]string, len(locs))
+	for i, l := range locs {
+		parts[i] = fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+	return strings.Join(parts, sep)
+}
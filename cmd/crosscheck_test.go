@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/tracker"
+)
+
+func TestCrosscheck_Command_JSONAndTable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/app/issues/1":
+			_, _ = w.Write([]byte(`{"state":"closed","title":"Shipped"}`))
+		case "/repos/acme/app/issues/2":
+			_, _ = w.Write([]byte(`{"state":"open","title":"Pending"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tmp := t.TempDir()
+	src := "// TODO(#1): remove shim\n// FIXME: see #1 again\n// TODO: wait for #2\n// TODO: gone #3\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"crosscheck", "--path", tmp, "--repo", "acme/app", "--api-url", srv.URL, "--report", "json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("crosscheck: %v", err)
+	}
+	var res tracker.Result
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if len(res.Closed) != 1 || res.Closed[0].Ticket.Title != "Shipped" || len(res.Closed[0].Locations) != 2 {
+		t.Fatalf("unexpected closed: %+v", res.Closed)
+	}
+	if res.Open != 1 || len(res.Unverifiable) != 1 || res.Unverifiable[0].Ticket.Ref != "#3" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"crosscheck", "--path", tmp, "--repo", "acme/app", "--api-url", srv.URL})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("crosscheck table: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Closed tickets still referenced") || !strings.Contains(out, "main.go:1") || !strings.Contains(out, "Unverifiable references") {
+		t.Fatalf("unexpected table output:\n%s", out)
+	}
+}
+
+func TestCrosscheck_Command_InvalidProvider(t *testing.T) {
+	rootCmd.SetArgs([]string{"crosscheck", "--path", t.TempDir(), "--provider", "jira"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected unsupported provider error")
+	}
+}
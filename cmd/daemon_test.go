@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func TestDaemon_Command_Stdio(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// FIXME: saved\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in := strings.Join([]string{
+		`{"id":1,"method":"scanFile","params":{"path":"main.go","content":"// HACK: unsaved\n"}}`,
+		`{"id":2,"method":"summary"}`,
+		`{"id":3,"method":"shutdown"}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(in))
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetIn(nil); rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"daemon", "--stdio", "--path", tmp, "--tags", "FIXME,HACK"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[2], `{"id":3,"result":{}`) {
+		t.Fatalf("responses:\n%s", buf.String())
+	}
+	got := make(map[string]string)
+	for _, line := range lines {
+		var resp struct {
+			ID     json.RawMessage
+			Result json.RawMessage
+			Error  *todo.ProtocolError
+		}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Error != nil {
+			t.Fatalf("response %s: %v", line, err)
+		}
+		got[string(resp.ID)] = string(resp.Result)
+	}
+	if !strings.Contains(got["1"], `"Tag":"HACK"`) || !strings.Contains(got["2"], `"byTag":{"FIXME":1}`) {
+		t.Errorf("results = %v", got)
+	}
+}
+
+func TestDaemon_Command_ScanLog(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// FIXME: saved\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in := `{"id":1,"method":"summary"}` + "\n" + `{"id":2,"method":"scanDir"}` + "\n" + `{"id":3,"method":"shutdown"}` + "\n"
+
+	tests := []struct {
+		name  string
+		flags []string
+		// want and not are substrings expected and not expected on stderr.
+		want []string
+		not  []string
+	}{
+		{"full", nil, []string{"re-scanned 1 files in ", ", 1 todos\n", "  Total: 1\n", "  FIXME: 1\n"}, nil},
+		{"quiet", []string{"--watch-quiet"}, []string{"re-scanned 1 files in ", ", 1 todos\n"}, []string{"Total"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errBuf bytes.Buffer
+			rootCmd.SetIn(strings.NewReader(in))
+			rootCmd.SetOut(io.Discard)
+			rootCmd.SetErr(&errBuf)
+			t.Cleanup(func() { rootCmd.SetIn(nil); rootCmd.SetOut(nil); rootCmd.SetErr(nil) })
+			rootCmd.SetArgs(append([]string{"daemon", "--stdio", "--path", tmp}, tt.flags...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			got := errBuf.String()
+			if n := strings.Count(got, "re-scanned"); n != 1 {
+				t.Errorf("stderr logs %d scans, want 1 for the cached tree:\n%s", n, got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("stderr lacks %q:\n%s", want, got)
+				}
+			}
+			for _, not := range tt.not {
+				if strings.Contains(got, not) {
+					t.Errorf("stderr has %q:\n%s", not, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDaemon_Command_RequiresStdio(t *testing.T) {
+	rootCmd.SetArgs([]string{"daemon"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--stdio") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// countingReader counts the files opened from disk.
+type countingReader struct{ opens atomic.Int64 }
+
+func (r *countingReader) Open(name string) (io.ReadCloser, error) {
+	r.opens.Add(1)
+	return todo.OSFileReader{}.Open(name)
+}
+
+func TestDaemon_InvalidateOnSignal(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// FIXME: saved\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &countingReader{}
+	server := todo.NewProtocolServer(tmp, todo.DefaultScanOptions(), r)
+	scanDir := func() {
+		in := strings.NewReader(`{"id":1,"method":"scanDir"}` + "\n")
+		if err := server.Serve(context.Background(), in, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal) // unbuffered: a send returns once invalidateOn took it
+	stopped := make(chan struct{})
+	go func() {
+		invalidateOn(ctx, signals, server)
+		close(stopped)
+	}()
+	scanDir()
+	scanDir()
+	if n := r.opens.Load(); n != 1 {
+		t.Fatalf("opens = %d, want the cached scan reused", n)
+	}
+	signals <- syscall.SIGHUP
+	// The signal is taken before Invalidate runs; wait for the rescan.
+	deadline := time.Now().Add(2 * time.Second)
+	for r.opens.Load() < 2 && time.Now().Before(deadline) {
+		scanDir()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := r.opens.Load(); n != 2 {
+		t.Fatalf("opens = %d after SIGHUP, want one rescan", n)
+	}
+	cancel()
+	<-stopped
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	fs := diffCmd.Flags()
+	fs.String("report", "table", "Output format: one of table, json, html")
+	fs.String("out", "", "Write the json/html report to this file instead of stdout (html defaults to diff.html)")
+	fs.String("match-by", todo.MatchByFingerprint, "How items of the two reports are matched: fingerprint (file, tag and text, ignoring the line) or exact (also the same line)")
+	_ = diffCmd.RegisterFlagCompletionFunc("report", cobra.FixedCompletions([]string{"table", "json", "html"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = diffCmd.RegisterFlagCompletionFunc("match-by", cobra.FixedCompletions(todo.MatchModes, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// diffCmd compares two JSON reports.
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD.json NEW.json",
+	Short: "Compare two JSON reports",
+	Long: `Lists the items added and resolved between two reports written with
+--report json. Items are matched by fingerprint (file, tag and text), so
+items that only moved to another line, e.g. after edits or reformatting
+above them, are not listed. With --match-by exact they must also be on the
+same line, and a moved item is listed as resolved and added.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+
+		r, _ := cmd.Flags().GetString("report")
+		outName, _ := cmd.Flags().GetString("out")
+		matchBy, _ := cmd.Flags().GetString("match-by")
+
+		r = strings.ToLower(strings.TrimSpace(r))
+		switch r {
+		case "", "table":
+			r = "table"
+		case "json", "html":
+		default:
+			return errors.New("invalid --report value; must be one of: table, json, html")
+		}
+		matchBy = strings.ToLower(strings.TrimSpace(matchBy))
+		if !slices.Contains(todo.MatchModes, matchBy) {
+			return fmt.Errorf("invalid --match-by value; must be one of: %s", strings.Join(todo.MatchModes, ", "))
+		}
+		old, err := todo.LoadJSONReport(args[0])
+		if err != nil {
+			return err
+		}
+		cur, err := todo.LoadJSONReport(args[1])
+		if err != nil {
+			return err
+		}
+		d := todo.DiffItemsBy(old, cur, matchBy)
+
+		if r == "html" {
+			if outName == "" {
+				outName = "diff.html"
+			}
+			if err := ensureParentDir(outName); err != nil {
+				return err
+			}
+			if err := todo.GenerateDiffHTMLReport(d, args[0], args[1], outName); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "HTML diff written to %s\n", outName)
+			return nil
+		}
+		w := cmd.OutOrStdout()
+		if outName != "" && r != "table" {
+			if err := ensureParentDir(outName); err != nil {
+				return err
+			}
+			f, err := os.Create(outName)
+			if err != nil {
+				return err
+			}
+			defer todo.SafeClose(f, outName)
+			w = f
+		}
+		if r == "json" {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(d)
+		}
+		writeDiffTable(w, d)
+		return nil
+	},
+}
+
+// writeDiffTable prints the added and resolved counts and a table of both,
+// grouped by file.
+func writeDiffTable(w io.Writer, d todo.Diff) {
+	_, _ = fmt.Fprintf(w, "%d added, %d resolved\n", len(d.Added), len(d.Resolved))
+	if len(d.Added)+len(d.Resolved) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"", "File", "Line", "Tag", "Text"})
+	table.SetAutoWrapText(false)
+	minus, plus := color.New(color.FgRed).Sprint("-"), color.New(color.FgGreen).Sprint("+")
+	for _, f := range d.Files() {
+		for _, t := range f.Resolved {
+			table.Append([]string{minus, t.File, strconv.Itoa(t.Line), t.Tag, t.Text})
+		}
+		for _, t := range f.Added {
+			table.Append([]string{plus, t.File, strconv.Itoa(t.Line), t.Tag, t.Text})
+		}
+	}
+	table.Render()
+}
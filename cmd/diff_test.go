@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func TestDiff_Command(t *testing.T) {
+	tmp := t.TempDir()
+	oldPath, newPath := filepath.Join(tmp, "old.json"), filepath.Join(tmp, "new.json")
+	if err := todo.GenerateJSONReport([]todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "kept"},
+		{Location: todo.Location{File: "a.go", Line: 2}, Tag: "BUG", Text: "fixed"},
+	}, oldPath, todo.ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := todo.GenerateJSONReport([]todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 4}, Tag: "TODO", Text: "kept"},
+		{Location: todo.Location{File: "b.go", Line: 1}, Tag: "FIXME", Text: "added"},
+	}, newPath, todo.ReportOptions{JSONShape: todo.JSONShapeNested}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs([]string{"diff", oldPath, newPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "1 added, 1 resolved") || !strings.Contains(out, "fixed") || strings.Contains(out, "kept") {
+		t.Fatalf("unexpected table output:\n%s", out)
+	}
+
+	buf.Reset()
+	htmlPath := filepath.Join(tmp, "out", "diff.html")
+	rootCmd.SetArgs([]string{"diff", oldPath, newPath, "--report", "html", "--out", htmlPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("diff --report html: %v", err)
+	}
+	html, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "+1 added") || !strings.Contains(string(html), "<h2>b.go</h2>") {
+		t.Fatalf("unexpected HTML diff:\n%s", html)
+	}
+
+	rootCmd.SetArgs([]string{"diff", oldPath, newPath, "--report", "md"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected an error for --report md")
+	}
+}
+
+func TestDiff_Command_MatchBy(t *testing.T) {
+	tmp := t.TempDir()
+	scanTo := func(name, src string) string {
+		dir := filepath.Join(tmp, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		report := filepath.Join(tmp, name+".json")
+		rootCmd.SetArgs([]string{"scan", "--path", dir, "--report", "json", "--out", report})
+		captureStdout(t, func() {
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+		})
+		return report
+	}
+	oldPath := scanTo("old", "package main\n\n// TODO: handle errors\nfunc main() {}\n")
+	newPath := scanTo("new", "package main\n\nimport \"os\"\n\n// TODO: handle errors\nfunc main() { os.Exit(0) }\n")
+
+	diff := func(args ...string) (string, error) {
+		var buf bytes.Buffer
+		rootCmd.SetOut(&buf)
+		t.Cleanup(func() { rootCmd.SetOut(nil) })
+		rootCmd.SetArgs(append([]string{"diff", oldPath, newPath}, args...))
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+	if out, err := diff(); err != nil || !strings.HasPrefix(out, "0 added, 0 resolved\n") {
+		t.Errorf("default matching: %v\n%s", err, out)
+	}
+	out, err := diff("--match-by", "exact")
+	if err != nil || !strings.HasPrefix(out, "1 added, 1 resolved\n") || !strings.Contains(out, "| - | main.go |    3 |") || !strings.Contains(out, "| + | main.go |    5 |") {
+		t.Errorf("--match-by exact: %v\n%s", err, out)
+	}
+	if _, err := diff("--match-by", "line"); err == nil || !strings.Contains(err.Error(), "must be one of: fingerprint, exact") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import "errors"
+
+// Exit codes of the todototum process; see the scan command's help.
+const (
+	// ExitOK: the command succeeded, whatever it found.
+	ExitOK = 0
+	// ExitUsage: invalid flags, arguments or configuration, or any failure
+	// of a command other than scan.
+	ExitUsage = 1
+	// ExitGating: the scan completed but a gate such as --error-tags,
+	// --fail-on-escalated or --exit-code-on-findings failed, or check found
+	// a tag over its budget.
+	ExitGating = 2
+	// ExitScanFailed: the scan itself failed, e.g. the root is missing or
+	// a report could not be written.
+	ExitScanFailed = 3
+)
+
+// GatingError reports a scan that completed but failed a gate.
+type GatingError struct{ Err error }
+
+func (e *GatingError) Error() string { return e.Err.Error() }
+
+func (e *GatingError) Unwrap() error { return e.Err }
+
+// ScanFailedError reports a scan that could not complete.
+type ScanFailedError struct{ Err error }
+
+func (e *ScanFailedError) Error() string { return e.Err.Error() }
+
+func (e *ScanFailedError) Unwrap() error { return e.Err }
+
+// gatingError wraps err, when non-nil, as a GatingError.
+func gatingError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &GatingError{Err: err}
+}
+
+// scanFailed wraps err as a ScanFailedError unless it is a GatingError.
+func scanFailed(err error) error {
+	var gate *GatingError
+	if err == nil || errors.As(err, &gate) {
+		return err
+	}
+	return &ScanFailedError{Err: err}
+}
+
+// exitCode returns the process exit code for err, an error returned by
+// rootCmd.Execute.
+func exitCode(err error) int {
+	var gate *GatingError
+	var failed *ScanFailedError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &gate):
+		return ExitGating
+	case errors.As(err, &failed):
+		return ExitScanFailed
+	}
+	return ExitUsage
+}
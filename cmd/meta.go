@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// reportMeta is the --write-meta sidecar: how and when a report was made.
+// The report itself is left unchanged.
+type reportMeta struct {
+	Tool        toolMeta       `json:"tool"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Report      string         `json:"report"`
+	Format      string         `json:"format"`
+	Root        string         `json:"root"`
+	Tags        []string       `json:"tags"`
+	Ignore      []string       `json:"ignore"`
+	Git         *gitMeta       `json:"git,omitempty"`
+	Duration    string         `json:"duration"`
+	Stats       todo.ScanStats `json:"stats"`
+}
+
+// toolMeta is the build of todototum that wrote a report.
+type toolMeta struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// gitMeta is the checkout a report was made from.
+type gitMeta struct {
+	Commit string `json:"commit"`
+	// Branch is empty on a detached HEAD.
+	Branch string `json:"branch,omitempty"`
+}
+
+// metaPath returns the sidecar path of a report, e.g. out/report.html ->
+// out/report.meta.json. A compressed report has a compressed sidecar:
+// report.json.gz -> report.meta.json.gz.
+func metaPath(reportPath string) string {
+	stem, ext := splitExt(reportPath)
+	if strings.HasSuffix(strings.ToLower(ext), "."+todo.CompressGzip) {
+		return stem + ".meta.json" + ext[len(ext)-len(todo.CompressGzip)-1:]
+	}
+	return stem + ".meta.json"
+}
+
+// newReportMeta describes a report of format written to reportPath from a
+// scan of root with opts. Git details are left out when root is standard
+// input (-) or not in a git checkout.
+func newReportMeta(ctx context.Context, reportPath, format, root string, opts todo.ScanOptions, stats todo.ScanStats) reportMeta {
+	tags := opts.Tags
+	if len(tags) == 0 {
+		tags = todo.DefaultTags
+	}
+	var git *gitMeta
+	if root != "-" {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		git = gitRef(ctx, root)
+	}
+	return reportMeta{
+		Tool:        toolMeta{Name: "todototum", Version: version, Commit: commit, Date: date},
+		GeneratedAt: now().UTC(),
+		Report:      filepath.Base(reportPath),
+		Format:      format,
+		Root:        filepath.ToSlash(root),
+		Tags:        tags,
+		Ignore:      append([]string{}, opts.IgnoreDirs...),
+		Git:         git,
+		Duration:    stats.Duration.Round(time.Millisecond).String(),
+		Stats:       stats,
+	}
+}
+
+// gitRef returns the commit and branch checked out in dir, or nil when dir
+// is not in a git checkout.
+func gitRef(ctx context.Context, dir string) *gitMeta {
+	head, err := gitRunner.Run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil || strings.TrimSpace(head) == "" {
+		return nil
+	}
+	ref := &gitMeta{Commit: strings.TrimSpace(head)}
+	if branch, err := gitRunner.Run(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		if b := strings.TrimSpace(branch); b != "HEAD" {
+			ref.Branch = b
+		}
+	}
+	return ref
+}
+
+// writeReportMeta writes meta as indented JSON to path through w, the
+// writer of the report, so the sidecar is compressed along with it.
+func writeReportMeta(w todo.FileWriter, path string, meta reportMeta) error {
+	if err := ensureParentDir(path); err != nil {
+		return err
+	}
+	f, err := w.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(meta)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetaPath(t *testing.T) {
+	cases := map[string]string{
+		"report.html":                       "report.meta.json",
+		filepath.Join("out", "report.json"): filepath.Join("out", "report.meta.json"),
+		"report.json.gz":                    "report.meta.json.gz",
+		"badge.svg":                         "badge.meta.json",
+	}
+	for in, want := range cases {
+		if got := metaPath(in); got != want {
+			t.Errorf("metaPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScan_Command_WriteMeta(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: one\n// HACK: two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orig := gitRunner
+	t.Cleanup(func() { gitRunner = orig })
+	gitRunner = argsGit{"rev-parse HEAD": "deadbeef\n", "rev-parse --abbrev-ref HEAD": "main\n"}
+
+	outDir := t.TempDir()
+	report := filepath.Join(outDir, "report.html")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "html", "--out", report, "--tags", "TODO,HACK", "--ignore", "vendor", "--write-meta"})
+	stdout := captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan --write-meta: %v", err)
+		}
+	})
+	sidecar := filepath.Join(outDir, "report.meta.json")
+	if !strings.Contains(stdout, "Metadata written to "+sidecar) {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta reportMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("sidecar: %v\n%s", err, data)
+	}
+	if meta.Tool.Version != version || meta.Tool.Commit != commit || meta.Report != "report.html" || meta.Format != "html" {
+		t.Errorf("meta = %+v", meta)
+	}
+	if !reflect.DeepEqual(meta.Tags, []string{"TODO", "HACK"}) || !reflect.DeepEqual(meta.Ignore, []string{"vendor"}) {
+		t.Errorf("tags = %v, ignore = %v", meta.Tags, meta.Ignore)
+	}
+	if meta.Root != filepath.ToSlash(tmp) || meta.Git == nil || meta.Git.Commit != "deadbeef" || meta.Git.Branch != "main" {
+		t.Errorf("root = %s, git = %+v", meta.Root, meta.Git)
+	}
+	if meta.Stats.Walked != 1 || meta.Duration == "" {
+		t.Errorf("stats = %+v, duration = %q", meta.Stats, meta.Duration)
+	}
+	html, err := os.ReadFile(report)
+	if err != nil || strings.Contains(string(html), "deadbeef") {
+		t.Errorf("report changed by --write-meta: %v", err)
+	}
+
+	gitRunner = argsGit{}
+	report = filepath.Join(outDir, "plain.json")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", report, "--write-meta"})
+	captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan --write-meta outside git: %v", err)
+		}
+	})
+	data, err = os.ReadFile(filepath.Join(outDir, "plain.meta.json"))
+	if err != nil || strings.Contains(string(data), `"git"`) || !strings.Contains(string(data), `"tags": [`) {
+		t.Errorf("sidecar outside git: %v\n%s", err, data)
+	}
+
+	report = filepath.Join(outDir, "packed.json")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", report, "--compress", "gz", "--write-meta"})
+	stdout = captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan --write-meta --compress: %v", err)
+		}
+	})
+	sidecar = filepath.Join(outDir, "packed.meta.json.gz")
+	if !strings.Contains(stdout, "Metadata written to "+sidecar) {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err = os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta = reportMeta{}
+	if err := json.Unmarshal(gunzip(t, data), &meta); err != nil || meta.Report != "packed.json.gz" {
+		t.Errorf("compressed sidecar: %v, report = %q", err, meta.Report)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--write-meta"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--write-meta requires a report file") {
+		t.Errorf("table report: err = %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// ownerReportExt maps the formats --split-by-owner supports to the
+// extension of the files it writes.
+var ownerReportExt = map[string]string{"md": ".md", "json": ".json"}
+
+// loadCodeOwners reads the CODEOWNERS file at path, or the one of the
+// repository containing dir when path is empty.
+func loadCodeOwners(dir, path string) (*todo.CodeOwners, error) {
+	if path != "" {
+		return todo.LoadCodeOwnersFile(path, dir)
+	}
+	co, err := todo.LoadCodeOwners(dir)
+	if err == nil && co == nil {
+		err = fmt.Errorf("no CODEOWNERS file found (looked for %v)", todo.CodeOwnersFiles)
+	}
+	return co, err
+}
+
+// writeOwnerReports writes one report per owner of items into outDir, a
+// catch-all report for unowned items and an index listing them all.
+func writeOwnerReports(format string, items []todo.Todo, outDir string, opts todo.ReportOptions) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	ext := ownerReportExt[format]
+	groups, unowned := todo.SplitByOwner(items)
+	idx := todo.NewOwnerIndex(items, groups, unowned, ext)
+	for i, g := range groups {
+		if err := writeReport(format, g.Items, filepath.Join(outDir, idx.Owners[i].File), todo.OSFileWriter{}, opts); err != nil {
+			return err
+		}
+	}
+	if idx.Unowned != nil {
+		if err := writeReport(format, unowned, filepath.Join(outDir, idx.Unowned.File), todo.OSFileWriter{}, opts); err != nil {
+			return err
+		}
+	}
+	indexPath := filepath.Join(outDir, "index"+ext)
+	if err := todo.GenerateOwnerIndexWithWriter(idx, indexPath, format, todo.OSFileWriter{}); err != nil {
+		return err
+	}
+	fmt.Printf("%d owner report(s) and index written to %s\n", len(groups), indexPath)
+	return nil
+}
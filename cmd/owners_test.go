@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScan_Command_SplitByOwner(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":           "ref: refs/heads/main\n",
+		"CODEOWNERS":          "*.go @acme/core\n/api/ @acme/api\n/api/shared/ @acme/api @acme/core\n/vendor/\n",
+		"main.go":             "// TODO: core item\n",
+		"api/handler.go":      "// FIXME: api item\n",
+		"api/shared/types.go": "// BUG: shared item\n",
+		"vendor/lib/lib.go":   "// NOTE: unowned item\n",
+		"docs/notes.txt":      "TODO: also unowned\n",
+	}
+	for rel, content := range files {
+		p := filepath.Join(tmp, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	outDir := filepath.Join(t.TempDir(), "owners")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "md", "--out-dir", outDir, "--split-by-owner"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan --split-by-owner: %v", err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return string(b)
+	}
+	core, api, unowned, index := read("acme-core.md"), read("acme-api.md"), read("unowned.md"), read("index.md")
+	if !strings.Contains(core, "core item") || !strings.Contains(core, "shared item") || strings.Contains(core, "api item") {
+		t.Fatalf("unexpected core report:\n%s", core)
+	}
+	if !strings.Contains(api, "api item") || !strings.Contains(api, "shared item") {
+		t.Fatalf("unexpected api report:\n%s", api)
+	}
+	if !strings.Contains(unowned, "unowned item") || !strings.Contains(unowned, "also unowned") {
+		t.Fatalf("unexpected unowned report:\n%s", unowned)
+	}
+	for _, want := range []string{"- Total: 5", "Shared by several owners: 1", "| @acme/api | 2 |", "| @acme/core | 2 |", "| _unowned_ | 2 |"} {
+		if !strings.Contains(index, want) {
+			t.Fatalf("missing %q in index:\n%s", want, index)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "html", "--out-dir", outDir, "--split-by-owner"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for --split-by-owner with html")
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "md", "--split-by-owner"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for --split-by-owner without --out-dir")
+	}
+}
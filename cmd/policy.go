@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// shortTextItems returns the items whose description, as scanned and before
+// reports prefix it with the tag, is shorter than min runes.
+func shortTextItems(items []todo.Todo, min int) []todo.Todo {
+	var out []todo.Todo
+	for _, t := range items {
+		if (todo.LintRules{MinTextLength: min}).Check(t.Text) != nil {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// shortTextError lists the items breaking --min-text-length on w and returns
+// an error counting them, or nil when there are none.
+func shortTextError(w io.Writer, items []todo.Todo, min int) error {
+	short := shortTextItems(items, min)
+	if len(short) == 0 {
+		return nil
+	}
+	_, _ = fmt.Fprintf(w, "Descriptions shorter than %d characters:\n", min)
+	for _, t := range short {
+		_, _ = fmt.Fprintf(w, "  %s:%d: %s: %q\n", t.File, t.Line, strings.ToUpper(t.Tag), strings.TrimSpace(t.Text))
+	}
+	return gatingError(fmt.Errorf("%d item(s) shorter than --min-text-length %d", len(short), min))
+}
+
+// ownerlessItems returns the items tagged with one of tags, compared
+// case-insensitively, that name no owner after the tag, as FIXME(ana) does.
+func ownerlessItems(items []todo.Todo, tags []string) []todo.Todo {
+	var out []todo.Todo
+	for _, t := range items {
+		if todo.Assignee(t.Text) != "" {
+			continue
+		}
+		for _, tag := range tags {
+			if strings.EqualFold(t.Tag, tag) {
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// ownerError lists the items breaking --require-owner-for on w and returns
+// an error counting them, or nil when there are none.
+func ownerError(w io.Writer, items []todo.Todo, tags []string) error {
+	missing := ownerlessItems(items, tags)
+	if len(missing) == 0 {
+		return nil
+	}
+	upper := make([]string, len(tags))
+	for i, tag := range tags {
+		upper[i] = strings.ToUpper(tag)
+	}
+	_, _ = fmt.Fprintf(w, "Items without an owner, e.g. %s(name): ...:\n", upper[0])
+	for _, t := range missing {
+		_, _ = fmt.Fprintf(w, "  %s:%d: %s: %q\n", t.File, t.Line, strings.ToUpper(t.Tag), strings.TrimSpace(t.Text))
+	}
+	return gatingError(fmt.Errorf("%d item(s) tagged %s without an owner", len(missing), strings.Join(upper, ",")))
+}
+
+// budgetError writes a line per tag over its budget on w (see
+// todo.BudgetViolation.String) and returns an error counting them, or nil
+// when every tag is within budget.
+func budgetError(w io.Writer, items []todo.Todo, budgets todo.Budgets) error {
+	over := todo.CheckBudgets(items, budgets)
+	if len(over) == 0 {
+		return nil
+	}
+	for _, v := range over {
+		_, _ = fmt.Fprintln(w, v)
+	}
+	return gatingError(fmt.Errorf("%d tag(s) over budget", len(over)))
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func TestShortTextError(t *testing.T) {
+	items := []todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 3}, Tag: "TODO", Text: "fix"},
+		{Location: todo.Location{File: "a.go", Line: 7}, Tag: "fixme", Text: "  "},
+		{Location: todo.Location{File: "b.go", Line: 1}, Tag: "TODO", Text: "handle the retry budget"},
+		{Location: todo.Location{File: "c.go", Line: 2}, Tag: "NOTE", Text: "été naïve"}, // 9 runes, more bytes
+	}
+	var buf bytes.Buffer
+	err := shortTextError(&buf, items, 10)
+	if err == nil || !strings.Contains(err.Error(), "3 item(s)") {
+		t.Fatalf("expected three offenders, got %v", err)
+	}
+	for _, want := range []string{"shorter than 10 characters", `a.go:3: TODO: "fix"`, `a.go:7: FIXME: ""`, `c.go:2: NOTE: "été naïve"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "b.go") {
+		t.Fatalf("long description listed:\n%s", buf.String())
+	}
+	if err := shortTextError(&buf, items[2:3], 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestScan_Command_MinTextLength(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: fix\n// TODO: explain the retry policy\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var runErr error
+	_ = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--min-text-length", "10"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr == nil || !strings.Contains(runErr.Error(), "1 item(s) shorter than --min-text-length 10") {
+		t.Fatalf("expected a policy failure, got %v", runErr)
+	}
+	_ = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--min-text-length", "3"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected failure: %v", runErr)
+	}
+}
+
+func TestScan_Command_DetectOrphans(t *testing.T) {
+	tmp := t.TempDir()
+	src := "package main\n\n// TODO: remove after MigrateUsersV1 is gone\nfunc MigrateUsersV2() {}\n\n// TODO: inline MigrateUsersV2\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var runErr error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--detect-orphans"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr != nil {
+		t.Fatalf("orphans alone must not fail the scan: %v", runErr)
+	}
+	if !strings.Contains(out, "Possibly obsolete: 1") {
+		t.Fatalf("missing orphan count:\n%s", out)
+	}
+	_ = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--detect-orphans", "--fail-on-orphans"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr == nil || !strings.Contains(runErr.Error(), "1 possibly obsolete item(s)") {
+		t.Fatalf("expected an orphan failure, got %v", runErr)
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--fail-on-orphans"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "requires --detect-orphans") {
+		t.Fatalf("expected --fail-on-orphans to require --detect-orphans, got %v", err)
+	}
+}
+
+func TestOwnerError(t *testing.T) {
+	items := []todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 3}, Tag: "FIXME", Text: "(ana): flaky retry"},
+		{Location: todo.Location{File: "a.go", Line: 7}, Tag: "fixme", Text: "unowned"},
+		{Location: todo.Location{File: "b.go", Line: 1}, Tag: "BUG", Text: "() empty owner"},
+		{Location: todo.Location{File: "b.go", Line: 4}, Tag: "TODO", Text: "nobody needed"},
+	}
+	var buf bytes.Buffer
+	err := ownerError(&buf, items, []string{"fixme", "BUG"})
+	if err == nil || !strings.Contains(err.Error(), "2 item(s) tagged FIXME,BUG without an owner") {
+		t.Fatalf("expected two offenders, got %v", err)
+	}
+	for _, want := range []string{"FIXME(name)", `a.go:7: FIXME: "unowned"`, `b.go:1: BUG: "() empty owner"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "a.go:3") || strings.Contains(buf.String(), "b.go:4") {
+		t.Fatalf("owned or unlisted items reported:\n%s", buf.String())
+	}
+	if err := ownerError(&buf, items, []string{"NOTE"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestScan_Command_RequireOwnerFor(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// FIXME(ana): owned\n// FIXME: orphan\n// TODO: free\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var runErr error
+	_ = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--require-owner-for", "FIXME"})
+		runErr = rootCmd.Execute()
+	})
+	var gate *GatingError
+	if !errors.As(runErr, &gate) || !strings.Contains(runErr.Error(), "1 item(s) tagged FIXME without an owner") {
+		t.Fatalf("expected a gating failure, got %v", runErr)
+	}
+	_ = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--require-owner-for", "BUG"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected failure: %v", runErr)
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/rewrite"
+)
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+	revertCmd.Flags().String("manifest", "", "Manifest written by a rewrite, e.g. .todototum-backup/<timestamp>/manifest.json")
+}
+
+// revertCmd undoes an in-place rewrite from its manifest and backup.
+var revertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "Restore the files changed by assign from their backup",
+	Long: `Reads the manifest of a rewrite (assign) and restores every file it
+changed from the backup taken before the change, byte for byte. A file
+edited again since the rewrite is left alone with a warning, as restoring it
+would lose those edits, and the command fails once the others are restored.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+
+		path, _ := cmd.Flags().GetString("manifest")
+		if path == "" {
+			return errors.New("revert needs --manifest")
+		}
+		m, err := rewrite.LoadManifest(path)
+		if err != nil {
+			return err
+		}
+		res, err := rewrite.Revert(m)
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		for _, file := range res.Restored {
+			_, _ = fmt.Fprintf(w, "restored %s\n", file)
+		}
+		skipped := make([]string, 0, len(res.Skipped))
+		for file := range res.Skipped {
+			skipped = append(skipped, file)
+		}
+		sort.Strings(skipped)
+		for _, file := range skipped {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s: %s; skipped\n", file, res.Skipped[file])
+		}
+		_, _ = fmt.Fprintf(w, "Restored %d of %d file(s)\n", len(res.Restored), len(m.Files))
+		if len(skipped) > 0 {
+			return fmt.Errorf("%d file(s) could not be restored", len(skipped))
+		}
+		return nil
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/config"
 )
 
 // rootCmd is the base command executed when no subcommand is provided.
@@ -15,16 +16,25 @@ var rootCmd = &cobra.Command{
 across any programming language. It outputs clear summaries to the terminal
 or generates reports for later analysis.`,
 	// no Run function here; 'scan' will handle execution
+
+	// Execute prints errors, once. Usage is printed for errors in the
+	// command line only: once flags parse, a failure such as a gate is not
+	// a usage error.
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+	},
 }
 
-// Execute runs the CLI. Called from main.go.
+// Execute runs the CLI. Called from main.go. Errors exit with the code
+// exitCode picks for them.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	// Any global (persistent) flags can go here later if needed.
+	rootCmd.PersistentFlags().StringVar(&configPath, config.FlagName, "", "Config file (default: .todototum.yml in the current directory, or $"+config.EnvName(config.FlagName)+")")
 }
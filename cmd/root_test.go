@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -76,3 +78,85 @@ func assertErr(msg string) error { return &simpleErr{s: msg} }
 type simpleErr struct{ s string }
 
 func (e *simpleErr) Error() string { return e.s }
+
+// TestExecute_ExitCodes runs Execute in a subprocess for each documented exit
+// code of the scan command.
+func TestExecute_ExitCodes(t *testing.T) {
+	if args := os.Getenv("WANT_EXIT_ARGS"); args != "" {
+		rootCmd.SetArgs(strings.Split(args, "\x1f"))
+		Execute()
+		return
+	}
+
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// BUG: broken\n// TODO: later\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable failed: %v", err)
+	}
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"findings", []string{"scan", "--path", tmp}, ExitOK},
+		{"no findings", []string{"scan", "--path", empty, "--exit-code-on-findings"}, ExitOK},
+		{"usage", []string{"scan", "--path", tmp, "--report", "bogus"}, ExitUsage},
+		{"unknown flag", []string{"scan", "--no-such-flag"}, ExitUsage},
+		{"error tags", []string{"scan", "--path", tmp, "--error-tags", "BUG"}, ExitGating},
+		{"any finding", []string{"scan", "--path", tmp, "--exit-code-on-findings"}, ExitGating},
+		{"missing root", []string{"scan", "--path", filepath.Join(tmp, "missing")}, ExitScanFailed},
+		{"unwritable report", []string{"scan", "--path", tmp, "--report", "json", "--out", filepath.Join(tmp, "main.go", "r.json")}, ExitScanFailed},
+	}
+	for _, tc := range cases {
+		cmd := exec.Command(exe, "-test.run", "^TestExecute_ExitCodes$")
+		cmd.Env = append(os.Environ(), "WANT_EXIT_ARGS="+strings.Join(tc.args, "\x1f"))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		got := 0
+		if ee, ok := err.(*exec.ExitError); ok {
+			got = ee.ExitCode()
+		} else if err != nil {
+			t.Fatalf("%s: run: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: exit code = %d, want %d; stderr:\n%s", tc.name, got, tc.want, stderr.String())
+		}
+	}
+}
+
+// TestExecute_GateFailureStderr checks that a failed gate prints its error
+// once, without cobra's "Error:" prefix or the usage text.
+func TestExecute_GateFailureStderr(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// BUG: broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable failed: %v", err)
+	}
+	cmd := exec.Command(exe, "-test.run", "^TestExecute_ExitCodes$")
+	args := []string{"scan", "--path", tmp, "--error-tags", "BUG"}
+	cmd.Env = append(os.Environ(), "WANT_EXIT_ARGS="+strings.Join(args, "\x1f"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != ExitGating {
+		t.Fatalf("run: %v, want exit code %d; stderr:\n%s", err, ExitGating, stderr.String())
+	}
+	out := stderr.String()
+	const msg = "1 item(s) tagged with --error-tags"
+	if n := strings.Count(out, msg); n != 1 {
+		t.Errorf("stderr has %q %d times, want once:\n%s", msg, n, out)
+	}
+	for _, unwanted := range []string{"Error:", "Usage:"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("stderr contains %q:\n%s", unwanted, out)
+		}
+	}
+}
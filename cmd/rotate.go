@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// now is a package-level clock to allow tests to control timestamps.
+var now = time.Now
+
+// timestampLayout is the UTC layout used by --timestamped-out filenames.
+const timestampLayout = "20060102T150405Z"
+
+// splitExt splits a report path into its stem and extension, e.g.
+// "out/report.json" -> ("out/report", ".json"), and
+// "report.json.gz" -> ("report", ".json.gz").
+func splitExt(path string) (string, string) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	if strings.EqualFold(ext, "."+todo.CompressGzip) {
+		// Keep compressed reports' double extension together.
+		inner := filepath.Ext(stem)
+		stem, ext = strings.TrimSuffix(stem, inner), inner+ext
+	}
+	return stem, ext
+}
+
+// rotatedPath returns the name of the n-th rotated copy of path,
+// e.g. report.json with n=2 -> report.2.json.
+func rotatedPath(path string, n int) string {
+	stem, ext := splitExt(path)
+	return fmt.Sprintf("%s.%d%s", stem, n, ext)
+}
+
+// pendingPath returns the hidden name a report is rendered under before it
+// replaces path, e.g. out/report.json -> out/.report.json.tmp.
+func pendingPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+}
+
+// rotateReports shifts existing reports out of the way before path is
+// replaced, keeping at most keep previous copies (path.1 is the newest).
+// --write-meta sidecars move along with their reports. Renames run from the
+// oldest to the newest so a crash mid-rotation never loses the most recent
+// existing report: at every step it is still present either under its
+// original name or as path.1.
+func rotateReports(path string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	name := func(i int) string {
+		if i == 0 {
+			return path
+		}
+		return rotatedPath(path, i)
+	}
+	for _, nameOf := range []func(int) string{name, func(i int) string { return metaPath(name(i)) }} {
+		if err := os.Remove(nameOf(keep)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for i := keep - 1; i >= 0; i-- {
+			src, dst := nameOf(i), nameOf(i+1)
+			if _, err := os.Stat(src); err != nil {
+				// Drop the copy left from an earlier rotation, e.g. the
+				// sidecar of a report rotated without one.
+				if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := os.Rename(src, dst); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// timestampedPath inserts a UTC timestamp before the extension of path,
+// e.g. report.json -> report-20240615T031200Z.json.
+func timestampedPath(path string, t time.Time) string {
+	stem, ext := splitExt(path)
+	return stem + "-" + t.UTC().Format(timestampLayout) + ext
+}
+
+// pruneTimestamped removes timestamped siblings of path (as produced by
+// timestampedPath), and their --write-meta sidecars, so that at most keep of
+// them remain, path included once written. The timestamp layout sorts
+// lexicographically, so the newest files are kept.
+func pruneTimestamped(path string, keep int) error {
+	dir := filepath.Dir(path)
+	stem, ext := splitExt(filepath.Base(path))
+	// Strip the timestamp we just generated to recover the base name.
+	if i := strings.LastIndex(stem, "-"); i >= 0 {
+		stem = stem[:i]
+	}
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(stem) + `-\d{8}T\d{6}Z` + regexp.QuoteMeta(ext) + "$")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var matches []string
+	for _, e := range entries {
+		if !e.IsDir() && re.MatchString(e.Name()) {
+			matches = append(matches, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	for i := keep; i < len(matches); i++ {
+		for _, name := range []string{matches[i], metaPath(matches[i])} {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
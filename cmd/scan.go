@@ -1,72 +1,345 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/valerioTomassi/todototum/internal/config"
 	"github.com/valerioTomassi/todototum/internal/todo"
 )
 
 var (
-	path   string
-	report string
-	out    string
-	ignore string
-	outDir string
-	serve  bool
+	path           string
+	report         string
+	out            string
+	ignore         string
+	ignoreFoldCase bool
+	outDir         string
+	serve          bool
+	keep           int
+	tsOut          bool
+
+	respectIgnoreComments bool
+	audit                 bool
+	auditLimit            int
+	mdBadges              string
+	onlyTrailing          bool
+	introduced            bool
+	introducedLimit       int
+	introducedTimeout     time.Duration
+	showStats             bool
+	skipGenerated         bool
+	sortBy                string
+	historyPath           string
+	historyKeep           int
+	trendThreshold        int
+	escalateAfter         int
+	failOnEscalated       bool
+	generatedHeader       string
+	tags                  string
+	tagsFile              string
+	firstMatchOnly        bool
+	normalizePaths        bool
+	includeRawLine        bool
+	width                 int
+	ignoreMatching        string
+	groupBy               string
+	ageAgingDays          int
+	ageOldDays            int
+	maxTextLength         int
+	skipSubmodules        bool
+	checkpointPath        string
+	checkpointEvery       int
+	resumePath            string
+	ghStepSummary         bool
+	ghStepSummaryRows     int
+	errorTags             string
+	warningTags           string
+	jsonShape             string
+	onlyPaths             string
+	modifiedWithin        string
+	trackedOnly           bool
+	pathDefault           string
+	badgeLabel            string
+	badgeTag              string
+	badgeGreen            int
+	badgeYellow           int
+	samplePercent         int
+	gitLog                bool
+	gitLogSince           string
+	gitLogMaxCount        int
+	splitByOwner          bool
+	codeOwnersPath        string
+	emptyOnly             bool
+	detect                string
+	debugPatterns         string
+	minTextLength         int
+	requireOwnerFor       string
+	filterNoise           bool
+	noiseWords            string
+	htmlStreamThreshold   int
+	relativeRoot          bool
+	rawText               bool
+	noRedact              bool
+	noDedupHardLinks      bool
+	reportLang            string
+	budget                string
+	redactPatterns        string
+	compressReport        string
+	commentSyntax         string
+	detectOrphans         bool
+	goSymbols             bool
+	failOnOrphans         bool
+	stdinFormat           string
+	planOnly              bool
+	includeRawBytes       bool
+	outTemplate           string
+	exitOnFindings        bool
+	writeMeta             bool
+	editorScheme          string
 )
 
+// gitRunner is used for history lookups; tests replace it with a scripted fake.
+var gitRunner todo.GitRunner = todo.ExecGitRunner{}
+
 func init() {
 	rootCmd.AddCommand(scanCmd)
-	scanCmd.Flags().StringVarP(&path, "path", "p", ".", "Directory path to scan")
-	scanCmd.Flags().StringVar(&report, "report", "table", "Output format: one of table, html, json, md")
-	scanCmd.Flags().StringVar(&out, "out", "", "Output filename when --report is html|json|md; defaults: report.html/report.json/report.md. Use with --out-dir to control directory")
-	scanCmd.Flags().StringVar(&ignore, "ignore", "", "Comma-separated list of directory names to skip")
-	scanCmd.Flags().StringVar(&outDir, "out-dir", "", "Directory where report is written when using --report html/json/md; if file path is relative it will be placed inside this directory")
-	scanCmd.Flags().BoolVar(&serve, "serve", false, "Generate an HTML report and open it in your default browser (ignores --report value)")
+	addScanFlags(scanCmd.Flags())
+	registerScanCompletions(scanCmd)
+}
+
+// addScanFlags defines the scan flags on fs. Commands that resolve or
+// describe scan settings, like config dump, share the same definitions.
+func addScanFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&path, "path", "p", ".", "Directory path to scan; - scans standard input, reported as <stdin>; @repo scans the git repository containing the current directory")
+	fs.StringVar(&pathDefault, "path-default", pathDefaultCwd, "What to scan when --path is not set: cwd (the current directory) or repo (the whole git repository containing it, as with --path @repo)")
+	fs.StringVar(&stdinFormat, "stdin-format", "", "Language of the content scanned with --path -, as a file extension (go or .go), for comment syntax and debug patterns; tags are matched without language knowledge when unset")
+	fs.StringVar(&report, "report", "table", "Output format: one of table, console, html, json, jsonl, md, treemap (JSON hierarchy of item counts by path), github (GitHub Actions annotations, printed to stdout), codequality (GitLab Code Quality JSON), ics (iCalendar VTODO entries for task apps), badge (SVG README badge of the item count), or a format an embedding program registered with RegisterReporter")
+	fs.StringVar(&groupBy, "group-by", "", "Group terminal output (--report table or console): tag prints one table per tag, most severe first; symbol (with --go-symbols) one per enclosing Go declaration, most items first")
+	fs.IntVar(&maxTextLength, "max-text-length", 0, "Truncate item text in table and console output to this many characters, ending with an ellipsis; 0 keeps full text. File reports are unaffected")
+	fs.IntVar(&width, "width", 0, "Terminal width used by --report console to wrap long text; 0 detects it")
+	fs.StringVar(&out, "out", "", "Output filename when --report is html|json|jsonl|md|treemap|codequality|ics|badge; defaults: report.html/report.json/report.jsonl/report.md/treemap.json/gl-code-quality-report.json/todos.ics/badge.svg. Use - to write to stdout. Use with --out-dir to control directory")
+	fs.BoolVar(&writeMeta, "write-meta", false, "Also write a <report>.meta.json sidecar next to a file report with the scan root, tags, ignore list, duration, stats, tool version and git commit")
+	fs.StringVar(&editorScheme, "editor-scheme", "", "Editor link template added to JSON and JSONL items as editorUri and used for HTML file links, e.g. vscode://file/{path}:{line} or idea://open?file={path}&line={line}; {path} is the item's absolute path and {line} its line")
+	fs.StringVar(&outTemplate, "out-template", "", "Output filename template used instead of the default name when --out is not given, e.g. todos-{date}-{format}.{ext}; {date} is today's UTC date (2006-01-02), {format} the --report value and {ext} its usual extension")
+	fs.StringVar(&ignore, "ignore", "", "Comma-separated list of directory names to skip; entries with a slash, e.g. src/generated, are paths relative to --path")
+	fs.StringVar(&badgeLabel, "badge-label", "", "Left-hand text of --report badge (default: todos, or the --badge-tag tag)")
+	fs.StringVar(&badgeTag, "badge-tag", "", "Count only items with this tag in --report badge, e.g. FIXME")
+	fs.IntVar(&badgeGreen, "badge-green", todo.DefaultBadgeGreen, "Highest count --report badge shows green")
+	fs.IntVar(&badgeYellow, "badge-yellow", todo.DefaultBadgeYellow, "Highest count --report badge shows yellow; larger counts are red")
+	fs.BoolVar(&ignoreFoldCase, "ignore-case-insensitive", false, "Match --ignore directory names regardless of case, e.g. vendor also skips Vendor")
+	fs.IntVar(&samplePercent, "sample", 0, "Scan only about this percentage (1-100) of files, picked by a hash of their path so runs sample the same files; summaries and reports add the estimated full total. Cannot be combined with failing gates")
+	fs.StringVar(&modifiedWithin, "modified-within", "", "Only scan files modified within this window, e.g. 7d, 2w or 36h, by their modification time on disk; no git needed")
+	fs.BoolVar(&trackedOnly, "tracked-only", false, "Scan only the files git tracks (git ls-files) instead of walking --path, skipping untracked build artifacts; outside a git repository every file is scanned")
+	fs.BoolVar(&splitByOwner, "split-by-owner", false, "With --report md or json and --out-dir, write one report per CODEOWNERS owner, an unowned report and an index; items with several owners appear in each of their reports")
+	fs.StringVar(&codeOwnersPath, "codeowners", "", "CODEOWNERS file used by --split-by-owner (default: .github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS in the repository)")
+	fs.StringVar(&onlyPaths, "only", "", "Comma-separated path patterns relative to --path (e.g. src/**,pkg/api/); only matching files are scanned and other directories are not walked")
+	fs.StringVar(&ignoreMatching, "ignore-matching", "", "Comma-separated regular expressions; items whose text matches any are dropped (prefix with path: to match the file path instead, e.g. path:^third_party/; write a literal comma as \\x2c)")
+	fs.StringVar(&outDir, "out-dir", "", "Directory where report is written when using --report html/json/md; if file path is relative it will be placed inside this directory")
+	fs.BoolVar(&serve, "serve", false, "Generate an HTML report and open it in your default browser (ignores --report value)")
+	fs.IntVar(&keep, "keep", 0, "Number of previous reports to keep when writing html/json/md (report.json -> report.1.json -> ...); 0 disables rotation")
+	fs.BoolVar(&respectIgnoreComments, "respect-ignore-comments", true, "Skip matches on lines marked with a todototum:ignore comment (or directly below one) and inside todototum:disable/enable regions")
+	fs.BoolVar(&audit, "audit", false, "Record skipped directories and files with the responsible rule in the report metadata (html/json/md)")
+	fs.IntVar(&auditLimit, "audit-limit", todo.DefaultAuditLimit, "Maximum number of exclusions recorded by --audit before the list is marked truncated")
+	fs.StringVar(&jsonShape, "json-shape", todo.JSONShapeFlat, "Layout of --report json: flat (a todos list) or nested (todos grouped by file under files)")
+	fs.StringVar(&mdBadges, "md-badges", "", "Decorate the Markdown report with severity badges: emoji (default when set without a value) or shields (adds img.shields.io summary badges); give a style as --md-badges=shields")
+	fs.Lookup("md-badges").NoOptDefVal = todo.MarkdownBadgesEmoji
+	fs.StringVar(&detect, "detect", "", "Comma-separated detectors run alongside tag matching: conflicts (merge conflict markers, tagged CONFLICT) and debug (debug statements such as console.log or pdb.set_trace(), tagged DEBUG)")
+	fs.StringVar(&debugPatterns, "debug-patterns", "", "Comma-separated ext:regexp debug statement patterns added to the built-in ones for --detect debug, e.g. .go:log\\.Printf\\(\"DEBUG (write a literal comma as \\x2c)")
+	fs.IntVar(&htmlStreamThreshold, "html-stream-threshold", todo.DefaultHTMLStreamThreshold, "Item count above which the HTML report is written in batches of rows to bound memory use (negative never streams)")
+	fs.StringVar(&reportLang, "lang", todo.DefaultLang, "Language of report headings, labels and the terminal summary: "+strings.Join(todo.Languages(), ", ")+"; tags and item texts are never translated")
+	fs.BoolVar(&noDedupHardLinks, "no-dedup-hardlinks", false, "Scan every hard link of a file instead of once, with its items under the first path and the other links listed as also at (on Unix; elsewhere links are always scanned separately)")
+	fs.BoolVar(&noRedact, "no-redact", false, "Keep credentials (AWS keys, long tokens after key/token/secret/password, PEM headers) in captured text instead of replacing them with [REDACTED]")
+	fs.StringVar(&commentSyntax, "comment-syntax", "", "Comma-separated ext:introducer comment syntaxes used to tell own-line from trailing items, e.g. .nim:#,.zig://; an extension listed here replaces its built-in introducers")
+	fs.StringVar(&compressReport, "compress", "", "Compress file-based reports: gz appends .gz to the output filename unless it already ends with it; with --out - compressed bytes go to stdout")
+	fs.StringVar(&redactPatterns, "redact-patterns", "", "Comma-separated extra regular expressions whose matches are redacted from captured text; a group named secret limits the replacement (write a literal comma as \\x2c)")
+	fs.BoolVar(&relativeRoot, "relative-root", false, "Show file paths without the directory prefix shared by all results")
+	fs.BoolVar(&rawText, "raw-text", false, "Keep item text as written in json, html and md reports instead of prefixing it with the tag (TAG: text)")
+	fs.IntVar(&minTextLength, "min-text-length", 0, "Fail when any item's description is shorter than this many characters, listing the offenders on stderr (0 disables); with --filter-noise, such items are set apart as low-signal instead")
+	fs.StringVar(&requireOwnerFor, "require-owner-for", "", "Comma-separated tags that must name an owner, e.g. FIXME,BUG requires FIXME(name): ...; fails listing the offenders on stderr")
+	fs.BoolVar(&filterNoise, "filter-noise", false, "Set low-signal items apart: descriptions shorter than --min-text-length, or one of --noise-words, once assignee and ticket references are stripped. They are listed in a collapsed section of HTML and Markdown reports and under noise in JSON, and never fail the scan")
+	fs.StringVar(&noiseWords, "noise-words", strings.Join(todo.DefaultNoiseWords, ","), "Comma-separated descriptions --filter-noise treats as low-signal, compared case-insensitively")
+	fs.BoolVar(&emptyOnly, "empty-only", false, "Only report items without a description, e.g. a bare // TODO (combine with --error-tags to fail CI on them)")
+	fs.BoolVar(&onlyTrailing, "only-trailing", false, "Only report tags in comments that trail code on the same line (e.g. x := f() // TODO: check error)")
+	fs.BoolVar(&introduced, "introduced", false, "Search git history for the commit that first introduced each item (slow; bounded by --introduced-limit)")
+	fs.IntVar(&introducedLimit, "introduced-limit", todo.DefaultIntroducedLimit, "Maximum number of items traced by --introduced, most severe first")
+	fs.DurationVar(&introducedTimeout, "introduced-timeout", todo.DefaultIntroducedTimeout, "Per-item time limit for --introduced history lookups")
+	fs.BoolVar(&planOnly, "plan", false, "Only walk --path and print what a scan would read: file and byte totals, skips by reason, top extensions and largest files (a table, or JSON with --report json); no file is opened")
+	fs.BoolVar(&showStats, "stats", false, "Print scan statistics (files walked/opened/skipped, duration) to stderr after the scan")
+	fs.BoolVar(&gitLog, "git-log", false, "Scan commit messages (git log in --path) instead of files; items report the commit hash as file and the message line as line")
+	fs.StringVar(&gitLogSince, "since", "", "With --git-log, only scan commits more recent than this date (passed to git log --since)")
+	fs.IntVar(&gitLogMaxCount, "max-count", 0, "With --git-log, scan at most this many commits, newest first (0 = all)")
+	fs.BoolVar(&skipSubmodules, "skip-submodules", false, "Skip nested repositories (submodules and other directories with their own .git); by default they are scanned with their own .gitignore")
+	fs.BoolVar(&skipGenerated, "skip-generated", false, "Skip files marked linguist-generated in .gitattributes or starting with a generated-code header (see --generated-header)")
+	fs.StringVar(&generatedHeader, "generated-header", todo.DefaultGeneratedHeader.String(), "Regular expression matching a generated-code header line within the first lines of a file, used by --skip-generated")
+	fs.StringVar(&tags, "tags", "", "Comma-separated list of tags to match instead of the defaults (TODO, FIXME, BUG, NOTE)")
+	fs.StringVar(&tagsFile, "tags-file", "", "YAML file defining the tags to match, each with an optional color, severity (error or warning) and aliases; replaces --tags, --error-tags and --warning-tags")
+	fs.BoolVar(&firstMatchOnly, "first-match-only", false, "Report only the first tag of each line, with the rest of the line as its text, instead of one item per tag (e.g. \"TODO: a FIXME: b\")")
+	fs.BoolVar(&normalizePaths, "normalize-paths", true, "Report file paths with forward slashes on every OS; use --normalize-paths=false for native separators")
+	fs.BoolVar(&includeRawLine, "include-raw-line", false, "Include each item's full source line in json/jsonl/html reports")
+	fs.BoolVar(&includeRawBytes, "include-raw-bytes", false, "With --include-raw-line, also keep the original bytes (base64 in json/jsonl) of source lines that had invalid UTF-8 or control characters removed")
+	fs.StringVar(&sortBy, "sort", "", "Order items deterministically before output: file (by file, then line) or age (oldest first, requires --introduced). With --report jsonl --out -, items are otherwise streamed as found")
+	fs.IntVar(&ageAgingDays, "age-aging-days", 90, "Age in days from which the table's Age column turns yellow (with --introduced)")
+	fs.IntVar(&ageOldDays, "age-old-days", 365, "Age in days from which the table's Age column turns red (with --introduced)")
+	fs.StringVar(&historyPath, "history", "", "History file recording each run; enables per-item run counts and escalation of persistent BUG/FIXME items")
+	fs.IntVar(&historyKeep, "history-keep", todo.DefaultHistoryKeep, "Number of runs retained in the --history file")
+	fs.IntVar(&trendThreshold, "trend-threshold", 0, "With --history, list top-level directories in the summary whose item count changed by more than this since the last run")
+	fs.IntVar(&escalateAfter, "escalate-after", todo.DefaultEscalateAfter, "Escalate BUG/FIXME items seen in this many consecutive --history runs")
+	fs.StringVar(&errorTags, "error-tags", "", "Comma-separated tags reported as errors, e.g. BUG,FIXME; the scan exits non-zero when any is found")
+	fs.StringVar(&warningTags, "warning-tags", "", "Comma-separated tags reported as warnings, e.g. TODO,NOTE (default with --error-tags: every other tag)")
+	fs.BoolVar(&failOnEscalated, "fail-on-escalated", false, "Exit with an error when any item is escalated (requires --history)")
+	fs.BoolVar(&goSymbols, "go-symbols", false, "Attribute Go items to their enclosing function, method or declaration, e.g. (*Server).handleLogin, shown in the table, JSON and HTML (files that do not parse get none)")
+	fs.BoolVar(&detectOrphans, "detect-orphans", false, "Flag Go items mentioning a CamelCase identifier declared nowhere in the scanned Go files as possibly obsolete (heuristic; never affects the exit code)")
+	fs.StringVar(&budget, "budget", "", "Comma-separated per-tag item budgets as TAG=N, e.g. FIXME=0,TODO=50; the scan exits non-zero when a tag has more items (see also todototum check)")
+	fs.BoolVar(&exitOnFindings, "exit-code-on-findings", false, "Exit with code 2 when any item is reported, after writing the output")
+	fs.BoolVar(&failOnOrphans, "fail-on-orphans", false, "Exit with an error when --detect-orphans flags any item")
+	fs.StringVar(&checkpointPath, "checkpoint", "", "Periodically write partial results to this file so an interrupted scan can be continued with --resume")
+	fs.IntVar(&checkpointEvery, "checkpoint-every", todo.DefaultCheckpointEvery, "Number of scanned files between --checkpoint writes")
+	fs.StringVar(&resumePath, "resume", "", "Continue an interrupted scan from this --checkpoint file, skipping files it already covers")
+	fs.BoolVar(&ghStepSummary, "gh-step-summary", false, "Append a compact Markdown summary to $GITHUB_STEP_SUMMARY (automatic with --report md when the variable is set)")
+	fs.IntVar(&ghStepSummaryRows, "gh-step-summary-rows", todo.DefaultMarkdownMaxRows, "Maximum number of items listed in the --gh-step-summary table")
+	fs.BoolVar(&tsOut, "timestamped-out", false, "Write html/json/md reports to a timestamped filename (e.g. report-20240615T031200Z.json); with --keep, older timestamped reports beyond N are pruned")
 }
 
 var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan a directory for TODO, FIXME, BUG, NOTE comments",
-	Long:  `Recursively searches a folder for common task markers inside code comments.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Ensure flags don't leak between test runs/executions by resetting Changed at exit.
+	Long: `Recursively searches a folder for common task markers inside code comments.
+
+Exit codes:
+  0  the scan succeeded, whatever it found, and no gate failed
+  1  usage error: invalid flags, arguments or configuration
+  2  the scan completed but a gate failed: --error-tags, --min-text-length,
+     --require-owner-for, --budget, --fail-on-escalated, --fail-on-orphans
+     or --exit-code-on-findings
+  3  the scan itself failed, e.g. --path does not exist or a report or
+     history file could not be read or written`,
+	Args: scanArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		// Ensure flags don't leak between test runs/executions by resetting them at exit.
+		defer resetFlags(cmd)
+		// Errors once the scan has started are scan failures unless a gate
+		// failed; see exitCode.
+		started := false
 		defer func() {
-			if f := cmd.Flags().Lookup("report"); f != nil {
-				f.Changed = false
-				_ = f.Value.Set("table")
-			}
-			if f := cmd.Flags().Lookup("out"); f != nil {
-				f.Changed = false
-				_ = f.Value.Set("")
-			}
-			if f := cmd.Flags().Lookup("out-dir"); f != nil {
-				f.Changed = false
-				_ = f.Value.Set("")
-			}
-			if f := cmd.Flags().Lookup("serve"); f != nil {
-				f.Changed = false
-				_ = f.Value.Set("false")
+			if started {
+				err = scanFailed(err)
 			}
 		}()
+		settings, err := resolveConfig(cmd)
+		if err != nil {
+			return err
+		}
 
 		// Read flag values at runtime
 		p, _ := cmd.Flags().GetString("path")
+		defaultTo, _ := cmd.Flags().GetString("path-default")
+		if p, err = resolveScanPath(p, settings.Settings["path"].Source == config.SourceDefault, defaultTo); err != nil {
+			return err
+		}
 		i, _ := cmd.Flags().GetString("ignore")
+		ignoreFold, _ := cmd.Flags().GetBool("ignore-case-insensitive")
+		modWindow, _ := cmd.Flags().GetString("modified-within")
+		sample, _ := cmd.Flags().GetInt("sample")
+		tracked, _ := cmd.Flags().GetBool("tracked-only")
+		badgeOpts := todo.BadgeOptions{}
+		badgeOpts.Label, _ = cmd.Flags().GetString("badge-label")
+		badgeOpts.Tag, _ = cmd.Flags().GetString("badge-tag")
+		badgeOpts.Green, _ = cmd.Flags().GetInt("badge-green")
+		badgeOpts.Yellow, _ = cmd.Flags().GetInt("badge-yellow")
 		r, _ := cmd.Flags().GetString("report")
 		outName, _ := cmd.Flags().GetString("out")
+		outTmpl, _ := cmd.Flags().GetString("out-template")
+		withMeta, _ := cmd.Flags().GetBool("write-meta")
 		od, _ := cmd.Flags().GetString("out-dir")
 		serveFlag, _ := cmd.Flags().GetBool("serve")
+		keepN, _ := cmd.Flags().GetInt("keep")
+		timestamped, _ := cmd.Flags().GetBool("timestamped-out")
+		respectIgnore, _ := cmd.Flags().GetBool("respect-ignore-comments")
+		auditFlag, _ := cmd.Flags().GetBool("audit")
+		auditMax, _ := cmd.Flags().GetInt("audit-limit")
+		badges, _ := cmd.Flags().GetString("md-badges")
+		shape, _ := cmd.Flags().GetString("json-shape")
+		lang, _ := cmd.Flags().GetString("lang")
+		budgetSpec, _ := cmd.Flags().GetString("budget")
+		editorTmpl, _ := cmd.Flags().GetString("editor-scheme")
+		trailingOnly, _ := cmd.Flags().GetBool("only-trailing")
+		bareOnly, _ := cmd.Flags().GetBool("empty-only")
+		minText, _ := cmd.Flags().GetInt("min-text-length")
+		noiseFilter, _ := cmd.Flags().GetBool("filter-noise")
+		ownerTags, _ := cmd.Flags().GetString("require-owner-for")
+		noiseList, _ := cmd.Flags().GetString("noise-words")
+		streamThreshold, _ := cmd.Flags().GetInt("html-stream-threshold")
+		relRoot, _ := cmd.Flags().GetBool("relative-root")
+		plainText, _ := cmd.Flags().GetBool("raw-text")
+		noRedact, _ := cmd.Flags().GetBool("no-redact")
+		noDedup, _ := cmd.Flags().GetBool("no-dedup-hardlinks")
+		redactExprs, _ := cmd.Flags().GetString("redact-patterns")
+		compressName, _ := cmd.Flags().GetString("compress")
+		detectList, _ := cmd.Flags().GetString("detect")
+		debugExprs, _ := cmd.Flags().GetString("debug-patterns")
+		syntaxList, _ := cmd.Flags().GetString("comment-syntax")
+		traceIntroduced, _ := cmd.Flags().GetBool("introduced")
+		traceLimit, _ := cmd.Flags().GetInt("introduced-limit")
+		traceTimeout, _ := cmd.Flags().GetDuration("introduced-timeout")
+		statsFlag, _ := cmd.Flags().GetBool("stats")
+		skipGen, _ := cmd.Flags().GetBool("skip-generated")
+		skipSubs, _ := cmd.Flags().GetBool("skip-submodules")
+		onlyList, _ := cmd.Flags().GetString("only")
+		logMode, _ := cmd.Flags().GetBool("git-log")
+		logSince, _ := cmd.Flags().GetString("since")
+		logMax, _ := cmd.Flags().GetInt("max-count")
+		ownerSplit, _ := cmd.Flags().GetBool("split-by-owner")
+		ownersFile, _ := cmd.Flags().GetString("codeowners")
+		cpPath, _ := cmd.Flags().GetString("checkpoint")
+		cpEvery, _ := cmd.Flags().GetInt("checkpoint-every")
+		resume, _ := cmd.Flags().GetString("resume")
+		stepSummary, _ := cmd.Flags().GetBool("gh-step-summary")
+		stepSummaryRows, _ := cmd.Flags().GetInt("gh-step-summary-rows")
+		sortKey, _ := cmd.Flags().GetString("sort")
+		histPath, _ := cmd.Flags().GetString("history")
+		histKeep, _ := cmd.Flags().GetInt("history-keep")
+		trendMin, _ := cmd.Flags().GetInt("trend-threshold")
+		escalateN, _ := cmd.Flags().GetInt("escalate-after")
+		failEscalated, _ := cmd.Flags().GetBool("fail-on-escalated")
+		orphans, _ := cmd.Flags().GetBool("detect-orphans")
+		goSyms, _ := cmd.Flags().GetBool("go-symbols")
+		stdinFmt, _ := cmd.Flags().GetString("stdin-format")
+		failOrphans, _ := cmd.Flags().GetBool("fail-on-orphans")
+		findingsExit, _ := cmd.Flags().GetBool("exit-code-on-findings")
+		planFlag, _ := cmd.Flags().GetBool("plan")
+		errTags, _ := cmd.Flags().GetString("error-tags")
+		warnTags, _ := cmd.Flags().GetString("warning-tags")
+		headerExpr, _ := cmd.Flags().GetString("generated-header")
+		tagList, _ := cmd.Flags().GetString("tags")
+		tagsPath, _ := cmd.Flags().GetString("tags-file")
+		firstOnly, _ := cmd.Flags().GetBool("first-match-only")
+		normalize, _ := cmd.Flags().GetBool("normalize-paths")
+		rawLine, _ := cmd.Flags().GetBool("include-raw-line")
+		rawBytes, _ := cmd.Flags().GetBool("include-raw-bytes")
+		consoleWidth, _ := cmd.Flags().GetInt("width")
+		matching, _ := cmd.Flags().GetString("ignore-matching")
+		group, _ := cmd.Flags().GetString("group-by")
+		agingDays, _ := cmd.Flags().GetInt("age-aging-days")
+		oldDays, _ := cmd.Flags().GetInt("age-old-days")
+		maxText, _ := cmd.Flags().GetInt("max-text-length")
 
 		r = strings.ToLower(strings.TrimSpace(r))
 		if serveFlag {
@@ -77,51 +350,660 @@ var scanCmd = &cobra.Command{
 		case "", "table":
 			// default
 			r = "table"
-		case "html", "json", "md":
+		case "console", "html", "json", "jsonl", "md", "treemap", "codequality", "ics", "badge":
 			// ok
+		case "github":
+			// Workflow commands only work on the runner's stdout.
+			if strings.TrimSpace(outName) == "" && outTmpl == "" {
+				outName = "-"
+			}
+		default:
+			if _, ok := todo.LookupReporter(r); !ok {
+				return fmt.Errorf("invalid --report value; must be one of: %s", strings.Join(allReportFormats(), ", "))
+			}
+		}
+		compression, err := todo.ParseCompression(compressName)
+		if err != nil {
+			return fmt.Errorf("--compress: %w", err)
+		}
+		if compression != "" && (r == "table" || r == "console" || serveFlag || ownerSplit) {
+			return errors.New("--compress requires a file-based --report and cannot be combined with --serve or --split-by-owner")
+		}
+		if consoleWidth < 0 {
+			return errors.New("invalid --width value; must be zero or greater")
+		}
+		if minText < 0 {
+			return errors.New("invalid --min-text-length value; must be zero or greater")
+		}
+		if noiseFilter && ownerSplit {
+			return errors.New("--filter-noise cannot be combined with --split-by-owner")
+		}
+		if maxText < 0 {
+			return errors.New("invalid --max-text-length value; must be zero or greater")
+		}
+		sortKey = strings.ToLower(strings.TrimSpace(sortKey))
+		switch sortKey {
+		case "", "file", "age":
 		default:
-			return errors.New("invalid --report value; must be one of: table, html, json, md")
+			return errors.New("invalid --sort value; must be one of: file, age")
+		}
+		if agingDays <= 0 || oldDays < agingDays {
+			return errors.New("invalid --age-aging-days/--age-old-days; need 0 < aging <= old")
+		}
+		ageThresholds := todo.AgeThresholds{Aging: time.Duration(agingDays) * 24 * time.Hour, Old: time.Duration(oldDays) * 24 * time.Hour}
+		group = strings.ToLower(strings.TrimSpace(group))
+		switch group {
+		case "":
+		case "tag", "symbol":
+			if r != "table" && r != "console" {
+				return errors.New("--group-by requires --report table or console")
+			}
+			if group == "symbol" && !goSyms {
+				return errors.New("--group-by symbol requires --go-symbols")
+			}
+		default:
+			return errors.New("invalid --group-by value; must be: tag, symbol")
+		}
+		shape = strings.ToLower(strings.TrimSpace(shape))
+		switch shape {
+		case "", todo.JSONShapeFlat:
+		case todo.JSONShapeNested:
+			if r != "json" {
+				return errors.New("--json-shape nested requires --report json")
+			}
+		default:
+			return errors.New("invalid --json-shape value; must be one of: flat, nested")
+		}
+		badges = strings.ToLower(strings.TrimSpace(badges))
+		switch badges {
+		case "", todo.MarkdownBadgesEmoji, todo.MarkdownBadgesShields:
+		default:
+			return errors.New("invalid --md-badges value; must be one of: emoji, shields")
+		}
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if err := todo.ValidateLang(lang); err != nil {
+			return fmt.Errorf("invalid --lang value: %w", err)
+		}
+		if keepN < 0 {
+			return errors.New("invalid --keep value; must be zero or greater")
+		}
+		if badgeOpts.Green < 0 || badgeOpts.Yellow < badgeOpts.Green {
+			return errors.New("invalid --badge-green/--badge-yellow; need 0 <= green <= yellow")
+		}
+		if (r == "table" || r == "console") && (keepN > 0 || timestamped) {
+			return errors.New("--keep and --timestamped-out require a file-based --report (html, json, jsonl, md, treemap)")
+		}
+		if outTmpl != "" {
+			if r == "table" || r == "console" || strings.TrimSpace(outName) != "" || ownerSplit {
+				return errors.New("--out-template requires a file-based --report and cannot be combined with --out or --split-by-owner")
+			}
+			if _, err := expandOutTemplate(outTmpl, r, now()); err != nil {
+				return fmt.Errorf("--out-template: %w", err)
+			}
+		}
+		if sortKey == "age" && !traceIntroduced {
+			return errors.New("--sort age requires --introduced")
+		}
+		var tagDefs *tagSet
+		if tagsPath != "" {
+			if tagList != "" || errTags != "" || warnTags != "" {
+				return errors.New("--tags-file cannot be combined with --tags, --error-tags or --warning-tags")
+			}
+			if tagDefs, err = loadTagsFile(tagsPath); err != nil {
+				return fmt.Errorf("--tags-file: %w", err)
+			}
+			errTags, warnTags = strings.Join(tagDefs.errors, ","), strings.Join(tagDefs.warnings, ",")
+			tagColors = tagDefs.colors
+			defer func() { tagColors = nil }()
+		}
+		classes, err := newTagClasses(errTags, warnTags)
+		if err != nil {
+			return err
+		}
+		budgets, err := todo.ParseBudgets(buildIgnoreList(budgetSpec))
+		if err != nil {
+			return fmt.Errorf("--budget: %w", err)
+		}
+		var links todo.ItemLinks
+		if strings.TrimSpace(editorTmpl) != "" {
+			if links.Editor, err = todo.ParseEditorScheme(strings.TrimSpace(editorTmpl)); err != nil {
+				return fmt.Errorf("invalid --editor-scheme: %w", err)
+			}
+		}
+		if !logMode && (logSince != "" || logMax != 0) {
+			return errors.New("--since and --max-count require --git-log")
+		}
+		if logMax < 0 {
+			return errors.New("invalid --max-count value; must be zero or greater")
+		}
+		if logMode && (traceIntroduced || cpPath != "" || resume != "") {
+			return errors.New("--git-log cannot be combined with --introduced, --checkpoint or --resume")
+		}
+		if ownerSplit {
+			if _, ok := ownerReportExt[r]; !ok || serveFlag {
+				return errors.New("--split-by-owner requires --report md or json")
+			}
+			if strings.TrimSpace(od) == "" || strings.TrimSpace(outName) != "" || keepN > 0 || timestamped {
+				return errors.New("--split-by-owner requires --out-dir and cannot be combined with --out, --keep or --timestamped-out")
+			}
+		}
+		if trendMin < 0 {
+			return errors.New("--trend-threshold must not be negative")
+		}
+		if failEscalated && histPath == "" {
+			return errors.New("--fail-on-escalated requires --history")
+		}
+		if failOrphans && !orphans {
+			return errors.New("--fail-on-orphans requires --detect-orphans")
+		}
+		if rawBytes && !rawLine {
+			return errors.New("--include-raw-bytes requires --include-raw-line")
+		}
+		if orphans && logMode {
+			return errors.New("--detect-orphans cannot be combined with --git-log")
+		}
+		var modSince time.Time
+		if strings.TrimSpace(modWindow) != "" {
+			if logMode {
+				return errors.New("--modified-within cannot be combined with --git-log")
+			}
+			window, err := parseWindow(modWindow)
+			if err != nil {
+				return fmt.Errorf("invalid --modified-within: %w", err)
+			}
+			modSince = now().Add(-window)
+		}
+		stdinMode := strings.TrimSpace(p) == "-"
+		if sample < 0 || sample > 100 {
+			return errors.New("invalid --sample value; must be a percentage from 1 to 100")
+		}
+		if sample > 0 && (logMode || stdinMode) {
+			return errors.New("--sample cannot be combined with --git-log or --path -")
+		}
+		// A gate passing on a sample says nothing about the files left out.
+		if sample > 0 && (strings.TrimSpace(errTags) != "" || (minText > 0 && !noiseFilter) || ownerTags != "" || failEscalated || failOrphans || findingsExit || len(budgets) > 0) {
+			return errors.New("--sample cannot be combined with --error-tags, --min-text-length, --require-owner-for, --fail-on-escalated, --fail-on-orphans, --exit-code-on-findings or --budget: gating on a sample is unsound")
+		}
+		if tracked && (logMode || stdinMode) {
+			return errors.New("--tracked-only cannot be combined with --git-log or --path -")
+		}
+		if stdinFmt != "" && !stdinMode {
+			return errors.New("--stdin-format requires --path -")
+		}
+		if stdinMode && (logMode || traceIntroduced || cpPath != "" || resume != "" || ownerSplit || orphans) {
+			return errors.New("--path - cannot be combined with --git-log, --introduced, --checkpoint, --resume, --split-by-owner or --detect-orphans")
+		}
+		if planFlag && ((r != "table" && r != "json") || serveFlag || logMode || stdinMode || traceIntroduced || cpPath != "" || ownerSplit || compression != "" || keepN > 0 || timestamped) {
+			return errors.New("--plan requires --report table or json and cannot be combined with --serve, --git-log, --path -, --introduced, --checkpoint, --split-by-owner, --compress, --keep or --timestamped-out")
+		}
+		stepSummaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+		if stepSummary && stepSummaryPath == "" {
+			return errors.New("--gh-step-summary requires the GITHUB_STEP_SUMMARY environment variable")
+		}
+		stepSummary = stepSummary || (stepSummaryPath != "" && r == "md")
+		toStdout := strings.TrimSpace(outName) == "-"
+		if toStdout && (r == "table" || r == "console" || serveFlag || keepN > 0 || timestamped) {
+			return errors.New("--out - requires --report html, json, jsonl, md or treemap and cannot be combined with --serve, --keep or --timestamped-out")
+		}
+		if withMeta && (r == "table" || r == "console" || toStdout || ownerSplit || planFlag) {
+			return errors.New("--write-meta requires a report file and cannot be combined with --out -, --split-by-owner or --plan")
 		}
 
-		ignoreList := buildIgnoreList(i)
+		var filters []todo.FilterRule
+		for _, pattern := range buildIgnoreList(matching) {
+			rule, err := todo.ParseFilterRule(pattern)
+			if err != nil {
+				return fmt.Errorf("--ignore-matching: %w", err)
+			}
+			filters = append(filters, rule)
+		}
 
-		items, err := todo.ScanDir(p, ignoreList)
-		if err != nil {
+		opts := todo.DefaultScanOptions()
+		opts.IgnoreDirs = buildIgnoreList(i)
+		opts.IgnoreDirsFoldCase = ignoreFold
+		opts.ModifiedSince = modSince
+		opts.SamplePercent = sample
+		opts.RespectIgnoreComments = respectIgnore
+		opts.Audit = auditFlag
+		opts.AuditLimit = auditMax
+		opts.SkipGenerated = skipGen
+		opts.SkipSubmodules = skipSubs
+		opts.Only = buildIgnoreList(onlyList)
+		if _, err := todo.ParseAllowlist(opts.Only); err != nil {
+			return fmt.Errorf("invalid --only: %w", err)
+		}
+		opts.Tags = buildIgnoreList(tagList)
+		if tagDefs != nil {
+			opts.Tags, opts.Aliases = tagDefs.tags, tagDefs.aliases
+		}
+		for _, name := range buildIgnoreList(detectList) {
+			if !slices.Contains(todo.Detectors, strings.ToLower(name)) {
+				return fmt.Errorf("invalid --detect value %q; must be one of: %s", name, strings.Join(todo.Detectors, ", "))
+			}
+			opts.Detect = append(opts.Detect, strings.ToLower(name))
+		}
+		for _, expr := range buildIgnoreList(debugExprs) {
+			ext, re, err := todo.ParseDebugPattern(expr)
+			if err != nil {
+				return fmt.Errorf("--debug-patterns: %w", err)
+			}
+			if opts.DebugPatterns == nil {
+				opts.DebugPatterns = make(map[string][]*regexp.Regexp)
+			}
+			opts.DebugPatterns[ext] = append(opts.DebugPatterns[ext], re)
+		}
+		for _, entry := range buildIgnoreList(syntaxList) {
+			ext, intro, err := todo.ParseCommentSyntax(entry)
+			if err != nil {
+				return fmt.Errorf("--comment-syntax: %w", err)
+			}
+			if opts.CommentSyntax == nil {
+				opts.CommentSyntax = make(map[string][]string)
+			}
+			opts.CommentSyntax[ext] = append(opts.CommentSyntax[ext], intro)
+		}
+		opts.Redact = !noRedact
+		opts.DedupHardLinks = !noDedup
+		for _, expr := range buildIgnoreList(redactExprs) {
+			re, err := todo.ParseRedactPattern(expr)
+			if err != nil {
+				return fmt.Errorf("--redact-patterns: %w", err)
+			}
+			opts.RedactPatterns = append(opts.RedactPatterns, re)
+		}
+		opts.NormalizePaths = normalize
+		opts.FirstMatchOnly = firstOnly
+		// Nested .todototum.yml files adjust matching for their subtree. The
+		// root belongs to the root config: a .todototum.yml there is either
+		// that file or one --config chose not to use.
+		opts.DirOverride = func(dir string) (*todo.Override, error) {
+			if dir == "." {
+				return nil, nil
+			}
+			file, err := loadDirConfig(cmd.Flags(), filepath.Join(p, filepath.FromSlash(dir)), settings.ConfigFile)
+			if file == nil || err != nil {
+				return nil, err
+			}
+			return dirOverride(file, settings), nil
+		}
+		opts.IncludeRawLine = rawLine
+		opts.RawBytes = rawBytes
+		if skipGen {
+			header, err := regexp.Compile(headerExpr)
+			if err != nil {
+				return fmt.Errorf("invalid --generated-header: %w", err)
+			}
+			opts.GeneratedHeader = header
+		}
+
+		started = true
+		if tracked {
+			if _, ok := todo.RepoRoot(p); ok {
+				files, err := todo.TrackedFiles(context.Background(), gitRunner, p)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: --tracked-only: %v; scanning every file\n", err)
+				} else {
+					opts.Files = files
+				}
+			}
+		}
+		var prior *todo.Checkpoint
+		var checkpointer *todo.Checkpointer
+		if resume != "" || cpPath != "" {
+			absRoot, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			// The key covers nested config files, so a checkpoint is not
+			// resumed after one changed what its subtree yields.
+			matchKey, err := opts.TreeMatchKey(p)
+			if err != nil {
+				return err
+			}
+			if resume != "" {
+				if prior, err = todo.LoadCheckpoint(resume); err != nil {
+					return fmt.Errorf("--resume: %w", err)
+				}
+				if err := prior.CheckRoot(absRoot); err != nil {
+					return fmt.Errorf("--resume: %w", err)
+				}
+				if prior.Stale(matchKey) {
+					fmt.Fprintf(os.Stderr, "--resume: %s was written with other scan settings (e.g. --tags); rescanning every file\n", resume)
+					prior = nil
+				} else {
+					opts.Done = prior.DoneSet()
+				}
+			}
+			if cpPath != "" {
+				checkpointer = todo.NewCheckpointer(cpPath, absRoot, matchKey, cpEvery, prior)
+				opts.OnFileScanned = checkpointer.FileScanned
+			}
+		}
+
+		if planFlag {
+			plan, err := todo.PlanDir(p, opts)
+			if err != nil {
+				return err
+			}
+			if r == "json" {
+				return writePlanJSON(plan, outName, od)
+			}
+			printPlan(os.Stdout, plan)
+			return nil
+		}
+
+		// --detect-orphans knows every declaration in the scanned Go files,
+		// including those of files a resumed scan already covered.
+		var goFiles []string
+		if orphans {
+			for f := range opts.Done {
+				if strings.EqualFold(filepath.Ext(f), ".go") {
+					goFiles = append(goFiles, f)
+				}
+			}
+			prev := opts.OnFileScanned
+			opts.OnFileScanned = func(file string, items []todo.Todo) {
+				if prev != nil {
+					prev(file, items)
+				}
+				if strings.EqualFold(filepath.Ext(file), ".go") {
+					goFiles = append(goFiles, file)
+				}
+			}
+		}
+
+		// Items link to the files scanned on disk; standard input and
+		// history have none.
+		if !stdinMode && !logMode {
+			if links.Root, err = filepath.Abs(p); err != nil {
+				return err
+			}
+		}
+
+		// Stream JSONL straight from the scan workers when nothing needs the
+		// complete result first (ordering or post-scan filtering/enrichment).
+		var stream *todo.JSONLStream
+		var streamOut io.WriteCloser
+		if r == "jsonl" && toStdout && canStreamJSONL(cmd.Flags(), stdinMode, prior != nil, classes) {
+			if streamOut, err = reportWriter(toStdout, compression).Create("-"); err != nil {
+				return err
+			}
+			stream = todo.NewJSONLStream(streamOut)
+			opts.OnItem = func(t todo.Todo) {
+				links.Apply(&t)
+				_ = stream.Write(t)
+			}
+		}
+
+		var res todo.ScanResult
+		var scanErr error
+		switch {
+		case logMode:
+			res, scanErr = scanGitLog(p, opts, todo.GitLogOptions{Since: logSince, MaxCount: logMax})
+		case stdinMode:
+			res, scanErr = scanStdin(cmd.InOrStdin(), opts, stdinFmt)
+		default:
+			res, scanErr = todo.ScanDirDetailed(p, opts, todo.OSFileReader{})
+		}
+		if scanErr != nil {
+			return scanErr
+		}
+		for _, pr := range res.Problems {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s (%s)\n", pr.Path, pr.Message, pr.Code)
+		}
+		if res.Stats.Redacted > 0 {
+			fmt.Fprintf(os.Stderr, "warning: redacted %d secret(s) in captured text (use --no-redact to keep them)\n", res.Stats.Redacted)
+		}
+		if checkpointer != nil {
+			if err := checkpointer.Flush(); err != nil {
+				return err
+			}
+		}
+		if prior != nil {
+			res.Items = append(append([]todo.Todo{}, prior.Items...), res.Items...)
+		}
+		if statsFlag {
+			printStats(os.Stderr, res.Stats)
+		}
+		if stream != nil {
+			if err := stream.Close(); err != nil {
+				return err
+			}
+			return streamOut.Close()
+		}
+		items, filtered := todo.FilterItems(res.Items, filters)
+		if trailingOnly {
+			items = filterPosition(items, todo.PositionTrailing)
+		}
+		if bareOnly {
+			items = filterEmpty(items)
+		}
+		var noise []todo.Todo
+		if noiseFilter {
+			items, noise = todo.SplitNoise(items, todo.NoiseRules{MinTextLength: minText, Words: buildIgnoreList(noiseList)})
+		}
+		var enrichers []todo.Enricher
+		if orphans {
+			enrichers = append(enrichers, todo.OrphanEnricher{Dir: p, Files: goFiles})
+		}
+		if goSyms {
+			enrichers = append(enrichers, todo.GoSymbolEnricher{Dir: p})
+		}
+		if traceIntroduced {
+			enrichers = append(enrichers, todo.IntroducedEnricher{Dir: p, Options: todo.IntroducedOptions{Limit: traceLimit, Timeout: traceTimeout}, Git: gitRunner})
+		}
+		if ownerSplit {
+			co, err := loadCodeOwners(p, ownersFile)
+			if err != nil {
+				return fmt.Errorf("--split-by-owner: %w", err)
+			}
+			enrichers = append(enrichers, todo.OwnersEnricher{Owners: co, Dir: p})
+		}
+		if items, err = todo.Enrich(items, enrichers); err != nil {
 			return err
 		}
-		if len(items) == 0 {
-			fmt.Println("No TODOs found.")
+		var burnDown *todo.BurnDown
+		var prevCounts *summaryCounts
+		if histPath != "" {
+			runs, err := todo.LoadHistory(histPath)
+			if err != nil {
+				return fmt.Errorf("read history: %w", err)
+			}
+			if len(runs) > 0 {
+				c := runSummary(runs[len(runs)-1])
+				prevCounts = &c
+			}
+			items = todo.ApplyHistory(items, runs, escalateN)
+			burnDown = todo.ComputeBurnDown(items, runs, now())
+			if err := todo.AppendHistory(histPath, items, now(), histKeep); err != nil {
+				return fmt.Errorf("write history: %w", err)
+			}
+		}
+		if sortKey != "" {
+			items = sortItems(items, sortKey)
+		}
+		if findingsExit {
+			defer func() {
+				if err == nil && len(items) > 0 {
+					err = gatingError(fmt.Errorf("%d item(s) found with --exit-code-on-findings", len(items)))
+				}
+			}()
+		}
+		if failOrphans {
+			defer func() {
+				if err == nil {
+					err = orphansError(items)
+				}
+			}()
+		}
+		if failEscalated {
+			defer func() {
+				if err == nil {
+					err = escalatedError(items)
+				}
+			}()
+		}
+		if classes.enabled() {
+			defer func() {
+				if err == nil {
+					err = errorTagsError(items, classes)
+				}
+			}()
+		}
+		if len(budgets) > 0 {
+			defer func() {
+				if err == nil {
+					err = budgetError(os.Stderr, items, budgets)
+				}
+			}()
+		}
+		if tags := buildIgnoreList(ownerTags); len(tags) > 0 {
+			defer func() {
+				if err == nil {
+					err = ownerError(os.Stderr, items, tags)
+				}
+			}()
+		}
+		if minText > 0 && !noiseFilter {
+			defer func() {
+				if err == nil {
+					err = shortTextError(os.Stderr, items, minText)
+				}
+			}()
+		}
+		reportOpts := todo.ReportOptions{Audit: res.Audit, MarkdownBadges: badges, FilteredByPattern: filtered, Now: now(), JSONShape: shape, BurnDown: burnDown, HTMLStreamThreshold: streamThreshold, RelativeRoot: relRoot, LinesScanned: res.Stats.Lines, Problems: res.Problems, Noise: noise, RawText: plainText, SamplePercent: sample, Badge: badgeOpts, Lang: lang, Links: links}
+		if stepSummary {
+			summaryOpts := reportOpts
+			summaryOpts.MarkdownMaxRows = stepSummaryRows
+			if err := todo.AppendMarkdownSummary(items, stepSummaryPath, summaryOpts); err != nil {
+				return fmt.Errorf("write step summary: %w", err)
+			}
+		}
+		if ownerSplit {
+			return writeOwnerReports(r, items, od, reportOpts)
+		}
+		// Only the terminal formats say so in words; a file report of zero
+		// items is still a valid report for whatever consumes it.
+		if len(items) == 0 && len(noise) == 0 && (r == "table" || r == "console") {
+			fmt.Println(todo.Message(lang, "no-todos"))
 			return nil
 		}
 
-		if r == "table" {
+		if r == "table" || r == "console" {
 			// print to terminal as a table then a short summary.
-			renderTable(os.Stdout, items)
-			printSummary(items)
+			shown := items
+			if relRoot {
+				shown = todo.TrimCommonDir(items)
+			}
+			render := func(items []todo.Todo) { renderTable(os.Stdout, truncateTexts(items, maxText), ageThresholds, lang) }
+			if r == "console" {
+				if consoleWidth == 0 {
+					consoleWidth = terminalWidth()
+				}
+				render = func(items []todo.Todo) { renderConsole(os.Stdout, truncateTexts(items, maxText), consoleWidth, lang) }
+			}
+			var est *todo.Sample
+			if sample > 0 && sample < 100 {
+				est = todo.NewSample(items, sample)
+				fmt.Println(color.New(color.FgYellow).Sprintf(todo.Message(lang, "sample.terminal"), sample))
+			}
+			switch group {
+			case "tag":
+				for _, g := range groupByTag(shown) {
+					fmt.Printf("%s (%d)\n", tagColor(g[0].Tag).Add(color.Bold).Sprint(strings.ToUpper(g[0].Tag)), len(g))
+					render(g)
+					fmt.Println()
+				}
+			case "symbol":
+				for _, g := range groupBySymbol(shown) {
+					fmt.Printf("%s (%d)\n", color.New(color.Bold).Sprint(symbolLabel(g[0].Symbol)), len(g))
+					render(g)
+					fmt.Println()
+				}
+			default:
+				render(shown)
+			}
+			printSummary(items, filtered, len(noise), res.Stats.Lines, prevCounts, trendMin, est, lang)
+			if classes.enabled() {
+				printClassSummary(items, classes, lang)
+			}
 			return nil
 		}
 
-		// For file-based reports, choose default output filename when not provided
+		// For file-based reports, choose default output filename when not
+		// provided: from --out-template when set, the fixed default otherwise.
+		if strings.TrimSpace(outName) == "" && outTmpl != "" {
+			if outName, err = expandOutTemplate(outTmpl, r, now()); err != nil {
+				return fmt.Errorf("--out-template: %w", err)
+			}
+		}
 		if strings.TrimSpace(outName) == "" {
 			switch r {
 			case "html":
 				outName = "report.html"
 			case "json":
 				outName = "report.json"
+			case "jsonl":
+				outName = "report.jsonl"
 			case "md":
 				outName = "report.md"
+			case "treemap":
+				outName = "treemap.json"
+			case "codequality":
+				outName = "gl-code-quality-report.json"
+			case "ics":
+				outName = "todos.ics"
+			case "badge":
+				outName = "badge.svg"
+			default:
+				outName = "report." + reportExt(r)
 			}
 		}
-		outPath := resolveOutputPath(outName, od)
+		w := reportWriter(toStdout, compression)
+		if toStdout {
+			return writeReport(r, items, "-", w, reportOpts)
+		}
+		outPath := resolveOutputPath(todo.CompressedName(outName, compression), od)
 		if err := ensureParentDir(outPath); err != nil {
 			return err
 		}
-
-		switch r {
-		case "html":
-			if err := todo.GenerateHTMLReport(items, outPath); err != nil {
+		if timestamped {
+			outPath = timestampedPath(outPath, now())
+		}
+		// With --keep, the report is rendered first, so a failure leaves the
+		// earlier reports as they were.
+		target := outPath
+		if keepN > 0 && !timestamped {
+			target = pendingPath(outPath)
+		}
+		if err := writeReport(r, items, target, w, reportOpts); err != nil {
+			if target != outPath {
+				_ = os.Remove(target)
+			}
+			return err
+		}
+		switch {
+		case keepN > 0 && timestamped:
+			if err := pruneTimestamped(outPath, keepN); err != nil {
+				return err
+			}
+		case keepN > 0:
+			if err := rotateReports(outPath, keepN); err != nil {
+				return err
+			}
+			if err := os.Rename(target, outPath); err != nil {
 				return err
 			}
+		}
+		metaOut := ""
+		if withMeta {
+			metaOut = metaPath(outPath)
+			if err := writeReportMeta(w, metaOut, newReportMeta(cmd.Context(), outPath, r, p, opts, res.Stats)); err != nil {
+				return fmt.Errorf("write --write-meta sidecar: %w", err)
+			}
+		}
+		switch r {
+		case "html":
 			fmt.Printf("HTML report written to %s\n", outPath)
 			if serveFlag {
 				if err := browserOpen(outPath); err != nil {
@@ -130,20 +1012,192 @@ var scanCmd = &cobra.Command{
 				fmt.Println("Opened in your default browser.")
 			}
 		case "json":
-			if err := todo.GenerateJSONReport(items, outPath); err != nil {
-				return err
-			}
 			fmt.Printf("JSON report written to %s\n", outPath)
+		case "jsonl":
+			fmt.Printf("JSONL report written to %s\n", outPath)
 		case "md":
-			if err := todo.GenerateMarkdownReport(items, outPath); err != nil {
-				return err
-			}
 			fmt.Printf("Markdown report written to %s\n", outPath)
+		case "treemap":
+			fmt.Printf("Treemap data written to %s\n", outPath)
+		case "github":
+			fmt.Printf("GitHub annotations written to %s\n", outPath)
+		case "codequality":
+			fmt.Printf("Code Quality report written to %s\n", outPath)
+		case "ics":
+			fmt.Printf("iCalendar tasks written to %s\n", outPath)
+		case "badge":
+			fmt.Printf("Badge written to %s\n", outPath)
+		default:
+			fmt.Printf("%s report written to %s\n", r, outPath)
+		}
+		if metaOut != "" {
+			fmt.Printf("Metadata written to %s\n", metaOut)
 		}
 		return nil
 	},
 }
 
+// reportExts holds the usual file extension of each file-based report, the
+// {ext} of --out-template.
+var reportExts = map[string]string{
+	"html":        "html",
+	"json":        "json",
+	"jsonl":       "jsonl",
+	"md":          "md",
+	"treemap":     "json",
+	"github":      "txt",
+	"codequality": "json",
+	"ics":         "ics",
+	"badge":       "svg",
+}
+
+// reportExt returns the file extension of format: its reportExts entry, or
+// the format name for a registered Reporter.
+func reportExt(format string) string {
+	if ext, ok := reportExts[format]; ok {
+		return ext
+	}
+	return format
+}
+
+// outTemplatePlaceholder matches a {name} placeholder of --out-template.
+var outTemplatePlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// expandOutTemplate fills in the placeholders of an --out-template for a
+// report of the given format written at t: {date}, {format} and {ext}.
+func expandOutTemplate(tmpl, format string, t time.Time) (string, error) {
+	var unknown string
+	name := outTemplatePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		switch key := m[1 : len(m)-1]; key {
+		case "date":
+			return t.UTC().Format("2006-01-02")
+		case "format":
+			return format
+		case "ext":
+			return reportExt(format)
+		default:
+			if unknown == "" {
+				unknown = m
+			}
+			return m
+		}
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("unknown placeholder %s; must be one of: {date}, {format}, {ext}", unknown)
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", errors.New("template expands to an empty filename")
+	}
+	return name, nil
+}
+
+// --path-default values.
+const (
+	pathDefaultCwd  = "cwd"
+	pathDefaultRepo = "repo"
+)
+
+// pathRepo is the --path value scanning the repository containing the
+// current directory.
+const pathRepo = "@repo"
+
+// resolveScanPath returns the directory to scan for the --path value p:
+// pathRepo, or p itself left at its default while defaultTo is repo, is the
+// root of the git repository containing the working directory; any other
+// value is used as given.
+func resolveScanPath(p string, isDefault bool, defaultTo string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(defaultTo)) {
+	case "", pathDefaultCwd:
+	case pathDefaultRepo:
+		if isDefault {
+			p = pathRepo
+		}
+	default:
+		return "", fmt.Errorf("invalid --path-default value %q; must be one of: %s, %s", defaultTo, pathDefaultCwd, pathDefaultRepo)
+	}
+	if strings.TrimSpace(p) != pathRepo {
+		return p, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	root, ok := todo.RepoRoot(wd)
+	if !ok {
+		return "", fmt.Errorf("%s: %s is not inside a git repository", pathRepo, wd)
+	}
+	return root, nil
+}
+
+// parseWindow parses a --modified-within window: a whole number of days or
+// weeks such as 7d or 2w, or a Go duration such as 36h or 90m.
+func parseWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	d, err := time.ParseDuration(s)
+	for unit, day := range map[string]int{"d": 1, "w": 7} {
+		if n, ok := strings.CutSuffix(s, unit); ok {
+			var days int
+			days, err = strconv.Atoi(n)
+			d = time.Duration(days*day) * 24 * time.Hour
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a window such as 7d, 2w or 36h", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("window %q must be positive", s)
+	}
+	return d, nil
+}
+
+// writeReport generates the report of the given format through w. Formats
+// other than the ones listed go to the registered Reporters, which include
+// html, json and md.
+func writeReport(format string, items []todo.Todo, outPath string, w todo.FileWriter, opts todo.ReportOptions) error {
+	switch format {
+	case "jsonl":
+		return todo.GenerateJSONLReportWithWriter(items, outPath, w, opts)
+	case "treemap":
+		return todo.GenerateTreemapReportWithWriter(items, outPath, w, opts)
+	case "github":
+		return todo.GenerateGitHubReportWithWriter(items, outPath, w, opts)
+	case "codequality":
+		return todo.GenerateCodeQualityReportWithWriter(items, outPath, w, opts)
+	case "ics":
+		return todo.GenerateICSReportWithWriter(items, outPath, w, opts)
+	case "badge":
+		return todo.GenerateBadgeWithWriter(items, outPath, w, opts)
+	}
+	return todo.GenerateReportWithWriter(format, items, outPath, w, opts)
+}
+
+// reportWriter returns the writer for the main report: standard output for
+// --out -, the filesystem otherwise, compressed per --compress.
+func reportWriter(toStdout bool, compression string) todo.FileWriter {
+	var w todo.FileWriter = todo.OSFileWriter{}
+	if toStdout {
+		w = stdoutWriter{}
+	}
+	if compression != "" {
+		w = todo.CompressingFileWriter{Writer: w, Compression: compression}
+	}
+	return w
+}
+
+// stdoutWriter is a todo.FileWriter that sends every report to standard
+// output, used for --out -.
+type stdoutWriter struct{}
+
+// Create ignores name and returns standard output; closing it is a no-op.
+func (stdoutWriter) Create(string) (io.WriteCloser, error) {
+	return nopWriteCloser{os.Stdout}, nil
+}
+
+// nopWriteCloser adds a no-op Close to an io.Writer.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
 // browserOpen is a package-level function variable to allow tests to stub the opener.
 var browserOpen = openInBrowser
 
@@ -174,6 +1228,35 @@ func openInBrowser(path string) error {
 	}
 }
 
+// scanGitLog scans the commit messages of the repository at dir with the
+// per-file options of opts.
+func scanGitLog(dir string, opts todo.ScanOptions, logOpts todo.GitLogOptions) (todo.ScanResult, error) {
+	start := time.Now()
+	s, err := todo.NewScanner(opts)
+	if err != nil {
+		return todo.ScanResult{}, err
+	}
+	items, err := todo.ScanGitLog(context.Background(), dir, s, logOpts, gitRunner)
+	if err != nil {
+		return todo.ScanResult{}, fmt.Errorf("--git-log: %w", err)
+	}
+	return todo.ScanResult{Items: items, Stats: todo.ScanStats{Duration: time.Since(start)}}, nil
+}
+
+// scanStdin scans r, the standard input, as content of the given format
+// with the per-file options of opts.
+func scanStdin(r io.Reader, opts todo.ScanOptions, format string) (todo.ScanResult, error) {
+	s, err := todo.NewScanner(opts)
+	if err != nil {
+		return todo.ScanResult{}, err
+	}
+	res, err := s.ScanReaderAs(todo.StdinName, format, r)
+	if err != nil {
+		return todo.ScanResult{}, fmt.Errorf("read standard input: %w", err)
+	}
+	return res, nil
+}
+
 // buildIgnoreList parses a comma-separated ignore string into a slice, trimming spaces.
 func buildIgnoreList(csv string) []string {
 	if csv == "" {
@@ -190,32 +1273,176 @@ func buildIgnoreList(csv string) []string {
 	return out
 }
 
-// renderTable writes the TODO items as a table to the provided writer.
-func renderTable(w *os.File, items []todo.Todo) {
-	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"File", "Line", "Tag", "Text"})
+// renderTable writes the TODO items as a table to the provided writer. When
+// any item carries history (see --introduced) an Age column is added,
+// colored by th; items without history show "-". Likewise a Symbol column
+// is added when any item has one (see --go-symbols).
+func renderTable(w *os.File, items []todo.Todo, th todo.AgeThresholds, lang string) {
+	withAge, withSymbol := false, false
 	for _, t := range items {
-		coloredTag := t.Tag
-		switch strings.ToUpper(t.Tag) {
-		case "TODO":
-			coloredTag = color.New(color.FgYellow).Sprint(t.Tag)
-		case "FIXME":
-			coloredTag = color.New(color.FgRed).Sprint(t.Tag)
-		case "BUG":
-			coloredTag = color.New(color.FgHiRed).Sprint(t.Tag)
-		case "NOTE":
-			coloredTag = color.New(color.FgCyan).Sprint(t.Tag)
+		if _, ok := todo.ItemAge(t, time.Time{}); ok {
+			withAge = true
+		}
+		if t.Symbol != "" {
+			withSymbol = true
 		}
+	}
+	ref := now()
+	table := tablewriter.NewWriter(w)
+	header := []string{todo.Message(lang, "col.file"), todo.Message(lang, "col.line"), todo.Message(lang, "col.tag")}
+	if withSymbol {
+		header = append(header, todo.Message(lang, "col.symbol"))
+	}
+	if withAge {
+		header = append(header, todo.Message(lang, "col.age"))
+	}
+	table.SetHeader(append(header, todo.Message(lang, "col.text")))
+	for _, t := range items {
+		coloredTag := tagColor(t.Tag).Sprint(t.Tag)
 		// Include the tag within the text column for clearer context
 		text := t.Tag
 		if strings.TrimSpace(t.Text) != "" {
 			text = t.Tag + ": " + t.Text
 		}
-		table.Append([]string{t.File, fmt.Sprintf("%d", t.Line), coloredTag, text})
+		row := []string{t.File, fmt.Sprintf("%d", t.Line), coloredTag}
+		if withSymbol {
+			row = append(row, t.Symbol)
+		}
+		if withAge {
+			row = append(row, formatAge(t, ref, th))
+		}
+		table.Append(append(row, text))
 	}
 	table.Render()
 }
 
+// truncateTexts returns a copy of items with Text cut by truncateText. A
+// limit of 0 disables truncation and returns items unchanged.
+func truncateTexts(items []todo.Todo, limit int) []todo.Todo {
+	if limit <= 0 {
+		return items
+	}
+	out := make([]todo.Todo, len(items))
+	for i, t := range items {
+		t.Text = truncateText(t.Text, limit)
+		out[i] = t
+	}
+	return out
+}
+
+// truncateText shortens s to at most limit runes, replacing the tail with an
+// ellipsis when anything is cut.
+func truncateText(s string, limit int) string {
+	r := []rune(s)
+	if limit <= 0 || len(r) <= limit {
+		return s
+	}
+	return strings.TrimRight(string(r[:limit-1]), " ") + "…"
+}
+
+// formatAge returns the humanized age of t at now, colored by its level in th,
+// or an uncolored "-" when t has no history.
+func formatAge(t todo.Todo, now time.Time, th todo.AgeThresholds) string {
+	age, ok := todo.ItemAge(t, now)
+	if !ok {
+		return "-"
+	}
+	c := color.New(color.FgGreen)
+	switch th.Level(age) {
+	case todo.AgeAging:
+		c = color.New(color.FgYellow)
+	case todo.AgeOld:
+		c = color.New(color.FgRed)
+	}
+	return c.Sprint(todo.HumanizeAge(age))
+}
+
+// noSymbolLabel heads the --group-by symbol group of items without a symbol.
+const noSymbolLabel = "(no symbol)"
+
+// symbolLabel returns the group heading for symbol.
+func symbolLabel(symbol string) string {
+	if symbol == "" {
+		return noSymbolLabel
+	}
+	return symbol
+}
+
+// groupBySymbol partitions items by Symbol, the group with most items first
+// and by symbol among equals; items without a symbol come last. Items keep
+// their order within a group.
+func groupBySymbol(items []todo.Todo) [][]todo.Todo {
+	index := make(map[string]int)
+	var groups [][]todo.Todo
+	for _, t := range items {
+		i, ok := index[t.Symbol]
+		if !ok {
+			i = len(groups)
+			index[t.Symbol] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i][0].Symbol, groups[j][0].Symbol
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return a < b
+	})
+	return groups
+}
+
+// groupByTag partitions items by tag (case-insensitively), most severe tag
+// first and alphabetically among equals. Items keep their order within a
+// group.
+func groupByTag(items []todo.Todo) [][]todo.Todo {
+	index := make(map[string]int)
+	var groups [][]todo.Todo
+	for _, t := range items {
+		key := strings.ToUpper(t.Tag)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := todo.StyleFor(groups[i][0].Tag).Severity, todo.StyleFor(groups[j][0].Tag).Severity
+		if a != b {
+			return a > b
+		}
+		return strings.ToUpper(groups[i][0].Tag) < strings.ToUpper(groups[j][0].Tag)
+	})
+	return groups
+}
+
+// tagColors holds the colors set by the --tags-file of the running scan.
+var tagColors map[string]color.Attribute
+
+// tagColor returns the terminal color used for tag.
+func tagColor(tag string) *color.Color {
+	if attr, ok := tagColors[strings.ToUpper(tag)]; ok {
+		return color.New(attr)
+	}
+	switch strings.ToUpper(tag) {
+	case "TODO":
+		return color.New(color.FgYellow)
+	case "FIXME":
+		return color.New(color.FgRed)
+	case "BUG":
+		return color.New(color.FgHiRed)
+	case "NOTE":
+		return color.New(color.FgCyan)
+	}
+	return color.New(color.Reset)
+}
+
 // resolveOutputPath determines the final output path based on the provided
 // filename and optional outDir. If filename is absolute, outDir is ignored.
 // If filename is relative and outDir is provided, the two are joined.
@@ -238,22 +1465,284 @@ func ensureParentDir(path string) error {
 	return os.MkdirAll(dir, 0o755)
 }
 
-// printSummary prints a simple summary of counts by tag.
-func printSummary(items []todo.Todo) {
-	counts := make(map[string]int)
-	for _, t := range items {
-		counts[strings.ToUpper(t.Tag)]++
+// scanArgs rejects positional arguments. --md-badges has an optional value,
+// so "--md-badges shields" parses as emoji badges plus a stray "shields";
+// that case gets an error pointing at the --md-badges=shields form.
+func scanArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && cmd.Flags().Changed("md-badges") {
+		return fmt.Errorf("unexpected argument %q; pass a --md-badges style as --md-badges=%s", args[0], args[0])
+	}
+	return cobra.NoArgs(cmd, args)
+}
+
+// streamBlockers are the scan flags whose feature needs the complete result,
+// so that --report jsonl --out - writes the items after the scan instead of
+// streaming them as they are found. A flag filtering, ordering, enriching or
+// gating on items after the scan belongs here.
+var streamBlockers = []string{
+	"sort",                  // orders every item
+	"only-trailing",         // filters by position after the scan
+	"empty-only",            // filters by text after the scan
+	"ignore-matching",       // filters by text or path after the scan
+	"filter-noise",          // sets low-signal items apart
+	"min-text-length",       // gates on, or with --filter-noise sets apart, short items
+	"require-owner-for",     // gates on items without an owner
+	"error-tags",            // gates on error-tagged items
+	"warning-tags",          // classifies items for the gate
+	"budget",                // gates on per-tag counts
+	"exit-code-on-findings", // gates on the item count
+	"introduced",            // enriches items from git history
+	"go-symbols",            // enriches Go items with their declaration
+	"detect-orphans",        // enriches Go items once every Go file is known
+	"split-by-owner",        // enriches items with their CODEOWNERS owners
+	"history",               // records the run and escalates persistent items
+	"relative-root",         // trims the prefix shared by every item
+	"gh-step-summary",       // summarizes the complete result
+	"git-log",               // scans commit messages, not files
+}
+
+// canStreamJSONL reports whether --report jsonl --out - can stream items
+// from the scan workers: no flag in streamBlockers differs from its default,
+// the content does not come from standard input, no --resume checkpoint
+// adds earlier items and no tag is classified, e.g. by --tags-file.
+func canStreamJSONL(fs *pflag.FlagSet, stdinMode, resumed bool, classes tagClasses) bool {
+	if stdinMode || resumed || classes.enabled() {
+		return false
+	}
+	for _, name := range streamBlockers {
+		if f := fs.Lookup(name); strings.TrimSpace(f.Value.String()) != strings.TrimSpace(f.DefValue) {
+			return false
+		}
 	}
+	return true
+}
+
+// resetFlags restores every local flag of cmd to its default value. Cobra
+// commands are package-level singletons, so without this values would leak
+// between executions (most notably across tests).
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+}
+
+// printSummary prints a simple summary of counts by tag, followed by the
+// numbers of items dropped by --ignore-matching and set apart by
+// --filter-noise when there were any, and the oldest and newest items when
+// their introduction dates were traced. With
+// prev, the counts of the last --history run, changed counts show their
+// delta (see summaryLines). With sample, from a --sample scan, it ends with
+// the estimated total of a full scan.
+func printSummary(items []todo.Todo, filtered, noise, lines int, prev *summaryCounts, dirThreshold int, sample *todo.Sample, lang string) {
+	msg := func(key string) string { return todo.Message(lang, key) }
 	fmt.Println()
-	fmt.Println(color.New(color.FgGreen, color.Bold).Sprint("Summary:"))
-	fmt.Printf("  Total: %d\n", len(items))
-	// Stable order for readability in tests and humans
-	keys := make([]string, 0, len(counts))
-	for k := range counts {
-		keys = append(keys, k)
+	fmt.Println(color.New(color.FgGreen, color.Bold).Sprint(msg("summary") + ":"))
+	for _, line := range summaryLines(countSummary(items), prev, dirThreshold, lang) {
+		fmt.Printf("  %s\n", line)
+	}
+	if byPosition := countPositions(items); len(byPosition) > 0 {
+		fmt.Printf("  %s\n", todo.FormatPositionSplit(byPosition, lang))
+	}
+	if lines > 0 {
+		fmt.Printf("  "+msg("density.summary")+"\n", todo.PerThousandLines(len(items), lines), lines)
+	}
+	if oldest, newest := todo.AgeRange(items, now()); oldest != nil {
+		fmt.Printf("  %s: %s:%d, %s (%s)\n", msg("oldest"), oldest.File, oldest.Line, fmt.Sprintf(msg("age.old"), oldest.Age), oldest.IntroducedAt.Format("2006-01-02"))
+		fmt.Printf("  %s: %s:%d, %s (%s)\n", msg("newest"), newest.File, newest.Line, fmt.Sprintf(msg("age.old"), newest.Age), newest.IntroducedAt.Format("2006-01-02"))
+	}
+	if n := countEscalated(items); n > 0 {
+		fmt.Printf("  %s: %d\n", msg("escalated"), n)
+	}
+	if n := countOrphans(items); n > 0 {
+		fmt.Printf("  %s: %d\n", msg("obsolete"), n)
+	}
+	if filtered > 0 {
+		fmt.Printf("  %s: %d\n", msg("filtered"), filtered)
+	}
+	if noise > 0 {
+		fmt.Printf("  %s: %d\n", msg("noise"), noise)
+	}
+	if sample != nil {
+		fmt.Printf("  %s\n", todo.FormatEstimate(sample, lang))
+	}
+}
+
+// countEscalated returns the number of escalated items.
+func countEscalated(items []todo.Todo) int {
+	n := 0
+	for _, t := range items {
+		if t.Escalated {
+			n++
+		}
+	}
+	return n
+}
+
+// escalatedError returns an error naming the escalated item count, or nil.
+func escalatedError(items []todo.Todo) error {
+	if n := countEscalated(items); n > 0 {
+		return gatingError(fmt.Errorf("%d escalated item(s) persisted past --escalate-after", n))
+	}
+	return nil
+}
+
+// countOrphans returns the number of items flagged by --detect-orphans.
+func countOrphans(items []todo.Todo) int {
+	n := 0
+	for _, t := range items {
+		if t.Orphan {
+			n++
+		}
+	}
+	return n
+}
+
+// orphansError returns an error counting the possibly obsolete items, or nil.
+func orphansError(items []todo.Todo) error {
+	if n := countOrphans(items); n > 0 {
+		return gatingError(fmt.Errorf("%d possibly obsolete item(s) found with --fail-on-orphans", n))
+	}
+	return nil
+}
+
+// printStats writes the end-of-run scan counters to w.
+func printStats(w io.Writer, s todo.ScanStats) {
+	resumed := ""
+	if s.Resumed > 0 {
+		resumed = fmt.Sprintf(", resumed %d", s.Resumed)
+	}
+	links := ""
+	if s.HardLinks > 0 {
+		links = fmt.Sprintf(", deduplicated %d hard link(s)", s.HardLinks)
+	}
+	lines := ""
+	if s.Lines > 0 {
+		lines = fmt.Sprintf(", read %d lines", s.Lines)
+	}
+	_, _ = fmt.Fprintf(w, "Scan stats: walked %d files, opened %d, skipped %d%s%s%s in %s\n",
+		s.Walked, s.Opened, s.Skipped, resumed, links, lines, s.Duration.Round(time.Millisecond))
+}
+
+// printPlan writes plan as a summary line followed by tables of skips,
+// extensions and the largest files.
+func printPlan(w io.Writer, plan todo.Plan) {
+	_, _ = fmt.Fprintf(w, "Scan plan: %d files (%d bytes) in %d directories\n", plan.Files, plan.Bytes, plan.Dirs)
+	if len(plan.Skipped) > 0 {
+		_, _ = fmt.Fprintln(w, "\nSkipped:")
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Reason", "Dirs", "Files"})
+		for _, s := range plan.Skipped {
+			table.Append([]string{s.Reason, strconv.Itoa(s.Dirs), strconv.Itoa(s.Files)})
+		}
+		table.Render()
+	}
+	if len(plan.Extensions) > 0 {
+		_, _ = fmt.Fprintln(w, "\nExtensions:")
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Ext", "Files", "Bytes"})
+		for _, e := range plan.Extensions {
+			ext := e.Ext
+			if ext == "" {
+				ext = "(none)"
+			}
+			table.Append([]string{ext, strconv.Itoa(e.Files), strconv.FormatInt(e.Bytes, 10)})
+		}
+		table.Render()
+	}
+	if len(plan.Largest) > 0 {
+		_, _ = fmt.Fprintln(w, "\nLargest files:")
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"File", "Bytes"})
+		for _, f := range plan.Largest {
+			table.Append([]string{f.Path, strconv.FormatInt(f.Bytes, 10)})
+		}
+		table.Render()
+	}
+}
+
+// writePlanJSON writes plan as indented JSON to outName, resolved like report
+// paths; stdout when outName is empty or -.
+func writePlanJSON(plan todo.Plan, outName, outDir string) error {
+	outName = strings.TrimSpace(outName)
+	var w io.Writer = os.Stdout
+	if outName != "" && outName != "-" {
+		outPath := resolveOutputPath(outName, outDir)
+		if err := ensureParentDir(outPath); err != nil {
+			return err
+		}
+		f, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		defer todo.SafeClose(f, outPath)
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
+
+// countPositions tallies items by Position, skipping those with unknown syntax.
+func countPositions(items []todo.Todo) map[string]int {
+	var counts map[string]int
+	for _, t := range items {
+		if t.Position == "" {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[t.Position]++
+	}
+	return counts
+}
+
+// sortItems returns a copy of items in the order named by key ("file": by
+// file, then line).
+func sortItems(items []todo.Todo, key string) []todo.Todo {
+	out := make([]todo.Todo, len(items))
+	copy(out, items)
+	switch key {
+	case "age":
+		// Oldest first; items without history keep their order at the end.
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := out[i].IntroducedAt, out[j].IntroducedAt
+			if a == nil || b == nil {
+				return a != nil && b == nil
+			}
+			return a.Before(*b)
+		})
+	case "file":
+		sort.SliceStable(out, func(i, j int) bool {
+			if out[i].File != out[j].File {
+				return out[i].File < out[j].File
+			}
+			return out[i].Line < out[j].Line
+		})
 	}
-	sort.Strings(keys)
-	for _, tag := range keys {
-		fmt.Printf("  %s: %d\n", tag, counts[tag])
+	return out
+}
+
+// filterEmpty keeps only items without a description. It must run on scan
+// results, before report enrichment replaces an empty text with the tag.
+func filterEmpty(items []todo.Todo) []todo.Todo {
+	out := items[:0:0]
+	for _, t := range items {
+		if strings.TrimSpace(t.Text) == "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// filterPosition keeps only items recorded at the given position.
+func filterPosition(items []todo.Todo, position string) []todo.Todo {
+	out := items[:0:0]
+	for _, t := range items {
+		if t.Position == position {
+			out = append(out, t)
+		}
 	}
+	return out
 }
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScan_Command_History_FailOnEscalated(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("package main\n// FIXME: flaky\n// TODO: later\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	hist := filepath.Join(t.TempDir(), "history.jsonl")
+	args := []string{"scan", "--path", tmp, "--history", hist, "--escalate-after", "2", "--fail-on-escalated"}
+
+	var runErr error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs(args)
+		runErr = rootCmd.Execute()
+	})
+	if runErr != nil {
+		t.Fatalf("first run should pass: %v", runErr)
+	}
+	if strings.Contains(out, "Escalated:") {
+		t.Fatalf("nothing should be escalated after one run:\n%s", out)
+	}
+
+	out = captureStdout(t, func() {
+		rootCmd.SetArgs(args)
+		runErr = rootCmd.Execute()
+	})
+	if runErr == nil || !strings.Contains(runErr.Error(), "1 escalated") {
+		t.Fatalf("second run should fail with one escalated item, got %v", runErr)
+	}
+	if !strings.Contains(out, "Escalated: 1") {
+		t.Fatalf("summary should report the escalation:\n%s", out)
+	}
+	b, err := os.ReadFile(hist)
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	if n := strings.Count(string(b), "\n"); n != 2 {
+		t.Fatalf("history runs = %d, want 2", n)
+	}
+}
+
+func TestScan_Command_FailOnEscalated_RequiresHistory(t *testing.T) {
+	rootCmd.SetArgs([]string{"scan", "--path", t.TempDir(), "--fail-on-escalated"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error without --history")
+	}
+}
+
+func TestScan_Command_History_BurnDown(t *testing.T) {
+	tmp := t.TempDir()
+	hist := filepath.Join(t.TempDir(), "history.jsonl")
+	out := filepath.Join(t.TempDir(), "report.md")
+	orig := now
+	t.Cleanup(func() { now = orig })
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, n := range []int{6, 4, 2} {
+		var b strings.Builder
+		b.WriteString("package main\n")
+		for j := range n {
+			fmt.Fprintf(&b, "// TODO: item %d\n", j)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(b.String()), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		now = func() time.Time { return start.AddDate(0, 0, 7*i) }
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--history", hist, "--report", "md", "--out", out})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	md := string(b)
+	for _, want := range []string{
+		"Since 2024-01-08: 2 resolved, 0 added, net -2 (4 → 2).",
+		"Total count reaches zero in ~7 days.",
+		"TODO count reaches zero in ~7 days.",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("missing %q in:\n%s", want, md)
+		}
+	}
+}
+
+func TestScan_Command_History_TrendDeltas(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write("api/a.go", "package api\n// TODO: one\n// TODO: two\n// FIXME: broken\n")
+	write("web/b.go", "package web\n// TODO: three\n")
+	hist := filepath.Join(t.TempDir(), "history.jsonl")
+	args := []string{"scan", "--path", tmp, "--history", hist, "--trend-threshold", "1"}
+
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("first run: %v", err)
+		}
+	})
+	if strings.Contains(out, "▲") || strings.Contains(out, "▼") {
+		t.Fatalf("first run has nothing to compare with:\n%s", out)
+	}
+
+	write("api/a.go", "package api\n")
+	write("web/b.go", "package web\n// TODO: three\n// TODO: four\n// BUG: crash\n")
+	out = captureStdout(t, func() {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("second run: %v", err)
+		}
+	})
+	for _, want := range []string{
+		"Total: 3 (▼ −1 since last run)",
+		"BUG: 1 (▲ +1)",
+		"FIXME: 0 (▼ −all 1)",
+		"TODO: 2 (▼ −1)",
+		"Directories: api ▼ −all 3, web ▲ +2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
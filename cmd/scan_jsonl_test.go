@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestScan_Command_JSONL_Stdout_OnlyJSONLines(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	if err := os.WriteFile(filepath.Join(tmp, "b.go"), []byte("package main\n// FIXME: b\n// NOTE: c\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"scan", "--path", tmp, "--report", "jsonl", "--out", "-"},
+		{"scan", "--path", tmp, "--report", "jsonl", "--out", "-", "--sort", "file"},
+	} {
+		var runErr error
+		got := captureStdout(t, func() {
+			rootCmd.SetArgs(args)
+			runErr = rootCmd.Execute()
+		})
+		if runErr != nil {
+			t.Fatalf("%v: %v", args, runErr)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("%v: want 3 items + summary, got:\n%s", args, got)
+		}
+		for i, line := range lines {
+			var rec struct {
+				Type  string `json:"type"`
+				File  string `json:"file"`
+				Total int    `json:"total"`
+			}
+			if err := json.Unmarshal([]byte(line), &rec); err != nil {
+				t.Fatalf("%v: stdout line %d is not JSON: %q", args, i+1, line)
+			}
+			last := i == len(lines)-1
+			if last && (rec.Type != "summary" || rec.Total != 3) {
+				t.Fatalf("%v: bad summary trailer: %s", args, line)
+			}
+			if !last && rec.Type != "todo" {
+				t.Fatalf("%v: line %d type = %q, want todo", args, i+1, rec.Type)
+			}
+		}
+		if args[len(args)-1] == "file" && (!strings.Contains(lines[0], `"b.go"`) || !strings.Contains(lines[2], `"main.go"`)) {
+			t.Fatalf("--sort file did not order by file:\n%s", got)
+		}
+	}
+}
+
+func TestScan_Command_JSONL_File(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	target := filepath.Join(t.TempDir(), "out.jsonl")
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "jsonl", "--out", target})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan jsonl failed: %v", err)
+	}
+	b, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.HasSuffix(string(b), "{\"type\":\"summary\",\"total\":1,\"byTag\":{\"TODO\":1}}\n") {
+		t.Fatalf("unexpected content:\n%s", b)
+	}
+}
+
+func TestScan_Command_StdoutOut_RejectsTable(t *testing.T) {
+	rootCmd.SetArgs([]string{"scan", "--path", t.TempDir(), "--out", "-"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for --out - with table report")
+	}
+}
+
+// TestScan_Command_JSONL_Stdout_Gates checks that gates still fail a scan
+// written as JSONL to stdout, which otherwise streams without the full result.
+func TestScan_Command_JSONL_Stdout_Gates(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// BUG: a\n// TODO: b\n// TODO: c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, gate := range [][]string{
+		{"--error-tags", "BUG"},
+		{"--budget", "TODO=1"},
+		{"--min-text-length", "5"},
+	} {
+		var runErr error
+		got := captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp, "--report", "jsonl", "--out", "-"}, gate...))
+			runErr = rootCmd.Execute()
+		})
+		if exitCode(runErr) != ExitGating {
+			t.Errorf("%v: err = %v, want a gating failure", gate, runErr)
+		}
+		if !strings.HasSuffix(got, "{\"type\":\"summary\",\"total\":3,\"byTag\":{\"BUG\":1,\"TODO\":2}}\n") {
+			t.Errorf("%v: report not written:\n%s", gate, got)
+		}
+	}
+}
+
+// streamSafe are the scan flags that leave streamed JSONL items as the scan
+// workers find them. Every scan flag must be listed here or in
+// streamBlockers, so a new post-scan feature cannot be silently skipped when
+// streaming.
+var streamSafe = []string{
+	"age-aging-days", "age-old-days", "audit", "audit-limit", "badge-green", "badge-label", "badge-tag", "badge-yellow",
+	"checkpoint", "checkpoint-every", "codeowners", "comment-syntax", "compress", "debug-patterns", "detect",
+	"editor-scheme", "escalate-after", "fail-on-escalated", "fail-on-orphans", "first-match-only", "generated-header",
+	"gh-step-summary-rows", "group-by", "help", "history-keep", "html-stream-threshold", "ignore", "ignore-case-insensitive",
+	"include-raw-bytes", "include-raw-line", "introduced-limit", "introduced-timeout", "json-shape", "keep", "lang",
+	"max-count", "max-text-length", "md-badges", "modified-within", "no-dedup-hardlinks", "no-redact", "noise-words",
+	"normalize-paths", "only", "out", "out-dir", "out-template", "path", "path-default", "plan", "raw-text",
+	"redact-patterns", "report", "respect-ignore-comments", "resume", "sample", "serve", "since", "skip-generated",
+	"skip-submodules", "stats", "stdin-format", "tags", "tags-file", "timestamped-out", "tracked-only",
+	"trend-threshold", "width", "write-meta",
+}
+
+func TestCanStreamJSONL_ClassifiesEveryFlag(t *testing.T) {
+	t.Cleanup(func() { resetFlags(scanCmd) })
+	known := map[string]bool{}
+	for _, name := range append(append([]string{}, streamSafe...), streamBlockers...) {
+		known[name] = true
+	}
+	scanCmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if !known[f.Name] {
+			t.Errorf("--%s is in neither streamBlockers nor streamSafe; add it to streamBlockers if it filters, orders, enriches or gates on items after the scan", f.Name)
+		}
+	})
+
+	if !canStreamJSONL(scanCmd.Flags(), false, false, tagClasses{}) {
+		t.Fatal("default flags do not stream")
+	}
+	for _, name := range streamBlockers {
+		f := scanCmd.Flags().Lookup(name)
+		if f == nil {
+			t.Errorf("streamBlockers names unknown flag --%s", name)
+			continue
+		}
+		value := map[string]string{"bool": "true", "int": "1"}[f.Value.Type()]
+		if value == "" {
+			value = "x"
+		}
+		if err := f.Value.Set(value); err != nil {
+			t.Fatalf("--%s=%s: %v", name, value, err)
+		}
+		if canStreamJSONL(scanCmd.Flags(), false, false, tagClasses{}) {
+			t.Errorf("--%s=%s still streams", name, value)
+		}
+		resetFlags(scanCmd)
+	}
+	if canStreamJSONL(scanCmd.Flags(), true, false, tagClasses{}) || canStreamJSONL(scanCmd.Flags(), false, true, tagClasses{}) {
+		t.Error("standard input or --resume still streams")
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -79,3 +80,77 @@ func TestScan_Command_MD_WithOutDir_AbsoluteFilename_IgnoresOutDir(t *testing.T)
 		t.Fatalf("out-dir %s should not be created when absolute md path is used", outDir)
 	}
 }
+
+func TestScan_Command_MD_InvalidBadges(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: x"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "md", "--out", filepath.Join(tmp, "r.md"), "--md-badges=rainbow"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for invalid --md-badges value")
+	}
+}
+
+func TestScan_Command_MD_BadgesSpaceSeparated(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: x"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	out := filepath.Join(tmp, "r.md")
+	// Args are validated before RunE, which would otherwise reset the flags.
+	defer resetFlags(scanCmd)
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "md", "--out", out, "--md-badges", "shields"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--md-badges=shields") {
+		t.Fatalf("expected an error pointing at --md-badges=shields, got %v", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Fatalf("report written despite the stray argument")
+	}
+
+	resetFlags(scanCmd)
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "stray"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), `unknown command "stray"`) {
+		t.Fatalf("expected positional arguments to be rejected, got %v", err)
+	}
+}
+
+func TestScan_Command_GitHubStepSummary(t *testing.T) {
+	tmp := t.TempDir()
+	src := "// TODO: one\n// TODO: two\n// FIXME: three\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary := filepath.Join(t.TempDir(), "step_summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	// Explicit flag with the table report, then automatic with --report md.
+	captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--gh-step-summary", "--gh-step-summary-rows", "2"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	})
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "md", "--out", filepath.Join(t.TempDir(), "r.md")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan md: %v", err)
+	}
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("read summary: %v", err)
+	}
+	out := string(data)
+	if n := strings.Count(out, "## todototum summary"); n != 2 {
+		t.Fatalf("expected two appended summaries, got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "_1 more not shown._") {
+		t.Fatalf("row cap not applied:\n%s", out)
+	}
+
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--gh-step-summary"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error without GITHUB_STEP_SUMMARY")
+	}
+}
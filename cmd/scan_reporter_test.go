@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/config"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// An embedding program registers its formats before the command runs.
+func init() {
+	todo.RegisterReporter("test-count", func(data todo.ReportData, w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%d items\n", data.Summary.Total)
+		return err
+	})
+}
+
+func TestScan_Command_RegisteredReporter(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: a\n// FIXME: b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "test-count", "--out-dir", outDir})
+	stdout := captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	out := filepath.Join(outDir, "report.test-count")
+	if data, err := os.ReadFile(out); err != nil || string(data) != "2 items\n" {
+		t.Fatalf("report = %q, %v", data, err)
+	}
+	if !strings.Contains(stdout, "test-count report written to "+out) {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "nope"})
+	var runErr error
+	captureStdout(t, func() { runErr = rootCmd.Execute() })
+	if runErr == nil || !strings.Contains(runErr.Error(), "must be one of: table, console, html,") || !strings.HasSuffix(runErr.Error(), ", test-count") {
+		t.Errorf("err = %v, want the registered format listed", runErr)
+	}
+
+	file, err := config.Parse(".todototum.yml", []byte("report: test-count\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(scanCmd.Flags(), file, scanConfigRules()); err != nil {
+		t.Errorf("a registered format should be a valid config value: %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateReports_PopulatedDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	p := filepath.Join(tmp, "report.json")
+	for name, content := range map[string]string{
+		"report.json":   "current",
+		"report.1.json": "one",
+		"report.2.json": "two",
+	} {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	if err := rotateReports(p, 2); err != nil {
+		t.Fatalf("rotateReports: %v", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be moved away, stat err: %v", p, err)
+	}
+	want := map[string]string{"report.1.json": "current", "report.2.json": "one"}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(data) != content {
+			t.Fatalf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "report.3.json")); err == nil {
+		t.Fatalf("rotation should drop reports beyond --keep")
+	}
+}
+
+func TestRotateReports_NothingToRotate(t *testing.T) {
+	tmp := t.TempDir()
+	if err := rotateReports(filepath.Join(tmp, "report.md"), 3); err != nil {
+		t.Fatalf("rotating a missing report should be a no-op: %v", err)
+	}
+}
+
+func TestPruneTimestamped_KeepsNewest(t *testing.T) {
+	tmp := t.TempDir()
+	names := []string{
+		"report-20240101T000000Z.json",
+		"report-20240201T000000Z.json",
+		"report-20240301T000000Z.json",
+		"report-20240401T000000Z.json",
+		"report.json",                 // not timestamped: untouched
+		"other-20240101T000000Z.json", // different base: untouched
+	}
+	for _, n := range names {
+		if err := os.WriteFile(filepath.Join(tmp, n), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", n, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "report-20240301T000000Z.meta.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	next := timestampedPath(filepath.Join(tmp, "report.json"), time.Date(2024, 6, 15, 3, 12, 0, 0, time.UTC))
+	if filepath.Base(next) != "report-20240615T031200Z.json" {
+		t.Fatalf("unexpected timestamped name: %s", next)
+	}
+	// Pruning runs once the new report is written, which counts towards keep.
+	if err := os.WriteFile(next, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pruneTimestamped(next, 2); err != nil {
+		t.Fatalf("pruneTimestamped: %v", err)
+	}
+	entries, _ := os.ReadDir(tmp)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := "other-20240101T000000Z.json,report-20240401T000000Z.json,report-20240615T031200Z.json,report.json"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("after prune got %v, want %s", got, want)
+	}
+}
+
+func TestScan_Command_Keep_RotatesExistingReport(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	out := filepath.Join(tmp, "report.json")
+	for i := 0; i < 3; i++ {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", out, "--keep", "1"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan --keep run %d failed: %v", i, err)
+		}
+	}
+	for _, name := range []string{"report.json", "report.1.json"} {
+		if _, err := os.Stat(filepath.Join(tmp, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "report.2.json")); err == nil {
+		t.Fatalf("report.2.json should not exist with --keep 1")
+	}
+}
+
+func TestScan_Command_Keep_FailedRenderKeepsReports(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	out := filepath.Join(t.TempDir(), "report.json")
+	for name, content := range map[string]string{"report.json": "current", "report.1.json": "one"} {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(out), name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory where the new report is rendered makes rendering fail.
+	if err := os.Mkdir(pendingPath(out), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", out, "--keep", "2"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("expected the render to fail")
+	}
+	for name, content := range map[string]string{"report.json": "current", "report.1.json": "one"} {
+		if data, err := os.ReadFile(filepath.Join(filepath.Dir(out), name)); err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v; want it untouched", name, data, err)
+		}
+	}
+}
+
+func TestScan_Command_Keep_RotatesSidecars(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	orig := gitRunner
+	t.Cleanup(func() { gitRunner = orig })
+	gitRunner = argsGit{}
+	outDir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out-dir", outDir, "--keep", "1", "--write-meta"})
+		captureStdout(t, func() {
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("run %d: %v", i, err)
+			}
+		})
+	}
+	entries, _ := os.ReadDir(outDir)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if want := "report.1.json,report.1.meta.json,report.json,report.meta.json"; strings.Join(got, ",") != want {
+		t.Errorf("files = %v, want %s", got, want)
+	}
+}
+
+func TestScan_Command_TimestampedOut(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	orig := now
+	t.Cleanup(func() { now = orig })
+	now = func() time.Time { return time.Date(2024, 6, 15, 3, 12, 0, 0, time.UTC) }
+
+	outDir := filepath.Join(tmp, "artifacts")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out-dir", outDir, "--timestamped-out"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan --timestamped-out failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "report-20240615T031200Z.json")); err != nil {
+		t.Fatalf("expected timestamped report: %v", err)
+	}
+
+	for day := 16; day <= 18; day++ {
+		now = func() time.Time { return time.Date(2024, 6, day, 3, 12, 0, 0, time.UTC) }
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out-dir", outDir, "--timestamped-out", "--keep", "2"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan --keep 2 --timestamped-out: %v", err)
+		}
+	}
+	entries, _ := os.ReadDir(outDir)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if want := "report-20240617T031200Z.json,report-20240618T031200Z.json"; strings.Join(got, ",") != want {
+		t.Errorf("after --keep 2: %v, want %s", got, want)
+	}
+}
+
+func TestScan_Command_Keep_RejectedForTable(t *testing.T) {
+	tmp := t.TempDir()
+	writeSampleFile(t, tmp)
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--keep", "3"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected --keep without a file-based report to be rejected")
+	}
+}
+
+func TestSplitExt_CompressedReports(t *testing.T) {
+	at := time.Date(2024, 6, 15, 3, 12, 0, 0, time.UTC)
+	if got := timestampedPath("out/report.json.gz", at); got != "out/report-20240615T031200Z.json.gz" {
+		t.Errorf("timestampedPath = %q", got)
+	}
+	if got := rotatedPath("report.jsonl.gz", 2); got != "report.2.jsonl.gz" {
+		t.Errorf("rotatedPath = %q", got)
+	}
+	if got := rotatedPath("report.json", 1); got != "report.1.json" {
+		t.Errorf("rotatedPath = %q", got)
+	}
+}
+
+func TestExpandOutTemplate(t *testing.T) {
+	at := time.Date(2024, 6, 15, 23, 30, 0, 0, time.FixedZone("X", -3*3600))
+	cases := []struct {
+		tmpl, format, want string
+	}{
+		{"todos-{date}-{format}.{ext}", "md", "todos-2024-06-16-md.md"},
+		{"archive/{format}/{date}.{ext}", "treemap", "archive/treemap/2024-06-16.json"},
+		{"fixed.json", "json", "fixed.json"},
+	}
+	for _, tc := range cases {
+		got, err := expandOutTemplate(tc.tmpl, tc.format, at)
+		if err != nil || got != tc.want {
+			t.Errorf("expandOutTemplate(%q, %q) = %q, %v; want %q", tc.tmpl, tc.format, got, err, tc.want)
+		}
+	}
+	if _, err := expandOutTemplate("todos-{time}.{ext}", "json", at); err == nil || !strings.Contains(err.Error(), "{time}") {
+		t.Errorf("expected an unknown placeholder error, got %v", err)
+	}
+}
+
+func TestScan_Command_OutTemplate(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "a.go"), []byte("// TODO: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prev := now
+	now = func() time.Time { return time.Date(2024, 6, 15, 3, 12, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = prev })
+
+	outDir := filepath.Join(tmp, "reports")
+	var err error
+	captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "jsonl", "--out-dir", outDir, "--out-template", "todos-{date}-{format}.{ext}"})
+		err = rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "todos-2024-06-15-jsonl.jsonl")); err != nil {
+		t.Fatalf("templated report missing: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", "x.json", "--out-template", "{date}.json"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--out-template") {
+		t.Fatalf("expected --out-template to conflict with --out, got %v", err)
+	}
+}
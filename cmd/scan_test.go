@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/valerioTomassi/todototum/internal/todo"
 )
@@ -163,12 +169,12 @@ func TestEnsureParentDir_Creates(t *testing.T) {
 
 func TestPrintSummary_OutputAndOrder(t *testing.T) {
 	items := []todo.Todo{
-		{File: "a.go", Line: 1, Tag: "FIXME", Text: "x"},
-		{File: "b.go", Line: 2, Tag: "TODO", Text: "y"},
-		{File: "c.go", Line: 3, Tag: "BUG", Text: "z"},
-		{File: "d.go", Line: 4, Tag: "NOTE", Text: "n"},
+		{Location: todo.Location{File: "a.go", Line: 1}, Tag: "FIXME", Text: "x"},
+		{Location: todo.Location{File: "b.go", Line: 2}, Tag: "TODO", Text: "y"},
+		{Location: todo.Location{File: "c.go", Line: 3}, Tag: "BUG", Text: "z"},
+		{Location: todo.Location{File: "d.go", Line: 4}, Tag: "NOTE", Text: "n"},
 	}
-	out := captureStdout(t, func() { printSummary(items) })
+	out := captureStdout(t, func() { printSummary(items, 0, 0, 0, nil, 0, nil, "") })
 	if !strings.Contains(out, "Total: 4") {
 		t.Fatalf("missing total in summary: %s", out)
 	}
@@ -180,6 +186,81 @@ func TestPrintSummary_OutputAndOrder(t *testing.T) {
 	if bugIdx >= fixIdx || fixIdx >= notIdx || notIdx >= todoIdx {
 		t.Fatalf("unexpected tag order in summary: %s", out)
 	}
+	if strings.Contains(out, "Density") {
+		t.Fatalf("density shown without a line count: %s", out)
+	}
+	out = captureStdout(t, func() { printSummary(items, 0, 0, 2000, nil, 0, nil, "") })
+	if !strings.Contains(out, "Density: 2.00 per 1000 lines (2000 lines scanned)") {
+		t.Fatalf("missing density in summary: %s", out)
+	}
+}
+
+func TestPrintSummary_AgeRange(t *testing.T) {
+	orig := now
+	t.Cleanup(func() { now = orig })
+	now = func() time.Time { return time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC) }
+
+	items := []todo.Todo{{Location: todo.Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "undated"}}
+	if out := captureStdout(t, func() { printSummary(items, 0, 0, 0, nil, 0, nil, "") }); strings.Contains(out, "Oldest") {
+		t.Fatalf("age range shown without dates: %s", out)
+	}
+	older := time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 12, 20, 0, 0, 0, 0, time.UTC)
+	items = append(items,
+		todo.Todo{Location: todo.Location{File: "b.go", Line: 2}, Tag: "BUG", Text: "old", IntroducedAt: &older},
+		todo.Todo{Location: todo.Location{File: "c.go", Line: 3}, Tag: "TODO", Text: "new", IntroducedAt: &newer},
+	)
+	out := captureStdout(t, func() { printSummary(items, 0, 0, 0, nil, 0, nil, "") })
+	if !strings.Contains(out, "  Oldest: b.go:2, 5y old (2018-01-02)\n  Newest: c.go:3, 13d old (2022-12-20)\n") {
+		t.Fatalf("missing age range in summary: %s", out)
+	}
+}
+
+func TestScan_Command_Lang(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: first\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--lang", "it"})
+	out := captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "Riepilogo:\n  Totale: 1\n  TODO: 1\n") || !strings.Contains(out, "TODO: first") {
+		t.Errorf("Italian summary missing:\n%s", out)
+	}
+	for _, want := range []string{"RIGA", "TESTO", "su riga propria: 1, in coda: 0", "Densità: 1000.00 ogni 1000 righe (1 righe analizzate)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Italian table or summary lacks %q:\n%s", want, out)
+		}
+	}
+	for _, english := range []string{"LINE", "TEXT", "own-line", "Density"} {
+		if strings.Contains(out, english) {
+			t.Errorf("Italian output has English %q:\n%s", english, out)
+		}
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--lang", "it", "--report", "console", "--width", "80"})
+	out = captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "Riga") || !strings.Contains(out, "Testo") || strings.Contains(out, "Line") {
+		t.Errorf("Italian console headers missing:\n%s", out)
+	}
+
+	t.Setenv("TODOTOTUM_LANG", "fr")
+	rootCmd.SetArgs([]string{"scan", "--path", t.TempDir()})
+	if out := captureStdout(t, func() { _ = rootCmd.Execute() }); !strings.Contains(out, "Aucun TODO trouvé.") {
+		t.Errorf("TODOTOTUM_LANG=fr output = %q", out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--lang", "de"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "must be one of: en, es, fr, it") {
+		t.Errorf("err = %v, want the available languages listed", err)
+	}
 }
 
 func TestRenderTable_Basic(t *testing.T) {
@@ -191,8 +272,8 @@ func TestRenderTable_Basic(t *testing.T) {
 	}
 	defer func() { _ = f.Close() }()
 
-	items := []todo.Todo{{File: "x.go", Line: 42, Tag: "TODO", Text: "do it"}}
-	renderTable(f, items)
+	items := []todo.Todo{{Location: todo.Location{File: "x.go", Line: 42}, Tag: "TODO", Text: "do it"}}
+	renderTable(f, items, todo.DefaultAgeThresholds, todo.DefaultLang)
 	data, err := os.ReadFile(p)
 	if err != nil {
 		t.Fatalf("read: %v", err)
@@ -204,3 +285,732 @@ func TestRenderTable_Basic(t *testing.T) {
 		}
 	}
 }
+
+func TestGroupByTag_SeverityOrder(t *testing.T) {
+	items := []todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 1}, Tag: "TODO"},
+		{Location: todo.Location{File: "a.go", Line: 2}, Tag: "HACK"},
+		{Location: todo.Location{File: "b.go", Line: 3}, Tag: "BUG"},
+		{Location: todo.Location{File: "c.go", Line: 4}, Tag: "todo"},
+		{Location: todo.Location{File: "c.go", Line: 5}, Tag: "NOTE"},
+	}
+	var got []string
+	for _, g := range groupByTag(items) {
+		got = append(got, fmt.Sprintf("%s:%d", strings.ToUpper(g[0].Tag), len(g)))
+	}
+	want := "BUG:1 TODO:2 NOTE:1 HACK:1"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("groups = %v, want %s", got, want)
+	}
+}
+
+func TestScan_Command_GroupByTag(t *testing.T) {
+	tmp := t.TempDir()
+	src := "// TODO: one\n// BUG: two\n// TODO: three\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--group-by", "tag"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	})
+	bug, todoIdx := strings.Index(out, "BUG (1)"), strings.Index(out, "TODO (2)")
+	if bug < 0 || todoIdx < bug {
+		t.Fatalf("expected BUG group before TODO group:\n%s", out)
+	}
+	if n := strings.Count(out, "TEXT"); n != 2 {
+		t.Fatalf("expected one table header per group, got %d:\n%s", n, out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--group-by", "tag", "--report", "json"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for --group-by with a file report")
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--group-by", "file"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error for unknown --group-by value")
+	}
+}
+
+func TestSortItems_AgeOldestFirst(t *testing.T) {
+	older := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []todo.Todo{
+		{Location: todo.Location{File: "a.go", Line: 1}, Tag: "TODO"},
+		{Location: todo.Location{File: "b.go", Line: 2}, Tag: "TODO", IntroducedAt: &newer},
+		{Location: todo.Location{File: "c.go", Line: 3}, Tag: "TODO"},
+		{Location: todo.Location{File: "d.go", Line: 4}, Tag: "TODO", IntroducedAt: &older},
+	}
+	var got []string
+	for _, t := range sortItems(items, "age") {
+		got = append(got, t.File)
+	}
+	if strings.Join(got, " ") != "d.go b.go a.go c.go" {
+		t.Fatalf("age order = %v", got)
+	}
+}
+
+func TestScan_Command_AgeColumn(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: trace me\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	origGit, origNow := gitRunner, now
+	t.Cleanup(func() { gitRunner, now = origGit, origNow })
+	gitRunner = cannedGit{out: "abc\t2020-02-03T04:05:06Z\tAda\n"}
+	now = func() time.Time { return time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--introduced", "--sort", "age"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	})
+	if !strings.Contains(out, "AGE") || !strings.Contains(out, "3y") {
+		t.Fatalf("expected an Age column with 3y:\n%s", out)
+	}
+
+	plain := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	})
+	if strings.Contains(plain, "AGE") {
+		t.Fatalf("Age column should only appear with history:\n%s", plain)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--sort", "age"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected --sort age to require --introduced")
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--age-aging-days", "400"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error when aging threshold exceeds old threshold")
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	cases := []struct {
+		in    string
+		limit int
+		want  string
+	}{
+		{"short", 0, "short"},
+		{"short", 5, "short"},
+		{"exactly ten", 10, "exactly t…"},
+		{"trailing space cut", 10, "trailing…"},
+		{"ünïcödé text", 4, "ünï…"},
+	}
+	for _, c := range cases {
+		if got := truncateText(c.in, c.limit); got != c.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", c.in, c.limit, got, c.want)
+		}
+	}
+}
+
+func TestScan_Command_MaxTextLength(t *testing.T) {
+	tmp := t.TempDir()
+	long := strings.Repeat("word ", 30)
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: "+long+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--max-text-length", "20"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	})
+	if !strings.Contains(out, "TODO: word word word word…") || strings.Contains(out, strings.TrimSpace(long)) {
+		t.Fatalf("expected truncated text in table:\n%s", out)
+	}
+
+	report := filepath.Join(t.TempDir(), "report.md")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "md", "--out", report, "--max-text-length", "20"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan md: %v", err)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), strings.TrimSpace(long)) {
+		t.Fatalf("file reports should keep the full text:\n%s", data)
+	}
+}
+
+func TestScan_Command_RespectIgnoreComments_Toggle(t *testing.T) {
+	tmp := t.TempDir()
+	content := []byte("package main\n// TODO: real\nvar s = \"TODO\" // todototum:ignore\n")
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), content, 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "r.json")
+	count := func(args ...string) int {
+		rootCmd.SetArgs(append([]string{"scan", "--path", tmp, "--report", "json", "--out", out}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		data, _ := os.ReadFile(out)
+		return strings.Count(string(data), `"Line"`)
+	}
+	if got := count(); got != 1 {
+		t.Fatalf("default run should honor ignore comments, got %d items", got)
+	}
+	if got := count("--respect-ignore-comments=false"); got != 2 {
+		t.Fatalf("disabled flag should report ignored line too, got %d items", got)
+	}
+}
+
+func TestPrintStats_Format(t *testing.T) {
+	var buf bytes.Buffer
+	printStats(&buf, todo.ScanStats{Walked: 10, Opened: 8, Skipped: 3, Duration: 1234 * time.Microsecond})
+	want := "Scan stats: walked 10 files, opened 8, skipped 3 in 1ms\n"
+	if buf.String() != want {
+		t.Fatalf("printStats = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestScan_Command_InvalidGeneratedHeader(t *testing.T) {
+	rootCmd.SetArgs([]string{"scan", "--path", t.TempDir(), "--skip-generated", "--generated-header", "("})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--generated-header") {
+		t.Fatalf("expected --generated-header error, got %v", err)
+	}
+}
+
+func TestScan_Command_SkipSubmodules(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"main.go":    "// TODO: parent\n",
+		"sub/.git":   "gitdir: ../.git/modules/sub\n",
+		"sub/lib.go": "// TODO: in submodule\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) string {
+		return captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp}, args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+		})
+	}
+	if out := run(); !strings.Contains(out, "in submodule") {
+		t.Fatalf("submodule should be scanned by default:\n%s", out)
+	}
+	if out := run("--skip-submodules"); strings.Contains(out, "in submodule") || !strings.Contains(out, "parent") {
+		t.Fatalf("--skip-submodules should drop the nested repo:\n%s", out)
+	}
+}
+
+func TestScan_Command_GoSymbols(t *testing.T) {
+	tmp := t.TempDir()
+	src := "package main\n\nfunc main() {\n\t// TODO: one\n\t// TODO: two\n}\n\nfunc (s *server) run() {\n\t// BUG: three\n}\n\n// NOTE: loose\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		return captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp}, args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+		})
+	}
+	if out := run(); strings.Contains(out, "SYMBOL") {
+		t.Fatalf("symbols should be opt-in:\n%s", out)
+	}
+	if out := run("--go-symbols"); !strings.Contains(out, "SYMBOL") || !strings.Contains(out, "(*server).run") {
+		t.Fatalf("expected a Symbol column:\n%s", out)
+	}
+	out := run("--go-symbols", "--group-by", "symbol")
+	main, run2, none := strings.Index(out, "main (2)"), strings.Index(out, "(*server).run (1)"), strings.Index(out, "(no symbol) (1)")
+	if main < 0 || run2 < main || none < run2 {
+		t.Fatalf("expected groups by size with unattributed items last:\n%s", out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--group-by", "symbol"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected --group-by symbol to require --go-symbols")
+	}
+}
+
+func TestScan_Command_IgnoreCaseInsensitive(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		"main.go":     "// TODO: parent\n",
+		"Vendor/a.go": "// TODO: vendored\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tmp, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) string {
+		return captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp, "--ignore", "vendor"}, args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+		})
+	}
+	if out := run(); !strings.Contains(out, "vendored") {
+		t.Fatalf("--ignore should be case-sensitive by default:\n%s", out)
+	}
+	if out := run("--ignore-case-insensitive"); strings.Contains(out, "vendored") || !strings.Contains(out, "parent") {
+		t.Fatalf("--ignore-case-insensitive should skip Vendor:\n%s", out)
+	}
+}
+
+func TestScan_Command_ModifiedWithin(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{"new.go": "// TODO: recent\n", "old.go": "// TODO: ancient\n"} {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().AddDate(0, 0, -10)
+	if err := os.Chtimes(filepath.Join(tmp, "old.go"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (string, error) {
+		var err error
+		out := captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp}, args...))
+			err = rootCmd.Execute()
+		})
+		return out, err
+	}
+
+	if out, err := run("--modified-within", "7d"); err != nil || !strings.Contains(out, "recent") || strings.Contains(out, "ancient") {
+		t.Fatalf("--modified-within 7d: %v\n%s", err, out)
+	}
+	if out, err := run("--modified-within", "2w"); err != nil || !strings.Contains(out, "ancient") {
+		t.Fatalf("--modified-within 2w should keep both files: %v\n%s", err, out)
+	}
+	for _, bad := range []string{"soon", "0d", "-3h"} {
+		if _, err := run("--modified-within", bad); err == nil || !strings.Contains(err.Error(), "--modified-within") {
+			t.Errorf("--modified-within %s: err = %v, want a usage error", bad, err)
+		}
+	}
+	if _, err := run("--modified-within", "7d", "--git-log"); err == nil {
+		t.Error("--modified-within with --git-log should be rejected")
+	}
+}
+
+func TestScan_Command_Sample(t *testing.T) {
+	tmp := t.TempDir()
+	for i := range 20 {
+		if err := os.WriteFile(filepath.Join(tmp, fmt.Sprintf("f%02d.go", i)), []byte("// TODO: item\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) (string, error) {
+		var err error
+		out := captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp}, args...))
+			err = rootCmd.Execute()
+		})
+		return out, err
+	}
+
+	out, err := run("--sample", "50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Sampled scan: the items below come from about 50% of files.") || !strings.Contains(out, "(sampled at 50%,") {
+		t.Fatalf("sampled table output lacks its labels:\n%s", out)
+	}
+
+	outFile := filepath.Join(t.TempDir(), "r.json")
+	if _, err := run("--sample", "50", "--report", "json", "--out", outFile); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep todo.ReportData
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Metadata == nil || rep.Metadata.Sample == nil || rep.Metadata.Sample.Percent != 50 || rep.Metadata.Sample.Scanned != len(rep.Todos) {
+		t.Fatalf("metadata = %+v", rep.Metadata)
+	}
+
+	for _, args := range [][]string{
+		{"--sample", "101"},
+		{"--sample", "-1"},
+		{"--sample", "10", "--error-tags", "BUG"},
+		{"--sample", "10", "--exit-code-on-findings"},
+		{"--sample", "10", "--min-text-length", "5"},
+		{"--sample", "10", "--budget", "TODO=5"},
+	} {
+		if _, err := run(args...); err == nil || !strings.Contains(err.Error(), "--sample") {
+			t.Errorf("%v: err = %v, want a usage error", args, err)
+		}
+	}
+	if _, err := run("--sample", "10", "--min-text-length", "5", "--filter-noise"); err != nil {
+		t.Errorf("--min-text-length only gates without --filter-noise: %v", err)
+	}
+}
+
+func TestScan_Command_PathDefaultRepo(t *testing.T) {
+	repo := t.TempDir()
+	for name, content := range map[string]string{
+		".git/HEAD":      "ref: refs/heads/main\n",
+		"main.go":        "// TODO: at the root\n",
+		"pkg/sub/sub.go": "// FIXME: in the subdirectory\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(repo, "pkg", "sub"))
+	run := func(args ...string) (string, error) {
+		var err error
+		out := captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan"}, args...))
+			err = rootCmd.Execute()
+		})
+		return out, err
+	}
+
+	if out, err := run(); err != nil || strings.Contains(out, "at the root") || !strings.Contains(out, "in the subdirectory") {
+		t.Fatalf("default should scan the current directory: %v\n%s", err, out)
+	}
+	for _, args := range [][]string{{"--path-default", "repo"}, {"--path", "@repo"}} {
+		if out, err := run(args...); err != nil || !strings.Contains(out, "at the root") || !strings.Contains(out, "in the subdirectory") {
+			t.Fatalf("%v should scan the whole repository: %v\n%s", args, err, out)
+		}
+	}
+	// An explicit --path wins over --path-default.
+	if out, err := run("--path-default", "repo", "--path", "."); err != nil || strings.Contains(out, "at the root") {
+		t.Fatalf("explicit --path: %v\n%s", err, out)
+	}
+	if _, err := run("--path-default", "home"); err == nil || !strings.Contains(err.Error(), "--path-default") {
+		t.Errorf("invalid --path-default: %v", err)
+	}
+
+	t.Chdir(t.TempDir())
+	if _, err := run("--path", "@repo"); err == nil || !strings.Contains(err.Error(), "not inside a git repository") {
+		t.Errorf("@repo outside a repository: %v", err)
+	}
+}
+
+func TestScan_Command_Badge(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: a\n// FIXME: b\n// FIXME: c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	run := func(args ...string) (string, error) {
+		var err error
+		out := captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", tmp, "--report", "badge", "--out-dir", outDir}, args...))
+			err = rootCmd.Execute()
+		})
+		return out, err
+	}
+
+	out, err := run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, filepath.Join(outDir, "badge.svg")) {
+		t.Errorf("output does not name the default badge.svg:\n%s", out)
+	}
+	svg, err := os.ReadFile(filepath.Join(outDir, "badge.svg"))
+	if err != nil || !strings.Contains(string(svg), "<title>todos: 3</title>") {
+		t.Fatalf("badge.svg = %s, %v", svg, err)
+	}
+
+	if _, err := run("--out", "fixme.svg", "--badge-tag", "FIXME", "--badge-label", "fixmes", "--badge-green", "1", "--badge-yellow", "5"); err != nil {
+		t.Fatal(err)
+	}
+	svg, _ = os.ReadFile(filepath.Join(outDir, "fixme.svg"))
+	if !strings.Contains(string(svg), "<title>fixmes: 2</title>") || !strings.Contains(string(svg), "#dfb317") {
+		t.Errorf("tag badge = %s", svg)
+	}
+
+	// A clean tree still gets its badge.
+	if _, err := run("--out", "bugs.svg", "--badge-tag", "BUG"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("--tags", "HACK", "--out", "none.svg"); err != nil {
+		t.Fatal(err)
+	}
+	if svg, err := os.ReadFile(filepath.Join(outDir, "none.svg")); err != nil || !strings.Contains(string(svg), "<title>todos: 0</title>") {
+		t.Errorf("empty scan badge = %s, %v", svg, err)
+	}
+
+	if _, err := run("--badge-green", "10", "--badge-yellow", "5"); err == nil {
+		t.Error("yellow below green should be rejected")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"7d":   7 * 24 * time.Hour,
+		" 2w ": 14 * 24 * time.Hour,
+		"36h":  36 * time.Hour,
+		"90m":  90 * time.Minute,
+	} {
+		if got, err := parseWindow(in); err != nil || got != want {
+			t.Errorf("parseWindow(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "1.5d", "7days", "0h", "-1d"} {
+		if _, err := parseWindow(in); err == nil {
+			t.Errorf("parseWindow(%q) accepted", in)
+		}
+	}
+}
+
+func TestScan_Command_Only(t *testing.T) {
+	tmp := t.TempDir()
+	for rel, content := range map[string]string{
+		"src/a.go":  "// TODO: wanted\n",
+		"docs/b.go": "// TODO: unwanted\n",
+	} {
+		p := filepath.Join(tmp, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	var runErr error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--only", "src/"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr != nil {
+		t.Fatalf("scan: %v", runErr)
+	}
+	if !strings.Contains(out, "wanted") || strings.Contains(out, "unwanted") {
+		t.Fatalf("expected only src items:\n%s", out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--only", "src/[x"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --only") {
+		t.Fatalf("expected invalid --only error, got %v", err)
+	}
+}
+
+func TestScan_Command_DetectConflicts_ErrorTags(t *testing.T) {
+	tmp := t.TempDir()
+	conflicted := "package main\n<<<<<<< HEAD\nvar a = 1\n=======\nvar a = 2\n>>>>>>> topic\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(conflicted), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var runErr error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--detect", "conflicts", "--error-tags", "CONFLICT"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr == nil {
+		t.Fatalf("expected --error-tags CONFLICT to fail the scan")
+	}
+	if !strings.Contains(out, "CONFLICT: unresolved merge") {
+		t.Fatalf("conflict not reported:\n%s", out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--detect", "secrets"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --detect") {
+		t.Fatalf("expected invalid --detect error, got %v", err)
+	}
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--detect", "debug", "--debug-patterns", "go"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected an error for a malformed --debug-patterns entry")
+	}
+}
+
+func TestScan_Command_Stdin(t *testing.T) {
+	t.Cleanup(func() { rootCmd.SetIn(nil) })
+	run := func(args ...string) (string, error) {
+		rootCmd.SetIn(strings.NewReader("x := f() // TODO: trailing\n// FIXME: own\n"))
+		var err error
+		out := captureStdout(t, func() {
+			rootCmd.SetArgs(append([]string{"scan", "--path", "-", "--report", "jsonl", "--out", "-"}, args...))
+			err = rootCmd.Execute()
+		})
+		return out, err
+	}
+	out, err := run("--stdin-format", "go")
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	for _, want := range []string{`"file":"\u003cstdin\u003e"`, `"position":"trailing"`, `"position":"own-line"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %s in:\n%s", want, out)
+		}
+	}
+	out, err = run()
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if strings.Contains(out, `"position"`) || !strings.Contains(out, `"total":2`) {
+		t.Fatalf("expected raw matching without a format:\n%s", out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", t.TempDir(), "--stdin-format", "go"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "requires --path -") {
+		t.Fatalf("expected --stdin-format to require --path -, got %v", err)
+	}
+}
+
+func TestScan_Command_Plan(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		"a.go":          "// TODO: a\n",
+		"b.go":          "// TODO: b\n",
+		"vendor/v.go":   "// TODO: vendored\n",
+		"docs/notes.md": "TODO: docs\n",
+	} {
+		full := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var err error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--plan", "--ignore", "vendor", "--report", "json"})
+		err = rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("scan --plan: %v", err)
+	}
+	var plan todo.Plan
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("decode plan: %v\n%s", err, out)
+	}
+	if plan.Files != 3 || len(plan.Skipped) != 1 || plan.Skipped[0].Reason != todo.SkipReasonIgnoreFlag {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+
+	out = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--plan"})
+		err = rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("scan --plan: %v", err)
+	}
+	for _, want := range []string{"Scan plan: 4 files", "Extensions:", "Largest files:"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Summary:") {
+		t.Fatalf("--plan should not scan:\n%s", out)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--plan", "--report", "md"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--plan requires") {
+		t.Fatalf("expected --plan to reject --report md, got %v", err)
+	}
+}
+
+func TestScan_Command_SanitizesTableOutput(t *testing.T) {
+	tmp := t.TempDir()
+	src := "// TODO: caf\xe9\n// FIXME: \x1b[31mred\x1b[0m\n// BUG: nul\x00here\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp})
+		err = rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if strings.ContainsAny(out, "\x1b\x00") || !utf8.ValidString(out) {
+		t.Fatalf("table output carries raw bytes: %q", out)
+	}
+	for _, want := range []string{"caf\uFFFD", "FIXME: red", "nulhere"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--include-raw-bytes"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "requires --include-raw-line") {
+		t.Fatalf("expected --include-raw-bytes to require --include-raw-line, got %v", err)
+	}
+}
+
+func TestScan_Command_TrackedOnly(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{"main.go": "// TODO: tracked\n", "dist/bundle.js": "// TODO: build artifact\n"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tmp, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orig := gitRunner
+	t.Cleanup(func() { gitRunner = orig })
+	gitRunner = cannedGit{out: "main.go\x00"}
+	run := func() []string {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "r.json")
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", out, "--tracked-only"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed struct {
+			Todos []struct{ File string } `json:"todos"`
+		}
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, it := range parsed.Todos {
+			files = append(files, it.File)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	// Outside a repository every file is scanned.
+	if got := run(); len(got) != 2 {
+		t.Fatalf("files outside a repo = %v, want both", got)
+	}
+	if err := os.Mkdir(filepath.Join(tmp, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Fatalf("files = %v, want only the tracked main.go", got)
+	}
+
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--tracked-only", "--git-log"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("--tracked-only with --git-log should be rejected")
+	}
+}
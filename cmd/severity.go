@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// Severity classes assigned by --error-tags and --warning-tags.
+const (
+	classError   = "error"
+	classWarning = "warning"
+)
+
+// tagClasses classifies tags as errors or warnings. With only error tags
+// given, every other tag is a warning; with both lists, tags in neither
+// are left unclassified.
+type tagClasses struct {
+	errors   map[string]bool
+	warnings map[string]bool
+}
+
+// newTagClasses parses the comma-separated --error-tags and --warning-tags
+// values. A tag may not be in both lists.
+func newTagClasses(errorCSV, warningCSV string) (tagClasses, error) {
+	c := tagClasses{errors: map[string]bool{}, warnings: map[string]bool{}}
+	for _, tag := range buildIgnoreList(errorCSV) {
+		c.errors[strings.ToUpper(tag)] = true
+	}
+	for _, tag := range buildIgnoreList(warningCSV) {
+		tag = strings.ToUpper(tag)
+		if c.errors[tag] {
+			return tagClasses{}, fmt.Errorf("tag %s is in both --error-tags and --warning-tags", tag)
+		}
+		c.warnings[tag] = true
+	}
+	return c, nil
+}
+
+// enabled reports whether any classification was requested.
+func (c tagClasses) enabled() bool {
+	return len(c.errors) > 0 || len(c.warnings) > 0
+}
+
+// class returns the class of tag, or "" when it is unclassified.
+func (c tagClasses) class(tag string) string {
+	tag = strings.ToUpper(tag)
+	switch {
+	case c.errors[tag]:
+		return classError
+	case c.warnings[tag], len(c.warnings) == 0 && len(c.errors) > 0:
+		return classWarning
+	}
+	return ""
+}
+
+// count tallies items per class and, within each class, per tag.
+func (c tagClasses) count(items []todo.Todo) map[string]map[string]int {
+	out := map[string]map[string]int{classError: {}, classWarning: {}}
+	for _, t := range items {
+		if cl := c.class(t.Tag); cl != "" {
+			out[cl][strings.ToUpper(t.Tag)]++
+		}
+	}
+	return out
+}
+
+// printClassSummary prints error and warning counts, each split by tag.
+func printClassSummary(items []todo.Todo, c tagClasses, lang string) {
+	counts := c.count(items)
+	for _, cl := range []struct {
+		name  string
+		label string
+		color *color.Color
+	}{
+		{classError, todo.Message(lang, "errors"), color.New(color.FgRed, color.Bold)},
+		{classWarning, todo.Message(lang, "warnings"), color.New(color.FgYellow, color.Bold)},
+	} {
+		byTag := counts[cl.name]
+		total := 0
+		tags := make([]string, 0, len(byTag))
+		for tag, n := range byTag {
+			total += n
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		parts := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			parts = append(parts, fmt.Sprintf("%s: %d", tag, byTag[tag]))
+		}
+		line := fmt.Sprintf("  %s: %d", cl.color.Sprint(cl.label), total)
+		if len(parts) > 0 {
+			line += " (" + strings.Join(parts, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+// errorTagsError returns an error naming the number of error-class items, or nil.
+func errorTagsError(items []todo.Todo, c tagClasses) error {
+	n := 0
+	for _, byTag := range c.count(items)[classError] {
+		n += byTag
+	}
+	if n > 0 {
+		return gatingError(fmt.Errorf("%d item(s) tagged with --error-tags", n))
+	}
+	return nil
+}
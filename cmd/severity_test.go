@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func TestTagClasses(t *testing.T) {
+	onlyErrors, err := newTagClasses("bug, FIXME", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onlyErrors.class("BUG") != classError || onlyErrors.class("todo") != classWarning || onlyErrors.class("HACK") != classWarning {
+		t.Fatalf("with only --error-tags, other tags should be warnings")
+	}
+	both, err := newTagClasses("BUG", "TODO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if both.class("NOTE") != "" || both.class("todo") != classWarning {
+		t.Fatalf("with both lists, unlisted tags should be unclassified")
+	}
+	if (tagClasses{}).enabled() {
+		t.Fatalf("zero value should be disabled")
+	}
+	if _, err := newTagClasses("BUG", "bug"); err == nil {
+		t.Fatalf("expected error for a tag in both lists")
+	}
+
+	items := []todo.Todo{{Tag: "BUG"}, {Tag: "FIXME"}, {Tag: "TODO"}}
+	if err := errorTagsError(items, onlyErrors); err == nil || !strings.Contains(err.Error(), "2 item(s)") {
+		t.Fatalf("errorTagsError = %v", err)
+	}
+	if err := errorTagsError(items[2:], onlyErrors); err != nil {
+		t.Fatalf("warnings alone should not fail: %v", err)
+	}
+}
+
+func TestScan_Command_ErrorAndWarningTags(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: a\n// NOTE: b\n// FIXME: c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var runErr error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--error-tags", "BUG,FIXME", "--warning-tags", "TODO,NOTE"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr == nil {
+		t.Fatalf("expected non-zero exit when an error tag is found")
+	}
+	if !strings.Contains(out, "Errors: 1 (FIXME: 1)") || !strings.Contains(out, "Warnings: 2 (NOTE: 1, TODO: 1)") {
+		t.Fatalf("unexpected class summary:\n%s", out)
+	}
+
+	out = captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--error-tags", "BUG"})
+		runErr = rootCmd.Execute()
+	})
+	if runErr != nil {
+		t.Fatalf("warnings only should pass: %v", runErr)
+	}
+	if !strings.Contains(out, "Errors: 0") || !strings.Contains(out, "Warnings: 3") {
+		t.Fatalf("unexpected class summary:\n%s", out)
+	}
+}
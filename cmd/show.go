@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	fs := showCmd.Flags()
+	fs.StringP("path", "p", ".", "Directory path to scan; file paths are relative to it")
+	fs.String("ignore", "", "Comma-separated list of directory names to skip")
+	fs.String("from", "", "Look the item up in this JSON report instead of a fresh scan")
+	fs.String("repo-url", "", "Repository web URL, e.g. https://github.com/org/repo, to link the item with a permalink")
+	fs.String("commit", "", "Commit the permalink points at (default: HEAD of the repository at --path)")
+	fs.Int("context", 3, "Lines of code shown above and below the item")
+}
+
+// showCmd prints one item as a Markdown snippet to paste into a review.
+var showCmd = &cobra.Command{
+	Use:   "show <fingerprint|file:line>",
+	Short: "Print an item as a Markdown snippet for review comments",
+	Long: `Finds one item and prints it as Markdown ready to paste into a pull request
+comment: its tag in bold, its text, its location, and a fenced block of the
+code around it with an arrow on the item's line.
+
+The item is given by its fingerprint, as in JSON reports, or by file:line
+with the file relative to --path. A fingerprint may be shortened to any
+prefix of at least 4 characters that no other item shares, like a git short
+hash. A file:line names the nearest item of the file within 5 lines, so a
+location from a slightly older revision still resolves.
+
+Items come from a fresh scan of --path, or from a JSON report given with
+--from; the code is always read from the files under --path, where an item
+that moved by up to 5 lines is found again by its fingerprint. With
+--repo-url the location links to the line at --commit, or at HEAD.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer resetFlags(cmd)
+
+		p, _ := cmd.Flags().GetString("path")
+		i, _ := cmd.Flags().GetString("ignore")
+		from, _ := cmd.Flags().GetString("from")
+		repoURL, _ := cmd.Flags().GetString("repo-url")
+		commit, _ := cmd.Flags().GetString("commit")
+		context, _ := cmd.Flags().GetInt("context")
+		if context < 0 {
+			return fmt.Errorf("invalid --context %d: must not be negative", context)
+		}
+
+		opts := todo.DefaultScanOptions()
+		opts.IgnoreDirs = buildIgnoreList(i)
+		var items []todo.Todo
+		var err error
+		if from != "" {
+			items, err = todo.LoadJSONReport(from)
+		} else {
+			items, err = todo.ScanDirWithOptions(p, opts, todo.OSFileReader{})
+		}
+		if err != nil {
+			return err
+		}
+
+		var t todo.Todo
+		if file, line, ok := parseFileLine(args[0]); ok {
+			t, err = todo.FindAtLine(items, file, line)
+		} else {
+			t, err = todo.FindByFingerprint(items, args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		src, err := os.ReadFile(filepath.Join(p, filepath.FromSlash(t.File)))
+		if err != nil {
+			return err
+		}
+		if from != "" {
+			s, err := todo.NewScanner(opts)
+			if err != nil {
+				return err
+			}
+			current, err := s.ScanString(t.File, string(src))
+			if err != nil {
+				return err
+			}
+			found, ok := todo.Locate(current, t)
+			if !ok {
+				return fmt.Errorf("%s:%d: the item is no longer within %d lines of where the report has it", t.File, t.Line, todo.LineDrift)
+			}
+			t = found
+		}
+
+		snippet := todo.SnippetOptions{Context: context}
+		if repoURL != "" {
+			if snippet.Permalink, err = showPermalink(cmd, p, repoURL, commit, t); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprint(cmd.OutOrStdout(), todo.RenderSnippet(t, src, snippet))
+		return nil
+	},
+}
+
+// parseFileLine splits a "file:line" argument. Anything else, such as a
+// fingerprint, reports false.
+func parseFileLine(arg string) (string, int, bool) {
+	i := strings.LastIndexByte(arg, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(arg[i+1:])
+	if err != nil || line < 1 {
+		return "", 0, false
+	}
+	return strings.TrimPrefix(filepath.ToSlash(arg[:i]), "./"), line, true
+}
+
+// showPermalink links t at commit, or at HEAD of the repository holding
+// dir, prefixing its path with dir's place in the repository.
+func showPermalink(cmd *cobra.Command, dir, repoURL, commit string, t todo.Todo) (string, error) {
+	prefix, err := gitRunner.Run(cmd.Context(), dir, "rev-parse", "--show-prefix")
+	inRepo := err == nil
+	if commit == "" {
+		if !inRepo {
+			return "", errors.New("--repo-url needs --commit outside a git repository")
+		}
+		head, err := gitRunner.Run(cmd.Context(), dir, "rev-parse", "HEAD")
+		if err != nil {
+			return "", fmt.Errorf("resolve HEAD for the permalink: %w", err)
+		}
+		commit = strings.TrimSpace(head)
+	}
+	file := t.File
+	if inRepo {
+		file = strings.TrimSpace(prefix) + file
+	}
+	return todo.Permalink(repoURL, commit, file, t.Line), nil
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// argsGit answers git commands from a map keyed by their joined arguments;
+// a missing key fails like git outside a repository.
+type argsGit map[string]string
+
+func (g argsGit) Run(_ context.Context, _ string, args ...string) (string, error) {
+	out, ok := g[strings.Join(args, " ")]
+	if !ok {
+		return "", errors.New("fatal: not a git repository")
+	}
+	return out, nil
+}
+
+func runShow(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"show"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestShow_Command(t *testing.T) {
+	tmp := t.TempDir()
+	src := "package main\n\n// TODO: first\nfunc main() {}\n// FIXME: second\n"
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orig := gitRunner
+	t.Cleanup(func() { gitRunner = orig })
+	gitRunner = argsGit{"rev-parse --show-prefix": "sub/\n", "rev-parse HEAD": "deadbeef\n"}
+
+	fp := todo.Fingerprint(todo.Todo{Location: todo.Location{File: "main.go"}, Tag: "FIXME", Text: "second"})
+	out, err := runShow(t, "--path", tmp, "--repo-url", "https://github.com/org/repo", fp[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "🔧 **FIXME** second\n\n" +
+		"[main.go:5](https://github.com/org/repo/blob/deadbeef/sub/main.go#L5)\n\n" +
+		"```go\n\n// TODO: first\nfunc main() {}\n// FIXME: second  // <--\n```\n"
+	if out != want {
+		t.Fatalf("output =\n%s\nwant\n%s", out, want)
+	}
+
+	out, err = runShow(t, "--path", tmp, "--context", "0", "main.go:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "`main.go:3`") || !strings.Contains(out, "```go\n// TODO: first  // <--\n```") {
+		t.Errorf("file:line lookup should find the nearest item:\n%s", out)
+	}
+
+	gitRunner = argsGit{}
+	if _, err := runShow(t, "--path", tmp, "--repo-url", "https://github.com/org/repo", "main.go:3"); err == nil || !strings.Contains(err.Error(), "--repo-url needs --commit") {
+		t.Errorf("err = %v", err)
+	}
+	out, err = runShow(t, "--path", tmp, "--repo-url", "https://github.com/org/repo", "--commit", "v1.0", "main.go:3")
+	if err != nil || !strings.Contains(out, "(https://github.com/org/repo/blob/v1.0/main.go#L3)") {
+		t.Errorf("--commit outside a repository: %v\n%s", err, out)
+	}
+}
+
+func TestShow_Command_FromReportWithDrift(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "main.go")
+	if err := os.WriteFile(file, []byte("// TODO: same text\n// TODO: same text\n// FIXME: moved\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(t.TempDir(), "report.json")
+	rootCmd.SetArgs([]string{"scan", "--path", tmp, "--report", "json", "--out", report})
+	captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Both items share a fingerprint, so any prefix is ambiguous.
+	fp := todo.Fingerprint(todo.Todo{Location: todo.Location{File: "main.go"}, Tag: "TODO", Text: "same text"})
+	_, err := runShow(t, "--path", tmp, "--from", report, fp[:4])
+	var amb *todo.AmbiguousError
+	if !errors.As(err, &amb) || !strings.Contains(err.Error(), "main.go:1 TODO: same text\n  "+fp+" main.go:2") {
+		t.Fatalf("err = %v, want the candidates listed", err)
+	}
+
+	// Three lines inserted above: the code is read from where the item is now.
+	if err := os.WriteFile(file, []byte("a\nb\nc\n// TODO: same text\n// TODO: same text\n// FIXME: moved\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runShow(t, "--path", tmp, "--from", report, "--context", "1", "main.go:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "🔧 **FIXME** moved\n\n`main.go:6`") || !strings.Contains(out, "// TODO: same text\n// FIXME: moved  // <--\n```") {
+		t.Errorf("the item should be found 3 lines down:\n%s", out)
+	}
+
+	if err := os.WriteFile(file, []byte("// TODO: other text\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runShow(t, "--path", tmp, "--from", report, "main.go:1"); err == nil || !strings.Contains(err.Error(), "no longer within 5 lines") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParseFileLine(t *testing.T) {
+	tests := []struct {
+		arg  string
+		file string
+		line int
+		ok   bool
+	}{
+		{"main.go:12", "main.go", 12, true},
+		{"./sub/a.go:3", "sub/a.go", 3, true},
+		{"C:/x.go:3", "C:/x.go", 3, true},
+		{"abc123", "", 0, false},
+		{"main.go:", "", 0, false},
+		{"main.go:0", "", 0, false},
+		{":3", "", 0, false},
+	}
+	for _, tt := range tests {
+		file, line, ok := parseFileLine(tt.arg)
+		if file != tt.file || line != tt.line || ok != tt.ok {
+			t.Errorf("parseFileLine(%q) = %q, %d, %v", tt.arg, file, line, ok)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/valerioTomassi/todototum/internal/todo"
+	"gopkg.in/yaml.v3"
+)
+
+// terminalColors are the color names a --tags-file may give a tag.
+var terminalColors = map[string]color.Attribute{
+	"black":      color.FgBlack,
+	"red":        color.FgRed,
+	"green":      color.FgGreen,
+	"yellow":     color.FgYellow,
+	"blue":       color.FgBlue,
+	"magenta":    color.FgMagenta,
+	"cyan":       color.FgCyan,
+	"white":      color.FgWhite,
+	"hi-black":   color.FgHiBlack,
+	"hi-red":     color.FgHiRed,
+	"hi-green":   color.FgHiGreen,
+	"hi-yellow":  color.FgHiYellow,
+	"hi-blue":    color.FgHiBlue,
+	"hi-magenta": color.FgHiMagenta,
+	"hi-cyan":    color.FgHiCyan,
+	"hi-white":   color.FgHiWhite,
+}
+
+// tagsFileKeys are the settings of a tag in a --tags-file.
+var tagsFileKeys = []string{"aliases", "color", "severity"}
+
+// tagSet is the tag definition read from a --tags-file: the tags to match,
+// in file order, with their aliases, terminal colors and severity classes.
+type tagSet struct {
+	tags []string
+	// aliases maps each upper-cased alias to its tag.
+	aliases map[string]string
+	// colors maps tags to their terminal color; tags without one keep the
+	// built-in color.
+	colors map[string]color.Attribute
+	// errors and warnings list the tags of each severity class.
+	errors, warnings []string
+}
+
+// loadTagsFile reads a --tags-file: a YAML mapping of tag names to their
+// color, severity (error or warning) and aliases, each optional, e.g.
+//
+//	FIXME:
+//	  color: red
+//	  severity: error
+//	  aliases: [HACK, XXX]
+//
+// Tags and aliases are case-insensitive; one spelling may only be defined
+// once. Errors name the file and line.
+func loadTagsFile(path string) (*tagSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode || len(doc.Content[0].Content) == 0 {
+		return nil, fmt.Errorf("%s: expected a mapping of tag names to their settings", path)
+	}
+	fail := func(n *yaml.Node, format string, args ...any) error {
+		return fmt.Errorf("%s:%d: %s", path, n.Line, fmt.Sprintf(format, args...))
+	}
+
+	s := &tagSet{aliases: map[string]string{}, colors: map[string]color.Attribute{}}
+	// defined maps each spelling seen so far to the line defining it.
+	defined := map[string]int{}
+	define := func(n *yaml.Node) (string, error) {
+		name := strings.ToUpper(strings.TrimSpace(n.Value))
+		if n.Kind != yaml.ScalarNode || name == "" {
+			return "", fail(n, "expected a tag name")
+		}
+		if _, err := todo.TagPattern([]string{name}); err != nil {
+			return "", fail(n, "%v", err)
+		}
+		if line, ok := defined[name]; ok {
+			return "", fail(n, "%s is already defined on line %d", name, line)
+		}
+		defined[name] = n.Line
+		return name, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], root.Content[i+1]
+		tag, err := define(key)
+		if err != nil {
+			return nil, err
+		}
+		s.tags = append(s.tags, tag)
+		if val.Kind == yaml.ScalarNode && val.Tag == "!!null" {
+			continue // a bare "TAG:" only adds the tag
+		}
+		if val.Kind != yaml.MappingNode {
+			return nil, fail(val, "%s: expected a mapping of %s", tag, strings.Join(tagsFileKeys, ", "))
+		}
+		for j := 0; j+1 < len(val.Content); j += 2 {
+			k, v := val.Content[j], val.Content[j+1]
+			switch k.Value {
+			case "color":
+				attr, ok := terminalColors[strings.ToLower(strings.TrimSpace(v.Value))]
+				if v.Kind != yaml.ScalarNode || !ok {
+					return nil, fail(v, "%s: unknown color %q; must be one of: %s", tag, v.Value, strings.Join(sortedColorNames(), ", "))
+				}
+				s.colors[tag] = attr
+			case "severity":
+				switch strings.ToLower(strings.TrimSpace(v.Value)) {
+				case classError:
+					s.errors = append(s.errors, tag)
+				case classWarning:
+					s.warnings = append(s.warnings, tag)
+				default:
+					return nil, fail(v, "%s: invalid severity %q; must be %s or %s", tag, v.Value, classError, classWarning)
+				}
+			case "aliases":
+				items := []*yaml.Node{v}
+				if v.Kind == yaml.SequenceNode {
+					items = v.Content
+				}
+				for _, item := range items {
+					alias, err := define(item)
+					if err != nil {
+						return nil, err
+					}
+					s.aliases[alias] = tag
+				}
+			default:
+				return nil, fail(k, "%s: unknown setting %q; must be one of: %s", tag, k.Value, strings.Join(tagsFileKeys, ", "))
+			}
+		}
+	}
+	return s, nil
+}
+
+// sortedColorNames returns the keys of terminalColors in order.
+func sortedColorNames() []string {
+	names := make([]string, 0, len(terminalColors))
+	for name := range terminalColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func writeTagsFile(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "tags.yaml")
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadTagsFile(t *testing.T) {
+	p := writeTagsFile(t, `fixme:
+  color: Red
+  severity: error
+  aliases: [hack, XXX]
+TODO:
+  severity: warning
+  aliases: LATER
+NOTE:
+`)
+	got, err := loadTagsFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &tagSet{
+		tags:     []string{"FIXME", "TODO", "NOTE"},
+		aliases:  map[string]string{"HACK": "FIXME", "XXX": "FIXME", "LATER": "TODO"},
+		colors:   map[string]color.Attribute{"FIXME": color.FgRed},
+		errors:   []string{"FIXME"},
+		warnings: []string{"TODO"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("loadTagsFile = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadTagsFile_Errors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"empty", "", "expected a mapping of tag names"},
+		{"not a mapping", "- TODO\n", "expected a mapping of tag names"},
+		{"duplicate tag", "TODO:\nNOTE:\ntodo:\n", ":3: TODO is already defined on line 1"},
+		{"alias of another tag", "TODO:\nFIXME:\n  aliases: [Todo]\n", ":3: TODO is already defined on line 1"},
+		{"duplicate alias", "TODO:\n  aliases: [LATER]\nFIXME:\n  aliases: [later]\n", ":4: LATER is already defined on line 2"},
+		{"unknown color", "TODO:\n  color: pink\n", `:2: TODO: unknown color "pink"; must be one of: black, blue,`},
+		{"bad severity", "TODO:\n  severity: fatal\n", `:2: TODO: invalid severity "fatal"; must be error or warning`},
+		{"unknown setting", "TODO:\n  colour: red\n", `:2: TODO: unknown setting "colour"`},
+		{"invalid tag", "TO DO:\n", `:1: invalid tag "TO DO"`},
+		{"invalid alias", "TODO:\n  aliases: [\"1x\"]\n", `:2: invalid tag "1X"`},
+		{"settings not a mapping", "TODO: red\n", ":1: TODO: expected a mapping of aliases, color, severity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTagsFile(writeTagsFile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagColor_TagsFile(t *testing.T) {
+	tagColors = map[string]color.Attribute{"TODO": color.FgMagenta}
+	defer func() { tagColors = nil }()
+	if !tagColor("todo").Equals(color.New(color.FgMagenta)) {
+		t.Error("a --tags-file color should replace the built-in one")
+	}
+	if !tagColor("FIXME").Equals(color.New(color.FgRed)) {
+		t.Error("tags without a --tags-file color should keep the built-in one")
+	}
+}
+
+func TestScan_Command_TagsFile(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("// TODO: a\n// hack: b\n// LATER: c\n// FIXME: d\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tagsPath := writeTagsFile(t, "FIXME:\n  severity: error\n  aliases: [HACK]\nTODO:\n  severity: warning\n  aliases: [LATER]\n")
+
+	var runErr error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--tags-file", tagsPath})
+		runErr = rootCmd.Execute()
+	})
+	if exitCode(runErr) != ExitGating {
+		t.Fatalf("err = %v, want a gating failure for the error tags", runErr)
+	}
+	if !strings.Contains(out, "Errors: 2 (FIXME: 2)") || !strings.Contains(out, "Warnings: 2 (TODO: 2)") {
+		t.Fatalf("aliases should count as their tag:\n%s", out)
+	}
+	if tagColors != nil {
+		t.Error("the --tags-file colors should not outlive the scan")
+	}
+
+	for _, extra := range [][]string{{"--tags", "TODO"}, {"--error-tags", "BUG"}, {"--warning-tags", "NOTE"}} {
+		args := append([]string{"scan", "--path", tmp, "--tags-file", tagsPath}, extra...)
+		captureStdout(t, func() {
+			rootCmd.SetArgs(args)
+			runErr = rootCmd.Execute()
+		})
+		if runErr == nil || !strings.Contains(runErr.Error(), "--tags-file cannot be combined") {
+			t.Errorf("%v: err = %v", extra, runErr)
+		}
+	}
+
+	bad := writeTagsFile(t, "TODO:\n  color: pink\n")
+	captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"scan", "--path", tmp, "--tags-file", bad})
+		runErr = rootCmd.Execute()
+	})
+	if runErr == nil || !strings.Contains(runErr.Error(), `--tags-file: `+bad+`:2: TODO: unknown color "pink"`) {
+		t.Errorf("err = %v", runErr)
+	}
+}
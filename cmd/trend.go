@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// summaryCounts are the counters the terminal summary shows and compares
+// with a previous run.
+type summaryCounts struct {
+	Total int
+	ByTag map[string]int
+	// ByDir counts items by top-level directory; nil when unknown.
+	ByDir map[string]int
+}
+
+// countSummary tallies items by upper-cased tag and top-level directory.
+func countSummary(items []todo.Todo) summaryCounts {
+	c := summaryCounts{Total: len(items), ByTag: make(map[string]int), ByDir: make(map[string]int)}
+	for _, t := range items {
+		c.ByTag[strings.ToUpper(t.Tag)]++
+		c.ByDir[todo.TopDir(t.File)]++
+	}
+	return c
+}
+
+// runSummary returns the counters recorded for a history run.
+func runSummary(run todo.HistoryRun) summaryCounts {
+	c := summaryCounts{Total: run.Total, ByTag: make(map[string]int), ByDir: run.ByDir}
+	for tag, n := range run.ByTag {
+		c.ByTag[strings.ToUpper(tag)] += n
+	}
+	return c
+}
+
+// summaryLines renders the total and per-tag lines of the terminal summary.
+// With a previous run each changed count is annotated with its delta, tags
+// that disappeared are listed at zero, and a line lists the top-level
+// directories whose count changed by more than dirThreshold. Without one the
+// lines carry no annotations.
+func summaryLines(cur summaryCounts, prev *summaryCounts, dirThreshold int, lang string) []string {
+	var prevTags map[string]int
+	if prev != nil {
+		prevTags = prev.ByTag
+	}
+	total := fmt.Sprintf("%s: %d", todo.Message(lang, "total"), cur.Total)
+	if prev != nil {
+		if d := formatDelta(cur.Total, prev.Total); d != "" {
+			total += " (" + d + " since last run)"
+		}
+	}
+	lines := []string{total}
+	for _, tag := range unionKeys(cur.ByTag, prevTags) {
+		line := fmt.Sprintf("%s: %d", tag, cur.ByTag[tag])
+		if prev != nil {
+			if d := formatDelta(cur.ByTag[tag], prevTags[tag]); d != "" {
+				line += " (" + d + ")"
+			}
+		}
+		lines = append(lines, line)
+	}
+	if prev == nil || prev.ByDir == nil {
+		return lines
+	}
+	var dirs []string
+	for _, dir := range unionKeys(cur.ByDir, prev.ByDir) {
+		change := cur.ByDir[dir] - prev.ByDir[dir]
+		if change > dirThreshold || -change > dirThreshold {
+			dirs = append(dirs, dir+" "+formatDelta(cur.ByDir[dir], prev.ByDir[dir]))
+		}
+	}
+	if len(dirs) > 0 {
+		lines = append(lines, "Directories: "+strings.Join(dirs, ", "))
+	}
+	return lines
+}
+
+// formatDelta describes the change from prev to cur: "▲ +4" in red or
+// "▼ −3" in green, "▼ −all 5" when the count dropped to zero, and "" when
+// it did not change.
+func formatDelta(cur, prev int) string {
+	switch {
+	case cur == prev:
+		return ""
+	case cur > prev:
+		return color.New(color.FgRed).Sprintf("▲ +%d", cur-prev)
+	case cur == 0:
+		return color.New(color.FgGreen).Sprintf("▼ −all %d", prev)
+	}
+	return color.New(color.FgGreen).Sprintf("▼ −%d", prev-cur)
+}
+
+// unionKeys returns the keys of a and b, sorted.
+func unionKeys(a, b map[string]int) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+	for _, m := range []map[string]int{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
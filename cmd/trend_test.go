@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSummaryLines(t *testing.T) {
+	old := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = old }()
+
+	cur := summaryCounts{
+		Total: 8,
+		ByTag: map[string]int{"TODO": 6, "BUG": 2},
+		ByDir: map[string]int{"api": 5, "web": 2, ".": 1},
+	}
+	prev := &summaryCounts{
+		Total: 10,
+		ByTag: map[string]int{"TODO": 5, "FIXME": 5},
+		ByDir: map[string]int{"api": 8, "web": 1, ".": 1},
+	}
+
+	tests := []struct {
+		name      string
+		prev      *summaryCounts
+		threshold int
+		want      []string
+	}{
+		{
+			name: "no previous run",
+			want: []string{"Total: 8", "BUG: 2", "TODO: 6"},
+		},
+		{
+			name: "deltas",
+			prev: prev,
+			want: []string{
+				"Total: 8 (▼ −2 since last run)",
+				"BUG: 2 (▲ +2)",
+				"FIXME: 0 (▼ −all 5)",
+				"TODO: 6 (▲ +1)",
+				"Directories: api ▼ −3, web ▲ +1",
+			},
+		},
+		{
+			name:      "directory threshold",
+			prev:      prev,
+			threshold: 1,
+			want: []string{
+				"Total: 8 (▼ −2 since last run)",
+				"BUG: 2 (▲ +2)",
+				"FIXME: 0 (▼ −all 5)",
+				"TODO: 6 (▲ +1)",
+				"Directories: api ▼ −3",
+			},
+		},
+		{
+			name: "unchanged",
+			prev: &cur,
+			want: []string{"Total: 8", "BUG: 2", "TODO: 6"},
+		},
+		{
+			name: "run without directories",
+			prev: &summaryCounts{Total: 8, ByTag: map[string]int{"TODO": 6, "BUG": 2}},
+			want: []string{"Total: 8", "BUG: 2", "TODO: 6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summaryLines(cur, tt.prev, tt.threshold, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("summaryLines =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package benchgate compares `go test -bench -benchmem` results against a
+// committed baseline, to fail CI when a benchmark regresses beyond a
+// threshold.
+package benchgate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultThreshold is the slowdown, in percent, tolerated when neither the
+// baseline nor the caller sets one.
+const DefaultThreshold = 20
+
+// Result is the measurement of one benchmark. With several runs of the same
+// benchmark (go test -count), each figure is the median of the runs.
+type Result struct {
+	NsPerOp     float64 `json:"nsPerOp"`
+	BytesPerOp  float64 `json:"bytesPerOp,omitempty"`
+	AllocsPerOp float64 `json:"allocsPerOp,omitempty"`
+}
+
+// Baseline is the committed reference the gate compares against.
+type Baseline struct {
+	// Threshold is the tolerated increase in percent; zero means
+	// DefaultThreshold.
+	Threshold float64 `json:"threshold,omitempty"`
+	// Benchmarks maps benchmark names, without the -GOMAXPROCS suffix, e.g.
+	// "BenchmarkScanDir/wide", to their reference results.
+	Benchmarks map[string]Result `json:"benchmarks"`
+}
+
+// LoadBaseline reads a Baseline from the JSON file at path.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// WriteBaseline writes b to w as indented JSON, benchmarks sorted by name.
+func WriteBaseline(w io.Writer, b *Baseline) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// benchLine matches a result line of go test -bench, e.g.
+// "BenchmarkScanDir/wide-8   12   98765 ns/op   1234 B/op   56 allocs/op".
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(.*)$`)
+
+// Parse reads go test -bench output and returns the result of each
+// benchmark, the median of its runs. Lines other than benchmark results,
+// such as goos: or PASS, are skipped.
+func Parse(r io.Reader) (map[string]Result, error) {
+	runs := make(map[string][]Result)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		m := benchLine.FindStringSubmatch(strings.TrimSpace(sc.Text()))
+		if m == nil {
+			continue
+		}
+		var res Result
+		fields := strings.Fields(m[2])
+		for i := 0; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid value %q", m[1], fields[i])
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				res.NsPerOp = v
+			case "B/op":
+				res.BytesPerOp = v
+			case "allocs/op":
+				res.AllocsPerOp = v
+			}
+		}
+		runs[m[1]] = append(runs[m[1]], res)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	out := make(map[string]Result, len(runs))
+	for name, rs := range runs {
+		out[name] = Result{
+			NsPerOp:     median(rs, func(r Result) float64 { return r.NsPerOp }),
+			BytesPerOp:  median(rs, func(r Result) float64 { return r.BytesPerOp }),
+			AllocsPerOp: median(rs, func(r Result) float64 { return r.AllocsPerOp }),
+		}
+	}
+	return out, nil
+}
+
+// median returns the median of the figure of rs picked by f.
+func median(rs []Result, f func(Result) float64) float64 {
+	vs := make([]float64, len(rs))
+	for i, r := range rs {
+		vs[i] = f(r)
+	}
+	slices.Sort(vs)
+	n := len(vs)
+	if n%2 == 1 {
+		return vs[n/2]
+	}
+	return (vs[n/2-1] + vs[n/2]) / 2
+}
+
+// Change is the comparison of one figure of one benchmark.
+type Change struct {
+	Benchmark string
+	// Metric is "ns/op" or "allocs/op".
+	Metric        string
+	Base, Current float64
+	// Delta is the change in percent; positive is slower or more allocations.
+	Delta float64
+	// Regressed is set when Delta exceeds the threshold.
+	Regressed bool
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s: %s -> %s (%+.1f%%)", c.Benchmark, c.Metric, formatFigure(c.Base), formatFigure(c.Current), c.Delta)
+	if c.Regressed {
+		s += " REGRESSION"
+	}
+	return s
+}
+
+// formatFigure prints v without a fraction when it is whole.
+func formatFigure(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Report is the outcome of Compare.
+type Report struct {
+	// Threshold is the tolerated increase in percent that was applied.
+	Threshold float64
+	// Changes lists ns/op and allocs/op of each benchmark in both the
+	// baseline and the results, by name.
+	Changes []Change
+	// Missing lists baseline benchmarks absent from the results, e.g.
+	// renamed or not run; New lists results absent from the baseline.
+	Missing, New []string
+}
+
+// Regressions returns the changes beyond the threshold.
+func (r Report) Regressions() []Change {
+	var out []Change
+	for _, c := range r.Changes {
+		if c.Regressed {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Compare checks results against base. A figure regresses when it grew by
+// more than threshold percent over the baseline; threshold zero uses the
+// baseline's, or DefaultThreshold. Allocations are compared like time, but
+// going from none to some always regresses: there is no percentage of zero.
+// Faster results never fail, however much faster.
+func Compare(base *Baseline, results map[string]Result, threshold float64) Report {
+	if threshold <= 0 {
+		threshold = base.Threshold
+	}
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	rep := Report{Threshold: threshold}
+	for name, b := range base.Benchmarks {
+		cur, ok := results[name]
+		if !ok {
+			rep.Missing = append(rep.Missing, name)
+			continue
+		}
+		rep.Changes = append(rep.Changes,
+			compareFigure(name, "ns/op", b.NsPerOp, cur.NsPerOp, threshold),
+			compareFigure(name, "allocs/op", b.AllocsPerOp, cur.AllocsPerOp, threshold))
+	}
+	for name := range results {
+		if _, ok := base.Benchmarks[name]; !ok {
+			rep.New = append(rep.New, name)
+		}
+	}
+	sort.SliceStable(rep.Changes, func(i, j int) bool { return rep.Changes[i].Benchmark < rep.Changes[j].Benchmark })
+	sort.Strings(rep.Missing)
+	sort.Strings(rep.New)
+	return rep
+}
+
+// compareFigure compares one figure against its baseline value.
+func compareFigure(name, metric string, base, cur, threshold float64) Change {
+	c := Change{Benchmark: name, Metric: metric, Base: base, Current: cur}
+	switch {
+	case base == 0 && cur == 0:
+	case base == 0:
+		c.Delta = math.Inf(1)
+		c.Regressed = true
+	default:
+		c.Delta = (cur - base) * 100 / base
+		c.Regressed = c.Delta > threshold
+	}
+	return c
+}
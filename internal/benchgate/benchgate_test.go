@@ -0,0 +1,120 @@
+package benchgate
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: github.com/valerioTomassi/todototum/internal/todo
+BenchmarkScanDir/wide-8         	       3	 200 ns/op	28000 B/op	  2100 allocs/op
+BenchmarkScanDir/wide-8         	       3	 100 ns/op	27000 B/op	  2000 allocs/op
+BenchmarkScanDir/wide-8         	       3	 400 ns/op	29000 B/op	  2200 allocs/op
+BenchmarkScanFileWithReader/match-free-8    3	 1675768 ns/op	 149.63 MB/s	 310112 B/op	    5009 allocs/op
+BenchmarkGitIgnoreMatch         	       3	    845.5 ns/op
+BenchmarkOdd-16 	10	 50 ns/op
+BenchmarkOdd-16 	10	 70 ns/op
+--- BENCH: BenchmarkSomething
+PASS
+ok  	github.com/valerioTomassi/todototum/internal/todo	6.711s
+`
+	got, err := Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Result{
+		"BenchmarkScanDir/wide":                  {NsPerOp: 200, BytesPerOp: 28000, AllocsPerOp: 2100},
+		"BenchmarkScanFileWithReader/match-free": {NsPerOp: 1675768, BytesPerOp: 310112, AllocsPerOp: 5009},
+		"BenchmarkGitIgnoreMatch":                {NsPerOp: 845.5},
+		"BenchmarkOdd":                           {NsPerOp: 60},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse = %+v, want %+v", got, want)
+	}
+
+	if _, err := Parse(strings.NewReader("BenchmarkX-8 3 fast ns/op\n")); err == nil {
+		t.Error("a malformed figure should fail")
+	}
+}
+
+func TestCompare_Threshold(t *testing.T) {
+	base := &Baseline{Threshold: 10, Benchmarks: map[string]Result{
+		"BenchmarkA":    {NsPerOp: 1000, AllocsPerOp: 100},
+		"BenchmarkB":    {NsPerOp: 1000, AllocsPerOp: 0},
+		"BenchmarkGone": {NsPerOp: 5},
+	}}
+	tests := []struct {
+		name      string
+		a, b      Result
+		threshold float64
+		// regressed lists the regressed "benchmark metric" pairs.
+		regressed []string
+	}{
+		{"unchanged", Result{NsPerOp: 1000, AllocsPerOp: 100}, Result{NsPerOp: 1000}, 0, nil},
+		{"at the baseline threshold", Result{NsPerOp: 1100, AllocsPerOp: 110}, Result{NsPerOp: 1000}, 0, nil},
+		{"just over it", Result{NsPerOp: 1101, AllocsPerOp: 111}, Result{NsPerOp: 1000}, 0, []string{"BenchmarkA ns/op", "BenchmarkA allocs/op"}},
+		{"caller threshold wins", Result{NsPerOp: 1400, AllocsPerOp: 100}, Result{NsPerOp: 1000}, 50, nil},
+		{"caller threshold exceeded", Result{NsPerOp: 1600, AllocsPerOp: 100}, Result{NsPerOp: 1000}, 50, []string{"BenchmarkA ns/op"}},
+		{"much faster is fine", Result{NsPerOp: 10, AllocsPerOp: 1}, Result{NsPerOp: 1}, 0, nil},
+		{"first allocation", Result{NsPerOp: 1000, AllocsPerOp: 100}, Result{NsPerOp: 1000, AllocsPerOp: 1}, 0, []string{"BenchmarkB allocs/op"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := Compare(base, map[string]Result{"BenchmarkA": tt.a, "BenchmarkB": tt.b, "BenchmarkNew": {NsPerOp: 1}}, tt.threshold)
+			var got []string
+			for _, c := range rep.Regressions() {
+				got = append(got, c.Benchmark+" "+c.Metric)
+			}
+			if !reflect.DeepEqual(got, tt.regressed) {
+				t.Errorf("regressions = %v, want %v", got, tt.regressed)
+			}
+			if !reflect.DeepEqual(rep.Missing, []string{"BenchmarkGone"}) || !reflect.DeepEqual(rep.New, []string{"BenchmarkNew"}) {
+				t.Errorf("missing %v, new %v", rep.Missing, rep.New)
+			}
+		})
+	}
+}
+
+func TestCompare_DeltaAndDefaults(t *testing.T) {
+	base := &Baseline{Benchmarks: map[string]Result{"BenchmarkA": {NsPerOp: 200, AllocsPerOp: 0}}}
+	rep := Compare(base, map[string]Result{"BenchmarkA": {NsPerOp: 150, AllocsPerOp: 3}}, 0)
+	if rep.Threshold != DefaultThreshold {
+		t.Errorf("threshold = %v, want the default", rep.Threshold)
+	}
+	ns, allocs := rep.Changes[0], rep.Changes[1]
+	if ns.Delta != -25 || ns.Regressed || ns.String() != "BenchmarkA ns/op: 200 -> 150 (-25.0%)" {
+		t.Errorf("ns change = %+v (%s)", ns, ns)
+	}
+	if !math.IsInf(allocs.Delta, 1) || !allocs.Regressed || !strings.HasSuffix(allocs.String(), "REGRESSION") {
+		t.Errorf("allocs change = %+v (%s)", allocs, allocs)
+	}
+}
+
+func TestBaseline_RoundTrip(t *testing.T) {
+	b := &Baseline{Threshold: 15, Benchmarks: map[string]Result{"BenchmarkA/x": {NsPerOp: 1.5, BytesPerOp: 2, AllocsPerOp: 3}}}
+	var buf bytes.Buffer
+	if err := WriteBaseline(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadBaseline(p)
+	if err != nil || !reflect.DeepEqual(got, b) {
+		t.Fatalf("LoadBaseline = %+v, %v; want %+v", got, err, b)
+	}
+	if err := os.WriteFile(p, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(p); err == nil || !strings.Contains(err.Error(), "parse baseline") {
+		t.Errorf("err = %v", err)
+	}
+}
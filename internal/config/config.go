@@ -0,0 +1,275 @@
+// Package config resolves todototum settings from command-line flags,
+// environment variables and a .todototum.yml file.
+//
+// Settings are keyed by flag name. Precedence, highest first: an explicitly
+// set flag, the TODOTOTUM_<NAME> environment variable, the config file, and
+// the flag default.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion versions the JSON shape of Resolved.
+const SchemaVersion = 1
+
+// EnvPrefix prefixes the environment variable of every setting.
+const EnvPrefix = "TODOTOTUM_"
+
+// FlagName is the flag selecting the config file. It is resolved before the
+// file is read and is not itself a setting.
+const FlagName = "config"
+
+// FileNames lists the config file names looked up, in order.
+var FileNames = []string{".todototum.yml", ".todototum.yaml"}
+
+// Sources of a resolved setting.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+	// SourceDir marks a setting taken from a nested config file; see
+	// Resolved.Overlay.
+	SourceDir = "dir"
+)
+
+// File is a parsed config file: a flat mapping of flag names to values.
+// Sequences are joined with commas, matching the list flags' syntax.
+type File struct {
+	Path   string
+	Values map[string]string
+	// Lines records the line each key was defined on.
+	Lines map[string]int
+	// Columns records the column of each key.
+	Columns map[string]int
+	// nodes keeps the value node of each key, for Validate to locate
+	// problems in values and list items.
+	nodes map[string]*yaml.Node
+}
+
+// Setting is the effective value of one flag and where it came from.
+type Setting struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Resolved is the effective configuration of a command.
+type Resolved struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	ConfigFile    string             `json:"configFile,omitempty"`
+	Settings      map[string]Setting `json:"settings"`
+	// Layers lists the nested config files applied by Overlay, outermost
+	// first.
+	Layers []Layer `json:"layers,omitempty"`
+}
+
+// Layer is a nested config file and the settings it supplied.
+type Layer struct {
+	Path     string   `json:"path"`
+	Settings []string `json:"settings"`
+}
+
+// FileMaySet reports whether a nested config file may set setting: it was
+// not given as a flag or in the environment.
+func (r *Resolved) FileMaySet(setting string) bool {
+	source := r.Settings[setting].Source
+	return source != SourceFlag && source != SourceEnv
+}
+
+// Overlay returns a copy of r in which the settings of file, a nested
+// config file, replace theirs with SourceDir, and file is appended to
+// Layers. Settings given as flags or in the environment win over nested
+// files as over the root file, and are kept. fs gives each setting's type.
+func (r *Resolved) Overlay(fs *pflag.FlagSet, file *File) *Resolved {
+	out := *r
+	out.Settings = make(map[string]Setting, len(r.Settings))
+	for k, v := range r.Settings {
+		out.Settings[k] = v
+	}
+	layer := Layer{Path: file.Path, Settings: make([]string, 0, len(file.Values))}
+	for k, v := range file.Values {
+		f := fs.Lookup(k)
+		if f == nil || !r.FileMaySet(k) {
+			continue
+		}
+		out.Settings[k] = Setting{Value: typedString(f.Value.Type(), v), Source: SourceDir}
+		layer.Settings = append(layer.Settings, k)
+	}
+	sort.Strings(layer.Settings)
+	out.Layers = append(append([]Layer(nil), r.Layers...), layer)
+	return &out
+}
+
+// File returns the config file that supplied setting: the innermost layer
+// setting it, or ConfigFile for settings from the root file, or "".
+func (r *Resolved) File(setting string) string {
+	switch r.Settings[setting].Source {
+	case SourceDir:
+		for i := len(r.Layers) - 1; i >= 0; i-- {
+			if slices.Contains(r.Layers[i].Settings, setting) {
+				return r.Layers[i].Path
+			}
+		}
+	case SourceFile:
+		return r.ConfigFile
+	}
+	return ""
+}
+
+// Find returns the path of the config file in dir, or "" if there is none.
+func Find(dir string) string {
+	for _, name := range FileNames {
+		p := filepath.Join(dir, name)
+		if st, err := os.Stat(p); err == nil && !st.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// Load parses the config file at path.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, b)
+}
+
+// Parse parses config file content; path is used in error messages.
+func Parse(path string, data []byte) (*File, error) {
+	f := &File{Path: path, Values: map[string]string{}, Lines: map[string]int{}, Columns: map[string]int{}, nodes: map[string]*yaml.Node{}}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return f, nil // empty file
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected a mapping of settings", path, root.Line)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], root.Content[i+1]
+		var v string
+		switch val.Kind {
+		case yaml.ScalarNode:
+			v = val.Value
+		case yaml.SequenceNode:
+			parts := make([]string, 0, len(val.Content))
+			for _, item := range val.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("%s:%d: %s: list items must be plain values", path, item.Line, key.Value)
+				}
+				parts = append(parts, item.Value)
+			}
+			v = strings.Join(parts, ",")
+		default:
+			return nil, fmt.Errorf("%s:%d: %s: expected a value or a list", path, val.Line, key.Value)
+		}
+		f.Values[key.Value] = v
+		f.Lines[key.Value] = key.Line
+		f.Columns[key.Value] = key.Column
+		f.nodes[key.Value] = val
+	}
+	return f, nil
+}
+
+// EnvName returns the environment variable for a flag, e.g. "out-dir" ->
+// "TODOTOTUM_OUT_DIR".
+func EnvName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Apply fills every flag in fs that was not set on the command line from the
+// environment (via lookupEnv) or from file, which may be nil, and returns the
+// effective configuration. Keys in file that name no flag are an error.
+// Values are assigned without marking flags as changed. The help and
+// FlagName flags are not settings and are skipped.
+func Apply(fs *pflag.FlagSet, file *File, lookupEnv func(string) (string, bool)) (*Resolved, error) {
+	res := &Resolved{SchemaVersion: SchemaVersion, Settings: map[string]Setting{}}
+	if file != nil {
+		res.ConfigFile = file.Path
+		keys := make([]string, 0, len(file.Values))
+		for k := range file.Values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if fs.Lookup(k) == nil || k == "help" || k == FlagName {
+				return nil, fmt.Errorf("%s:%d: unknown setting %q", file.Path, file.Lines[k], k)
+			}
+		}
+	}
+
+	var errs []error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" || f.Name == FlagName {
+			return
+		}
+		source := SourceDefault
+		switch {
+		case f.Changed:
+			source = SourceFlag
+		default:
+			if v, ok := lookupEnv(EnvName(f.Name)); ok {
+				if err := f.Value.Set(v); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", EnvName(f.Name), err))
+				}
+				source = SourceEnv
+			} else if v, ok := file.value(f.Name); ok {
+				if err := f.Value.Set(v); err != nil {
+					errs = append(errs, fmt.Errorf("%s:%d: %s: %w", file.Path, file.Lines[f.Name], f.Name, err))
+				}
+				source = SourceFile
+			}
+		}
+		res.Settings[f.Name] = Setting{Value: typedValue(f), Source: source}
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return res, nil
+}
+
+// value returns the file's value for key; a nil file has none.
+func (f *File) value(key string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	v, ok := f.Values[key]
+	return v, ok
+}
+
+// typedValue converts a flag value to a JSON-friendly type.
+func typedValue(f *pflag.Flag) any {
+	return typedString(f.Value.Type(), f.Value.String())
+}
+
+// typedString converts s, a value of the flag type typ, to a JSON-friendly
+// type.
+func typedString(typ, s string) any {
+	switch typ {
+	case "bool":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case "int":
+		if n, err := strconv.Atoi(s); err == nil {
+			return n
+		}
+	}
+	return s
+}
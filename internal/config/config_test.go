@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("scan", pflag.ContinueOnError)
+	fs.String("report", "table", "")
+	fs.String("out-dir", "", "")
+	fs.String("ignore", "", "")
+	fs.Int("keep", 0, "")
+	fs.Bool("audit", false, "")
+	return fs
+}
+
+func TestParse_ScalarsAndLists(t *testing.T) {
+	f, err := Parse("cfg.yml", []byte("report: md\nignore:\n  - vendor\n  - dist\nkeep: 3\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if f.Values["report"] != "md" || f.Values["ignore"] != "vendor,dist" || f.Values["keep"] != "3" {
+		t.Fatalf("unexpected values: %v", f.Values)
+	}
+	if f.Lines["keep"] != 5 {
+		t.Fatalf("keep line = %d, want 5", f.Lines["keep"])
+	}
+	if _, err := Parse("cfg.yml", []byte("- a\n- b\n")); err == nil {
+		t.Fatalf("expected error for a top-level list")
+	}
+	if _, err := Parse("cfg.yml", []byte("report:\n  nested: x\n")); err == nil || !strings.Contains(err.Error(), "cfg.yml:2") {
+		t.Fatalf("expected located error for nested mapping, got %v", err)
+	}
+}
+
+func TestApply_Precedence(t *testing.T) {
+	fs := newFlags()
+	if err := fs.Parse([]string{"--keep", "7"}); err != nil {
+		t.Fatal(err)
+	}
+	file := &File{Path: "cfg.yml", Values: map[string]string{"report": "md", "out-dir": "from-file", "keep": "2"}, Lines: map[string]int{}}
+	env := map[string]string{"TODOTOTUM_OUT_DIR": "from-env"}
+	res, err := Apply(fs, file, func(k string) (string, bool) { v, ok := env[k]; return v, ok })
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	want := map[string]Setting{
+		"report":  {"md", SourceFile},
+		"out-dir": {"from-env", SourceEnv},
+		"keep":    {7, SourceFlag},
+		"audit":   {false, SourceDefault},
+		"ignore":  {"", SourceDefault},
+	}
+	for name, w := range want {
+		if got := res.Settings[name]; got != w {
+			t.Errorf("%s = %#v, want %#v", name, got, w)
+		}
+	}
+	if v, _ := fs.GetString("report"); v != "md" {
+		t.Fatalf("flag not updated from file: %q", v)
+	}
+	if fs.Lookup("report").Changed {
+		t.Fatalf("applying config must not mark flags as changed")
+	}
+	if res.SchemaVersion != SchemaVersion || res.ConfigFile != "cfg.yml" {
+		t.Fatalf("unexpected header: %+v", res)
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	file := &File{Path: "cfg.yml", Values: map[string]string{"colour": "red"}, Lines: map[string]int{"colour": 4}}
+	noEnv := func(string) (string, bool) { return "", false }
+	if _, err := Apply(newFlags(), file, noEnv); err == nil || !strings.Contains(err.Error(), `cfg.yml:4: unknown setting "colour"`) {
+		t.Fatalf("expected unknown setting error, got %v", err)
+	}
+	file = &File{Path: "cfg.yml", Values: map[string]string{"keep": "many"}, Lines: map[string]int{"keep": 1}}
+	if _, err := Apply(newFlags(), file, noEnv); err == nil || !strings.Contains(err.Error(), "cfg.yml:1: keep") {
+		t.Fatalf("expected invalid value error, got %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	if Find(dir) != "" {
+		t.Fatalf("expected no config")
+	}
+	p := filepath.Join(dir, ".todototum.yaml")
+	if err := os.WriteFile(p, []byte("report: json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if Find(dir) != p {
+		t.Fatalf("Find = %q, want %q", Find(dir), p)
+	}
+}
+
+func TestResolved_Overlay(t *testing.T) {
+	fs := newFlags()
+	root := &File{Path: "root.yml", Values: map[string]string{"ignore": "vendor", "keep": "2"}, Lines: map[string]int{}}
+	res, err := Apply(fs, root, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested := res.Overlay(fs, &File{Path: "a/.todototum.yml", Values: map[string]string{"ignore": "dist", "audit": "true"}})
+	deeper := nested.Overlay(fs, &File{Path: "a/b/.todototum.yml", Values: map[string]string{"ignore": "build"}})
+
+	if got := deeper.Settings["audit"]; got != (Setting{true, SourceDir}) {
+		t.Errorf("audit = %#v", got)
+	}
+	for setting, want := range map[string]string{"ignore": "a/b/.todototum.yml", "audit": "a/.todototum.yml", "keep": "root.yml", "report": ""} {
+		if got := deeper.File(setting); got != want {
+			t.Errorf("File(%q) = %q, want %q", setting, got, want)
+		}
+	}
+	if deeper.Settings["ignore"].Value != "build" || len(deeper.Layers) != 2 {
+		t.Errorf("deeper = %+v", deeper)
+	}
+	if res.Settings["ignore"].Value != "vendor" || res.Layers != nil || len(nested.Layers) != 1 {
+		t.Error("Overlay must not modify the configuration it layers over")
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules constrain config file values beyond each flag accepting its value.
+type Rules struct {
+	// Enums lists the allowed values of settings with a fixed set, compared
+	// case-insensitively. Each entry of a list setting is checked.
+	Enums map[string][]string
+	// TagList names the setting listing the tags to scan for, and TagRefs
+	// the list settings whose entries must be among them when the file sets
+	// TagList, e.g. error-tags.
+	TagList string
+	TagRefs []string
+}
+
+// Problem is one invalid entry of a config file, located at its key, value
+// or list item.
+type Problem struct {
+	Path   string
+	Line   int
+	Column int
+	Key    string
+	Msg    string
+}
+
+func (p *Problem) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.Path, p.Line, p.Column, p.Key, p.Msg)
+}
+
+// Validate checks every key of file against the flags of fs and rules:
+// keys must name a setting, values must parse as their flag's type, enum
+// settings must hold one of their values and tag references must name a
+// listed tag. It returns all problems found, in file order, joined with
+// errors.Join, or nil. A nil file is valid.
+func Validate(fs *pflag.FlagSet, file *File, rules Rules) error {
+	if file == nil {
+		return nil
+	}
+	var problems []*Problem
+	report := func(key string, at located, format string, args ...any) {
+		problems = append(problems, &Problem{Path: file.Path, Line: at.line, Column: at.column, Key: key, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	var tags map[string]bool
+	if _, ok := file.Values[rules.TagList]; ok && rules.TagList != "" {
+		tags = make(map[string]bool)
+		for _, t := range file.entries(rules.TagList) {
+			tags[strings.ToUpper(t.value)] = true
+		}
+	}
+
+	for key := range file.Values {
+		f := fs.Lookup(key)
+		if f == nil || key == "help" || key == FlagName {
+			msg := fmt.Sprintf("unknown setting %q", key)
+			if near := closestFlag(fs, key); near != "" {
+				msg += fmt.Sprintf("; did you mean %q?", near)
+			}
+			report(key, file.keyPos(key), "%s", msg)
+			continue
+		}
+		if err := checkType(f.Value.Type(), file.Values[key]); err != nil {
+			report(key, file.valuePos(key), "%v", err)
+			continue
+		}
+		if allowed, ok := rules.Enums[key]; ok {
+			for _, e := range file.entries(key) {
+				if !slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, e.value) }) {
+					report(key, e.located, "invalid value %q; must be one of: %s", e.value, strings.Join(allowed, ", "))
+				}
+			}
+		}
+		if tags != nil && slices.Contains(rules.TagRefs, key) {
+			for _, e := range file.entries(key) {
+				if !tags[strings.ToUpper(e.value)] {
+					report(key, e.located, "tag %q is not in %s", e.value, rules.TagList)
+				}
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(problems, func(a, b *Problem) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	errs := make([]error, len(problems))
+	for i, p := range problems {
+		errs[i] = p
+	}
+	return errors.Join(errs...)
+}
+
+// checkType reports whether v parses as a value of the scalar flag type
+// typ; other types accept any string.
+func checkType(typ, v string) error {
+	var err error
+	switch typ {
+	case "bool":
+		_, err = strconv.ParseBool(v)
+	case "int":
+		_, err = strconv.Atoi(v)
+	case "float64":
+		_, err = strconv.ParseFloat(v, 64)
+	case "duration":
+		_, err = time.ParseDuration(v)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q", typ, v)
+	}
+	return nil
+}
+
+// located is a position in the config file.
+type located struct {
+	line, column int
+}
+
+// entry is one comma-separated entry of a setting, with its position.
+type entry struct {
+	value string
+	located
+}
+
+// keyPos returns the position of key.
+func (f *File) keyPos(key string) located {
+	return located{f.Lines[key], f.Columns[key]}
+}
+
+// valuePos returns the position of key's value, or of the key when the
+// file was not parsed.
+func (f *File) valuePos(key string) located {
+	if n := f.nodes[key]; n != nil {
+		return located{n.Line, n.Column}
+	}
+	return f.keyPos(key)
+}
+
+// entries splits key's value into its non-empty entries: the items of a
+// YAML list, each at its own position, or the comma-separated parts of a
+// scalar, at the scalar's.
+func (f *File) entries(key string) []entry {
+	var out []entry
+	add := func(v string, at located) {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, entry{part, at})
+			}
+		}
+	}
+	if n := f.nodes[key]; n != nil && n.Kind == yaml.SequenceNode {
+		for _, item := range n.Content {
+			add(item.Value, located{item.Line, item.Column})
+		}
+		return out
+	}
+	add(f.Values[key], f.valuePos(key))
+	return out
+}
+
+// closestFlag returns the setting of fs nearest to name when it is at most
+// two edits away, to suggest for a misspelled key.
+func closestFlag(fs *pflag.FlagSet, name string) string {
+	best, bestDist := "", 3
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" || f.Name == FlagName {
+			return
+		}
+		if d := editDistance(name, f.Name); d < bestDist {
+			best, bestDist = f.Name, d
+		}
+	})
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
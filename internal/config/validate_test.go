@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+var testRules = Rules{
+	Enums:   map[string][]string{"report": {"table", "json", "md"}, "detect": {"todo", "debug"}},
+	TagList: "tags",
+	TagRefs: []string{"error-tags"},
+}
+
+func validateFlags() *pflag.FlagSet {
+	fs := newFlags()
+	fs.String("tags", "", "")
+	fs.String("error-tags", "", "")
+	fs.String("detect", "", "")
+	fs.String("severity", "", "")
+	return fs
+}
+
+func validate(t *testing.T, content string) []string {
+	t.Helper()
+	f, err := Parse("cfg.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	err = Validate(validateFlags(), f, testRules)
+	if err == nil {
+		return nil
+	}
+	var lines []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var p *Problem
+		if !errors.As(e, &p) {
+			t.Fatalf("%v is not a *Problem", e)
+		}
+		lines = append(lines, e.Error())
+	}
+	return lines
+}
+
+func TestValidate_CleanFile(t *testing.T) {
+	if got := validate(t, "report: JSON\ntags: [TODO, HACK]\nerror-tags:\n  - hack\nkeep: 3\naudit: true\n"); got != nil {
+		t.Fatalf("clean file reported %v", got)
+	}
+	if err := Validate(validateFlags(), nil, testRules); err != nil {
+		t.Fatalf("nil file: %v", err)
+	}
+}
+
+func TestValidate_UnknownKey(t *testing.T) {
+	got := validate(t, "report: md\n  # indented comment\nserverity: high\n")
+	want := []string{`cfg.yml:3:1: serverity: unknown setting "serverity"; did you mean "severity"?`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	got = validate(t, "help: true\nzzz: 1\n")
+	if len(got) != 2 || !strings.HasSuffix(got[1], `unknown setting "zzz"`) {
+		t.Fatalf("got %q, want help and zzz rejected without a suggestion", got)
+	}
+}
+
+func TestValidate_BadEnumAndType(t *testing.T) {
+	got := validate(t, "report: pdf\ndetect:\n  - todo\n  - lint\nkeep: many\n")
+	want := []string{
+		`cfg.yml:1:9: report: invalid value "pdf"; must be one of: table, json, md`,
+		`cfg.yml:4:5: detect: invalid value "lint"; must be one of: todo, debug`,
+		`cfg.yml:5:7: keep: invalid int value "many"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidate_DanglingTagReference(t *testing.T) {
+	got := validate(t, "tags: TODO,FIXME\nerror-tags: [fixme, BUG]\n")
+	want := []string{`cfg.yml:2:21: error-tags: tag "BUG" is not in tags`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	// Without a tags list in the file, references are not checked.
+	if got := validate(t, "error-tags: BUG\n"); got != nil {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -0,0 +1,367 @@
+// Package rewrite is the safety net of the commands that edit source files
+// in place, such as assign. A Session refuses to start on a dirty git
+// working tree, rewrites a file only while it still has the content that
+// was scanned, mirrors each original into a backup directory before
+// replacing it, and records every changed line in a Manifest, from which
+// Revert restores the originals.
+package rewrite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// ManifestName is the file name of the manifest written into a backup.
+const ManifestName = "manifest.json"
+
+// ManifestVersion is the version of the manifest format.
+const ManifestVersion = 1
+
+// ErrChanged is returned by Session.Rewrite when a file no longer has the
+// content it had when it was scanned.
+var ErrChanged = errors.New("changed since the scan")
+
+// Checksum returns the hex SHA-256 of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChecksumReader is a todo.FileReader opening files below a root directory
+// and recording the checksum of each, so a scan of the root through it
+// captures the content every item was found in. Files are hashed as they
+// are read; closing one hashes what the scan left unread. It is safe for
+// concurrent use.
+type ChecksumReader struct {
+	root string
+
+	mu   sync.Mutex
+	sums map[string]string
+}
+
+// NewChecksumReader returns a ChecksumReader for the files below root.
+func NewChecksumReader(root string) *ChecksumReader {
+	return &ChecksumReader{root: root, sums: make(map[string]string)}
+}
+
+// Open opens name, a path relative to the root.
+func (c *ChecksumReader) Open(name string) (io.ReadCloser, error) {
+	rc, err := os.Open(filepath.Join(c.root, name))
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	return &hashingFile{ReadCloser: rc, tee: io.TeeReader(rc, h), done: func() {
+		c.mu.Lock()
+		c.sums[filepath.Clean(name)] = hex.EncodeToString(h.Sum(nil))
+		c.mu.Unlock()
+	}}, nil
+}
+
+// Sum returns the checksum recorded for file, a slash-separated path
+// relative to the root.
+func (c *ChecksumReader) Sum(file string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sum, ok := c.sums[filepath.Clean(filepath.FromSlash(file))]
+	return sum, ok
+}
+
+// hashingFile hashes a file as it is read and records the sum on Close.
+type hashingFile struct {
+	io.ReadCloser
+	tee  io.Reader
+	done func()
+}
+
+func (f *hashingFile) Read(p []byte) (int, error) {
+	return f.tee.Read(p)
+}
+
+func (f *hashingFile) Close() error {
+	if _, err := io.Copy(io.Discard, f.tee); err == nil {
+		f.done()
+	}
+	return f.ReadCloser.Close()
+}
+
+// CheckClean returns an error listing the uncommitted changes below dir when
+// it is inside a git working tree, so a rewrite never mixes its edits with
+// work in progress. Outside a working tree, or without git, it returns nil.
+// Backups are not changes.
+func CheckClean(ctx context.Context, git todo.GitRunner, dir string) error {
+	if _, err := git.Run(ctx, dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil
+	}
+	out, err := git.Run(ctx, dir, "status", "--porcelain", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return fmt.Errorf("git status: %w", err)
+	}
+	var dirty []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 || strings.Contains("/"+line[3:], "/"+todo.BackupDirName+"/") {
+			continue
+		}
+		dirty = append(dirty, line[3:])
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+	const shown = 5
+	list := strings.Join(dirty[:min(len(dirty), shown)], ", ")
+	if len(dirty) > shown {
+		list += fmt.Sprintf(" and %d more", len(dirty)-shown)
+	}
+	return fmt.Errorf("the working tree has uncommitted changes (%s); commit or stash them first", list)
+}
+
+// Manifest records the changes of a rewrite, to audit or revert them.
+type Manifest struct {
+	Version int `json:"version"`
+	// Command names the command that made the changes, e.g. "assign".
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+	// Root is the absolute directory the file paths are relative to.
+	Root string `json:"root"`
+	// Backup is the absolute directory mirroring the originals below Root;
+	// empty when backups were disabled, which leaves nothing to revert to.
+	Backup string       `json:"backup,omitempty"`
+	Files  []FileChange `json:"files"`
+}
+
+// FileChange records the rewrite of one file.
+type FileChange struct {
+	// File is the slash-separated path relative to Manifest.Root.
+	File string `json:"file"`
+	// Before and After are the checksums of the file around the rewrite.
+	Before  string       `json:"before"`
+	After   string       `json:"after"`
+	Changes []LineChange `json:"changes"`
+}
+
+// LineChange is one rewritten line.
+type LineChange struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// LoadManifest reads a Manifest from the JSON file at path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", path, err)
+	}
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("%s: unsupported manifest version %d", path, m.Version)
+	}
+	return &m, nil
+}
+
+// Session carries the rewrites of one command run below a root directory.
+type Session struct {
+	root   string
+	backup bool
+	// dir is the timestamped directory below root/.todototum-backup of
+	// this run, holding the backup, if any, and the default manifest.
+	dir string
+	m   Manifest
+}
+
+// NewSession starts the rewrites of command below root, stamped with now.
+// The run owns a directory root/.todototum-backup/<timestamp>/; a run
+// within the same second as an earlier one gets a numbered directory
+// instead of sharing it. With backup, originals are mirrored there before
+// being replaced.
+func NewSession(root, command string, now time.Time, backup bool) (*Session, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{root: abs, backup: backup, m: Manifest{Version: ManifestVersion, Command: command, Created: now.UTC(), Root: abs}}
+	base := filepath.Join(abs, todo.BackupDirName, now.UTC().Format("20060102T150405Z"))
+	s.dir = base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(s.dir); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		s.dir = fmt.Sprintf("%s-%d", base, i)
+	}
+	if backup {
+		s.m.Backup = s.dir
+	}
+	return s, nil
+}
+
+// Rewrite applies edit to the lines of file, a slash-separated path
+// relative to the root, as todo.RewriteLines does. The file must still have
+// the checksum sum, taken when it was scanned; otherwise it is left alone
+// and ErrChanged is returned. The original is backed up before the file is
+// replaced, and the changes go to the manifest.
+func (s *Session) Rewrite(file, sum string, edit func(n int, line string) (string, bool)) (map[int][2]string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(file))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if Checksum(data) != sum {
+		return nil, ErrChanged
+	}
+	out, changes := todo.EditLines(data, edit)
+	if len(changes) == 0 {
+		return changes, nil
+	}
+	if s.backup {
+		if err := s.backupFile(file, path, data); err != nil {
+			return nil, fmt.Errorf("back up %s: %w", file, err)
+		}
+	}
+	if err := todo.ReplaceFile(path, out); err != nil {
+		return nil, err
+	}
+	fc := FileChange{File: file, Before: sum, After: Checksum(out)}
+	lines := make([]int, 0, len(changes))
+	for n := range changes {
+		lines = append(lines, n)
+	}
+	sort.Ints(lines)
+	for _, n := range lines {
+		fc.Changes = append(fc.Changes, LineChange{Line: n, Before: changes[n][0], After: changes[n][1]})
+	}
+	s.m.Files = append(s.m.Files, fc)
+	return changes, nil
+}
+
+// backupFile writes data, the original of file at path, into the backup
+// mirror with the permissions of path.
+func (s *Session) backupFile(file, path string, data []byte) error {
+	dest := filepath.Join(s.m.Backup, filepath.FromSlash(file))
+	if err := s.mkdir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	return os.WriteFile(dest, data, mode)
+}
+
+// mkdir creates dir, below the directory of the run. The first call also
+// ignores the backup directory in git, so it does not count as a change.
+func (s *Session) mkdir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	ignore := filepath.Join(s.root, todo.BackupDirName, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		return os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	return nil
+}
+
+// Manifest returns the changes made so far.
+func (s *Session) Manifest() *Manifest {
+	return &s.m
+}
+
+// WriteManifest writes the manifest as indented JSON to path, or, when path
+// is empty, as ManifestName into the directory of the run, whether or not
+// it holds a backup. It returns the path written.
+func (s *Session) WriteManifest(path string) (string, error) {
+	data, err := json.MarshalIndent(s.m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = filepath.Join(s.dir, ManifestName)
+		err = s.mkdir(s.dir)
+	} else {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// RevertResult is the outcome of Revert.
+type RevertResult struct {
+	// Restored lists the files put back to their original content.
+	Restored []string
+	// Skipped maps files left alone to the reason, e.g. edits made after
+	// the rewrite, which restoring would lose.
+	Skipped map[string]string
+}
+
+// Revert restores the files of m from its backup. A file is restored only
+// while it still has the content the rewrite left, and only from a backup
+// with the checksum of the original.
+func Revert(m *Manifest) (RevertResult, error) {
+	res := RevertResult{Skipped: map[string]string{}}
+	if m.Backup == "" {
+		return res, errors.New("the manifest has no backup to restore from; the rewrite ran without one")
+	}
+	for _, fc := range m.Files {
+		path, ok := below(m.Root, fc.File)
+		backup, okBackup := below(m.Backup, fc.File)
+		if !ok || !okBackup {
+			res.Skipped[fc.File] = "path is outside the manifest root"
+			continue
+		}
+		cur, err := os.ReadFile(path)
+		if err != nil {
+			res.Skipped[fc.File] = err.Error()
+			continue
+		}
+		if Checksum(cur) != fc.After {
+			res.Skipped[fc.File] = "changed since the rewrite"
+			continue
+		}
+		orig, err := os.ReadFile(backup)
+		if err != nil {
+			res.Skipped[fc.File] = "backup: " + err.Error()
+			continue
+		}
+		if Checksum(orig) != fc.Before {
+			res.Skipped[fc.File] = "backup does not match the original checksum"
+			continue
+		}
+		if err := todo.ReplaceFile(path, orig); err != nil {
+			return res, err
+		}
+		res.Restored = append(res.Restored, fc.File)
+	}
+	return res, nil
+}
+
+// below joins the slash-separated relative file to dir. It reports false
+// when file is absolute or climbs out of dir, as a hand-edited manifest
+// could make it.
+func below(dir, file string) (string, bool) {
+	if file == "" || filepath.IsAbs(filepath.FromSlash(file)) || filepath.VolumeName(filepath.FromSlash(file)) != "" {
+		return "", false
+	}
+	path := filepath.Join(dir, filepath.FromSlash(file))
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
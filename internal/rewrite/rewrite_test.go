@@ -0,0 +1,255 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGit answers git commands from a map keyed by the first argument; a
+// missing key fails like git outside a repository.
+type fakeGit map[string]string
+
+func (g fakeGit) Run(_ context.Context, _ string, args ...string) (string, error) {
+	out, ok := g[args[0]]
+	if !ok {
+		return "", errors.New("fatal: not a git repository")
+	}
+	return out, nil
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// scanSum reads file through a ChecksumReader, as a scan does, and returns
+// its recorded checksum.
+func scanSum(t *testing.T, dir, file string) string {
+	t.Helper()
+	r := NewChecksumReader(dir)
+	f, err := r.Open(filepath.FromSlash(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Read only part of the file: Close hashes the rest.
+	if _, err := io.ReadFull(f, make([]byte, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum, ok := r.Sum(file)
+	if !ok {
+		t.Fatalf("no checksum recorded for %s", file)
+	}
+	return sum
+}
+
+func upper(_ int, line string) (string, bool) {
+	return strings.ToUpper(line), true
+}
+
+func TestCheckClean(t *testing.T) {
+	ctx := context.Background()
+	if err := CheckClean(ctx, fakeGit{}, "."); err != nil {
+		t.Errorf("outside a repository: %v", err)
+	}
+	clean := fakeGit{"rev-parse": "true\n", "status": "?? .todototum-backup/.gitignore\n?? sub/.todototum-backup/x/a.go\n"}
+	if err := CheckClean(ctx, clean, "."); err != nil {
+		t.Errorf("backups only: %v", err)
+	}
+	dirty := fakeGit{"rev-parse": "true\n", "status": " M a.go\n?? b.go\nA  c.go\nD  d.go\nR  e.go -> f.go\n?? g.go\n"}
+	err := CheckClean(ctx, dirty, ".")
+	if err == nil || !strings.Contains(err.Error(), "(a.go, b.go, c.go, d.go, e.go -> f.go and 1 more)") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSession_SkipsFilesChangedSinceTheScan(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.txt": "one\ntwo\n", "b.txt": "three\n"})
+	sumA, sumB := scanSum(t, dir, "a.txt"), scanSum(t, dir, "b.txt")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nedited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSession(dir, "test", time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rewrite("a.txt", sumA, upper); !errors.Is(err, ErrChanged) {
+		t.Fatalf("err = %v, want ErrChanged", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(b) != "one\ntwo\nedited\n" {
+		t.Fatalf("changed file was rewritten: %q", b)
+	}
+	if _, err := s.Rewrite("b.txt", sumB, upper); err != nil {
+		t.Fatal(err)
+	}
+	m := s.Manifest()
+	if len(m.Files) != 1 || m.Files[0].File != "b.txt" || m.Files[0].Changes[0] != (LineChange{Line: 1, Before: "three", After: "THREE"}) {
+		t.Fatalf("manifest files = %+v", m.Files)
+	}
+}
+
+func TestSession_RevertRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"a.txt":     "one\r\ntwo\r\n",
+		"sub/b.txt": "three\nno final newline",
+	}
+	dir := writeFiles(t, files)
+	if err := os.Chmod(filepath.Join(dir, "a.txt"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sums := map[string]string{}
+	for name := range files {
+		sums[name] = scanSum(t, dir, name)
+	}
+
+	now := time.Date(2024, 6, 15, 3, 12, 0, 0, time.UTC)
+	s, err := NewSession(dir, "test", now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if _, err := s.Rewrite(name, sums[name], upper); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest, err := s.WriteManifest("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".todototum-backup", "20240615T031200Z", ManifestName); manifest != want {
+		t.Fatalf("manifest at %s, want %s", manifest, want)
+	}
+	if again, _ := NewSession(dir, "test", now, true); !strings.HasSuffix(again.Manifest().Backup, "20240615T031200Z-2") {
+		t.Errorf("a second run in the same second shares the backup %s", again.Manifest().Backup)
+	}
+
+	m, err := LoadManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Revert(m)
+	if err != nil || len(res.Restored) != 2 || len(res.Skipped) != 0 {
+		t.Fatalf("Revert = %+v, %v", res, err)
+	}
+	for name, want := range files {
+		if b, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); string(b) != want {
+			t.Errorf("%s = %q, want %q", name, b, want)
+		}
+	}
+	if st, _ := os.Stat(filepath.Join(dir, "a.txt")); st.Mode().Perm() != 0o755 {
+		t.Errorf("mode = %v, want 0755 kept", st.Mode().Perm())
+	}
+
+	// A file edited after the rewrite is not clobbered by a second revert.
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = Revert(m)
+	if err != nil || res.Skipped["a.txt"] != "changed since the rewrite" {
+		t.Fatalf("Revert = %+v, %v", res, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(b) != "mine\n" {
+		t.Errorf("edited file was overwritten: %q", b)
+	}
+}
+
+func TestRevert_WithoutBackup(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.txt": "one\n"})
+	s, err := NewSession(dir, "test", time.Now(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rewrite("a.txt", scanSum(t, dir, "a.txt"), upper); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".todototum-backup")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup dir created without backups: %v", err)
+	}
+	p, err := s.WriteManifest("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".todototum-backup"); filepath.Dir(filepath.Dir(p)) != want || filepath.Base(p) != ManifestName {
+		t.Errorf("manifest written to %s, want <timestamp>/%s below %s", p, ManifestName, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(p))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("run directory holds %v, %v; want the manifest alone", entries, err)
+	}
+	m, err := LoadManifest(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 1 || m.Backup != "" {
+		t.Errorf("manifest = %+v, want one file and no backup", m)
+	}
+	if _, err := Revert(m); err == nil {
+		t.Error("reverting without a backup should fail")
+	}
+}
+
+func TestRevert_RejectsPathsOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "repo")
+	backup := filepath.Join(root, ".todototum-backup", "run")
+	victim := filepath.Join(parent, "victim.txt")
+	for p, content := range map[string]string{victim: "keep\n", filepath.Join(backup, "x.txt"): "evil\n"} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Checksums match, so only the path check stands between the manifest
+	// and the victim.
+	hostile := []string{"../victim.txt", "sub/../../victim.txt", victim, filepath.ToSlash(victim)}
+	m := &Manifest{Version: ManifestVersion, Root: root, Backup: backup}
+	for _, f := range hostile {
+		m.Files = append(m.Files, FileChange{File: f, Before: Checksum([]byte("evil\n")), After: Checksum([]byte("keep\n"))})
+	}
+	res, err := Revert(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Restored) != 0 {
+		t.Errorf("restored %v outside the root", res.Restored)
+	}
+	for _, f := range hostile {
+		if res.Skipped[f] != "path is outside the manifest root" {
+			t.Errorf("Skipped[%q] = %q", f, res.Skipped[f])
+		}
+	}
+	if b, _ := os.ReadFile(victim); string(b) != "keep\n" {
+		t.Errorf("file outside the root was overwritten: %q", b)
+	}
+}
+
+func TestLoadManifest_Version(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "m.json")
+	if err := os.WriteFile(p, []byte(`{"version": 9, "files": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadManifest(p); err == nil || !strings.Contains(err.Error(), "unsupported manifest version 9") {
+		t.Errorf("err = %v", err)
+	}
+}
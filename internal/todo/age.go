@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ageDay   = 24 * time.Hour
+	ageMonth = 30 * ageDay
+	ageYear  = 365 * ageDay
+)
+
+// Age levels returned by AgeThresholds.Level.
+const (
+	AgeFresh = "fresh"
+	AgeAging = "aging"
+	AgeOld   = "old"
+)
+
+// AgeThresholds splits item ages into levels: younger than Aging is fresh,
+// younger than Old is aging, anything else is old.
+type AgeThresholds struct {
+	Aging time.Duration
+	Old   time.Duration
+}
+
+// DefaultAgeThresholds treats items under 90 days as fresh and items under a
+// year as aging.
+var DefaultAgeThresholds = AgeThresholds{Aging: 90 * ageDay, Old: ageYear}
+
+// Level returns the age level of d.
+func (th AgeThresholds) Level(d time.Duration) string {
+	switch {
+	case d < th.Aging:
+		return AgeFresh
+	case d < th.Old:
+		return AgeAging
+	}
+	return AgeOld
+}
+
+// ItemAge returns how long before now t was introduced. ok is false when the
+// introduction date is unknown, i.e. history was not traced for t.
+func ItemAge(t Todo, now time.Time) (age time.Duration, ok bool) {
+	if t.IntroducedAt == nil || t.IntroducedAt.IsZero() {
+		return 0, false
+	}
+	return max(now.Sub(*t.IntroducedAt), 0), true
+}
+
+// HumanizeAge formats d in its largest whole unit: "3y", "8mo", "12d", or
+// "<1d" for anything shorter than a day.
+func HumanizeAge(d time.Duration) string {
+	switch {
+	case d >= ageYear:
+		return fmt.Sprintf("%dy", d/ageYear)
+	case d >= ageMonth:
+		return fmt.Sprintf("%dmo", d/ageMonth)
+	case d >= ageDay:
+		return fmt.Sprintf("%dd", d/ageDay)
+	}
+	return "<1d"
+}
+
+// FormatItemAge returns the humanized age of t, or "-" when it is unknown.
+func FormatItemAge(t Todo, now time.Time) string {
+	if age, ok := ItemAge(t, now); ok {
+		return HumanizeAge(age)
+	}
+	return "-"
+}
@@ -0,0 +1,75 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeAge(t *testing.T) {
+	cases := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "<1d"},
+		{5 * time.Hour, "<1d"},
+		{ageDay - time.Nanosecond, "<1d"},
+		{ageDay, "1d"},
+		{12 * ageDay, "12d"},
+		{29 * ageDay, "29d"},
+		{ageMonth, "1mo"},
+		{8*ageMonth + 3*ageDay, "8mo"},
+		{ageYear - time.Nanosecond, "12mo"},
+		{ageYear, "1y"},
+		{3*ageYear + 200*ageDay, "3y"},
+	}
+	for _, c := range cases {
+		if got := HumanizeAge(c.in); got != c.want {
+			t.Errorf("HumanizeAge(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestAgeThresholds_Level(t *testing.T) {
+	th := DefaultAgeThresholds
+	cases := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, AgeFresh},
+		{time.Hour, AgeFresh},
+		{90*ageDay - time.Nanosecond, AgeFresh},
+		{90 * ageDay, AgeAging},
+		{ageYear - time.Nanosecond, AgeAging},
+		{ageYear, AgeOld},
+		{10 * ageYear, AgeOld},
+	}
+	for _, c := range cases {
+		if got := th.Level(c.in); got != c.want {
+			t.Errorf("Level(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	custom := AgeThresholds{Aging: 7 * ageDay, Old: 30 * ageDay}
+	if got := custom.Level(7 * ageDay); got != AgeAging {
+		t.Errorf("custom Level(7d) = %q, want %q", got, AgeAging)
+	}
+}
+
+func TestItemAge_AndFormat(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	introduced := now.Add(-40 * ageDay)
+	future := now.Add(time.Hour)
+	var zero time.Time
+
+	if got := FormatItemAge(Todo{IntroducedAt: &introduced}, now); got != "1mo" {
+		t.Errorf("traced item age = %q, want 1mo", got)
+	}
+	if got := FormatItemAge(Todo{}, now); got != "-" {
+		t.Errorf("untraced item age = %q, want -", got)
+	}
+	if _, ok := ItemAge(Todo{IntroducedAt: &zero}, now); ok {
+		t.Errorf("zero introduction time should count as unknown")
+	}
+	if age, ok := ItemAge(Todo{IntroducedAt: &future}, now); !ok || age != 0 {
+		t.Errorf("future introduction should clamp to 0, got %v %v", age, ok)
+	}
+}
@@ -0,0 +1,115 @@
+package todo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Allowlist restricts a scan to paths matching any of its patterns. Patterns
+// are slash-separated and relative to the scan root; each segment is a
+// path.Match glob and "**" matches any number of segments. A pattern that
+// matches a directory allows everything beneath it, so "src", "src/" and
+// "src/**" are equivalent.
+type Allowlist struct {
+	patterns [][]string
+}
+
+// ParseAllowlist compiles patterns into an Allowlist. Empty patterns are
+// ignored; an empty result allows everything.
+func ParseAllowlist(patterns []string) (Allowlist, error) {
+	var a Allowlist
+	for _, p := range patterns {
+		p = strings.Trim(strings.TrimPrefix(normalizePath(strings.TrimSpace(p)), "./"), "/")
+		if p == "" || p == "." {
+			continue
+		}
+		segs := strings.Split(p, "/")
+		for _, s := range segs {
+			if _, err := path.Match(s, ""); err != nil {
+				return Allowlist{}, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+		a.patterns = append(a.patterns, segs)
+	}
+	return a, nil
+}
+
+// Empty reports whether a has no patterns and so allows everything.
+func (a Allowlist) Empty() bool {
+	return len(a.patterns) == 0
+}
+
+// Allows reports whether the file or directory at rel, a slash-separated
+// path relative to the scan root, matches a pattern itself or through one
+// of its parent directories.
+func (a Allowlist) Allows(rel string) bool {
+	if a.Empty() {
+		return true
+	}
+	segs := splitRel(rel)
+	for _, p := range a.patterns {
+		for n := 1; n <= len(segs); n++ {
+			if matchSegments(p, segs[:n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanContain reports whether the directory at rel may hold an allowed path,
+// i.e. whether a walk has to descend into it. It only compares rel with the
+// leading segments of each pattern, so "src/**" and "pkg/api/" let the walk
+// into "src", "pkg" and "pkg/api" but not "docs" or "pkg/cli".
+func (a Allowlist) CanContain(rel string) bool {
+	if a.Empty() || a.Allows(rel) {
+		return true
+	}
+	segs := splitRel(rel)
+	for _, p := range a.patterns {
+		if prefixOfMatch(p, segs) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRel splits a relative path into segments; the root has none.
+func splitRel(rel string) []string {
+	rel = strings.Trim(normalizePath(rel), "/")
+	if rel == "" || rel == "." {
+		return nil
+	}
+	return strings.Split(rel, "/")
+}
+
+// matchSegments reports whether segs matches the whole of pattern.
+func matchSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return len(segs) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(segs) > 0 && matchPattern(pattern[0], segs[0]) && matchSegments(pattern[1:], segs[1:])
+}
+
+// prefixOfMatch reports whether some path matching pattern starts with the
+// directories in segs.
+func prefixOfMatch(pattern, segs []string) bool {
+	switch {
+	case len(segs) == 0:
+		return true
+	case len(pattern) == 0:
+		return false
+	case pattern[0] == "**":
+		return true
+	}
+	return matchPattern(pattern[0], segs[0]) && prefixOfMatch(pattern[1:], segs[1:])
+}
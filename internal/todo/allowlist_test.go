@@ -0,0 +1,135 @@
+package todo
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestAllowlist_CanContain(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		dir      string
+		want     bool
+	}{
+		// Prefixes of a pattern are walked, siblings are pruned.
+		{[]string{"src/**", "pkg/api/"}, "src", true},
+		{[]string{"src/**", "pkg/api/"}, "pkg", true},
+		{[]string{"src/**", "pkg/api/"}, "docs", false},
+		{[]string{"src/**", "pkg/api/"}, "pkg/cli", false},
+		// Below a matched directory everything may be allowed.
+		{[]string{"src/**", "pkg/api/"}, "src/deep/er", true},
+		{[]string{"src/**", "pkg/api/"}, "pkg/api/v1", true},
+		{[]string{"src"}, "src/x", true},
+		// Patterns anchored deeper than the directory.
+		{[]string{"services/*/internal/**"}, "services", true},
+		{[]string{"services/*/internal/**"}, "services/billing", true},
+		{[]string{"services/*/internal/**"}, "services/billing/cmd", false},
+		{[]string{"a/b/c/d.go"}, "a/b", true},
+		{[]string{"a/b/c/d.go"}, "a/x", false},
+		// Globs within a segment.
+		{[]string{"cmd-*/main.go"}, "cmd-server", true},
+		{[]string{"cmd-*/main.go"}, "tools", false},
+		// A leading ** can match at any depth.
+		{[]string{"**/testdata/"}, "anything/at/all", true},
+		{[]string{"src/**/gen/*.go"}, "src/a/b", true},
+		{[]string{"src/**/gen/*.go"}, "lib", false},
+		// A file pattern at the root does not let the walk into directories.
+		{[]string{"*.go"}, "docs", false},
+		// No patterns allow everything.
+		{nil, "docs", true},
+	}
+	for _, tc := range cases {
+		a, err := ParseAllowlist(tc.patterns)
+		if err != nil {
+			t.Fatalf("ParseAllowlist(%q): %v", tc.patterns, err)
+		}
+		if got := a.CanContain(tc.dir); got != tc.want {
+			t.Errorf("%q.CanContain(%q) = %v, want %v", tc.patterns, tc.dir, got, tc.want)
+		}
+	}
+}
+
+func TestAllowlist_Allows(t *testing.T) {
+	a, err := ParseAllowlist([]string{"./src/", "pkg/api/**", "*.go", "cmd/**/main.go"})
+	if err != nil {
+		t.Fatalf("ParseAllowlist: %v", err)
+	}
+	cases := map[string]bool{
+		"src/a.go":              true,
+		"src/deep/b.txt":        true,
+		"pkg/api/h.go":          true,
+		"pkg/cli/h.go":          false,
+		"main.go":               true,
+		"docs/x.go":             false,
+		"cmd/main.go":           true,
+		"cmd/tool/sub/main.go":  true,
+		"cmd/tool/sub/other.go": false,
+		"README.md":             false,
+	}
+	for rel, want := range cases {
+		if got := a.Allows(rel); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestParseAllowlist_Invalid(t *testing.T) {
+	if _, err := ParseAllowlist([]string{"src/[a"}); err == nil {
+		t.Fatalf("expected an error for a malformed glob")
+	}
+	a, err := ParseAllowlist([]string{" ", "./", ""})
+	if err != nil || !a.Empty() {
+		t.Fatalf("blank patterns should give an empty allowlist, got %+v, %v", a, err)
+	}
+}
+
+func TestScanDirDetailed_OnlyPrunesAndKeepsGitIgnore(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "src/gen/\n")
+	mustWriteFile(t, root, "src/a.go", "// TODO: in src\n")
+	mustWriteFile(t, root, "src/gen/b.go", "// TODO: ignored by git\n")
+	mustWriteFile(t, root, "pkg/api/c.go", "// TODO: in api\n")
+	mustWriteFile(t, root, "pkg/cli/d.go", "// TODO: not allowed\n")
+	mustWriteFile(t, root, "docs/e.md", "TODO: docs\n")
+	mustWriteFile(t, root, "root.go", "// TODO: root file\n")
+
+	opts := DefaultScanOptions()
+	opts.Only = []string{"src/**", "pkg/api/"}
+	opts.Audit = true
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed: %v", err)
+	}
+	var files []string
+	for _, it := range res.Items {
+		files = append(files, it.File)
+	}
+	sort.Strings(files)
+	if len(files) != 2 || files[0] != "pkg/api/c.go" || files[1] != "src/a.go" {
+		t.Fatalf("files = %v, want [pkg/api/c.go src/a.go]", files)
+	}
+
+	skipped := map[string]string{}
+	for _, e := range res.Audit.Entries {
+		skipped[e.Path] = e.Reason
+	}
+	for path, reason := range map[string]string{
+		"docs":    SkipReasonAllowlist,
+		"pkg/cli": SkipReasonAllowlist,
+		"root.go": SkipReasonAllowlist,
+		"src/gen": SkipReasonGitIgnore,
+	} {
+		if skipped[path] != reason {
+			t.Errorf("audit[%s] = %q, want %q (all: %v)", path, skipped[path], reason, skipped)
+		}
+	}
+	// Pruned directories are not walked, so their files leave no trace.
+	if _, ok := skipped["docs/e.md"]; ok {
+		t.Errorf("docs/e.md should not have been visited")
+	}
+
+	opts.Only = []string{"[bad"}
+	if _, err := ScanDirDetailed(root, opts, OSFileReader{}); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+}
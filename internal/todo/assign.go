@@ -0,0 +1,134 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// HasAssignee reports whether t names an owner right after its tag, as in
+// "TODO(alice): ...".
+func HasAssignee(t Todo) bool {
+	return strings.HasPrefix(t.Text, "(")
+}
+
+// Assignee returns the owner named at the start of text, an item's
+// description, as in "(alice): ..."; empty when there is none.
+func Assignee(text string) string {
+	if !strings.HasPrefix(text, "(") {
+		return ""
+	}
+	end := strings.IndexByte(text, ')')
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(text[1:end])
+}
+
+// ErrLineChanged is returned by InsertAssignee when the line no longer holds
+// the scanned item.
+var ErrLineChanged = errors.New("line no longer matches the scanned item")
+
+// InsertAssignee returns line with "(handle)" inserted right after t's tag.
+// The line must still hold t: the same tag at the same column with the same
+// text, matched by s (the scan's Scanner) and sanitized and redacted as the
+// scan did; otherwise ErrLineChanged is returned. Without a column, t must
+// be the line's first item. Everything else on the line is kept as is.
+func InsertAssignee(line string, t Todo, handle string, s *Scanner) (string, error) {
+	for _, m := range s.matchAll(line) {
+		if t.Column > 0 && m.Column != t.Column {
+			continue
+		}
+		if !strings.EqualFold(m.Tag, t.Tag) || s.opts.itemText(m.Text) != t.Text {
+			break
+		}
+		if strings.HasPrefix(line[m.end:], "(") {
+			return "", fmt.Errorf("%w: already assigned", ErrLineChanged)
+		}
+		return line[:m.end] + "(" + handle + ")" + line[m.end:], nil
+	}
+	return "", ErrLineChanged
+}
+
+// RewriteLines applies edit to every line of the file at path, numbered
+// from 1 and without its line ending, and replaces the file atomically when
+// any line changed. Line endings, including a missing final newline, are
+// preserved. It returns the changed lines as old and new content keyed by
+// line number; with dryRun the file is left untouched.
+func RewriteLines(path string, edit func(n int, line string) (string, bool), dryRun bool) (map[int][2]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out, changes := EditLines(data, edit)
+	if len(changes) == 0 || dryRun {
+		return changes, nil
+	}
+	return changes, ReplaceFile(path, out)
+}
+
+// EditLines is the editing step of RewriteLines on file content: it returns
+// data with the edited lines and the changes, keyed by line number.
+func EditLines(data []byte, edit func(n int, line string) (string, bool)) ([]byte, map[int][2]string) {
+	changes := make(map[int][2]string)
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for n, rest := 1, data; len(rest) > 0; n++ {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		rest = rest[len(line):]
+		content := bytes.TrimSuffix(line, []byte("\n"))
+		content = bytes.TrimSuffix(content, []byte("\r"))
+		ending := line[len(content):]
+		if repl, ok := edit(n, string(content)); ok && repl != string(content) {
+			changes[n] = [2]string{string(content), repl}
+			out.WriteString(repl)
+		} else {
+			out.Write(content)
+		}
+		out.Write(ending)
+	}
+	return out.Bytes(), changes
+}
+
+// BlameEmails returns the author email of every line of file, keyed by line
+// number, from git blame run in dir. Lines not committed yet are left out.
+func BlameEmails(ctx context.Context, git GitRunner, dir, file string) (map[int]string, error) {
+	out, err := git.Run(ctx, dir, "blame", "--line-porcelain", "--", file)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlamePorcelain(out), nil
+}
+
+// parseBlamePorcelain reads `git blame --line-porcelain` output: a header
+// "<sha> <orig-line> <final-line> [<count>]" per line, followed by
+// key-value lines including "author-mail <email>", then the line content
+// prefixed with a tab.
+func parseBlamePorcelain(out string) map[int]string {
+	emails := make(map[int]string)
+	line := 0
+	for _, l := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(l, "\t"):
+			line = 0
+		case line == 0:
+			fields := strings.Fields(l)
+			if len(fields) >= 3 && len(fields[0]) >= 40 {
+				line, _ = strconv.Atoi(fields[2])
+			}
+		case strings.HasPrefix(l, "author-mail "):
+			email := strings.Trim(strings.TrimPrefix(l, "author-mail "), "<>")
+			if email != "" && email != "not.committed.yet" {
+				emails[line] = email
+			}
+		}
+	}
+	return emails
+}
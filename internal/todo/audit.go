@@ -0,0 +1,89 @@
+package todo
+
+import "sync"
+
+// Skip reasons recorded in audit logs.
+const (
+	// SkipReasonVCS marks the built-in skip of .git metadata directories.
+	SkipReasonVCS = "vcs"
+	// SkipReasonIgnoreFlag marks entries skipped by an --ignore directory name.
+	SkipReasonIgnoreFlag = "ignore-flag"
+	// SkipReasonGitIgnore marks entries excluded by a .gitignore rule.
+	SkipReasonGitIgnore = "gitignore"
+	// SkipReasonGenerated marks files skipped because they are generated code.
+	SkipReasonGenerated = "generated"
+	// SkipReasonSubmodule marks nested repositories skipped by SkipSubmodules.
+	SkipReasonSubmodule = "submodule"
+	// SkipReasonAllowlist marks entries outside the ScanOptions.Only patterns.
+	SkipReasonAllowlist = "only"
+	// SkipReasonModified marks files last modified before
+	// ScanOptions.ModifiedSince.
+	SkipReasonModified = "modified"
+	// SkipReasonSample marks files left out of a ScanOptions.SamplePercent
+	// sample.
+	SkipReasonSample = "sample"
+	// SkipReasonOutsideRoot marks files that resolve, through a symlink or a
+	// ".." in a listed path, to a location outside the scan root.
+	SkipReasonOutsideRoot = "outside-root"
+	// SkipReasonBackup marks the BackupDirName directories holding the
+	// originals of rewritten files.
+	SkipReasonBackup = "backup"
+	// SkipReasonHardLink marks files that are hard links to a file already
+	// scanned (see ScanOptions.DedupHardLinks).
+	SkipReasonHardLink = "hardlink"
+)
+
+// DefaultAuditLimit caps the number of audit entries when no limit is given.
+const DefaultAuditLimit = 1000
+
+// SkipRecord describes a single directory or file excluded from a scan and
+// the rule responsible for it.
+type SkipRecord struct {
+	Path   string `json:"path"`
+	Dir    bool   `json:"dir"`
+	Reason string `json:"reason"`
+	Rule   string `json:"rule,omitempty"`
+}
+
+// AuditLog is the bounded list of exclusions recorded when auditing is on.
+type AuditLog struct {
+	Entries   []SkipRecord `json:"entries"`
+	Limit     int          `json:"limit"`
+	Truncated bool         `json:"truncated"`
+}
+
+// auditCollector gathers skip records up to a fixed limit. A nil collector
+// means auditing is off.
+type auditCollector struct {
+	mu  sync.Mutex
+	log AuditLog
+}
+
+// newAuditCollector returns a collector holding at most limit records.
+// A non-positive limit falls back to DefaultAuditLimit.
+func newAuditCollector(limit int) *auditCollector {
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+	return &auditCollector{log: AuditLog{Entries: []SkipRecord{}, Limit: limit}}
+}
+
+// add records rec, or flags the log as truncated once the limit is reached.
+func (c *auditCollector) add(rec SkipRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.log.Entries) >= c.log.Limit {
+		c.log.Truncated = true
+		return
+	}
+	c.log.Entries = append(c.log.Entries, rec)
+}
+
+// result returns a copy of the collected log.
+func (c *auditCollector) result() *AuditLog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := c.log
+	out.Entries = append([]SkipRecord{}, c.log.Entries...)
+	return &out
+}
@@ -0,0 +1,192 @@
+package todo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The benchmarks below are the ones the perf gate tracks (see the bench
+// command and testdata/bench-baseline.json); renaming one drops it from
+// the comparison until the baseline is updated.
+
+// benchTree is the shape of a synthetic tree for the scan benchmarks.
+type benchTree struct {
+	// dirs, depth and files lay out dirs directories per level, depth levels
+	// deep along the first directory, with files files in each.
+	dirs, depth, files int
+	// lines is the number of lines per file; every tenth line carries a tag.
+	lines int
+	// ignored is the number of extra ignored directories of the same size
+	// as a leaf, listed in .gitignore.
+	ignored int
+}
+
+var benchTrees = []struct {
+	name string
+	tree benchTree
+}{
+	{"wide", benchTree{dirs: 200, depth: 1, files: 10, lines: 40}},
+	{"deep", benchTree{dirs: 1, depth: 60, files: 20, lines: 40}},
+	{"many-ignored", benchTree{dirs: 20, depth: 1, files: 10, lines: 40, ignored: 300}},
+	{"large-files", benchTree{dirs: 4, depth: 1, files: 5, lines: 5000}},
+}
+
+// benchSource returns a Go file of n lines, every tenth one a tagged comment.
+func benchSource(n int) string {
+	var b strings.Builder
+	b.WriteString("package bench\n")
+	for i := 1; i < n; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "\t// TODO(alice): handle case %d before release #%d\n", i, i)
+		} else {
+			fmt.Fprintf(&b, "\tx%d := compute(%d, \"value\") // plain comment\n", i, i)
+		}
+	}
+	return b.String()
+}
+
+// writeBenchTree lays out tree under a temp dir of tb and returns its root.
+func writeBenchTree(tb testing.TB, tree benchTree) string {
+	tb.Helper()
+	root := tb.TempDir()
+	src := []byte(benchSource(tree.lines))
+	writeDir := func(dir string) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := range tree.files {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", f)), src, 0o644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	parent := root
+	for range tree.depth {
+		for d := range tree.dirs {
+			writeDir(filepath.Join(parent, fmt.Sprintf("d%d", d)))
+		}
+		parent = filepath.Join(parent, "d0")
+	}
+	if tree.ignored > 0 {
+		var gi strings.Builder
+		for i := range tree.ignored {
+			writeDir(filepath.Join(root, fmt.Sprintf("gen%d", i)))
+			fmt.Fprintf(&gi, "/gen%d/\n", i)
+		}
+		if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(gi.String()), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return root
+}
+
+func BenchmarkScanDir(b *testing.B) {
+	for _, bt := range benchTrees {
+		b.Run(bt.name, func(b *testing.B) {
+			root := writeBenchTree(b, bt.tree)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := ScanDir(root, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkScanFileWithReader(b *testing.B) {
+	for _, c := range []struct {
+		name, content string
+	}{
+		{"match-heavy", strings.ReplaceAll(benchSource(5000), "plain comment", "FIXME: and another")},
+		{"match-free", strings.ReplaceAll(benchSource(5000), "TODO(alice)", "Later, alice")},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			reader := mockFileReader{files: map[string]string{"a.go": c.content}}
+			opts := DefaultScanOptions()
+			if err := opts.compileMatchers(); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(c.content)))
+			for b.Loop() {
+				if _, _, err := scanFileWithReader("a.go", reader, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGitIgnoreMatch(b *testing.B) {
+	g := &gitIgnore{root: "/repo"}
+	for i := range 200 {
+		for _, line := range []string{fmt.Sprintf("build%d/", i), fmt.Sprintf("**/cache%d/*.tmp", i), fmt.Sprintf("/docs/gen%d/**", i), fmt.Sprintf("!keep%d.log", i)} {
+			if rule, ok := parseGitIgnoreLine(line); ok {
+				g.rules = append(g.rules, rule)
+			}
+		}
+	}
+	paths := []string{
+		"src/github.com/acme/project/internal/service/handlers/v2/users/profile/avatar_test.go",
+		"vendor/golang.org/x/tools/internal/lsp/source/completion/deep_completion.go",
+		"a/b/c/d/e/f/g/h/i/j/k/l/m/n/o/p/q/r/s/t/u/v/w/x/y/z/cache199/file.tmp",
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, p := range paths {
+			g.match(p, false)
+		}
+	}
+}
+
+// BenchmarkGitIgnoreMatchWorkload matches 50k paths in 2500 directories
+// against 500 rules, with the reference matcher for comparison. Each
+// iteration starts from a fresh gitIgnore, so the directory memo is rebuilt.
+func BenchmarkGitIgnoreMatchWorkload(b *testing.B) {
+	var rules []gitIgnoreRule
+	for i := range 100 {
+		for _, line := range []string{fmt.Sprintf("*.out%d", i), fmt.Sprintf("gen%d/", i), fmt.Sprintf("/docs/api%d/*.html", i), fmt.Sprintf("cache%d/tmp/*", i), fmt.Sprintf("!keep%d.out0", i)} {
+			if rule, ok := parseGitIgnoreLine(line); ok {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	paths := make([]string, 0, 50_000)
+	for i := range cap(paths) {
+		paths = append(paths, fmt.Sprintf("services/svc%d/internal/pkg%d/file%d.go", i%50, i/20%50, i%20))
+	}
+	run := func(b *testing.B, match func(g *gitIgnore, rel string) bool) {
+		b.ReportAllocs()
+		for b.Loop() {
+			g := &gitIgnore{rules: rules}
+			for _, p := range paths {
+				match(g, p)
+			}
+		}
+	}
+	b.Run("reference", func(b *testing.B) {
+		run(b, func(g *gitIgnore, rel string) bool { _, ok := referenceMatchRule(g, rel, false); return ok })
+	})
+	b.Run("compiled", func(b *testing.B) {
+		run(b, func(g *gitIgnore, rel string) bool { return g.match(rel, false) })
+	})
+}
+
+func BenchmarkBuildReportData(b *testing.B) {
+	items := make([]Todo, 100_000)
+	tags := []string{"TODO", "FIXME", "BUG", "NOTE"}
+	for i := range items {
+		items[i] = Todo{Location: Location{File: fmt.Sprintf("pkg%d/file%d.go", i%300, i%4000), Line: i%900 + 1}, Tag: tags[i%len(tags)], Text: fmt.Sprintf("item %d", i), Position: PositionOwnLine}
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		buildReportData(items, ReportOptions{})
+	}
+}
@@ -0,0 +1,68 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Budgets caps the number of items allowed per tag, keyed by upper-case tag.
+type Budgets map[string]int
+
+// ParseBudgets parses budget specs of the form TAG=N, e.g. "FIXME=0" or
+// "TODO=50", with N zero or greater. Tags are compared case-insensitively;
+// a tag given twice is an error.
+func ParseBudgets(specs []string) (Budgets, error) {
+	b := make(Budgets, len(specs))
+	for _, spec := range specs {
+		tag, n, ok := strings.Cut(spec, "=")
+		tag = strings.ToUpper(strings.TrimSpace(tag))
+		if !ok || tag == "" {
+			return nil, fmt.Errorf("invalid budget %q; want TAG=N, e.g. FIXME=0", spec)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid budget %q: the limit must be a whole number, zero or greater", spec)
+		}
+		if _, dup := b[tag]; dup {
+			return nil, fmt.Errorf("budget for %s given twice", tag)
+		}
+		b[tag] = limit
+	}
+	return b, nil
+}
+
+// Tags returns the budgeted tags, sorted.
+func (b Budgets) Tags() []string {
+	return sortedKeys(b)
+}
+
+// BudgetViolation is a tag with more items than its budget allows.
+type BudgetViolation struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+	Limit int    `json:"limit"`
+}
+
+// String renders v as one logfmt-style line, e.g.
+// "over-budget tag=FIXME count=3 limit=0", for scripts to parse.
+func (v BudgetViolation) String() string {
+	return fmt.Sprintf("over-budget tag=%s count=%d limit=%d", v.Tag, v.Count, v.Limit)
+}
+
+// CheckBudgets counts items per tag, as report summaries do, and returns the
+// tags over their budget, sorted by tag. Tags without a budget are not
+// limited.
+func CheckBudgets(items []Todo, budgets Budgets) []BudgetViolation {
+	counts := make(map[string]int)
+	for tag, n := range buildReportData(items, ReportOptions{RawText: true}).Summary.ByTag {
+		counts[strings.ToUpper(tag)] += n
+	}
+	var out []BudgetViolation
+	for _, tag := range budgets.Tags() {
+		if n := counts[tag]; n > budgets[tag] {
+			out = append(out, BudgetViolation{Tag: tag, Count: n, Limit: budgets[tag]})
+		}
+	}
+	return out
+}
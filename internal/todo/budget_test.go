@@ -0,0 +1,54 @@
+package todo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBudgets(t *testing.T) {
+	got, err := ParseBudgets([]string{"fixme=0", " TODO = 50 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Budgets{"FIXME": 0, "TODO": 50}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseBudgets = %v, want %v", got, want)
+	}
+	if tags := got.Tags(); !reflect.DeepEqual(tags, []string{"FIXME", "TODO"}) {
+		t.Errorf("Tags = %v", tags)
+	}
+
+	for spec, want := range map[string]string{
+		"FIXME":    "want TAG=N",
+		"=3":       "want TAG=N",
+		"TODO=-1":  "zero or greater",
+		"TODO=few": "whole number",
+	} {
+		if _, err := ParseBudgets([]string{spec}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseBudgets(%q) err = %v, want %q", spec, err, want)
+		}
+	}
+	if _, err := ParseBudgets([]string{"TODO=1", "todo=2"}); err == nil || !strings.Contains(err.Error(), "given twice") {
+		t.Errorf("duplicate tag: err = %v", err)
+	}
+}
+
+func TestCheckBudgets(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "one"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "two"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "FIXME", Text: "three"},
+		{Location: Location{File: "b.go", Line: 2}, Tag: "NOTE", Text: "unbudgeted"},
+	}
+	got := CheckBudgets(items, Budgets{"TODO": 1, "FIXME": 0, "BUG": 0, "NOTE": 1})
+	want := []BudgetViolation{{Tag: "FIXME", Count: 1, Limit: 0}, {Tag: "TODO", Count: 2, Limit: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckBudgets = %+v, want %+v", got, want)
+	}
+	if s := got[0].String(); s != "over-budget tag=FIXME count=1 limit=0" {
+		t.Errorf("String = %q", s)
+	}
+	if got := CheckBudgets(items, Budgets{"TODO": 2}); got != nil {
+		t.Errorf("within budget: %+v", got)
+	}
+}
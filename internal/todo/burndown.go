@@ -0,0 +1,233 @@
+package todo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BurnDownWindow is the number of most recent data points, the current run
+// included, that projections are fitted over.
+const BurnDownWindow = 14
+
+// Projection labels used when no zero date is projected.
+const (
+	ProjectionInsufficient = "insufficient data"
+	ProjectionNoZero       = "no projected zero date"
+	ProjectionAtZero       = "already zero"
+)
+
+// BurnDown compares the current run with the previous run recorded in a
+// history file and projects when counts reach zero.
+type BurnDown struct {
+	// Baseline is when the previous run was recorded.
+	Baseline      time.Time `json:"baseline"`
+	BaselineTotal int       `json:"baselineTotal"`
+	Current       int       `json:"current"`
+	// Resolved and Added count items that disappeared or appeared since the
+	// baseline, matched by fingerprint.
+	Resolved int `json:"resolved"`
+	Added    int `json:"added"`
+	Net      int `json:"net"`
+	// Projections holds the total first, then one entry per tag, most severe
+	// first.
+	Projections []Projection `json:"projections"`
+}
+
+// Projection is the linear trend of one count.
+type Projection struct {
+	// Tag is the projected tag, or "" for the total.
+	Tag     string `json:"tag,omitempty"`
+	Current int    `json:"current"`
+	// PerDay is the fitted change per day; negative means burning down.
+	PerDay float64 `json:"perDay"`
+	// DaysToZero is set only when the trend reaches zero.
+	DaysToZero float64 `json:"daysToZero,omitempty"`
+	// Label is "~N days/weeks/months" or one of the Projection* labels.
+	Label string `json:"label"`
+}
+
+// Subject names what a projection counts in lang, e.g. "FIXME count".
+func (p Projection) Subject(lang string) string {
+	if p.Tag == "" {
+		return Message(lang, "projection.total")
+	}
+	return fmt.Sprintf(Message(lang, "projection.tag"), p.Tag)
+}
+
+// projectionLabelKeys are the catalog keys of the Projection* labels.
+var projectionLabelKeys = map[string]string{
+	ProjectionInsufficient: "projection.insufficient",
+	ProjectionNoZero:       "projection.no-zero",
+	ProjectionAtZero:       "projection.at-zero",
+}
+
+// Sentence describes the projection for reports, in lang.
+func (p Projection) Sentence(lang string) string {
+	if p.DaysToZero > 0 {
+		return fmt.Sprintf(Message(lang, "projection.zero"), p.Subject(lang), humanizeDaysIn(lang, p.DaysToZero))
+	}
+	label := p.Label
+	if key, ok := projectionLabelKeys[label]; ok {
+		label = Message(lang, key)
+	}
+	return fmt.Sprintf(Message(lang, "projection.other"), p.Subject(lang), label)
+}
+
+// BurnDownPoint is one observation of a count.
+type BurnDownPoint struct {
+	At    time.Time
+	Count int
+}
+
+// ProjectZero fits a least-squares line through the last BurnDownWindow
+// points, oldest first, and projects when the latest count reaches zero.
+// Fewer than three points give ProjectionInsufficient; a flat or rising
+// trend gives ProjectionNoZero.
+func ProjectZero(points []BurnDownPoint) Projection {
+	var p Projection
+	if len(points) > 0 {
+		p.Current = points[len(points)-1].Count
+	}
+	if len(points) > BurnDownWindow {
+		points = points[len(points)-BurnDownWindow:]
+	}
+	if len(points) < 3 {
+		p.Label = ProjectionInsufficient
+		return p
+	}
+	origin := points[0].At
+	var sx, sy, sxx, sxy float64
+	for _, pt := range points {
+		x := pt.At.Sub(origin).Hours() / 24
+		y := float64(pt.Count)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	n := float64(len(points))
+	den := n*sxx - sx*sx
+	if den == 0 {
+		// Every point on the same day: no time axis to fit.
+		p.Label = ProjectionInsufficient
+		return p
+	}
+	p.PerDay = (n*sxy - sx*sy) / den
+	switch {
+	case p.Current == 0:
+		p.Label = ProjectionAtZero
+	case p.PerDay >= 0:
+		p.Label = ProjectionNoZero
+	default:
+		p.DaysToZero = float64(p.Current) / -p.PerDay
+		p.Label = humanizeDays(p.DaysToZero)
+	}
+	return p
+}
+
+// humanizeDays formats a projected duration, e.g. "~9 weeks".
+func humanizeDays(days float64) string {
+	return humanizeDaysIn(DefaultLang, days)
+}
+
+// humanizeDaysIn is humanizeDays in lang.
+func humanizeDaysIn(lang string, days float64) string {
+	unit := func(n int, key string) string {
+		if n == 1 {
+			return Message(lang, "duration."+key)
+		}
+		return fmt.Sprintf(Message(lang, "duration."+key+"s"), n)
+	}
+	switch {
+	case days < 14:
+		return unit(max(int(math.Round(days)), 1), "day")
+	case days < 90:
+		return unit(int(math.Round(days/7)), "week")
+	}
+	return unit(int(math.Round(days/30)), "month")
+}
+
+// ComputeBurnDown compares items with the latest of runs, the history
+// recorded before this run, oldest first. It returns nil when there is no
+// earlier run.
+func ComputeBurnDown(items []Todo, runs []HistoryRun, now time.Time) *BurnDown {
+	if len(runs) == 0 {
+		return nil
+	}
+	base := runs[len(runs)-1]
+	bd := &BurnDown{Baseline: base.At, BaselineTotal: base.Total, Current: len(items), Net: len(items) - base.Total}
+
+	current := make(map[string]bool, len(items))
+	byTag := make(map[string]int)
+	for _, t := range items {
+		current[fingerprint(t)] = true
+		byTag[strings.ToUpper(t.Tag)]++
+	}
+	previous := make(map[string]bool, len(base.Fingerprints))
+	for _, fp := range base.Fingerprints {
+		previous[fp] = true
+		if !current[fp] {
+			bd.Resolved++
+		}
+	}
+	for fp := range current {
+		if !previous[fp] {
+			bd.Added++
+		}
+	}
+
+	tags := make(map[string]bool)
+	for tag := range byTag {
+		tags[tag] = true
+	}
+	for _, run := range runs {
+		for tag := range run.ByTag {
+			tags[strings.ToUpper(tag)] = true
+		}
+	}
+	ordered := make([]string, 0, len(tags))
+	for tag := range tags {
+		ordered = append(ordered, tag)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := StyleFor(ordered[i]).Severity, StyleFor(ordered[j]).Severity
+		if a != b {
+			return a > b
+		}
+		return ordered[i] < ordered[j]
+	})
+
+	total := burnDownSeries(runs, now, len(items), func(r HistoryRun) int { return r.Total })
+	bd.Projections = append(bd.Projections, ProjectZero(total))
+	for _, tag := range ordered {
+		pts := burnDownSeries(runs, now, byTag[tag], func(r HistoryRun) int { return tagCount(r.ByTag, tag) })
+		proj := ProjectZero(pts)
+		proj.Tag = tag
+		bd.Projections = append(bd.Projections, proj)
+	}
+	return bd
+}
+
+// burnDownSeries returns the points of one count across runs, followed by
+// the current value at now.
+func burnDownSeries(runs []HistoryRun, now time.Time, current int, count func(HistoryRun) int) []BurnDownPoint {
+	pts := make([]BurnDownPoint, 0, len(runs)+1)
+	for _, run := range runs {
+		pts = append(pts, BurnDownPoint{At: run.At, Count: count(run)})
+	}
+	return append(pts, BurnDownPoint{At: now, Count: current})
+}
+
+// tagCount returns the count of tag in byTag, ignoring case.
+func tagCount(byTag map[string]int, tag string) int {
+	n := 0
+	for k, v := range byTag {
+		if strings.EqualFold(k, tag) {
+			n += v
+		}
+	}
+	return n
+}
@@ -0,0 +1,156 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func points(start time.Time, stepDays int, counts ...int) []BurnDownPoint {
+	pts := make([]BurnDownPoint, len(counts))
+	for i, c := range counts {
+		pts[i] = BurnDownPoint{At: start.AddDate(0, 0, i*stepDays), Count: c}
+	}
+	return pts
+}
+
+func TestProjectZero(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		pts   []BurnDownPoint
+		label string
+	}{
+		{"no points", nil, ProjectionInsufficient},
+		{"two points", points(start, 7, 10, 5), ProjectionInsufficient},
+		{"same day", points(start, 0, 10, 8, 6), ProjectionInsufficient},
+		{"flat", points(start, 7, 10, 10, 10), ProjectionNoZero},
+		{"rising", points(start, 7, 10, 12, 14), ProjectionNoZero},
+		{"at zero", points(start, 7, 4, 2, 0), ProjectionAtZero},
+		{"declining", points(start, 7, 30, 25, 20), "~4 weeks"},
+		{"fast", points(start, 1, 9, 6, 3), "~1 day"},
+		{"slow", points(start, 30, 30, 29, 28), "~28 months"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ProjectZero(tc.pts)
+			if got.Label != tc.label {
+				t.Fatalf("label = %q, want %q (perDay %.3f)", got.Label, tc.label, got.PerDay)
+			}
+		})
+	}
+}
+
+func TestProjectZero_UsesWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A long rise followed by BurnDownWindow points of steady decline: only
+	// the decline is fitted.
+	counts := []int{1, 50, 100}
+	for i := range BurnDownWindow {
+		counts = append(counts, 100-5*i)
+	}
+	got := ProjectZero(points(start, 1, counts...))
+	if got.PerDay != -5 {
+		t.Fatalf("perDay = %v, want -5", got.PerDay)
+	}
+	if got.DaysToZero != float64(got.Current)/5 {
+		t.Fatalf("daysToZero = %v", got.DaysToZero)
+	}
+}
+
+func TestComputeBurnDown(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	fixme := Todo{Location: Location{File: "a.go"}, Tag: "FIXME", Text: "leak"}
+	todoA := Todo{Location: Location{File: "a.go"}, Tag: "TODO", Text: "one"}
+	todoB := Todo{Location: Location{File: "b.go"}, Tag: "TODO", Text: "two"}
+	todoC := Todo{Location: Location{File: "c.go"}, Tag: "TODO", Text: "three"}
+
+	if bd := ComputeBurnDown([]Todo{fixme}, nil, now); bd != nil {
+		t.Fatalf("expected nil without history, got %+v", bd)
+	}
+
+	base := HistoryRun{
+		At:           now.AddDate(0, 0, -7),
+		Total:        3,
+		ByTag:        map[string]int{"FIXME": 1, "TODO": 2},
+		Fingerprints: []string{fingerprint(fixme), fingerprint(todoA), fingerprint(todoB)},
+	}
+	older := HistoryRun{At: now.AddDate(0, 0, -14), Total: 5, ByTag: map[string]int{"FIXME": 2, "TODO": 3}}
+
+	bd := ComputeBurnDown([]Todo{todoA, todoC}, []HistoryRun{older, base}, now)
+	if bd.Resolved != 2 || bd.Added != 1 || bd.Net != -1 {
+		t.Fatalf("resolved/added/net = %d/%d/%d, want 2/1/-1", bd.Resolved, bd.Added, bd.Net)
+	}
+	if !bd.Baseline.Equal(base.At) || bd.BaselineTotal != 3 || bd.Current != 2 {
+		t.Fatalf("unexpected baseline: %+v", bd)
+	}
+	var tags []string
+	for _, p := range bd.Projections {
+		tags = append(tags, p.Tag)
+	}
+	if got := strings.Join(tags, ","); got != ",FIXME,TODO" {
+		t.Fatalf("projection order = %q", got)
+	}
+	if p := bd.Projections[1]; p.Label != ProjectionAtZero {
+		t.Fatalf("FIXME projection = %+v, want at zero", p)
+	}
+	if p := bd.Projections[0]; p.DaysToZero == 0 || !strings.Contains(p.Sentence(""), "Total count reaches zero in ~") {
+		t.Fatalf("total projection = %+v (%s)", p, p.Sentence(""))
+	}
+}
+
+func TestComputeBurnDown_EmptyBaseline(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	runs := []HistoryRun{{At: now.AddDate(0, 0, -1), ByTag: map[string]int{}}}
+	bd := ComputeBurnDown([]Todo{{Location: Location{File: "a.go"}, Tag: "TODO", Text: "new"}}, runs, now)
+	if bd.Resolved != 0 || bd.Added != 1 || bd.Net != 1 {
+		t.Fatalf("resolved/added/net = %d/%d/%d, want 0/1/1", bd.Resolved, bd.Added, bd.Net)
+	}
+	for _, p := range bd.Projections {
+		if p.Label != ProjectionInsufficient {
+			t.Fatalf("projection %+v, want insufficient data", p)
+		}
+	}
+}
+
+func TestRenderMarkdown_BurnDown(t *testing.T) {
+	bd := &BurnDown{
+		Baseline: time.Date(2024, 2, 23, 0, 0, 0, 0, time.UTC), BaselineTotal: 10, Current: 8,
+		Resolved: 3, Added: 1, Net: -2,
+		Projections: []Projection{{Current: 8, PerDay: -0.5, DaysToZero: 16, Label: "~2 weeks"}, {Tag: "BUG", Label: ProjectionNoZero}},
+	}
+	opts := ReportOptions{BurnDown: bd}
+	out := renderMarkdown(buildReportData(nil, opts), opts)
+	for _, want := range []string{
+		"## Burn-down",
+		"Since 2024-02-23: 3 resolved, 1 added, net -2 (10 → 8).",
+		"- At the current pace, Total count reaches zero in ~2 weeks.",
+		"- BUG count: no projected zero date.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if out := renderMarkdown(buildReportData(nil, ReportOptions{}), ReportOptions{}); strings.Contains(out, "Burn-down") {
+		t.Fatalf("burn-down section without data:\n%s", out)
+	}
+}
+
+func TestGenerateHTML_BurnDown(t *testing.T) {
+	bd := &BurnDown{
+		Baseline: time.Date(2024, 2, 23, 0, 0, 0, 0, time.UTC), BaselineTotal: 10, Current: 8,
+		Resolved: 3, Added: 1, Net: -2,
+		Projections: []Projection{{Current: 8, PerDay: -0.5, DaysToZero: 16, Label: "~2 weeks"}},
+	}
+	var buf bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(nil, "ignored.html", mockFileWriter{buf: &buf}, ReportOptions{BurnDown: bd}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{`id="burn-down"`, "Since 2024-02-23:", "net -2", "Total count reaches zero in ~2 weeks."} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in HTML", want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// CheckpointVersion is the format version of checkpoint files. Files of
+// another version are rejected rather than resumed.
+const CheckpointVersion = 1
+
+// DefaultCheckpointEvery is the number of scanned files between checkpoint
+// writes.
+const DefaultCheckpointEvery = 500
+
+// Checkpoint is the partial result of an interrupted scan: the files already
+// scanned and the items found in them.
+type Checkpoint struct {
+	Version int `json:"v"`
+	// Root is the scanned directory; a checkpoint only resumes the same root.
+	Root string `json:"root"`
+	// Settings is the ScanOptions.MatchKey of the scan; see Stale.
+	Settings string   `json:"settings,omitempty"`
+	Done     []string `json:"done"`
+	Items    []Todo   `json:"items"`
+}
+
+// LoadCheckpoint reads the checkpoint at path.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cp.Version != CheckpointVersion {
+		return nil, fmt.Errorf("%s: unsupported checkpoint version %d", path, cp.Version)
+	}
+	return &cp, nil
+}
+
+// DoneSet returns the scanned files as a set for ScanOptions.Done.
+func (cp *Checkpoint) DoneSet() map[string]bool {
+	done := make(map[string]bool, len(cp.Done))
+	for _, f := range cp.Done {
+		done[f] = true
+	}
+	return done
+}
+
+// Checkpointer accumulates scan progress and writes it to a checkpoint file
+// every few files. Its FileScanned method is meant for
+// ScanOptions.OnFileScanned, which serializes calls.
+type Checkpointer struct {
+	path    string
+	every   int
+	pending int
+	cp      Checkpoint
+	err     error
+}
+
+// NewCheckpointer returns a Checkpointer for a scan of root with the given
+// ScanOptions.MatchKey, writing to path every every files
+// (DefaultCheckpointEvery if <= 0). A non-nil prior checkpoint, from a
+// resumed run, seeds the progress so later writes still cover it.
+func NewCheckpointer(path, root, settings string, every int, prior *Checkpoint) *Checkpointer {
+	if every <= 0 {
+		every = DefaultCheckpointEvery
+	}
+	c := &Checkpointer{path: path, every: every, cp: Checkpoint{Version: CheckpointVersion, Root: root, Settings: settings, Done: []string{}, Items: []Todo{}}}
+	if prior != nil {
+		c.cp.Done = append(c.cp.Done, prior.Done...)
+		c.cp.Items = append(c.cp.Items, prior.Items...)
+	}
+	return c
+}
+
+// FileScanned records file as done with its items, writing the checkpoint
+// once every files have accumulated. The first write error is kept for
+// Flush and stops further periodic writes.
+func (c *Checkpointer) FileScanned(file string, items []Todo) {
+	c.cp.Done = append(c.cp.Done, file)
+	c.cp.Items = append(c.cp.Items, items...)
+	c.pending++
+	if c.pending >= c.every && c.err == nil {
+		c.err = c.write()
+	}
+}
+
+// Flush writes the checkpoint and returns the first error encountered.
+func (c *Checkpointer) Flush() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.write()
+}
+
+// write replaces the checkpoint file atomically, so an interrupted write
+// leaves the previous checkpoint intact.
+func (c *Checkpointer) write() error {
+	c.pending = 0
+	sort.Strings(c.cp.Done)
+	b, err := json.Marshal(c.cp)
+	if err != nil {
+		return err
+	}
+	if err := ReplaceFile(c.path, b); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Stale reports whether cp was written under scan settings other than
+// settings, a ScanOptions.MatchKey: its items may then differ from what the
+// files yield now, and it should not be resumed. Checkpoints that record no
+// settings, written before they were, resume as they always have.
+func (cp *Checkpoint) Stale(settings string) bool {
+	return cp.Settings != "" && cp.Settings != settings
+}
+
+// CheckRoot returns an error unless cp was written for a scan of root.
+func (cp *Checkpoint) CheckRoot(root string) error {
+	if cp.Root != root {
+		return fmt.Errorf("checkpoint is for %s, not %s", cp.Root, root)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package todo
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestCheckpointer_WritesEveryNAndOnFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partial.json")
+	prior := &Checkpoint{Version: CheckpointVersion, Root: "/src", Done: []string{"z.go"}, Items: []Todo{{Location: Location{File: "z.go", Line: 1}, Tag: "TODO"}}}
+	c := NewCheckpointer(path, "/src", "k", 2, prior)
+
+	c.FileScanned("a.go", nil)
+	if _, err := LoadCheckpoint(path); err == nil {
+		t.Fatalf("checkpoint written before %d files", 2)
+	}
+	c.FileScanned("b.go", []Todo{{Location: Location{File: "b.go", Line: 3}, Tag: "FIXME"}})
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("load after 2 files: %v", err)
+	}
+	if !reflect.DeepEqual(cp.Done, []string{"a.go", "b.go", "z.go"}) || len(cp.Items) != 2 {
+		t.Fatalf("unexpected checkpoint: %+v", cp)
+	}
+
+	c.FileScanned("c.go", nil)
+	if err := c.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	cp, err = LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("load after flush: %v", err)
+	}
+	if len(cp.Done) != 4 || !cp.DoneSet()["c.go"] {
+		t.Fatalf("flush should record every file: %+v", cp.Done)
+	}
+	if err := cp.CheckRoot("/src"); err != nil {
+		t.Fatalf("CheckRoot: %v", err)
+	}
+	if err := cp.CheckRoot("/other"); err == nil {
+		t.Fatalf("expected root mismatch error")
+	}
+	if cp.Stale("k") || !cp.Stale("other") || (&Checkpoint{}).Stale("k") {
+		t.Fatalf("Stale should compare the recorded settings %q", cp.Settings)
+	}
+}
+
+func TestScanOptions_MatchKey(t *testing.T) {
+	base := DefaultScanOptions()
+	key := base.MatchKey()
+
+	same := DefaultScanOptions()
+	same.Tags = []string{"note", "BUG", " todo", "FIXME"}
+	same.IgnoreDirs = []string{"vendor"}
+	same.Audit = true
+	if same.MatchKey() != key {
+		t.Error("default tags in another order and case, or walk settings, changed the key")
+	}
+
+	for name, change := range map[string]func(*ScanOptions){
+		"tags":   func(o *ScanOptions) { o.Tags = []string{"TODO", "HACK"} },
+		"detect": func(o *ScanOptions) { o.Detect = []string{DetectDebug} },
+		"debug patterns": func(o *ScanOptions) {
+			o.DebugPatterns = map[string][]*regexp.Regexp{".go": {regexp.MustCompile(`dbg\(`)}}
+		},
+		"comment syntax": func(o *ScanOptions) { o.CommentSyntax = map[string][]string{".nim": {"#"}} },
+		"ignore comments": func(o *ScanOptions) {
+			o.RespectIgnoreComments = !o.RespectIgnoreComments
+		},
+		"raw line":        func(o *ScanOptions) { o.IncludeRawLine = true },
+		"redact patterns": func(o *ScanOptions) { o.RedactPatterns = []*regexp.Regexp{regexp.MustCompile(`sk_[a-z]+`)} },
+	} {
+		o := DefaultScanOptions()
+		change(&o)
+		if o.MatchKey() == key {
+			t.Errorf("changing %s kept the key", name)
+		}
+	}
+}
+
+func TestScanOptions_TreeMatchKey(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: a\n")
+	mustWriteFile(t, root, "sub/b.go", "// TODO: b\n")
+	tags := map[string][]string{}
+	opts := DefaultScanOptions()
+	opts.DirOverride = func(dir string) (*Override, error) {
+		if t, ok := tags[dir]; ok {
+			return &Override{Tags: t}, nil
+		}
+		return nil, nil
+	}
+	key := func() string {
+		t.Helper()
+		k, err := opts.TreeMatchKey(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	if key() != opts.MatchKey() {
+		t.Error("without overrides the key is not MatchKey")
+	}
+	tags["sub"] = []string{"FIXME"}
+	fixme := key()
+	if fixme == opts.MatchKey() {
+		t.Error("a directory override kept the key")
+	}
+	tags["sub"] = []string{"BUG"}
+	if key() == fixme {
+		t.Error("changing a directory override kept the key")
+	}
+}
+
+func TestLoadCheckpoint_RejectsOtherVersions(t *testing.T) {
+	dir := t.TempDir()
+	p := mustWriteFile(t, dir, "cp.json", `{"v":99,"root":"/src","done":[],"items":[]}`)
+	if _, err := LoadCheckpoint(p); err == nil {
+		t.Fatalf("expected version error")
+	}
+}
+
+func TestScanDirDetailed_SkipsDoneFilesAndReportsProgress(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: already scanned\n")
+	mustWriteFile(t, root, "b.go", "// TODO: pending\n")
+	mustWriteFile(t, root, "c.go", "package c\n")
+
+	opts := DefaultScanOptions()
+	opts.Done = map[string]bool{"a.go": true}
+	var scanned []string
+	opts.OnFileScanned = func(file string, _ []Todo) { scanned = append(scanned, file) }
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	sort.Strings(scanned)
+	if !reflect.DeepEqual(scanned, []string{"b.go", "c.go"}) {
+		t.Fatalf("scanned = %v", scanned)
+	}
+	if len(res.Items) != 1 || res.Items[0].File != "b.go" || res.Stats.Resumed != 1 {
+		t.Fatalf("unexpected result: %+v %+v", res.Items, res.Stats)
+	}
+}
@@ -0,0 +1,144 @@
+package todo
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CodeOwnersFiles lists where a repository's CODEOWNERS file is looked up,
+// relative to its root, in the order GitHub uses.
+var CodeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeOwnersRule is one CODEOWNERS line: a gitignore-style pattern and the
+// owners of the paths it matches.
+type codeOwnersRule struct {
+	gitIgnoreRule
+	owners []string
+}
+
+// CodeOwners maps repository paths to their owners. As on GitHub, the last
+// matching rule wins, and a rule without owners leaves its paths unowned.
+type CodeOwners struct {
+	// root is the directory patterns are relative to.
+	root  string
+	rules []codeOwnersRule
+}
+
+// LoadCodeOwners finds the CODEOWNERS file of the repository containing dir.
+// It returns nil without an error when the repository has none.
+func LoadCodeOwners(dir string) (*CodeOwners, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root := findRepoRoot(abs)
+	for _, name := range CodeOwnersFiles {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if st, err := os.Stat(p); err == nil && !st.IsDir() {
+			return loadCodeOwnersFile(p, root)
+		}
+	}
+	return nil, nil
+}
+
+// LoadCodeOwnersFile reads the CODEOWNERS file at p for the repository
+// containing dir; its patterns are relative to the repository root.
+func LoadCodeOwnersFile(p, dir string) (*CodeOwners, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return loadCodeOwnersFile(p, findRepoRoot(abs))
+}
+
+// loadCodeOwnersFile parses the file at p with patterns relative to root.
+func loadCodeOwnersFile(p, root string) (*CodeOwners, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer SafeClose(f, p)
+	return ParseCodeOwners(root, f)
+}
+
+// ParseCodeOwners reads CODEOWNERS rules from r; root is the directory their
+// patterns are relative to. Lines hold a pattern followed by owners
+// separated by whitespace; '#' starts a comment.
+func ParseCodeOwners(root string, r io.Reader) (*CodeOwners, error) {
+	c := &CodeOwners{root: root}
+	sc := bufio.NewScanner(r)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		line := sc.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		rule, ok := parseGitIgnoreLine(fields[0])
+		if !ok || rule.negative {
+			continue
+		}
+		rule.source = "CODEOWNERS"
+		rule.line = lineNum
+		c.rules = append(c.rules, codeOwnersRule{gitIgnoreRule: rule, owners: fields[1:]})
+	}
+	return c, sc.Err()
+}
+
+// Owners returns the owners of the file at rel, a slash-separated path
+// relative to the repository root, or nil when it has none. A pattern
+// matching one of the file's directories covers the file.
+func (c *CodeOwners) Owners(rel string) []string {
+	if c == nil {
+		return nil
+	}
+	rel = normalizePath(rel)
+	var owners []string
+	for i := range c.rules {
+		r := &c.rules[i]
+		if r.matches(rel, false) || r.matchesParent(rel) {
+			owners = r.owners
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	return append([]string(nil), owners...)
+}
+
+// matchesParent reports whether the rule matches a directory above rel.
+func (r *gitIgnoreRule) matchesParent(rel string) bool {
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if r.matches(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// Assign returns a copy of items with Owners set from c. dir is the
+// directory item paths are relative to.
+func (c *CodeOwners) Assign(items []Todo, dir string) []Todo {
+	out := make([]Todo, len(items))
+	copy(out, items)
+	abs, err := filepath.Abs(dir)
+	if err != nil || c == nil {
+		return out
+	}
+	for i := range out {
+		rel, err := filepath.Rel(c.root, filepath.Join(abs, filepath.FromSlash(out[i].File)))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		out[i].Owners = c.Owners(rel)
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package todo
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const codeOwnersFixture = `# Default owners
+*                   @acme/core
+/internal/          @acme/platform
+/internal/todo/     @acme/platform @acme/reports  # shared
+*.md                @acme/docs
+/internal/todo/gen/
+`
+
+func TestCodeOwners_Owners(t *testing.T) {
+	co, err := ParseCodeOwners("/repo", strings.NewReader(codeOwnersFixture))
+	if err != nil {
+		t.Fatalf("ParseCodeOwners: %v", err)
+	}
+	cases := map[string][]string{
+		"main.go":                    {"@acme/core"},
+		"internal/config/config.go":  {"@acme/platform"},
+		"internal/todo/scan.go":      {"@acme/platform", "@acme/reports"},
+		"internal/todo/deep/x.go":    {"@acme/platform", "@acme/reports"},
+		"internal/todo/README.md":    {"@acme/docs"},
+		"internal/todo/gen/types.go": nil, // a rule without owners unsets them
+		"docs/guide.md":              {"@acme/docs"},
+	}
+	for rel, want := range cases {
+		if got := co.Owners(rel); !reflect.DeepEqual(got, want) {
+			t.Errorf("Owners(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	var none *CodeOwners
+	if got := none.Owners("main.go"); got != nil {
+		t.Fatalf("nil CodeOwners should own nothing, got %v", got)
+	}
+}
+
+func TestCodeOwners_LoadAndAssign(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "")
+	mustWriteFile(t, root, ".github/CODEOWNERS", codeOwnersFixture)
+
+	// Scanning a subdirectory: item paths are relative to it, patterns to the
+	// repository root.
+	dir := filepath.Join(root, "internal")
+	co, err := LoadCodeOwners(dir)
+	if err != nil || co == nil {
+		t.Fatalf("LoadCodeOwners: %v, %v", co, err)
+	}
+	items := []Todo{{Location: Location{File: "todo/scan.go"}}, {Location: Location{File: "config/config.go"}}}
+	got := co.Assign(items, dir)
+	if !reflect.DeepEqual(got[0].Owners, []string{"@acme/platform", "@acme/reports"}) || !reflect.DeepEqual(got[1].Owners, []string{"@acme/platform"}) {
+		t.Fatalf("unexpected owners: %v, %v", got[0].Owners, got[1].Owners)
+	}
+	if items[0].Owners != nil {
+		t.Fatalf("Assign must not modify its input")
+	}
+
+	if co, err := LoadCodeOwners(t.TempDir()); co != nil || err != nil {
+		t.Fatalf("expected no CODEOWNERS, got %v, %v", co, err)
+	}
+}
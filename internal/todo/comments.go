@@ -0,0 +1,139 @@
+package todo
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Positions reported in Todo.Position.
+const (
+	// PositionOwnLine marks a tag whose comment starts the line ("// TODO: x").
+	PositionOwnLine = "own-line"
+	// PositionTrailing marks a tag in a comment after code ("f() // TODO: x").
+	PositionTrailing = "trailing"
+)
+
+// commentSyntax maps a lowercase file extension to the comment introducers
+// of its language. Extensions missing here have unknown comment syntax;
+// ScanOptions.CommentSyntax adds languages without code changes.
+var commentSyntax = map[string][]string{
+	".go":    {"//", "/*"},
+	".c":     {"//", "/*"},
+	".h":     {"//", "/*"},
+	".cc":    {"//", "/*"},
+	".cpp":   {"//", "/*"},
+	".hpp":   {"//", "/*"},
+	".cs":    {"//", "/*"},
+	".java":  {"//", "/*"},
+	".kt":    {"//", "/*"},
+	".scala": {"//", "/*"},
+	".swift": {"//", "/*"},
+	".rs":    {"//", "/*"},
+	".dart":  {"//", "/*"},
+	".proto": {"//", "/*"},
+	".zig":   {"//"},
+	".js":    {"//", "/*"},
+	".mjs":   {"//", "/*"},
+	".cjs":   {"//", "/*"},
+	".jsx":   {"//", "/*"},
+	".ts":    {"//", "/*"},
+	".tsx":   {"//", "/*"},
+	".php":   {"//", "#", "/*"},
+	".css":   {"/*"},
+	".scss":  {"//", "/*"},
+	".less":  {"//", "/*"},
+	".py":    {"#"},
+	".rb":    {"#"},
+	".sh":    {"#"},
+	".bash":  {"#"},
+	".zsh":   {"#"},
+	".pl":    {"#"},
+	".r":     {"#"},
+	".yaml":  {"#"},
+	".yml":   {"#"},
+	".toml":  {"#"},
+	".ps1":   {"#"},
+	".ex":    {"#"},
+	".exs":   {"#"},
+	".jl":    {"#"},
+	".nim":   {"#"},
+	".tf":    {"#", "//", "/*"},
+	".ini":   {";", "#"},
+	".erl":   {"%"},
+	".tex":   {"%"},
+	".clj":   {";"},
+	".lisp":  {";"},
+	".el":    {";"},
+	".sql":   {"--", "/*"},
+	".lua":   {"--"},
+	".hs":    {"--"},
+	".html":  {"<!--"},
+	".xml":   {"<!--"},
+	".vue":   {"//", "/*", "<!--"},
+}
+
+// CommentSyntax returns a copy of the built-in comment introducers by
+// lowercase file extension.
+func CommentSyntax() map[string][]string {
+	out := maps.Clone(commentSyntax)
+	for ext, intros := range out {
+		out[ext] = slices.Clone(intros)
+	}
+	return out
+}
+
+// ParseCommentSyntax parses an "ext:introducer" entry, e.g. ".nim:#", into
+// its lowercase extension and introducer.
+func ParseCommentSyntax(s string) (string, string, error) {
+	ext, intro, ok := strings.Cut(s, ":")
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	intro = strings.TrimSpace(intro)
+	if !ok || ext == "" || intro == "" {
+		return "", "", fmt.Errorf("comment syntax %q: want ext:introducer", s)
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext, intro, nil
+}
+
+// commentIntroducers returns the comment introducers for path's extension
+// under o, or nil when the language is unknown. o.CommentSyntax replaces
+// the built-in introducers of the extensions it lists.
+func (o ScanOptions) commentIntroducers(path string) []string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if intros, ok := o.CommentSyntax[ext]; ok {
+		return intros
+	}
+	return commentSyntax[ext]
+}
+
+// classifyPosition reports whether the tag starting at byte offset tagStart
+// sits in a comment that opens the line or trails code. It returns "" when
+// introducers is nil (unknown syntax) or the comment cannot be located.
+func classifyPosition(line string, tagStart int, introducers []string) string {
+	if introducers == nil {
+		return ""
+	}
+	before := line[:tagStart]
+	start := -1
+	for _, intro := range introducers {
+		if i := strings.Index(before, intro); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	if start < 0 {
+		// Continuation line of a block comment, e.g. " * TODO: x".
+		if strings.TrimLeft(before, " \t*") == "" {
+			return PositionOwnLine
+		}
+		return ""
+	}
+	if strings.TrimSpace(before[:start]) == "" {
+		return PositionOwnLine
+	}
+	return PositionTrailing
+}
@@ -0,0 +1,96 @@
+package todo
+
+import "testing"
+
+func TestClassifyPosition(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		line string
+		want string
+	}{
+		{"go own-line", "a.go", "// TODO: x", PositionOwnLine},
+		{"go indented own-line", "a.go", "\t\t// TODO: x", PositionOwnLine},
+		{"go trailing", "a.go", "x := f() // TODO: check error", PositionTrailing},
+		{"go block comment", "a.go", "/* FIXME: later */", PositionOwnLine},
+		{"go block continuation", "a.go", " * NOTE: details", PositionOwnLine},
+		{"python own-line", "a.py", "    # TODO: x", PositionOwnLine},
+		{"python trailing", "a.py", "value = compute()  # FIXME: slow", PositionTrailing},
+		{"sql own-line", "q.sql", "  -- TODO: add index", PositionOwnLine},
+		{"sql trailing", "q.sql", "SELECT * FROM t; -- BUG: full scan", PositionTrailing},
+		{"uppercase extension", "Q.SQL", "-- TODO: x", PositionOwnLine},
+		{"not inside a comment", "a.go", `fmt.Println("TODO")`, ""},
+		{"unknown syntax", "notes.txt", "TODO: x", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			idx := pattern.FindStringSubmatchIndex(c.line)
+			if idx == nil {
+				t.Fatalf("pattern did not match %q", c.line)
+			}
+			if got := classifyPosition(c.line, idx[2], ScanOptions{}.commentIntroducers(c.file)); got != c.want {
+				t.Fatalf("classifyPosition(%q) = %q, want %q", c.line, got, c.want)
+			}
+		})
+	}
+}
+
+func TestScanOptions_CommentSyntax(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.CommentSyntax = map[string][]string{".nim": {"#"}, ".go": {"#"}}
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := s.ScanString("a.nim", "# TODO: own\necho 1 # FIXME: trailing\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].Position != PositionOwnLine || items[1].Position != PositionTrailing {
+		t.Fatalf("unexpected positions: %+v", items)
+	}
+	// Listed extensions replace the built-in introducers.
+	items, _ = s.ScanString("a.go", "x() // TODO: y")
+	if items[0].Position != "" {
+		t.Fatalf("built-in .go syntax still applied: %+v", items[0])
+	}
+	if CommentSyntax()[".nim"][0] != "#" {
+		t.Fatal("expected a built-in .nim entry")
+	}
+	CommentSyntax()[".go"][0] = "changed"
+	if commentSyntax[".go"][0] != "//" {
+		t.Fatal("CommentSyntax returned the shared map")
+	}
+}
+
+func TestParseCommentSyntax(t *testing.T) {
+	ext, intro, err := ParseCommentSyntax(" NIM : # ")
+	if err != nil || ext != ".nim" || intro != "#" {
+		t.Fatalf("got %q %q %v", ext, intro, err)
+	}
+	ext, intro, err = ParseCommentSyntax(".zig://")
+	if err != nil || ext != ".zig" || intro != "//" {
+		t.Fatalf("got %q %q %v", ext, intro, err)
+	}
+	for _, bad := range []string{"", ".nim", ".nim:", ":#"} {
+		if _, _, err := ParseCommentSyntax(bad); err == nil {
+			t.Errorf("ParseCommentSyntax(%q) accepted", bad)
+		}
+	}
+}
+
+func TestBuildReportData_PositionSplit(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Position: PositionOwnLine},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Position: PositionTrailing},
+		{Location: Location{File: "a.go", Line: 3}, Tag: "TODO", Position: PositionOwnLine},
+		{Location: Location{File: "a.txt", Line: 1}, Tag: "TODO"},
+	}
+	data := buildReportData(items, ReportOptions{})
+	if data.Summary.ByPosition[PositionOwnLine] != 2 || data.Summary.ByPosition[PositionTrailing] != 1 {
+		t.Fatalf("unexpected position split: %#v", data.Summary.ByPosition)
+	}
+	if got := FormatPositionSplit(data.Summary.ByPosition, DefaultLang); got != "own-line: 2, trailing: 1" {
+		t.Fatalf("unexpected formatted split: %q", got)
+	}
+}
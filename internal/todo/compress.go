@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CompressGzip is the gzip report compression.
+const CompressGzip = "gz"
+
+// ParseCompression validates a report compression name: "" for none or
+// CompressGzip.
+func ParseCompression(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "", CompressGzip:
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported compression %q; must be: %s", s, CompressGzip)
+}
+
+// CompressedName returns name with the extension of compression appended,
+// unless it already ends with it. With no compression name is returned as is.
+func CompressedName(name, compression string) string {
+	if compression == "" || strings.HasSuffix(strings.ToLower(name), "."+compression) {
+		return name
+	}
+	return name + "." + compression
+}
+
+// CompressingFileWriter is a FileWriter that compresses everything written
+// through Writer, so every report generator can produce compressed output.
+// Names are passed through unchanged; see CompressedName.
+type CompressingFileWriter struct {
+	// Writer creates the underlying files; OSFileWriter when nil.
+	Writer FileWriter
+	// Compression is CompressGzip.
+	Compression string
+}
+
+// Create implements FileWriter.
+func (c CompressingFileWriter) Create(name string) (io.WriteCloser, error) {
+	if c.Compression != CompressGzip {
+		return nil, fmt.Errorf("unsupported compression %q", c.Compression)
+	}
+	w := c.Writer
+	if w == nil {
+		w = OSFileWriter{}
+	}
+	f, err := w.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipFile{Writer: gzip.NewWriter(f), f: f}, nil
+}
+
+// gzipFile compresses into f; closing it flushes the stream and closes f.
+type gzipFile struct {
+	*gzip.Writer
+	f io.WriteCloser
+}
+
+// Close finishes the gzip stream, then closes the underlying file.
+func (g *gzipFile) Close() error {
+	err := g.Writer.Close()
+	if cerr := g.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
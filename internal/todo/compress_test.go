@@ -0,0 +1,54 @@
+package todo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestCompressingFileWriter_RoundTrip(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}, {Location: Location{File: "b.go", Line: 2}, Tag: "BUG", Text: "y"}}
+	var plain, packed bytes.Buffer
+	if err := GenerateJSONLReportWithWriter(items, "r.jsonl", jsonMockFileWriter{buf: &plain}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w := CompressingFileWriter{Writer: jsonMockFileWriter{buf: &packed}, Compression: CompressGzip}
+	if err := GenerateJSONLReportWithWriter(items, "r.jsonl.gz", w, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&packed)
+	if err != nil {
+		t.Fatalf("not gzip: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain.Bytes()) {
+		t.Fatalf("decompressed report differs:\n%s\nwant:\n%s", got, plain.Bytes())
+	}
+}
+
+func TestCompressedName(t *testing.T) {
+	tests := []struct{ name, compression, want string }{
+		{"report.json", CompressGzip, "report.json.gz"},
+		{"report.json.gz", CompressGzip, "report.json.gz"},
+		{"REPORT.JSON.GZ", CompressGzip, "REPORT.JSON.GZ"},
+		{"report.json", "", "report.json"},
+	}
+	for _, tt := range tests {
+		if got := CompressedName(tt.name, tt.compression); got != tt.want {
+			t.Errorf("CompressedName(%q, %q) = %q, want %q", tt.name, tt.compression, got, tt.want)
+		}
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	if c, err := ParseCompression(" GZ "); err != nil || c != CompressGzip {
+		t.Fatalf("got %q, %v", c, err)
+	}
+	if _, err := ParseCompression("zstd"); err == nil {
+		t.Fatal("zstd accepted")
+	}
+}
@@ -0,0 +1,151 @@
+package todo
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Detectors that run alongside tag matching (see ScanOptions.Detect).
+const (
+	// DetectConflicts reports unresolved merge conflict markers.
+	DetectConflicts = "conflicts"
+	// DetectDebug reports debug statements left in code.
+	DetectDebug = "debug"
+)
+
+// Detectors lists the available detectors.
+var Detectors = []string{DetectConflicts, DetectDebug}
+
+// Tags of the items reported by detectors.
+const (
+	TagConflict = "CONFLICT"
+	TagDebug    = "DEBUG"
+)
+
+// debugPatterns maps a lowercase file extension to the expressions matching
+// debug leftovers in that language. ScanOptions.DebugPatterns adds to it.
+var debugPatterns = compileDebugPatterns(map[string][]string{
+	".js":  {`\bconsole\.(log|debug|trace)\(`, `^\s*debugger\s*;?\s*$`},
+	".jsx": {`\bconsole\.(log|debug|trace)\(`, `^\s*debugger\s*;?\s*$`},
+	".ts":  {`\bconsole\.(log|debug|trace)\(`, `^\s*debugger\s*;?\s*$`},
+	".tsx": {`\bconsole\.(log|debug|trace)\(`, `^\s*debugger\s*;?\s*$`},
+	".vue": {`\bconsole\.(log|debug|trace)\(`, `^\s*debugger\s*;?\s*$`},
+	".go":  {`\bfmt\.(Print|Println|Printf)\(.*DEBUG`},
+	".py":  {`\b(i?pdb)\.set_trace\(\)`, `^\s*breakpoint\(\)`},
+	".rb":  {`\bbinding\.(pry|irb)\b`, `^\s*byebug\b`},
+	".php": {`\b(var_dump|dd)\(`},
+	".rs":  {`\bdbg!\(`},
+})
+
+func compileDebugPatterns(src map[string][]string) map[string][]*regexp.Regexp {
+	out := make(map[string][]*regexp.Regexp, len(src))
+	for ext, exprs := range src {
+		for _, e := range exprs {
+			out[ext] = append(out[ext], regexp.MustCompile(e))
+		}
+	}
+	return out
+}
+
+// ParseDebugPattern parses an "ext:regexp" debug pattern, e.g.
+// `.go:log\.Printf\("DEBUG`, into its lowercase extension and expression.
+func ParseDebugPattern(s string) (string, *regexp.Regexp, error) {
+	ext, expr, ok := strings.Cut(s, ":")
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if !ok || ext == "" || expr == "" {
+		return "", nil, fmt.Errorf("debug pattern %q: want ext:regexp", s)
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("debug pattern %q: %w", s, err)
+	}
+	return ext, re, nil
+}
+
+// checkDetectors returns an error for names that are not Detectors.
+func checkDetectors(names []string) error {
+	for _, n := range names {
+		if !detectorEnabled(Detectors, n) {
+			return fmt.Errorf("unknown detector %q; must be one of: %s", n, strings.Join(Detectors, ", "))
+		}
+	}
+	return nil
+}
+
+// detectorEnabled reports whether name is in names, ignoring case.
+func detectorEnabled(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugMatchers returns the debug patterns for the file name under opts.
+func (o ScanOptions) debugMatchers(name string) []*regexp.Regexp {
+	ext := strings.ToLower(filepath.Ext(name))
+	return slices.Concat(debugPatterns[ext], o.DebugPatterns[ext])
+}
+
+// Conflict marker kinds returned by conflictMarker.
+const (
+	markerNone = iota
+	markerStart
+	markerSep
+	markerEnd
+)
+
+// conflictMarker classifies line as a merge conflict marker: seven '<', '='
+// or '>' at the start of the line, followed by the end of the line or, for
+// '<' and '>', a space and a label.
+func conflictMarker(line string) int {
+	if len(line) < 7 {
+		return markerNone
+	}
+	head, rest := line[:7], line[7:]
+	labeled := rest == "" || rest[0] == ' '
+	switch {
+	case head == "<<<<<<<" && labeled:
+		return markerStart
+	case head == "=======" && strings.TrimRight(rest, " \t\r") == "":
+		return markerSep
+	case head == ">>>>>>>" && labeled:
+		return markerEnd
+	}
+	return markerNone
+}
+
+// conflictTracker finds complete conflict blocks: a start marker followed by
+// a separator and then an end marker. Stray markers, as in documentation
+// about git, do not form a block.
+type conflictTracker struct {
+	start, sep int
+}
+
+// line feeds the marker kind of line n and returns a CONFLICT item when it
+// closes a block.
+func (c *conflictTracker) line(n int, kind int) (Todo, bool) {
+	switch kind {
+	case markerStart:
+		c.start, c.sep = n, 0
+	case markerSep:
+		if c.start > 0 {
+			c.sep = n
+		}
+	case markerEnd:
+		if c.start > 0 && c.sep > 0 {
+			t := Todo{Location: Location{Line: c.start, Column: 1}, Tag: TagConflict, Text: fmt.Sprintf("unresolved merge conflict (lines %d-%d)", c.start, n)}
+			c.start, c.sep = 0, 0
+			return t, true
+		}
+		c.start, c.sep = 0, 0
+	}
+	return Todo{}, false
+}
@@ -0,0 +1,133 @@
+package todo
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const conflictDoc = "# Resolving conflicts\n\n" +
+	"Git marks a conflict with a line starting `<<<<<<< HEAD`, a `=======`\n" +
+	"separator and a closing `>>>>>>> branch` line.\n\n" +
+	"Heading\n" +
+	"=======\n\n" +
+	"<<<<<<< is also how the first marker looks on its own.\n"
+
+const conflictedFile = "package main\n\n" +
+	"func main() {\n" +
+	"<<<<<<< HEAD\n" +
+	"\tprintln(\"ours\")\n" +
+	"=======\n" +
+	"\tprintln(\"theirs\")\n" +
+	">>>>>>> feature\n" +
+	"}\n"
+
+func scanWithDetect(t *testing.T, name, content string, opts ScanOptions) []Todo {
+	t.Helper()
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	got, err := s.ScanString(name, content)
+	if err != nil {
+		t.Fatalf("ScanString: %v", err)
+	}
+	return got
+}
+
+func TestDetectConflicts(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Detect = []string{DetectConflicts}
+
+	if got := scanWithDetect(t, "docs/conflicts.md", conflictDoc, opts); len(got) != 0 {
+		t.Fatalf("documentation about markers must not match: %+v", got)
+	}
+	got := scanWithDetect(t, "main.go", conflictedFile+"// TODO: after\n", opts)
+	if len(got) != 2 {
+		t.Fatalf("got %d items, want the conflict and the TODO: %+v", len(got), got)
+	}
+	c := got[0]
+	if c.Tag != TagConflict || c.Line != 4 || c.Text != "unresolved merge conflict (lines 4-8)" {
+		t.Fatalf("unexpected conflict item: %+v", c)
+	}
+	if got[1].Tag != "TODO" || got[1].Line != 10 {
+		t.Fatalf("items should stay in line order: %+v", got)
+	}
+
+	// Without the detector the same file only yields its tags.
+	if got := scanWithDetect(t, "main.go", conflictedFile, DefaultScanOptions()); len(got) != 0 {
+		t.Fatalf("conflicts reported without --detect: %+v", got)
+	}
+}
+
+func TestConflictMarker(t *testing.T) {
+	cases := map[string]int{
+		"<<<<<<< HEAD":      markerStart,
+		"<<<<<<<":           markerStart,
+		"=======":           markerSep,
+		"======= ":          markerSep,
+		">>>>>>> a1b2c3":    markerEnd,
+		"<<<<<<<< HEAD":     markerNone,
+		"========":          markerNone,
+		" <<<<<<< HEAD":     markerNone,
+		"=======  heading":  markerNone,
+		"x >>>>>>> feature": markerNone,
+	}
+	for line, want := range cases {
+		if got := conflictMarker(line); got != want {
+			t.Errorf("conflictMarker(%q) = %d, want %d", line, got, want)
+		}
+	}
+}
+
+func TestDetectDebug(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Detect = []string{DetectDebug}
+	cases := []struct {
+		name, content string
+		want          []string
+	}{
+		{"app.js", "const a = 1;\n  console.log(a);\n  debugger;\nlogger.log(a)\n", []string{"console.log(a);", "debugger;"}},
+		{"main.go", "fmt.Println(\"DEBUG\", x)\nfmt.Println(\"done\")\n", []string{"fmt.Println(\"DEBUG\", x)"}},
+		{"tool.py", "import pdb; pdb.set_trace()\nbreakpoint()\n", []string{"import pdb; pdb.set_trace()", "breakpoint()"}},
+		{"app.rb", "binding.pry\n", []string{"binding.pry"}},
+		{"notes.txt", "console.log(x)\n", nil},
+	}
+	for _, tc := range cases {
+		got := scanWithDetect(t, tc.name, tc.content, opts)
+		var texts []string
+		for _, it := range got {
+			if it.Tag != TagDebug {
+				t.Fatalf("%s: unexpected tag %q", tc.name, it.Tag)
+			}
+			texts = append(texts, it.Text)
+		}
+		if strings.Join(texts, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("%s: got %q, want %q", tc.name, texts, tc.want)
+		}
+	}
+
+	// Custom patterns extend the built-in table.
+	ext, re, err := ParseDebugPattern(`go:log\.Printf\("DEBUG`)
+	if err != nil || ext != ".go" {
+		t.Fatalf("ParseDebugPattern: %q, %v", ext, err)
+	}
+	opts.DebugPatterns = map[string][]*regexp.Regexp{ext: {re}}
+	got := scanWithDetect(t, "main.go", "log.Printf(\"DEBUG %v\", x)\n", opts)
+	if len(got) != 1 || got[0].Column != 1 {
+		t.Fatalf("custom pattern not applied: %+v", got)
+	}
+}
+
+func TestDetect_Errors(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Detect = []string{"secrets"}
+	if _, err := NewScanner(opts); err == nil {
+		t.Fatalf("expected an error for an unknown detector")
+	}
+	for _, bad := range []string{"nocolon", ":expr", ".go:("} {
+		if _, _, err := ParseDebugPattern(bad); err == nil {
+			t.Errorf("ParseDebugPattern(%q): expected an error", bad)
+		}
+	}
+}
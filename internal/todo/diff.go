@@ -0,0 +1,159 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Diff lists the items added and resolved between two reports.
+type Diff struct {
+	Added    []Todo `json:"added"`
+	Resolved []Todo `json:"resolved"`
+}
+
+// DiffFile is the part of a Diff in one file.
+type DiffFile struct {
+	File     string
+	Added    []Todo
+	Resolved []Todo
+}
+
+// Item matching modes of DiffItemsBy.
+const (
+	// MatchByFingerprint matches items by Fingerprint (file, tag and text),
+	// ignoring their line.
+	MatchByFingerprint = "fingerprint"
+	// MatchByExact also requires matched items to be on the same line.
+	MatchByExact = "exact"
+)
+
+// MatchModes lists the modes accepted by DiffItemsBy.
+var MatchModes = []string{MatchByFingerprint, MatchByExact}
+
+// DiffItems compares the items of an old and a new report by Fingerprint,
+// so items that only moved to another line are neither added nor resolved.
+// Items sharing a fingerprint are matched by count. Both lists are in
+// file/line order.
+func DiffItems(old, new []Todo) Diff {
+	return DiffItemsBy(old, new, MatchByFingerprint)
+}
+
+// DiffItemsBy is like DiffItems with items matched according to matchBy,
+// one of MatchModes: with MatchByExact an item that moved to another line
+// is listed as resolved at its old line and added at its new one.
+func DiffItemsBy(old, new []Todo, matchBy string) Diff {
+	key := fingerprint
+	if matchBy == MatchByExact {
+		key = func(t Todo) string { return fingerprint(t) + ":" + strconv.Itoa(t.Line) }
+	}
+	remaining := make(map[string]int, len(old))
+	for _, t := range old {
+		remaining[key(t)]++
+	}
+	var d Diff
+	for _, t := range new {
+		fp := key(t)
+		if remaining[fp] > 0 {
+			remaining[fp]--
+			continue
+		}
+		d.Added = append(d.Added, t)
+	}
+	// Whatever is left over in old was resolved; walk old from the end so
+	// the earliest duplicates are the ones matched.
+	for i := len(old) - 1; i >= 0; i-- {
+		fp := key(old[i])
+		if remaining[fp] > 0 {
+			remaining[fp]--
+			d.Resolved = append(d.Resolved, old[i])
+		}
+	}
+	sortTodos(d.Added)
+	sortTodos(d.Resolved)
+	return d
+}
+
+// Files groups d by file, in path order.
+func (d Diff) Files() []DiffFile {
+	byFile := make(map[string]*DiffFile)
+	get := func(file string) *DiffFile {
+		f := byFile[file]
+		if f == nil {
+			f = &DiffFile{File: file}
+			byFile[file] = f
+		}
+		return f
+	}
+	for _, t := range d.Added {
+		f := get(t.File)
+		f.Added = append(f.Added, t)
+	}
+	for _, t := range d.Resolved {
+		f := get(t.File)
+		f.Resolved = append(f.Resolved, t)
+	}
+	files := make([]DiffFile, 0, len(byFile))
+	for _, f := range byFile {
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].File < files[j].File })
+	return files
+}
+
+// LoadJSONReport reads the items of a JSON report written by
+// GenerateJSONReport, in either shape.
+func LoadJSONReport(path string) ([]Todo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rep struct {
+		Todos []Todo            `json:"todos"`
+		Files map[string][]Todo `json:"files"`
+	}
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return nil, fmt.Errorf("%s: not a JSON report: %w", path, err)
+	}
+	items := rep.Todos
+	for _, fileItems := range rep.Files {
+		items = append(items, fileItems...)
+	}
+	return items, nil
+}
+
+// diffReportData feeds the diff HTML template.
+type diffReportData struct {
+	Old, New        string
+	Added, Resolved int
+	Files           []DiffFile
+}
+
+// GenerateDiffHTMLReport writes d as an HTML page to the given output path
+// using the default OS-backed writer; old and new name the compared reports.
+func GenerateDiffHTMLReport(d Diff, old, new, output string) error {
+	return GenerateDiffHTMLReportWithWriter(d, old, new, output, OSFileWriter{})
+}
+
+// GenerateDiffHTMLReportWithWriter allows dependency injection of writers
+// for testing. The page shows the added and resolved counts, then one
+// section per file with added items in green and resolved ones in red.
+func GenerateDiffHTMLReportWithWriter(d Diff, old, new, output string, w FileWriter) error {
+	tmpl, err := template.ParseFS(templatesFS, "templates/diff.html")
+	if err != nil {
+		return fmt.Errorf("parse diff template: %w", err)
+	}
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+	return tmpl.Execute(f, diffReportData{
+		Old: old, New: new,
+		Added: len(d.Added), Resolved: len(d.Resolved),
+		Files: d.Files(),
+	})
+}
@@ -0,0 +1,110 @@
+package todo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffItems(t *testing.T) {
+	old := []Todo{
+		{Location: Location{File: "a.go", Line: 3}, Tag: "TODO", Text: "kept"},
+		{Location: Location{File: "a.go", Line: 9}, Tag: "FIXME", Text: "fixed"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "TODO", Text: "twice"},
+		{Location: Location{File: "b.go", Line: 5}, Tag: "TODO", Text: "twice"},
+	}
+	cur := []Todo{
+		{Location: Location{File: "a.go", Line: 7}, Tag: "TODO", Text: "kept"}, // moved
+		{Location: Location{File: "b.go", Line: 1}, Tag: "TODO", Text: "twice"},
+		{Location: Location{File: "c.go", Line: 2}, Tag: "BUG", Text: "new"},
+	}
+	d := DiffItems(old, cur)
+	if len(d.Added) != 1 || d.Added[0].File != "c.go" {
+		t.Errorf("added = %+v", d.Added)
+	}
+	if len(d.Resolved) != 2 || d.Resolved[0].Text != "fixed" || d.Resolved[1].Line != 5 {
+		t.Errorf("resolved = %+v", d.Resolved)
+	}
+	files := d.Files()
+	if len(files) != 3 || files[0].File != "a.go" || len(files[2].Added) != 1 {
+		t.Errorf("files = %+v", files)
+	}
+}
+
+func TestDiffItemsBy_LineShifted(t *testing.T) {
+	// Three lines inserted at the top of a.go push both of its items down.
+	old := []Todo{
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "handle errors"},
+		{Location: Location{File: "a.go", Line: 8}, Tag: "FIXME", Text: "leaks the fd"},
+		{Location: Location{File: "b.go", Line: 4}, Tag: "NOTE", Text: "untouched"},
+	}
+	cur := []Todo{
+		{Location: Location{File: "a.go", Line: 5}, Tag: "TODO", Text: "handle  errors"}, // reformatted
+		{Location: Location{File: "a.go", Line: 11}, Tag: "FIXME", Text: "leaks the fd"},
+		{Location: Location{File: "b.go", Line: 4}, Tag: "NOTE", Text: "untouched"},
+	}
+	if d := DiffItemsBy(old, cur, MatchByFingerprint); len(d.Added)+len(d.Resolved) != 0 {
+		t.Errorf("fingerprint: shifted items listed: %+v", d)
+	}
+	d := DiffItemsBy(old, cur, MatchByExact)
+	if len(d.Added) != 2 || d.Added[0].Line != 5 || d.Added[1].Line != 11 {
+		t.Errorf("exact: added = %+v", d.Added)
+	}
+	if len(d.Resolved) != 2 || d.Resolved[0].Line != 2 || d.Resolved[1].Line != 8 {
+		t.Errorf("exact: resolved = %+v", d.Resolved)
+	}
+
+	// Reports store a fingerprint, which matching prefers to the text.
+	old[0].Fingerprint, cur[0].Fingerprint = "abc", "abc"
+	old[0].Text = "TODO: handle errors"
+	if d := DiffItemsBy(old, cur, MatchByExact); len(d.Added) != 2 {
+		t.Errorf("exact with stored fingerprints: added = %+v", d.Added)
+	}
+}
+
+func TestLoadJSONReport_BothShapes(t *testing.T) {
+	dir := t.TempDir()
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}, {Location: Location{File: "b.go", Line: 2}, Tag: "BUG", Text: "y"}}
+	for _, shape := range []string{JSONShapeFlat, JSONShapeNested} {
+		out := filepath.Join(dir, shape+".json")
+		if err := GenerateJSONReport(items, out, ReportOptions{JSONShape: shape}); err != nil {
+			t.Fatalf("GenerateJSONReport: %v", err)
+		}
+		got, err := LoadJSONReport(out)
+		if err != nil {
+			t.Fatalf("LoadJSONReport(%s): %v", shape, err)
+		}
+		if d := DiffItems(items, got); len(d.Added)+len(d.Resolved) != 0 {
+			t.Errorf("%s: round trip differs: %+v", shape, d)
+		}
+	}
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("nope"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadJSONReport(bad); err == nil {
+		t.Errorf("expected an error for a non-JSON file")
+	}
+}
+
+func TestGenerateDiffHTMLReport(t *testing.T) {
+	d := Diff{
+		Added:    []Todo{{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "<new>"}},
+		Resolved: []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "BUG", Text: "old"}, {Location: Location{File: "b.go", Line: 4}, Tag: "NOTE", Text: "gone"}},
+	}
+	var buf bytes.Buffer
+	if err := GenerateDiffHTMLReportWithWriter(d, "old.json", "new.json", "diff.html", jsonMockFileWriter{buf: &buf}); err != nil {
+		t.Fatalf("GenerateDiffHTMLReportWithWriter: %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"+1 added", "−2 resolved", "<h2>a.go</h2>", "<h2>b.go</h2>", `<tr class="added">`, `<tr class="resolved">`, "&lt;new&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in diff HTML", want)
+		}
+	}
+	if strings.Index(html, "<h2>a.go</h2>") > strings.Index(html, "<h2>b.go</h2>") {
+		t.Errorf("files should be in path order")
+	}
+}
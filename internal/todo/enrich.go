@@ -0,0 +1,112 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+)
+
+// Enricher attaches metadata to scanned items, e.g. owners or history, or
+// fields of Todo.Extra. Enrich returns the enriched items; it may drop or
+// reorder them, and may modify the slice it is given.
+type Enricher interface {
+	Enrich(items []Todo) ([]Todo, error)
+}
+
+// namedEnricher is implemented by enrichers that name themselves in errors.
+type namedEnricher interface {
+	Name() string
+}
+
+// Enrich runs enrichers over items in order, each on the output of the
+// previous one. The first error aborts the chain, wrapped with the failing
+// enricher's position and name. items itself is not modified.
+func Enrich(items []Todo, enrichers []Enricher) ([]Todo, error) {
+	if len(enrichers) == 0 {
+		return items, nil
+	}
+	out := make([]Todo, len(items))
+	copy(out, items)
+	for i, e := range enrichers {
+		var err error
+		if out, err = e.Enrich(out); err != nil {
+			return nil, fmt.Errorf("enricher %d (%s): %w", i+1, enricherName(e), err)
+		}
+	}
+	return out, nil
+}
+
+// enricherName returns e's Name, or its type when it has none.
+func enricherName(e Enricher) string {
+	if n, ok := e.(namedEnricher); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+// IntroducedEnricher records when each item was added, from git history.
+// See TraceIntroduced.
+type IntroducedEnricher struct {
+	// Ctx bounds the whole trace; context.Background() when nil.
+	Ctx context.Context
+	// Dir is the directory item paths are relative to.
+	Dir     string
+	Options IntroducedOptions
+	// Git runs the history lookups; ExecGitRunner when nil.
+	Git GitRunner
+}
+
+// Name implements namedEnricher.
+func (IntroducedEnricher) Name() string { return "introduced" }
+
+// Enrich implements Enricher. Failed lookups leave items untouched rather
+// than failing.
+func (e IntroducedEnricher) Enrich(items []Todo) ([]Todo, error) {
+	ctx, git := e.Ctx, e.Git
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if git == nil {
+		git = ExecGitRunner{}
+	}
+	return TraceIntroduced(ctx, e.Dir, items, e.Options, git), nil
+}
+
+// OwnersEnricher sets Owners from a CODEOWNERS file. See CodeOwners.Assign.
+type OwnersEnricher struct {
+	Owners *CodeOwners
+	// Dir is the directory item paths are relative to.
+	Dir string
+}
+
+// Name implements namedEnricher.
+func (OwnersEnricher) Name() string { return "owners" }
+
+// Enrich implements Enricher.
+func (e OwnersEnricher) Enrich(items []Todo) ([]Todo, error) {
+	return e.Owners.Assign(items, e.Dir), nil
+}
+
+// RefsEnricher sets Refs from Text. Scanners already do this for the items
+// they find; it is for items built or edited elsewhere.
+type RefsEnricher struct{}
+
+// Name implements namedEnricher.
+func (RefsEnricher) Name() string { return "refs" }
+
+// Enrich implements Enricher.
+func (RefsEnricher) Enrich(items []Todo) ([]Todo, error) {
+	for i := range items {
+		items[i].Refs = ExtractRefs(items[i].Text)
+	}
+	return items, nil
+}
+
+// ExtraValues returns t's Extra values for cols, in order; missing ones are
+// empty.
+func (t Todo) ExtraValues(cols []string) []string {
+	vals := make([]string, len(cols))
+	for i, c := range cols {
+		vals[i] = t.Extra[c]
+	}
+	return vals
+}
@@ -0,0 +1,115 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// catalogEnricher sets Extra fields from a fixed service catalog.
+type catalogEnricher struct{ services map[string]string }
+
+func (c catalogEnricher) Enrich(items []Todo) ([]Todo, error) {
+	for i := range items {
+		items[i].Extra = map[string]string{"service": c.services[items[i].File], "risk": "high"}
+	}
+	return items, nil
+}
+
+type failingEnricher struct{}
+
+func (failingEnricher) Name() string { return "catalog" }
+
+func (failingEnricher) Enrich([]Todo) ([]Todo, error) { return nil, errors.New("catalog unreachable") }
+
+type errEnricher struct{}
+
+func (errEnricher) Enrich([]Todo) ([]Todo, error) { return nil, errors.New("boom") }
+
+func TestEnrich_ExtraColumnsInReports(t *testing.T) {
+	items := []Todo{{Location: Location{File: "api/a.go", Line: 3}, Tag: "TODO", Text: "retry"}}
+	opts := ReportOptions{
+		Enrichers:    []Enricher{catalogEnricher{services: map[string]string{"api/a.go": "billing"}}},
+		ExtraColumns: []string{"service", "risk"},
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var body struct {
+		Todos []struct {
+			Extra map[string]string `json:"extra"`
+		} `json:"todos"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Todos) != 1 || body.Todos[0].Extra["service"] != "billing" || body.Todos[0].Extra["risk"] != "high" {
+		t.Fatalf("unexpected extra: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatalf("md: %v", err)
+	}
+	md := buf.String()
+	if !strings.Contains(md, "| File | Line | Tag | Text | service | risk |\n|------|------:|-----:|------|------|------|\n") ||
+		!strings.Contains(md, "| api/a.go | 3 | TODO | TODO: retry | billing | high |\n") {
+		t.Fatalf("extra columns missing or out of order:\n%s", md)
+	}
+
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(items, "ignored.html", mockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatalf("html: %v", err)
+	}
+	html := buf.String()
+	if i, j := strings.Index(html, "<th>service</th>"), strings.Index(html, "<th>risk</th>"); i < 0 || j < i {
+		t.Fatalf("extra headers missing or out of order")
+	}
+	if !strings.Contains(html, `<td class="col-extra-val">billing</td>`) {
+		t.Fatalf("extra cell missing")
+	}
+	if items[0].Extra != nil {
+		t.Fatalf("input items were modified: %+v", items)
+	}
+}
+
+func TestEnrich_ErrorNamesEnricher(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO"}}
+	_, err := Enrich(items, []Enricher{RefsEnricher{}, failingEnricher{}})
+	if err == nil || !strings.Contains(err.Error(), "enricher 2 (catalog): catalog unreachable") {
+		t.Fatalf("err = %v", err)
+	}
+	_, err = Enrich(items, []Enricher{catalogEnricher{}, errEnricher{}})
+	if err == nil || !strings.Contains(err.Error(), "enricher 2 (todo.errEnricher)") {
+		t.Fatalf("err = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "x.json", jsonMockFileWriter{buf: &buf}, ReportOptions{Enrichers: []Enricher{failingEnricher{}}}); err == nil {
+		t.Fatal("expected the report to fail")
+	}
+}
+
+func TestBuiltinEnrichers(t *testing.T) {
+	co, err := ParseCodeOwners("/repo", strings.NewReader("api/ @team-api\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []Todo{
+		{Location: Location{File: "api/a.go"}, Tag: "TODO", Text: "see #12"},
+		{Location: Location{File: "web/b.go"}, Tag: "TODO", Text: "later"},
+	}
+	got, err := Enrich(items, []Enricher{RefsEnricher{}, OwnersEnricher{Owners: co, Dir: "/repo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got[0].Refs) != 1 || got[0].Refs[0] != "#12" || len(got[0].Owners) != 1 || got[0].Owners[0] != "@team-api" {
+		t.Fatalf("api item not enriched: %+v", got[0])
+	}
+	if got[1].Refs != nil || got[1].Owners != nil {
+		t.Fatalf("web item should have no refs or owners: %+v", got[1])
+	}
+}
@@ -0,0 +1,65 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// filterPathPrefix marks a filter pattern that applies to the file path
+// instead of the item text.
+const filterPathPrefix = "path:"
+
+// FilterRule drops items whose text, or file path when Path is set, matches
+// Pattern.
+type FilterRule struct {
+	Pattern *regexp.Regexp
+	Path    bool
+}
+
+// ParseFilterRule compiles a filter pattern. Patterns starting with "path:"
+// match the reported file path; all others match the item text.
+func ParseFilterRule(s string) (FilterRule, error) {
+	rule := FilterRule{}
+	expr := s
+	if rest, ok := strings.CutPrefix(s, filterPathPrefix); ok {
+		rule.Path = true
+		expr = rest
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return FilterRule{}, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+	rule.Pattern = re
+	return rule, nil
+}
+
+// matches reports whether the rule selects t.
+func (r FilterRule) matches(t Todo) bool {
+	if r.Path {
+		return r.Pattern.MatchString(t.File)
+	}
+	return r.Pattern.MatchString(t.Text)
+}
+
+// FilterItems returns the items matching none of rules, and the number of
+// items dropped.
+func FilterItems(items []Todo, rules []FilterRule) ([]Todo, int) {
+	if len(rules) == 0 {
+		return items, 0
+	}
+	out := make([]Todo, 0, len(items))
+	for _, t := range items {
+		drop := false
+		for _, r := range rules {
+			if r.matches(t) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			out = append(out, t)
+		}
+	}
+	return out, len(items) - len(out)
+}
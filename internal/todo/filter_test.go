@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustFilterRules(t *testing.T, patterns ...string) []FilterRule {
+	t.Helper()
+	rules := make([]FilterRule, 0, len(patterns))
+	for _, p := range patterns {
+		r, err := ParseFilterRule(p)
+		if err != nil {
+			t.Fatalf("ParseFilterRule(%q): %v", p, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func filterLocations(items []Todo) []string {
+	out := make([]string, 0, len(items))
+	for _, t := range items {
+		out = append(out, fmt.Sprintf("%s:%d", t.File, t.Line))
+	}
+	return out
+}
+
+func TestFilterItems(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "third_party/sdk/client.go", Line: 1}, Tag: "TODO", Text: "autogenerated stub"},
+		{Location: Location{File: "third_party/sdk/client.go", Line: 9}, Tag: "FIXME", Text: "real bug in vendored code"},
+		{Location: Location{File: "internal/app.go", Line: 3}, Tag: "TODO", Text: "autogenerated stub"},
+		{Location: Location{File: "internal/app.go", Line: 4}, Tag: "TODO", Text: "handle errors"},
+	}
+	cases := []struct {
+		name     string
+		patterns []string
+		want     []string
+		filtered int
+	}{
+		{"none", nil, []string{"third_party/sdk/client.go:1", "third_party/sdk/client.go:9", "internal/app.go:3", "internal/app.go:4"}, 0},
+		{"text", []string{"^autogenerated stub$"}, []string{"third_party/sdk/client.go:9", "internal/app.go:4"}, 2},
+		{"path", []string{"path:^third_party/"}, []string{"internal/app.go:3", "internal/app.go:4"}, 2},
+		{"text or path", []string{"path:^third_party/", "stub"}, []string{"internal/app.go:4"}, 3},
+		{"path pattern ignores text", []string{"path:stub"}, []string{"third_party/sdk/client.go:1", "third_party/sdk/client.go:9", "internal/app.go:3", "internal/app.go:4"}, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, n := FilterItems(items, mustFilterRules(t, c.patterns...))
+			if n != c.filtered {
+				t.Fatalf("filtered = %d, want %d", n, c.filtered)
+			}
+			if locs := filterLocations(got); !reflect.DeepEqual(locs, c.want) {
+				t.Fatalf("kept %v, want %v", locs, c.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterRule_InvalidPattern(t *testing.T) {
+	for _, p := range []string{"(unclosed", "path:[a-"} {
+		if _, err := ParseFilterRule(p); err == nil {
+			t.Fatalf("expected error for %q", p)
+		} else if want := "\"" + p + "\""; !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should name the pattern %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitRunner abstracts invoking git so history-based features can be tested
+// with scripted output instead of a real repository.
+type GitRunner interface {
+	// Run executes git with args inside dir and returns its standard output.
+	Run(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// ExecGitRunner implements GitRunner using the git binary on PATH.
+type ExecGitRunner struct{}
+
+// Run executes git via os/exec. Stderr is folded into the returned error.
+func (ExecGitRunner) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.String(), nil
+}
+
+// TrackedFiles returns the files git tracks below dir, relative to it and
+// slash-separated, for ScanOptions.Files. It fails when dir is not inside a
+// git working tree.
+func TrackedFiles(ctx context.Context, git GitRunner, dir string) ([]string, error) {
+	out, err := git.Run(ctx, dir, "ls-files", "-z", "--cached")
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
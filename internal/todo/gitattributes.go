@@ -0,0 +1,101 @@
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitAttrRule is a .gitattributes line that sets or unsets
+// linguist-generated. Pattern matching reuses the gitignore rule semantics.
+type gitAttrRule struct {
+	rule      gitIgnoreRule
+	generated bool
+}
+
+// gitAttributes holds the linguist-generated rules of a repository.
+type gitAttributes struct {
+	rules []gitAttrRule
+}
+
+// loadGitAttributes loads linguist-generated rules from a .gitattributes file
+// at base. If not present, or no line mentions linguist-generated, it returns nil.
+func loadGitAttributes(base string) (*gitAttributes, error) {
+	p := filepath.Join(base, ".gitattributes")
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil // no .gitattributes is fine
+	}
+	defer SafeClose(f, p)
+
+	var rules []gitAttrRule
+	sc := bufio.NewScanner(f)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		generated, ok := linguistGenerated(fields[1:])
+		if !ok {
+			continue
+		}
+		pat := fields[0]
+		anchored := strings.HasPrefix(pat, "/")
+		pat = strings.TrimPrefix(pat, "/")
+		if pat == "" {
+			continue
+		}
+		rules = append(rules, gitAttrRule{
+			rule: gitIgnoreRule{
+				pattern:  pat,
+				anchored: anchored,
+				hasSlash: strings.Contains(pat, "/"),
+				source:   ".gitattributes",
+				line:     lineNum,
+			},
+			generated: generated,
+		})
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return &gitAttributes{rules: rules}, nil
+}
+
+// linguistGenerated inspects attribute tokens and reports the value the line
+// assigns to linguist-generated, if any. The last occurrence wins.
+func linguistGenerated(attrs []string) (value bool, ok bool) {
+	for _, a := range attrs {
+		switch a {
+		case "linguist-generated", "linguist-generated=true":
+			value, ok = true, true
+		case "-linguist-generated", "linguist-generated=false", "!linguist-generated":
+			value, ok = false, true
+		}
+	}
+	return value, ok
+}
+
+// generated reports whether the file at rel (relative to the repository
+// root) is marked linguist-generated, and by which rule. Later lines override
+// earlier ones, as in git.
+func (g *gitAttributes) generated(rel string) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+	rel = normalizePath(rel)
+	var decided *gitAttrRule
+	for i := range g.rules {
+		if g.rules[i].rule.matches(rel, false) {
+			decided = &g.rules[i]
+		}
+	}
+	if decided == nil || !decided.generated {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d: %s linguist-generated", decided.rule.source, decided.rule.line, decided.rule.pattern), true
+}
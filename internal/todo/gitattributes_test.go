@@ -0,0 +1,128 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoadGitAttributes_GeneratedRules(t *testing.T) {
+	root := t.TempDir()
+	content := "# generated code\n*.pb.go linguist-generated=true\n/gen/** linguist-generated\n*.md text\ngen/keep.go -linguist-generated\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitattributes"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write .gitattributes: %v", err)
+	}
+	ga, err := loadGitAttributes(root)
+	if err != nil || ga == nil {
+		t.Fatalf("expected rules, got %v %v", ga, err)
+	}
+	cases := []struct {
+		path string
+		want bool
+		rule string
+	}{
+		{"api/service.pb.go", true, ".gitattributes:2: *.pb.go linguist-generated"},
+		{"gen/models.go", true, ".gitattributes:3: gen/** linguist-generated"},
+		{"gen/keep.go", false, ""},
+		{"README.md", false, ""},
+		{"main.go", false, ""},
+	}
+	for _, c := range cases {
+		rule, got := ga.generated(c.path)
+		if got != c.want || rule != c.rule {
+			t.Errorf("generated(%q) = %q, %v; want %q, %v", c.path, rule, got, c.rule, c.want)
+		}
+	}
+}
+
+func TestLoadGitAttributes_MissingOrIrrelevant(t *testing.T) {
+	root := t.TempDir()
+	if ga, _ := loadGitAttributes(root); ga != nil {
+		t.Fatalf("expected nil without .gitattributes")
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitattributes"), []byte("* text=auto\n"), 0o644); err != nil {
+		t.Fatalf("write .gitattributes: %v", err)
+	}
+	if ga, _ := loadGitAttributes(root); ga != nil {
+		t.Fatalf("expected nil when no linguist-generated rules exist")
+	}
+}
+
+func TestScanDir_SkipGenerated(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "")
+	mustWriteFile(t, root, ".gitattributes", "*.pb.go linguist-generated\n")
+	mustWriteFile(t, root, "api.pb.go", "// TODO: generated\n")
+	mustWriteFile(t, root, "main.go", "// TODO: handwritten\n")
+
+	opts := DefaultScanOptions()
+	items, err := ScanDirWithOptions(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("generated files should be scanned by default, got %#v", items)
+	}
+
+	opts.SkipGenerated = true
+	items, err = ScanDirWithOptions(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	if len(items) != 1 || items[0].File != "main.go" {
+		t.Fatalf("expected only main.go with --skip-generated, got %#v", items)
+	}
+}
+
+func TestScanDir_SkipGeneratedHeader(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "mock.go", "// Code generated by MockGen. DO NOT EDIT.\n// Source: api.go\n\npackage api\n// TODO: generated\n")
+	mustWriteFile(t, root, "late.go", "package late\n"+strings.Repeat("\n", 12)+"// Code generated by hand. DO NOT EDIT.\n// TODO: kept\n")
+	mustWriteFile(t, root, "gen.py", "# @generated by codegen\n# TODO: generated python\n")
+	mustWriteFile(t, root, "main.go", "// TODO: handwritten\n")
+
+	opts := DefaultScanOptions()
+	opts.SkipGenerated = true
+	opts.Audit = true
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	got := map[string]bool{}
+	for _, it := range res.Items {
+		got[it.File] = true
+	}
+	if got["mock.go"] || !got["late.go"] || !got["main.go"] || !got["gen.py"] {
+		t.Fatalf("unexpected files with items: %v", got)
+	}
+	if res.Stats.Skipped != 1 {
+		t.Fatalf("Skipped = %d, want 1", res.Stats.Skipped)
+	}
+	if len(res.Audit.Entries) != 1 || res.Audit.Entries[0].Reason != SkipReasonGenerated ||
+		res.Audit.Entries[0].Rule != "header:1: // Code generated by MockGen. DO NOT EDIT." {
+		t.Fatalf("unexpected audit: %+v", res.Audit.Entries)
+	}
+
+	// A custom expression covers other generators.
+	opts.GeneratedHeader = regexp.MustCompile(`@generated`)
+	res, err = ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	for _, it := range res.Items {
+		if it.File == "gen.py" {
+			t.Fatalf("gen.py should be skipped with a custom header expression")
+		}
+	}
+
+	// Without SkipGenerated the header is ignored.
+	items, err := ScanDirWithOptions(root, DefaultScanOptions(), OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("want 4 items without skipping, got %d", len(items))
+	}
+}
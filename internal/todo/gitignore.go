@@ -2,10 +2,12 @@ package todo
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // gitIgnoreRule represents a single .gitignore rule.
@@ -14,9 +16,13 @@ import (
 // - negation with leading '!'
 // - trailing '/' means directory-only rule
 // - leading '/' anchors the pattern to the repository root
+// - leading '**/' matches in any directory, as in git: "**/dist/" ignores
+//   dist/ and a/b/dist/, "**/a/b" ignores a/b anywhere
 // - patterns without '/' match against the basename
 // - patterns with '/' can match from any path segment downwards
 // - globbing uses path.Match semantics with forward slashes
+// - a backslash escapes the next character: "\#" and "\!" start literal
+//   patterns, "\ " keeps a trailing space, "\*" matches a literal '*'
 // It is not a full .gitignore implementation, but adequate for typical setups
 // (e.g., node_modules/, vendor/, *.tmp, build/**, etc.).
 
@@ -24,22 +30,119 @@ type gitIgnoreRule struct {
 	pattern  string
 	negative bool
 	anchored bool
+	// anyDepth records a leading "**/": the rest of the pattern matches
+	// in any directory.
+	anyDepth bool
 	dirOnly  bool
 	// hasSlash precomputed for performance
 	hasSlash bool
+	// kind and segs say how gitIgnore matches the rule, and lead and tail
+	// are the literal start and end of its last segment, which a name must
+	// have to match; see compile.
+	kind       ruleKind
+	segs       []string
+	lead, tail string
+	// source and line locate the rule for audit output
+	source string
+	line   int
+}
+
+// ruleKind is the way gitIgnore matches a rule, chosen once when the rule is
+// parsed so matching a path does no per-rule analysis.
+type ruleKind uint8
+
+const (
+	// ruleGeneric rules go through matches: character classes may match
+	// '/' and escapes may hide one, so they cannot be split into segments.
+	ruleGeneric ruleKind = iota
+	// ruleBase rules have no slash and match the basename.
+	ruleBase
+	// ruleAnchored rules match the whole path, segment by segment.
+	ruleAnchored
+	// ruleSuffix rules have a slash but no anchor, and match the last
+	// len(segs) segments of the path.
+	ruleSuffix
+)
+
+// compile sets the kind of r and, for the segment kinds, splits its pattern
+// at '/'. Without classes and escapes, '/' in a pattern only matches '/', and
+// '*' and '?' never do, so a pattern of n segments only matches the path or
+// path suffix of n segments, each segment matching its own. Such patterns
+// also get the literal lead and tail around the wildcards of their last
+// segment, to turn most names down without path.Match.
+func (r *gitIgnoreRule) compile() {
+	plain := !strings.ContainsAny(r.pattern, `[\`)
+	switch {
+	case r.anchored && !r.anyDepth:
+		r.kind = ruleAnchored
+	case !r.hasSlash:
+		r.kind = ruleBase
+	default:
+		r.kind = ruleSuffix
+	}
+	if !plain {
+		if r.kind != ruleBase {
+			r.kind = ruleGeneric
+		}
+		return
+	}
+	if r.kind != ruleBase {
+		r.segs = strings.Split(r.pattern, "/")
+	}
+	last := r.pattern[strings.LastIndexByte(r.pattern, '/')+1:]
+	if i := strings.IndexAny(last, "*?"); i >= 0 {
+		r.lead, r.tail = last[:i], last[strings.LastIndexAny(last, "*?")+1:]
+	} else {
+		r.lead = last
+	}
+}
+
+// bounded reports whether name has the lead and tail of r.
+func (r *gitIgnoreRule) bounded(name string) bool {
+	return strings.HasPrefix(name, r.lead) && strings.HasSuffix(name, r.tail)
+}
+
+// origin describes where the rule was defined, e.g. ".gitignore:3: vendor/".
+func (r gitIgnoreRule) origin() string {
+	raw := r.pattern
+	if r.anyDepth {
+		raw = "**/" + raw
+	}
+	if r.anchored {
+		raw = "/" + raw
+	}
+	if r.dirOnly {
+		raw += "/"
+	}
+	if r.negative {
+		raw = "!" + raw
+	}
+	return fmt.Sprintf("%s:%d: %s", r.source, r.line, raw)
 }
 
 type gitIgnore struct {
 	root  string // repository root used for anchoring
 	rules []gitIgnoreRule
+
+	mu sync.Mutex
+	// dirs memoizes candidates per directory, as every entry of a
+	// directory shares the directory part of each match.
+	dirs map[string][]int
+	// memoRules is the number of rules dirs was computed for.
+	memoRules int
 }
 
-// findRepoRoot returns the nearest ancestor directory that contains a .git directory.
-// If none is found, it returns the input dir.
+// maxMemoDirs bounds gitIgnore.dirs; the memo starts over when it is full.
+// A walk finishes a directory's entries before moving on, so older entries
+// are rarely needed again.
+const maxMemoDirs = 4096
+
+// findRepoRoot returns the nearest ancestor directory that contains a .git
+// entry (see isRepoRoot). If none is found, it returns the input dir.
 func findRepoRoot(start string) string {
 	d := start
 	for {
-		if fi, err := os.Stat(filepath.Join(d, ".git")); err == nil && fi.IsDir() {
+		if isRepoRoot(d) {
 			return d
 		}
 		parent := filepath.Dir(d)
@@ -50,6 +153,50 @@ func findRepoRoot(start string) string {
 	}
 }
 
+// RepoRoot returns the top of the git working tree containing dir, and
+// false when dir is not inside one.
+func RepoRoot(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	root := findRepoRoot(abs)
+	return root, isRepoRoot(root)
+}
+
+// isRepoRoot reports whether dir is the top of a git working tree: it holds
+// a .git directory, or a .git file pointing at the real git dir as linked
+// worktrees and submodules do.
+func isRepoRoot(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, ".git"))
+	if err != nil {
+		return false
+	}
+	if fi.IsDir() {
+		return true
+	}
+	return fi.Mode().IsRegular() && gitDirFromFile(filepath.Join(dir, ".git")) != ""
+}
+
+// gitDirFromFile reads the "gitdir: <path>" line of a .git file and returns
+// the path, resolved against the file's directory, or "" if there is none.
+func gitDirFromFile(p string) string {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(b), "\n")
+	dir, ok := strings.CutPrefix(strings.TrimSpace(line), "gitdir:")
+	dir = strings.TrimSpace(dir)
+	if !ok || dir == "" {
+		return ""
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(p), dir)
+	}
+	return dir
+}
+
 // loadGitIgnore loads rules from a .gitignore file at base. If not present, returns nil.
 func loadGitIgnore(base string) (*gitIgnore, error) {
 	p := filepath.Join(base, ".gitignore")
@@ -61,106 +208,258 @@ func loadGitIgnore(base string) (*gitIgnore, error) {
 
 	rules := make([]gitIgnoreRule, 0, 16)
 	sc := bufio.NewScanner(f)
+	lineNum := 0
 	for sc.Scan() {
-		line := sc.Text()
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		neg := false
-		if strings.HasPrefix(line, "!") {
-			neg = true
-			line = strings.TrimSpace(line[1:])
-			if line == "" { // a bare '!' line is ignored
-				continue
-			}
-		}
-		dirOnly := false
-		if strings.HasSuffix(line, "/") {
-			dirOnly = true
-			line = strings.TrimSuffix(line, "/")
+		lineNum++
+		if rule, ok := parseGitIgnoreLine(sc.Text()); ok {
+			rule.source = ".gitignore"
+			rule.line = lineNum
+			rules = append(rules, rule)
 		}
-		anchored := false
-		if strings.HasPrefix(line, "/") {
-			anchored = true
-			line = strings.TrimPrefix(line, "/")
-		}
-		if line == "" {
-			continue
-		}
-		rules = append(rules, gitIgnoreRule{
-			pattern:  line,
-			negative: neg,
-			anchored: anchored,
-			dirOnly:  dirOnly,
-			hasSlash: strings.Contains(line, "/"),
-		})
 	}
 	// ignore scanner error silently (non-critical)
 	return &gitIgnore{root: base, rules: rules}, nil
 }
 
+// parseGitIgnoreLine parses one .gitignore line. It reports false for blank
+// lines, comments and lines without a pattern. Escapes are kept in the
+// pattern, where path.Match interprets them; here they only stop a leading
+// "\#" or "\!" from reading as a comment or negation, and keep an escaped
+// trailing space from being trimmed.
+func parseGitIgnoreLine(line string) (gitIgnoreRule, bool) {
+	line = trimUnescapedTrailingSpace(strings.TrimLeft(line, " \t"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitIgnoreRule{}, false
+	}
+	neg := false
+	if strings.HasPrefix(line, "!") {
+		neg = true
+		line = strings.TrimLeft(line[1:], " \t")
+		if line == "" { // a bare '!' line is ignored
+			return gitIgnoreRule{}, false
+		}
+	}
+	dirOnly := false
+	if strings.HasSuffix(line, "/") && !escapedAt(line, len(line)-1) {
+		dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	anchored := false
+	if strings.HasPrefix(line, "/") {
+		anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	anyDepth := false
+	for strings.HasPrefix(line, "**/") {
+		anyDepth = true
+		line = line[len("**/"):]
+	}
+	if line == "" {
+		return gitIgnoreRule{}, false
+	}
+	r := gitIgnoreRule{
+		pattern:  line,
+		negative: neg,
+		anchored: anchored,
+		anyDepth: anyDepth,
+		dirOnly:  dirOnly,
+		hasSlash: strings.Contains(line, "/"),
+	}
+	r.compile()
+	return r, true
+}
+
+// trimUnescapedTrailingSpace removes trailing spaces and tabs, stopping at
+// one escaped with a backslash.
+func trimUnescapedTrailingSpace(s string) string {
+	end := len(s)
+	for end > 0 && (s[end-1] == ' ' || s[end-1] == '\t') && !escapedAt(s, end-1) {
+		end--
+	}
+	return s[:end]
+}
+
+// escapedAt reports whether the byte at i is preceded by an odd number of
+// backslashes.
+func escapedAt(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// unescapePattern resolves backslash escapes, giving the literal a pattern
+// without wildcards stands for.
+func unescapePattern(p string) string {
+	if !strings.Contains(p, `\`) {
+		return p
+	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] == '\\' && i+1 < len(p) {
+			i++
+		}
+		b.WriteByte(p[i])
+	}
+	return b.String()
+}
+
 // normalizePath converts OS-specific separators to '/' for matching.
 func normalizePath(p string) string {
-	return strings.ReplaceAll(p, string(os.PathSeparator), "/")
+	return normalizeSeparator(p, os.PathSeparator)
+}
+
+// normalizeSeparator replaces sep with forward slashes in p.
+func normalizeSeparator(p string, sep rune) string {
+	return strings.ReplaceAll(p, string(sep), "/")
 }
 
 // match applies gitignore rules to a path relative to repo root.
 // isDir indicates whether the path is a directory.
 func (g *gitIgnore) match(rel string, isDir bool) bool {
+	_, ok := g.matchRule(rel, isDir)
+	return ok
+}
+
+// matchRule is like match but also returns the rule that decided the outcome
+// when the path is ignored.
+func (g *gitIgnore) matchRule(rel string, isDir bool) (*gitIgnoreRule, bool) {
 	if g == nil {
-		return false
+		return nil, false
 	}
-	rel = normalizePath(rel)
-	// Track last match state to allow later rules to override earlier ones.
-	matched := false
-	for _, r := range g.rules {
-		if r.dirOnly && !isDir {
-			continue
-		}
-		if r.anchored {
-			if matchPattern(r.pattern, rel) {
-				matched = !r.negative
+	p := newGitPath(normalizePath(rel))
+	cands := g.candidates(p)
+	// The last matching rule decides, so later rules override earlier ones.
+	for i := len(cands) - 1; i >= 0; i-- {
+		r := &g.rules[cands[i]]
+		if r.matchesPath(p, isDir) {
+			if r.negative {
+				return nil, false
 			}
-			continue
+			return r, true
 		}
-		// Unanchored
-		if !r.hasSlash {
-			// Match against basename
-			base := path.Base(rel)
-			if matchPattern(r.pattern, base) {
-				matched = !r.negative
-			}
-			// Additionally, for directory-only patterns like "vendor"
-			if isDir && (r.pattern == base) {
-				matched = !r.negative
-			}
-			continue
+	}
+	return nil, false
+}
+
+// gitPath is a normalized path relative to the repository root, split once
+// for all rules: dir is everything up to and including the last '/', name
+// the rest.
+type gitPath struct {
+	rel, dir, name, base string
+}
+
+func newGitPath(rel string) gitPath {
+	i := strings.LastIndexByte(rel, '/')
+	return gitPath{rel: rel, dir: rel[:i+1], name: rel[i+1:], base: path.Base(rel)}
+}
+
+// candidates returns the indices, in order, of the rules that may match an
+// entry of p's directory: those of the segment kinds whose leading segments
+// match the directory, and all others.
+func (g *gitIgnore) candidates(p gitPath) []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.memoRules != len(g.rules) || len(g.dirs) >= maxMemoDirs {
+		g.dirs, g.memoRules = nil, len(g.rules)
+	}
+	if c, ok := g.dirs[p.dir]; ok {
+		return c
+	}
+	var dirSegs []string
+	if p.dir != "" {
+		dirSegs = strings.Split(p.dir[:len(p.dir)-1], "/")
+	}
+	var c []int
+	for i := range g.rules {
+		if g.rules[i].mayMatchIn(dirSegs) {
+			c = append(c, i)
 		}
-		// Pattern has slash but is unanchored: allow match from any segment downward.
-		// We check the full rel and each suffix after a '/'.
-		if matchPattern(r.pattern, rel) {
-			matched = !r.negative
-			continue
+	}
+	if g.dirs == nil {
+		g.dirs = make(map[string][]int)
+	}
+	g.dirs[p.dir] = c
+	return c
+}
+
+// mayMatchIn reports whether r may match an entry of the directory split
+// into dirSegs: for the segment kinds, whether all but the last pattern
+// segment match the directory's segments, or its last ones.
+func (r *gitIgnoreRule) mayMatchIn(dirSegs []string) bool {
+	k := len(r.segs) - 1
+	switch r.kind {
+	case ruleAnchored:
+		return len(dirSegs) == k && matchEach(r.segs[:k], dirSegs)
+	case ruleSuffix:
+		return len(dirSegs) >= k && matchEach(r.segs[:k], dirSegs[len(dirSegs)-k:])
+	}
+	return true
+}
+
+// matchEach reports whether each of patterns matches the name at its
+// index; unlike in allowlists, "**" is just "*" here, as in path.Match.
+func matchEach(patterns, names []string) bool {
+	for i, p := range patterns {
+		if !matchPattern(p, names[i]) {
+			return false
 		}
-		for i := 0; i < len(rel); i++ {
-			if rel[i] == '/' && i+1 < len(rel) {
-				suf := rel[i+1:]
-				if matchPattern(r.pattern, suf) {
-					matched = !r.negative
-					break
-				}
+	}
+	return true
+}
+
+// matchesPath is matches for a rule among the candidates of p's directory,
+// which leaves the last segment to check for the segment kinds.
+func (r *gitIgnoreRule) matchesPath(p gitPath, isDir bool) bool {
+	switch r.kind {
+	case ruleBase:
+		return (!r.dirOnly || isDir) && r.bounded(p.base) && r.matchesBase(p.base, isDir)
+	case ruleAnchored, ruleSuffix:
+		return (!r.dirOnly || isDir) && r.bounded(p.name) && matchPattern(r.segs[len(r.segs)-1], p.name)
+	}
+	return r.matches(p.rel, isDir)
+}
+
+// matches reports whether the rule's pattern applies to rel, a normalized
+// path relative to the repository root. Negation is left to the caller.
+func (r *gitIgnoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored && !r.anyDepth {
+		return matchPattern(r.pattern, rel)
+	}
+	// Unanchored
+	if !r.hasSlash {
+		return r.matchesBase(path.Base(rel), isDir)
+	}
+	// Pattern has slash but is unanchored: allow match from any segment downward.
+	// We check the full rel and each suffix after a '/'.
+	if matchPattern(r.pattern, rel) {
+		return true
+	}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && i+1 < len(rel) {
+			if matchPattern(r.pattern, rel[i+1:]) {
+				return true
 			}
 		}
 	}
-	return matched
+	return false
+}
+
+// matchesBase matches a rule without slash against a basename.
+func (r *gitIgnoreRule) matchesBase(base string, isDir bool) bool {
+	// Directory-only patterns like "vendor" also match by plain equality
+	return matchPattern(r.pattern, base) || (isDir && unescapePattern(r.pattern) == base)
 }
 
 func matchPattern(pattern, name string) bool {
 	ok, err := path.Match(pattern, name)
 	if err != nil {
 		// In case of invalid pattern, fall back to simple equality
-		return pattern == name
+		return unescapePattern(pattern) == name
 	}
 	return ok
 }
@@ -0,0 +1,217 @@
+package todo
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestParseGitIgnoreLine_Escapes(t *testing.T) {
+	cases := []struct {
+		line    string
+		ok      bool
+		pattern string
+		neg     bool
+		dirOnly bool
+	}{
+		{"# comment", false, "", false, false},
+		{`\#not-a-comment`, true, `\#not-a-comment`, false, false},
+		{"!keep.go", true, "keep.go", true, false},
+		{`\!literal-bang`, true, `\!literal-bang`, false, false},
+		{`foo\ bar/`, true, `foo\ bar`, false, true},
+		{`foo\ `, true, `foo\ `, false, false},
+		{"foo   ", true, "foo", false, false},
+		{`foo\  `, true, `foo\ `, false, false},
+		{`foo\\ `, true, `foo\\`, false, false},
+		{`\*.go`, true, `\*.go`, false, false},
+		{"   ", false, "", false, false},
+	}
+	for _, c := range cases {
+		r, ok := parseGitIgnoreLine(c.line)
+		if ok != c.ok {
+			t.Errorf("parse(%q) ok = %v, want %v", c.line, ok, c.ok)
+			continue
+		}
+		if ok && (r.pattern != c.pattern || r.negative != c.neg || r.dirOnly != c.dirOnly) {
+			t.Errorf("parse(%q) = {pattern:%q neg:%v dir:%v}, want {%q %v %v}", c.line, r.pattern, r.negative, r.dirOnly, c.pattern, c.neg, c.dirOnly)
+		}
+	}
+}
+
+func TestGitIgnore_EscapedPatternsMatchLiterally(t *testing.T) {
+	rule := func(line string) gitIgnoreRule {
+		r, ok := parseGitIgnoreLine(line)
+		if !ok {
+			t.Fatalf("parse(%q) failed", line)
+		}
+		return r
+	}
+	cases := []struct {
+		line  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{`\#comment`, "#comment", false, true},
+		{`\#comment`, "comment", false, false},
+		{`\!literal-bang`, "!literal-bang", false, true},
+		{`\!literal-bang`, "literal-bang", false, false},
+		{`foo\ `, "foo ", false, true},
+		{`foo\ `, "foo", false, false},
+		{`foo\ bar/`, "foo bar", true, true},
+		{`foo\ bar/`, "foo bar", false, false},
+		{`\*.go`, "*.go", false, true},
+		{`\*.go`, "main.go", false, false},
+		{`what\?`, "what?", false, true},
+		{`what\?`, "whats", false, false},
+	}
+	for _, c := range cases {
+		r := rule(c.line)
+		if got := r.matches(c.path, c.isDir); got != c.want {
+			t.Errorf("%q matches(%q, dir=%v) = %v, want %v", c.line, c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+// The expectations match git check-ignore.
+func TestGitIgnore_LeadingDoubleStar(t *testing.T) {
+	cases := []struct {
+		line  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"**/dist/", "dist", true, true},
+		{"**/dist/", "a/b/dist", true, true},
+		{"**/dist/", "a/b/dist", false, false},
+		{"**/dist/", "a/distro", true, false},
+		{"**/node_modules", "node_modules", true, true},
+		{"**/node_modules", "web/app/node_modules", true, true},
+		{"**/node_modules", "web/node_modules", false, true},
+		{"**/*.log", "x.log", false, true},
+		{"**/*.log", "a/b/x.log", false, true},
+		{"**/*.log", "a/b/x.go", false, false},
+		{"**/foo/bar", "foo/bar", false, true},
+		{"**/foo/bar", "a/b/foo/bar", false, true},
+		{"**/foo/bar", "a/foo/baz", false, false},
+		{"**/foo/bar", "bar", false, false},
+		{"/**/baz", "baz", false, true},
+		{"/**/baz", "a/baz", false, true},
+		{"**/**/baz", "a/b/baz", false, true},
+	}
+	for _, c := range cases {
+		r, ok := parseGitIgnoreLine(c.line)
+		if !ok {
+			t.Fatalf("parse(%q) failed", c.line)
+		}
+		if got := r.matches(c.path, c.isDir); got != c.want {
+			t.Errorf("%q matches(%q, dir=%v) = %v, want %v", c.line, c.path, c.isDir, got, c.want)
+		}
+	}
+	if r, _ := parseGitIgnoreLine("!**/dist/"); r.origin() != ":0: !**/dist/" {
+		t.Errorf("origin = %q", r.origin())
+	}
+}
+
+func TestScanDir_RespectsGitIgnore_LeadingDoubleStar(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "**/dist/\n**/*.log\n")
+	mustWriteFile(t, root, "a/b/dist/bundle.go", "// TODO: ignored\n")
+	mustWriteFile(t, root, "dist/top.go", "// TODO: ignored\n")
+	mustWriteFile(t, root, "a/debug.log", "TODO: ignored\n")
+	mustWriteFile(t, root, "a/b/keep.go", "// TODO: kept\n")
+
+	items, err := ScanDir(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || normalizePath(items[0].File) != "a/b/keep.go" {
+		t.Fatalf("items = %+v, want only a/b/keep.go", items)
+	}
+}
+
+func TestUnescapePattern(t *testing.T) {
+	for in, want := range map[string]string{
+		`plain`:     "plain",
+		`foo\ `:     "foo ",
+		`\#x`:       "#x",
+		`a\\b`:      `a\b`,
+		`trailing\`: `trailing\`,
+	} {
+		if got := unescapePattern(in); got != want {
+			t.Errorf("unescapePattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// referenceMatchRule is the plain matcher gitIgnore.matchRule must agree
+// with: every rule tried against the whole path, the last match deciding.
+func referenceMatchRule(g *gitIgnore, rel string, isDir bool) (*gitIgnoreRule, bool) {
+	rel = normalizePath(rel)
+	var decided *gitIgnoreRule
+	for i := range g.rules {
+		if g.rules[i].matches(rel, isDir) {
+			decided = &g.rules[i]
+		}
+	}
+	if decided == nil || decided.negative {
+		return nil, false
+	}
+	return decided, true
+}
+
+func TestGitIgnore_MatchesReference(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	segments := []string{"a", "b", "ab", "x.go", "*", "?", "a*", "*.go", "**", "[ab]", `\*`, `a\ `, "[", ""}
+	names := []string{"a", "b", "ab", "x.go", "y.go", "*", "a ", "", "."}
+	pick := func(from []string, n int) string {
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = from[rng.IntN(len(from))]
+		}
+		return strings.Join(parts, "/")
+	}
+	for round := range 300 {
+		g := &gitIgnore{}
+		for range 1 + rng.IntN(8) {
+			line := pick(segments, 1+rng.IntN(3))
+			for _, affix := range []string{"!", "/", "**/"} {
+				if rng.IntN(4) == 0 {
+					line = affix + line
+				}
+			}
+			if rng.IntN(4) == 0 {
+				line += "/"
+			}
+			if r, ok := parseGitIgnoreLine(line); ok {
+				g.rules = append(g.rules, r)
+			}
+		}
+		for range 50 {
+			rel := pick(names, 1+rng.IntN(4))
+			if rng.IntN(10) == 0 {
+				rel += "/"
+			}
+			for _, isDir := range []bool{false, true} {
+				got, gotOK := g.matchRule(rel, isDir)
+				want, wantOK := referenceMatchRule(g, rel, isDir)
+				if got != want || gotOK != wantOK {
+					var rules []string
+					for _, r := range g.rules {
+						rules = append(rules, r.origin())
+					}
+					t.Fatalf("round %d: matchRule(%q, dir=%v) = %v, %v; reference %v, %v\nrules:\n%s",
+						round, rel, isDir, describeRule(got), gotOK, describeRule(want), wantOK, strings.Join(rules, "\n"))
+				}
+			}
+		}
+	}
+}
+
+func describeRule(r *gitIgnoreRule) string {
+	if r == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%q", r.origin())
+}
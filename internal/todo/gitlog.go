@@ -0,0 +1,50 @@
+package todo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// gitLogRecordSep starts every commit in ScanGitLog's git output (%x1e in
+// the format), so message bodies can contain anything, blank lines included.
+const gitLogRecordSep = "\x1e"
+
+// GitLogOptions selects the commits searched by ScanGitLog.
+type GitLogOptions struct {
+	// Since is passed to git log --since when non-empty, e.g. "2 weeks ago".
+	Since string
+	// MaxCount limits the number of commits when > 0 (git log --max-count).
+	MaxCount int
+}
+
+// ScanGitLog searches the commit messages of the repository at dir, newest
+// first, using s. Items are reported with the full commit hash as File and
+// the line within the message as Line, the subject being line 1.
+func ScanGitLog(ctx context.Context, dir string, s *Scanner, opts GitLogOptions, git GitRunner) ([]Todo, error) {
+	args := []string{"log", "--format=%x1e%H%n%B"}
+	if opts.Since != "" {
+		args = append(args, "--since="+opts.Since)
+	}
+	if opts.MaxCount > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(opts.MaxCount))
+	}
+	out, err := git.Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	var todos []Todo
+	for _, rec := range strings.Split(out, gitLogRecordSep) {
+		hash, body, ok := strings.Cut(rec, "\n")
+		hash = strings.TrimSpace(hash)
+		if !ok || hash == "" {
+			continue
+		}
+		items, err := s.ScanString(hash, body)
+		if err != nil {
+			continue
+		}
+		todos = append(todos, items...)
+	}
+	return todos, nil
+}
@@ -0,0 +1,75 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// logGit returns fixed git log output and records the arguments it got.
+type logGit struct {
+	out  string
+	err  error
+	args []string
+}
+
+func (g *logGit) Run(_ context.Context, _ string, args ...string) (string, error) {
+	g.args = args
+	return g.out, g.err
+}
+
+func TestScanGitLog(t *testing.T) {
+	git := &logGit{out: "\x1eaaa111\nAdd parser\n\nTODO: handle empty input\n\n" +
+		"\x1ebbb222\nFIXME: revert once upstream is fixed\n\n" +
+		"\x1eccc333\nPlain commit\n\n"}
+	s, err := NewScanner(DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	got, err := ScanGitLog(context.Background(), ".", s, GitLogOptions{Since: "2 weeks ago", MaxCount: 50}, git)
+	if err != nil {
+		t.Fatalf("ScanGitLog: %v", err)
+	}
+	wantArgs := []string{"log", "--format=%x1e%H%n%B", "--since=2 weeks ago", "--max-count=50"}
+	if !reflect.DeepEqual(git.args, wantArgs) {
+		t.Fatalf("args = %q, want %q", git.args, wantArgs)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d items, want 2: %+v", len(got), got)
+	}
+	if got[0].File != "aaa111" || got[0].Line != 3 || got[0].Tag != "TODO" || got[0].Text != "handle empty input" {
+		t.Fatalf("unexpected first item: %+v", got[0])
+	}
+	if got[1].File != "bbb222" || got[1].Line != 1 || got[1].Tag != "FIXME" {
+		t.Fatalf("unexpected second item: %+v", got[1])
+	}
+}
+
+func TestScanGitLog_Error(t *testing.T) {
+	s, _ := NewScanner(DefaultScanOptions())
+	git := &logGit{err: errors.New("not a git repository")}
+	if _, err := ScanGitLog(context.Background(), ".", s, GitLogOptions{}, git); err == nil {
+		t.Fatalf("expected the git error")
+	}
+	if !reflect.DeepEqual(git.args, []string{"log", "--format=%x1e%H%n%B"}) {
+		t.Fatalf("unexpected args without options: %q", git.args)
+	}
+}
+
+func TestTrackedFiles(t *testing.T) {
+	git := &logGit{out: "a.go\x00dir/b c.go\x00"}
+	got, err := TrackedFiles(context.Background(), git, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go", "dir/b c.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+	if want := []string{"ls-files", "-z", "--cached"}; !reflect.DeepEqual(git.args, want) {
+		t.Errorf("args = %q", git.args)
+	}
+	if _, err := TrackedFiles(context.Background(), &logGit{err: errors.New("not a git repository")}, "."); err == nil {
+		t.Error("expected the git error")
+	}
+}
@@ -0,0 +1,82 @@
+package todo
+
+import (
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// fileID identifies a file across its hard links: its device and inode.
+type fileID struct {
+	dev, ino uint64
+}
+
+// linkSet tracks the files of a walk that have several hard links, so each
+// is scanned once. It is safe for concurrent use.
+type linkSet struct {
+	mu sync.Mutex
+	// paths lists the reported paths of each file, the scanned one first.
+	paths map[fileID][]string
+}
+
+func newLinkSet() *linkSet {
+	return &linkSet{paths: make(map[fileID][]string)}
+}
+
+// add records shown as a path of the file of d. It returns the path the file
+// was first recorded under, whose scan covers this one, or false when shown
+// is the first or the platform or filesystem does not tell hard links apart.
+func (s *linkSet) add(shown string, d fs.DirEntry) (string, bool) {
+	id, ok := hardLinkID(d)
+	if !ok {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := s.paths[id]
+	s.paths[id] = append(seen, shown)
+	if len(seen) == 0 {
+		return "", false
+	}
+	return seen[0], true
+}
+
+// linkNames are the paths of one hard-linked file: Primary, the
+// lexicographically first, and AlsoAt, the others in order.
+type linkNames struct {
+	Primary string
+	AlsoAt  []string
+}
+
+// byScanned returns the names of every file recorded under several paths,
+// keyed by the path it was scanned under.
+func (s *linkSet) byScanned() map[string]linkNames {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]linkNames)
+	for _, paths := range s.paths {
+		if len(paths) < 2 {
+			continue
+		}
+		sorted := append([]string(nil), paths...)
+		sort.Strings(sorted)
+		out[paths[0]] = linkNames{Primary: sorted[0], AlsoAt: sorted[1:]}
+	}
+	return out
+}
+
+// applyHardLinks reports the items of hard-linked files under their primary
+// path, listing the other links in AlsoAt, so the result does not depend on
+// which link the walk reached first.
+func applyHardLinks(items []Todo, links *linkSet) {
+	names := links.byScanned()
+	if len(names) == 0 {
+		return
+	}
+	for i := range items {
+		if n, ok := names[items[i].File]; ok {
+			items[i].File = n.Primary
+			items[i].AlsoAt = n.AlsoAt
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package todo
+
+import "io/fs"
+
+// hardLinkID reports no identity: hard links are not told apart here, so
+// every link is scanned.
+func hardLinkID(fs.DirEntry) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,125 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// hardLinkTree writes b/gen.go and hard-links it as a/gen.go and c.go,
+// skipping the test where links cannot be made or told apart.
+func hardLinkTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := filepath.Join(root, "b", "gen.go")
+	if err := os.WriteFile(src, []byte("// TODO: one\n// FIXME: two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range []string{filepath.Join("a", "gen.go"), "c.go"} {
+		if err := os.Link(src, filepath.Join(root, link)); err != nil {
+			t.Skipf("hard links unsupported: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hardLinkID(entries[0]); !ok {
+		t.Skip("hard links are not told apart on this platform")
+	}
+	return root
+}
+
+func TestScanDir_DedupHardLinks(t *testing.T) {
+	root := hardLinkTree(t)
+	opts := DefaultScanOptions()
+	opts.Audit = true
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 2 {
+		t.Fatalf("items = %+v, want the linked file counted once", res.Items)
+	}
+	for _, it := range res.Items {
+		if it.File != "a/gen.go" || !reflect.DeepEqual(it.AlsoAt, []string{"b/gen.go", "c.go"}) {
+			t.Errorf("item = %s also at %v, want a/gen.go also at b/gen.go, c.go", it.File, it.AlsoAt)
+		}
+	}
+	if res.Stats.HardLinks != 2 || res.Stats.Opened != 1 {
+		t.Errorf("stats = %+v", res.Stats)
+	}
+	var links int
+	for _, e := range res.Audit.Entries {
+		if e.Reason == SkipReasonHardLink {
+			links++
+			if !strings.HasPrefix(e.Rule, "same file as ") {
+				t.Errorf("audit rule = %q", e.Rule)
+			}
+		}
+	}
+	if links != 2 {
+		t.Errorf("audit = %+v, want the two skipped links", res.Audit.Entries)
+	}
+
+	opts.DedupHardLinks = false
+	items, err := ScanDirWithOptions(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 6 {
+		t.Errorf("without dedup: %d items, want every link scanned", len(items))
+	}
+}
+
+func TestLinkSet_Concurrent(t *testing.T) {
+	root := hardLinkTree(t)
+	var entries []os.DirEntry
+	for _, dir := range []string{"a", "b", "."} {
+		list, err := os.ReadDir(filepath.Join(root, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range list {
+			if !e.IsDir() {
+				entries = append(entries, e)
+			}
+		}
+	}
+	s := newLinkSet()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	firsts := 0
+	for i, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, dup := s.add(string(rune('x'+i)), e); !dup {
+				mu.Lock()
+				firsts++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firsts != 1 {
+		t.Errorf("%d links reported as first, want 1", firsts)
+	}
+	names := s.byScanned()
+	if len(names) != 1 {
+		t.Fatalf("names = %+v", names)
+	}
+	for _, n := range names {
+		if n.Primary != "x" || !reflect.DeepEqual(n.AlsoAt, []string{"y", "z"}) {
+			t.Errorf("names = %+v, want x first whatever the order of adds", n)
+		}
+	}
+}
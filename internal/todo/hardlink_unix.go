@@ -0,0 +1,25 @@
+//go:build unix
+
+package todo
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// hardLinkID returns the identity of the file of d when it has more than one
+// hard link. Files with a single link need no tracking.
+func hardLinkID(d fs.DirEntry) (fileID, bool) {
+	if !d.Type().IsRegular() {
+		return fileID{}, false
+	}
+	info, err := d.Info()
+	if err != nil {
+		return fileID{}, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || uint64(st.Nlink) < 2 {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
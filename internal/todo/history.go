@@ -0,0 +1,235 @@
+package todo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryVersion is the format version written to every history record.
+// Readers skip records of other versions, so the format can grow without
+// breaking older files.
+const HistoryVersion = 1
+
+// Defaults for history tracking.
+const (
+	// DefaultHistoryKeep is the number of runs retained in a history file.
+	DefaultHistoryKeep = 20
+	// DefaultEscalateAfter is the number of consecutive runs after which a
+	// persistent BUG or FIXME is escalated.
+	DefaultEscalateAfter = 5
+)
+
+// HistoryRun is one line of a history file: a scan summary plus the sorted
+// fingerprints of every item it reported.
+type HistoryRun struct {
+	Version int            `json:"v"`
+	At      time.Time      `json:"at"`
+	Total   int            `json:"total"`
+	ByTag   map[string]int `json:"byTag"`
+	// ByDir counts items by top-level directory (see TopDir); runs
+	// recorded before it existed have none.
+	ByDir        map[string]int `json:"byDir,omitempty"`
+	Fingerprints []string       `json:"fingerprints"`
+}
+
+// TopDir returns the first directory of an item's file path, or "." for
+// files at the top of the scanned tree.
+func TopDir(file string) string {
+	file = strings.TrimPrefix(normalizePath(file), "./")
+	if i := strings.IndexByte(file, '/'); i > 0 {
+		return file[:i]
+	}
+	return "."
+}
+
+// Fingerprint identifies an item across runs by its file, tag and text, with
+// runs of whitespace in the text collapsed. The line number is left out, so
+// it survives edits that only shift lines; it changes when the item is
+// edited, retagged or moved to another file.
+func Fingerprint(t Todo) string {
+	sum := sha1.Sum([]byte(normalizePath(t.File) + "\x00" + strings.ToUpper(t.Tag) + "\x00" + strings.Join(strings.Fields(t.Text), " ")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// fingerprint returns t.Fingerprint, or computes it when unset. It is the
+// key history and burn-down match items by.
+func fingerprint(t Todo) string {
+	if t.Fingerprint != "" {
+		return t.Fingerprint
+	}
+	return Fingerprint(t)
+}
+
+// setFingerprints fills in the Fingerprint of items. Reports call it before
+// trimming paths, so fingerprints match the ones recorded in history.
+func setFingerprints(items []Todo) {
+	for i := range items {
+		items[i].Fingerprint = fingerprint(items[i])
+	}
+}
+
+// escalatable reports whether items with tag may be escalated: only tags at
+// least as severe as FIXME are.
+func escalatable(tag string) bool {
+	return StyleFor(tag).Severity >= StyleFor("FIXME").Severity
+}
+
+// LoadHistory reads the runs recorded in the history file at path, oldest
+// first. A missing file yields no runs; malformed lines and records of an
+// unknown version are skipped.
+func LoadHistory(path string) ([]HistoryRun, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var runs []HistoryRun
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		var run HistoryRun
+		if json.Unmarshal(sc.Bytes(), &run) != nil || run.Version != HistoryVersion {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, sc.Err()
+}
+
+// ApplyHistory sets SeenInRuns on a copy of items: the number of consecutive
+// runs, ending with the current one, in which the item was reported. BUG and
+// FIXME items seen in at least escalateAfter runs (DefaultEscalateAfter if
+// <= 0) are marked Escalated. A single run without the item resets its count.
+func ApplyHistory(items []Todo, runs []HistoryRun, escalateAfter int) []Todo {
+	if escalateAfter <= 0 {
+		escalateAfter = DefaultEscalateAfter
+	}
+	sets := make([]map[string]bool, len(runs))
+	for i, run := range runs {
+		sets[i] = make(map[string]bool, len(run.Fingerprints))
+		for _, fp := range run.Fingerprints {
+			sets[i][fp] = true
+		}
+	}
+	out := make([]Todo, len(items))
+	copy(out, items)
+	for i := range out {
+		fp := fingerprint(out[i])
+		seen := 1
+		for j := len(sets) - 1; j >= 0 && sets[j][fp]; j-- {
+			seen++
+		}
+		out[i].SeenInRuns = seen
+		out[i].Escalated = seen >= escalateAfter && escalatable(out[i].Tag)
+	}
+	return out
+}
+
+// AppendHistory records items as a new run at time at. While the file holds
+// fewer than keep runs (DefaultHistoryKeep if <= 0) the record is appended in
+// place; otherwise the newest keep runs are written to a temporary file that
+// atomically replaces the original, so concurrent readers always see either
+// the old or the new history, never a partial one.
+func AppendHistory(path string, items []Todo, at time.Time, keep int) error {
+	if keep <= 0 {
+		keep = DefaultHistoryKeep
+	}
+	run := HistoryRun{Version: HistoryVersion, At: at.UTC(), Total: len(items), ByTag: map[string]int{}, ByDir: map[string]int{}, Fingerprints: []string{}}
+	seen := make(map[string]bool, len(items))
+	for _, t := range items {
+		run.ByTag[t.Tag]++
+		run.ByDir[TopDir(t.File)]++
+		if fp := fingerprint(t); !seen[fp] {
+			seen[fp] = true
+			run.Fingerprints = append(run.Fingerprints, fp)
+		}
+	}
+	sort.Strings(run.Fingerprints)
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	runs, err := LoadHistory(path)
+	if err != nil {
+		return err
+	}
+	if len(runs) < keep {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(line); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	var buf bytes.Buffer
+	for _, r := range runs[len(runs)-keep+1:] {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	buf.Write(line)
+	return ReplaceFile(path, buf.Bytes())
+}
+
+// ReplaceFile writes data to a temporary file next to path and renames it
+// over path, keeping the permissions of an existing file. Readers see the
+// old content or the new, never a partial write.
+func ReplaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if st, err := os.Stat(path); err == nil {
+		_ = tmp.Chmod(st.Mode().Perm())
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// persistentItems returns the escalated items, longest-running first.
+// Items are expected in file/line order for stable ties.
+func persistentItems(items []Todo) []Todo {
+	var out []Todo
+	for _, t := range items {
+		if t.Escalated {
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].SeenInRuns > out[j].SeenInRuns
+	})
+	return out
+}
@@ -0,0 +1,139 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory_EscalationThresholdAndReset(t *testing.T) {
+	bug := Todo{Location: Location{File: "a.go", Line: 1}, Tag: "BUG", Text: "races"}
+	fixme := Todo{Location: Location{File: "b.go", Line: 2}, Tag: "FIXME", Text: "leaks"}
+	note := Todo{Location: Location{File: "c.go", Line: 3}, Tag: "TODO", Text: "tidy"}
+	runs := [][]Todo{
+		{bug, fixme, note},
+		{bug, fixme, note},
+		{bug, note}, // fixme disappears for one run
+		{bug, fixme, note},
+		{bug, fixme, note},
+	}
+	wantSeen := map[string][]int{
+		"BUG":   {1, 2, 3, 4, 5},
+		"FIXME": {1, 2, 0, 1, 2},
+		"TODO":  {1, 2, 3, 4, 5},
+	}
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, items := range runs {
+		// Line numbers drift between runs; fingerprints must not depend on them.
+		for j := range items {
+			items[j].Line += i
+		}
+		hist, err := LoadHistory(path)
+		if err != nil {
+			t.Fatalf("run %d: load: %v", i+1, err)
+		}
+		got := ApplyHistory(items, hist, 3)
+		for _, it := range got {
+			if it.SeenInRuns != wantSeen[it.Tag][i] {
+				t.Fatalf("run %d: %s SeenInRuns = %d, want %d", i+1, it.Tag, it.SeenInRuns, wantSeen[it.Tag][i])
+			}
+			wantEscalated := it.Tag != "TODO" && it.SeenInRuns >= 3
+			if it.Escalated != wantEscalated {
+				t.Fatalf("run %d: %s Escalated = %v, want %v", i+1, it.Tag, it.Escalated, wantEscalated)
+			}
+		}
+		if err := AppendHistory(path, got, start.Add(time.Duration(i)*time.Hour), 10); err != nil {
+			t.Fatalf("run %d: append: %v", i+1, err)
+		}
+	}
+}
+
+func TestAppendHistory_PrunesToKeep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		items := make([]Todo, i+1)
+		for j := range items {
+			items[j] = Todo{Location: Location{File: "a.go", Line: j + 1}, Tag: "TODO", Text: strings.Repeat("x", j+1)}
+		}
+		if err := AppendHistory(path, items, start.Add(time.Duration(i)*time.Hour), 2); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	runs, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Total != 4 || runs[1].Total != 5 {
+		t.Fatalf("want the last two runs (4, 5 items), got %+v", runs)
+	}
+	if len(runs[1].Fingerprints) != 5 {
+		t.Fatalf("fingerprints = %v", runs[1].Fingerprints)
+	}
+	if runs[1].ByDir["."] != 5 {
+		t.Fatalf("byDir = %v, want 5 items at the top", runs[1].ByDir)
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestLoadHistory_SkipsUnknownVersionsAndGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	content := "not json\n" +
+		`{"v":99,"total":1,"fingerprints":["x"]}` + "\n" +
+		`{"v":1,"at":"2024-01-01T00:00:00Z","total":1,"byTag":{"TODO":1},"fingerprints":["abc"]}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Fingerprints[0] != "abc" {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+	if runs, err := LoadHistory(filepath.Join(t.TempDir(), "missing")); err != nil || runs != nil {
+		t.Fatalf("missing file: runs=%v err=%v", runs, err)
+	}
+}
+
+func TestTopDir(t *testing.T) {
+	for file, want := range map[string]string{
+		"main.go":          ".",
+		"./main.go":        ".",
+		"api/v1/server.go": "api",
+	} {
+		if got := TopDir(file); got != want {
+			t.Errorf("TopDir(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestFingerprint_StableAcrossLineShifts(t *testing.T) {
+	a := Todo{Location: Location{File: "pkg/a.go", Line: 3}, Tag: "TODO", Text: "tidy  up"}
+	moved := Todo{Location: Location{File: "pkg/a.go", Line: 40}, Tag: "todo", Text: "tidy up "}
+	if Fingerprint(a) != Fingerprint(moved) {
+		t.Fatal("fingerprint changed with line, tag case or whitespace")
+	}
+	for _, b := range []Todo{
+		{Location: Location{File: "pkg/b.go"}, Tag: "TODO", Text: "tidy up"},
+		{Location: Location{File: "pkg/a.go"}, Tag: "FIXME", Text: "tidy up"},
+		{Location: Location{File: "pkg/a.go"}, Tag: "TODO", Text: "tidy up later"},
+	} {
+		if Fingerprint(a) == Fingerprint(b) {
+			t.Errorf("%+v shares the fingerprint of %+v", b, a)
+		}
+	}
+
+	// A preset Fingerprint is the matching key.
+	custom := Todo{Location: Location{File: "x.go"}, Tag: "TODO", Text: "renamed", Fingerprint: Fingerprint(a)}
+	runs := []HistoryRun{{Version: HistoryVersion, Fingerprints: []string{Fingerprint(a)}}}
+	if got := ApplyHistory([]Todo{custom}, runs, 0); got[0].SeenInRuns != 2 {
+		t.Fatalf("SeenInRuns = %d, want 2", got[0].SeenInRuns)
+	}
+}
@@ -0,0 +1,358 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLang is the language of the report chrome when none is chosen.
+const DefaultLang = "en"
+
+// messages is the catalog of the report chrome: headings, summary labels
+// and column headers, by language and key. Tags and item texts are never
+// translated. A key missing from a language falls back to English (see
+// Message), so a catalog may be incomplete.
+var messages = map[string]map[string]string{
+	"en": {
+		"report.title":            "todototum report",
+		"summary.title":           "todototum summary",
+		"summary":                 "Summary",
+		"total":                   "Total",
+		"oldest":                  "Oldest",
+		"newest":                  "Newest",
+		"todos":                   "Todos",
+		"todos.sampled":           "Todos (sample of %d%% of files)",
+		"all-items":               "All items",
+		"sampled-items":           "Sampled items",
+		"more-not-shown":          "%d more not shown.",
+		"top-files":               "Top files",
+		"by-extension":            "By extension",
+		"oldest-debt":             "Oldest debt",
+		"persistent":              "Persistent items",
+		"obsolete":                "Possibly obsolete",
+		"obsolete.note":           "These items mention identifiers declared nowhere in the scanned Go files.",
+		"low-signal":              "Low-signal items",
+		"problems":                "Problems",
+		"exclusions":              "Exclusions",
+		"no-todos":                "No TODOs found.",
+		"generated-by":            "generated by",
+		"age.old":                 "%s old",
+		"top-issues":              "Top issues",
+		"escalated":               "Escalated",
+		"filtered":                "Filtered by pattern",
+		"noise":                   "Low-signal",
+		"also-at":                 "also at",
+		"own-line":                "own-line",
+		"trailing":                "trailing",
+		"density":                 "%.2f todos per 1000 lines (%d lines scanned)",
+		"density.summary":         "Density: %.2f per 1000 lines (%d lines scanned)",
+		"estimate":                "Estimated total: %d (sampled at %d%%, %d found)",
+		"estimate.short":          "Estimated total: %d (sampled at %d%%)",
+		"sample.banner":           "Sampled scan: only about %d%% of files were read, and the items below are the %d found in them.",
+		"sample.terminal":         "Sampled scan: the items below come from about %d%% of files.",
+		"errors":                  "Errors",
+		"warnings":                "Warnings",
+		"burn-down":               "Burn-down",
+		"burn-down.since":         "Since %s:",
+		"burn-down.changes":       "%d resolved, %d added, net %+d (%d → %d).",
+		"projection.total":        "Total count",
+		"projection.tag":          "%s count",
+		"projection.zero":         "At the current pace, %s reaches zero in %s.",
+		"projection.other":        "%s: %s.",
+		"projection.insufficient": "insufficient data",
+		"projection.no-zero":      "no projected zero date",
+		"projection.at-zero":      "already zero",
+		"duration.day":            "~1 day",
+		"duration.days":           "~%d days",
+		"duration.week":           "~1 week",
+		"duration.weeks":          "~%d weeks",
+		"duration.month":          "~1 month",
+		"duration.months":         "~%d months",
+		"kind.file":               "file",
+		"kind.dir":                "dir",
+		"truncated":               "Truncated after %d entries.",
+		"col.file":                "File",
+		"col.line":                "Line",
+		"col.tag":                 "Tag",
+		"col.text":                "Text",
+		"col.count":               "Count",
+		"col.share":               "Share",
+		"col.items":               "Items",
+		"col.extension":           "Extension",
+		"col.introduced":          "Introduced",
+		"col.age":                 "Age",
+		"col.by":                  "By",
+		"col.runs":                "Runs",
+		"col.path":                "Path",
+		"col.phase":               "Phase",
+		"col.code":                "Code",
+		"col.message":             "Message",
+		"col.type":                "Type",
+		"col.reason":              "Reason",
+		"col.rule":                "Rule",
+		"col.symbol":              "Symbol",
+	},
+	"it": {
+		"report.title":            "Report todototum",
+		"summary.title":           "Riepilogo todototum",
+		"summary":                 "Riepilogo",
+		"total":                   "Totale",
+		"oldest":                  "Più vecchia",
+		"newest":                  "Più recente",
+		"todos":                   "Attività",
+		"todos.sampled":           "Attività (campione del %d%% dei file)",
+		"all-items":               "Tutte le attività",
+		"sampled-items":           "Attività campionate",
+		"more-not-shown":          "Altre %d non mostrate.",
+		"top-files":               "File principali",
+		"by-extension":            "Per estensione",
+		"oldest-debt":             "Debito più vecchio",
+		"persistent":              "Attività persistenti",
+		"obsolete":                "Forse obsolete",
+		"obsolete.note":           "Queste attività citano identificatori non dichiarati in nessuno dei file Go analizzati.",
+		"low-signal":              "Attività poco significative",
+		"problems":                "Problemi",
+		"exclusions":              "Esclusioni",
+		"no-todos":                "Nessun TODO trovato.",
+		"generated-by":            "generato da",
+		"age.old":                 "da %s",
+		"top-issues":              "Problemi principali",
+		"escalated":               "Scalate",
+		"filtered":                "Filtrate per pattern",
+		"noise":                   "Poco significative",
+		"also-at":                 "anche in",
+		"own-line":                "su riga propria",
+		"trailing":                "in coda",
+		"density":                 "%.2f attività ogni 1000 righe (%d righe analizzate)",
+		"density.summary":         "Densità: %.2f ogni 1000 righe (%d righe analizzate)",
+		"estimate":                "Totale stimato: %d (campione del %d%%, %d trovate)",
+		"estimate.short":          "Totale stimato: %d (campione del %d%%)",
+		"sample.banner":           "Scansione a campione: è stato letto solo il %d%% circa dei file e le attività qui sotto sono le %d trovate in essi.",
+		"sample.terminal":         "Scansione a campione: le attività qui sotto provengono dal %d%% circa dei file.",
+		"errors":                  "Errori",
+		"warnings":                "Avvisi",
+		"burn-down":               "Andamento",
+		"burn-down.since":         "Dal %s:",
+		"burn-down.changes":       "%d risolte, %d aggiunte, saldo %+d (%d → %d).",
+		"projection.total":        "il totale",
+		"projection.tag":          "il numero di %s",
+		"projection.zero":         "Al ritmo attuale, %s arriva a zero in %s.",
+		"projection.other":        "Per %s: %s.",
+		"projection.insufficient": "dati insufficienti",
+		"projection.no-zero":      "nessuna data prevista per lo zero",
+		"projection.at-zero":      "già a zero",
+		"duration.day":            "~1 giorno",
+		"duration.days":           "~%d giorni",
+		"duration.week":           "~1 settimana",
+		"duration.weeks":          "~%d settimane",
+		"duration.month":          "~1 mese",
+		"duration.months":         "~%d mesi",
+		"kind.file":               "file",
+		"kind.dir":                "cartella",
+		"truncated":               "Troncato dopo %d voci.",
+		"col.file":                "File",
+		"col.line":                "Riga",
+		"col.tag":                 "Tag",
+		"col.text":                "Testo",
+		"col.count":               "Numero",
+		"col.share":               "Quota",
+		"col.items":               "Attività",
+		"col.extension":           "Estensione",
+		"col.introduced":          "Introdotta",
+		"col.age":                 "Età",
+		"col.by":                  "Autore",
+		"col.runs":                "Esecuzioni",
+		"col.path":                "Percorso",
+		"col.phase":               "Fase",
+		"col.code":                "Codice",
+		"col.message":             "Messaggio",
+		"col.type":                "Tipo",
+		"col.reason":              "Motivo",
+		"col.rule":                "Regola",
+		"col.symbol":              "Simbolo",
+	},
+	"es": {
+		"report.title":            "Informe de todototum",
+		"summary.title":           "Resumen de todototum",
+		"summary":                 "Resumen",
+		"total":                   "Total",
+		"oldest":                  "Más antigua",
+		"newest":                  "Más reciente",
+		"todos":                   "Tareas",
+		"todos.sampled":           "Tareas (muestra del %d%% de los archivos)",
+		"all-items":               "Todas las tareas",
+		"sampled-items":           "Tareas de la muestra",
+		"more-not-shown":          "%d más sin mostrar.",
+		"top-files":               "Archivos principales",
+		"by-extension":            "Por extensión",
+		"oldest-debt":             "Deuda más antigua",
+		"persistent":              "Tareas persistentes",
+		"obsolete":                "Posiblemente obsoletas",
+		"obsolete.note":           "Estas tareas mencionan identificadores que no se declaran en ningún archivo Go analizado.",
+		"low-signal":              "Tareas poco relevantes",
+		"problems":                "Problemas",
+		"exclusions":              "Exclusiones",
+		"no-todos":                "No se encontraron TODOs.",
+		"generated-by":            "generado por",
+		"age.old":                 "hace %s",
+		"top-issues":              "Problemas principales",
+		"escalated":               "Escaladas",
+		"filtered":                "Filtradas por patrón",
+		"noise":                   "Poco relevantes",
+		"also-at":                 "también en",
+		"own-line":                "en su propia línea",
+		"trailing":                "al final de línea",
+		"density":                 "%.2f tareas por cada 1000 líneas (%d líneas analizadas)",
+		"density.summary":         "Densidad: %.2f por cada 1000 líneas (%d líneas analizadas)",
+		"estimate":                "Total estimado: %d (muestra del %d%%, %d encontradas)",
+		"estimate.short":          "Total estimado: %d (muestra del %d%%)",
+		"sample.banner":           "Análisis por muestreo: solo se leyó alrededor del %d%% de los archivos y las tareas siguientes son las %d encontradas en ellos.",
+		"sample.terminal":         "Análisis por muestreo: las tareas siguientes provienen de alrededor del %d%% de los archivos.",
+		"errors":                  "Errores",
+		"warnings":                "Advertencias",
+		"burn-down":               "Evolución",
+		"burn-down.since":         "Desde el %s:",
+		"burn-down.changes":       "%d resueltas, %d añadidas, neto %+d (%d → %d).",
+		"projection.total":        "el total",
+		"projection.tag":          "el número de %s",
+		"projection.zero":         "Al ritmo actual, %s llega a cero en %s.",
+		"projection.other":        "Para %s: %s.",
+		"projection.insufficient": "datos insuficientes",
+		"projection.no-zero":      "sin fecha prevista para llegar a cero",
+		"projection.at-zero":      "ya en cero",
+		"duration.day":            "~1 día",
+		"duration.days":           "~%d días",
+		"duration.week":           "~1 semana",
+		"duration.weeks":          "~%d semanas",
+		"duration.month":          "~1 mes",
+		"duration.months":         "~%d meses",
+		"kind.file":               "archivo",
+		"kind.dir":                "directorio",
+		"truncated":               "Truncado tras %d entradas.",
+		"col.file":                "Archivo",
+		"col.line":                "Línea",
+		"col.tag":                 "Etiqueta",
+		"col.text":                "Texto",
+		"col.count":               "Cantidad",
+		"col.share":               "Proporción",
+		"col.items":               "Tareas",
+		"col.extension":           "Extensión",
+		"col.introduced":          "Introducida",
+		"col.age":                 "Antigüedad",
+		"col.by":                  "Autor",
+		"col.runs":                "Ejecuciones",
+		"col.path":                "Ruta",
+		"col.phase":               "Fase",
+		"col.code":                "Código",
+		"col.message":             "Mensaje",
+		"col.type":                "Tipo",
+		"col.reason":              "Motivo",
+		"col.rule":                "Regla",
+		"col.symbol":              "Símbolo",
+	},
+	"fr": {
+		"report.title":            "Rapport todototum",
+		"summary.title":           "Résumé todototum",
+		"summary":                 "Résumé",
+		"total":                   "Total",
+		"oldest":                  "Plus ancienne",
+		"newest":                  "Plus récente",
+		"todos":                   "Tâches",
+		"todos.sampled":           "Tâches (échantillon de %d%% des fichiers)",
+		"all-items":               "Toutes les tâches",
+		"sampled-items":           "Tâches échantillonnées",
+		"more-not-shown":          "%d de plus non affichées.",
+		"top-files":               "Fichiers principaux",
+		"by-extension":            "Par extension",
+		"oldest-debt":             "Dette la plus ancienne",
+		"persistent":              "Tâches persistantes",
+		"obsolete":                "Peut-être obsolètes",
+		"obsolete.note":           "Ces tâches mentionnent des identifiants déclarés dans aucun des fichiers Go analysés.",
+		"low-signal":              "Tâches peu pertinentes",
+		"problems":                "Problèmes",
+		"exclusions":              "Exclusions",
+		"no-todos":                "Aucun TODO trouvé.",
+		"generated-by":            "généré par",
+		"age.old":                 "depuis %s",
+		"top-issues":              "Problèmes principaux",
+		"escalated":               "Escaladées",
+		"filtered":                "Filtrées par motif",
+		"noise":                   "Peu pertinentes",
+		"also-at":                 "aussi dans",
+		"own-line":                "sur sa propre ligne",
+		"trailing":                "en fin de ligne",
+		"density":                 "%.2f tâches pour 1000 lignes (%d lignes analysées)",
+		"density.summary":         "Densité : %.2f pour 1000 lignes (%d lignes analysées)",
+		"estimate":                "Total estimé : %d (échantillon de %d %%, %d trouvées)",
+		"estimate.short":          "Total estimé : %d (échantillon de %d %%)",
+		"sample.banner":           "Analyse par échantillonnage : seuls environ %d %% des fichiers ont été lus et les tâches ci-dessous sont les %d qui y ont été trouvées.",
+		"sample.terminal":         "Analyse par échantillonnage : les tâches ci-dessous proviennent d'environ %d %% des fichiers.",
+		"errors":                  "Erreurs",
+		"warnings":                "Avertissements",
+		"burn-down":               "Évolution",
+		"burn-down.since":         "Depuis le %s :",
+		"burn-down.changes":       "%d résolues, %d ajoutées, solde %+d (%d → %d).",
+		"projection.total":        "le total",
+		"projection.tag":          "le nombre de %s",
+		"projection.zero":         "Au rythme actuel, %s atteint zéro dans %s.",
+		"projection.other":        "Pour %s : %s.",
+		"projection.insufficient": "données insuffisantes",
+		"projection.no-zero":      "aucune date prévue pour zéro",
+		"projection.at-zero":      "déjà à zéro",
+		"duration.day":            "~1 jour",
+		"duration.days":           "~%d jours",
+		"duration.week":           "~1 semaine",
+		"duration.weeks":          "~%d semaines",
+		"duration.month":          "~1 mois",
+		"duration.months":         "~%d mois",
+		"kind.file":               "fichier",
+		"kind.dir":                "dossier",
+		"truncated":               "Tronqué après %d entrées.",
+		"col.file":                "Fichier",
+		"col.line":                "Ligne",
+		"col.tag":                 "Étiquette",
+		"col.text":                "Texte",
+		"col.count":               "Nombre",
+		"col.share":               "Part",
+		"col.items":               "Tâches",
+		"col.extension":           "Extension",
+		"col.introduced":          "Introduite",
+		"col.age":                 "Âge",
+		"col.by":                  "Auteur",
+		"col.runs":                "Exécutions",
+		"col.path":                "Chemin",
+		"col.phase":               "Phase",
+		"col.code":                "Code",
+		"col.message":             "Message",
+		"col.type":                "Type",
+		"col.reason":              "Raison",
+		"col.rule":                "Règle",
+		"col.symbol":              "Symbole",
+	},
+}
+
+// Languages returns the languages of the report chrome, sorted.
+func Languages() []string {
+	return sortedKeys(messages)
+}
+
+// ValidateLang returns an error listing the available languages when lang
+// is not one of them. An empty lang stands for DefaultLang.
+func ValidateLang(lang string) error {
+	if _, ok := messages[lang]; ok || lang == "" {
+		return nil
+	}
+	return fmt.Errorf("unknown language %q; must be one of: %s", lang, strings.Join(Languages(), ", "))
+}
+
+// Message returns the report chrome text key in lang, falling back to
+// English when lang lacks it, and to key itself when English does too.
+func Message(lang, key string) string {
+	if s, ok := messages[lang][key]; ok {
+		return s
+	}
+	if s, ok := messages[DefaultLang][key]; ok {
+		return s
+	}
+	return key
+}
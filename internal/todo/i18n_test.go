@@ -0,0 +1,134 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateLang(t *testing.T) {
+	for _, lang := range []string{"", "en", "it", "es", "fr"} {
+		if err := ValidateLang(lang); err != nil {
+			t.Errorf("ValidateLang(%q) = %v", lang, err)
+		}
+	}
+	err := ValidateLang("de")
+	if err == nil || !strings.Contains(err.Error(), "must be one of: en, es, fr, it") {
+		t.Errorf("ValidateLang(de) = %v, want the available languages listed", err)
+	}
+}
+
+func TestMessage_FallsBackToEnglish(t *testing.T) {
+	messages["xx"] = map[string]string{"total": "Tot"}
+	t.Cleanup(func() { delete(messages, "xx") })
+
+	if got := Message("xx", "total"); got != "Tot" {
+		t.Errorf("Message(xx, total) = %q", got)
+	}
+	if got := Message("xx", "todos"); got != "Todos" {
+		t.Errorf("Message(xx, todos) = %q, want the English text", got)
+	}
+	if got := Message("", "summary"); got != "Summary" {
+		t.Errorf("Message(\"\", summary) = %q", got)
+	}
+	if got := Message("it", "no-such-key"); got != "no-such-key" {
+		t.Errorf("Message(it, no-such-key) = %q, want the key", got)
+	}
+
+	var buf bytes.Buffer
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{Lang: "xx"}); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "- Tot: 1\n") || !strings.Contains(out, "## Todos\n") {
+		t.Errorf("Markdown should mix the partial catalog with English:\n%s", out)
+	}
+}
+
+func TestMessages_KeysKnownInEnglish(t *testing.T) {
+	for lang, catalog := range messages {
+		for key := range catalog {
+			if _, ok := messages[DefaultLang][key]; !ok {
+				t.Errorf("%s: key %q has no English text", lang, key)
+			}
+		}
+	}
+}
+
+func TestReports_Italian(t *testing.T) {
+	introduced := time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "review the total", IntroducedAt: &introduced},
+		{Location: Location{File: "b.go", Line: 2}, Tag: "FIXME", Text: "fix"},
+	}
+	opts := ReportOptions{Lang: "it", Now: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), LinesScanned: 400}
+	opts.BurnDown = &BurnDown{
+		Baseline: time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC), BaselineTotal: 3, Current: 2, Resolved: 2, Added: 1, Net: -1,
+		Projections: []Projection{{Current: 2, DaysToZero: 21, Label: "~3 weeks"}, {Tag: "FIXME", Current: 1, Label: ProjectionNoZero}},
+	}
+	opts.Audit = &AuditLog{Entries: []SkipRecord{{Path: "vendor", Dir: true, Reason: SkipReasonIgnoreFlag, Rule: "vendor"}, {Path: "a.min.js", Reason: SkipReasonGitIgnore}}, Limit: 2, Truncated: true}
+
+	var html bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(items, "ignored.html", mdMockFileWriter{buf: &html}, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<html lang="it">`,
+		"<title>Report todototum</title>",
+		`<div class="label">Totale</div>`,
+		"<th>Riga</th>",
+		"<th>Testo</th>",
+		"<summary>Debito più vecchio</summary>",
+		"Più vecchia: a.go:1, da 5y (2018-01-02); più recente: a.go:1",
+		"generato da <strong>",
+		`<span class="tag FIXME">FIXME</span>`,
+		"TODO: review the total",
+		"5.00 attività ogni 1000 righe (400 righe analizzate)",
+		"<strong>Dal 2022-12-01:</strong>",
+		"2 risolte, 1 aggiunte, saldo -1 (3 → 2).",
+		"<li>Al ritmo attuale, il totale arriva a zero in ~3 settimane.</li>",
+		"<li>Per il numero di FIXME: nessuna data prevista per lo zero.</li>",
+		"<td>cartella</td>",
+		"<p>Troncato dopo 2 voci.</p>",
+	} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML report lacks %q", want)
+		}
+	}
+
+	var md bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &md}, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Report todototum\n\n## Riepilogo\n\n- Totale: 2\n- FIXME: 1",
+		"## Debito più vecchio\n\n| Introdotta | Età | Autore | File | Riga | Testo |",
+		"## Attività\n\n| File | Riga | Tag | Testo |",
+		"| TODO: review the total |",
+		"- 5.00 attività ogni 1000 righe (400 righe analizzate)\n",
+		"## Andamento\n\nDal 2022-12-01: 2 risolte, 1 aggiunte, saldo -1 (3 → 2).\n\n- Al ritmo attuale, il totale arriva a zero in ~3 settimane.\n- Per il numero di FIXME: nessuna data prevista per lo zero.\n",
+		"| vendor | cartella | ignore-flag | vendor |",
+		"| a.min.js | file | gitignore |  |",
+		"_Troncato dopo 2 voci._",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown report lacks %q:\n%s", want, md.String())
+		}
+	}
+
+	opts.MarkdownCompact = true
+	opts.MarkdownMaxRows = 1
+	compact := renderMarkdown(buildReportData(items, opts), opts)
+	for _, want := range []string{
+		"## Riepilogo todototum\n\n**Totale: 2**",
+		"| Tag | Numero | Quota |",
+		"### File principali\n\n| File | Attività |",
+		"<summary>Tutte le attività (2)</summary>",
+		"_Altre 1 non mostrate._",
+	} {
+		if !strings.Contains(compact, want) {
+			t.Errorf("compact Markdown lacks %q:\n%s", want, compact)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package todo
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults for TraceIntroduced.
+const (
+	DefaultIntroducedLimit   = 200
+	DefaultIntroducedTimeout = 5 * time.Second
+)
+
+// IntroducedOptions bounds the history search performed by TraceIntroduced.
+type IntroducedOptions struct {
+	// Limit is the maximum number of items traced, most severe first
+	// (DefaultIntroducedLimit if <= 0).
+	Limit int
+	// Timeout bounds each git invocation (DefaultIntroducedTimeout if <= 0).
+	Timeout time.Duration
+}
+
+// introducedResult is what a single history lookup yields.
+type introducedResult struct {
+	at     time.Time
+	by     string
+	commit string
+	ok     bool
+}
+
+// TraceIntroduced finds, for each item, the earliest commit whose diff added
+// the item's text to its file (a `git log -S` pickaxe search) and records it
+// in IntroducedAt, IntroducedBy and IntroducedCommit. dir is the directory
+// item paths are relative to. Lookups are expensive, so only the opts.Limit
+// most severe items are traced, each under its own timeout, and identical
+// file/tag/text combinations are looked up once. Items whose lookup fails or
+// times out are left untouched.
+func TraceIntroduced(ctx context.Context, dir string, items []Todo, opts IntroducedOptions, git GitRunner) []Todo {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultIntroducedLimit
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultIntroducedTimeout
+	}
+
+	out := make([]Todo, len(items))
+	copy(out, items)
+
+	// Trace the most severe items first; file/line order breaks ties.
+	order := make([]int, len(out))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ta, tb := out[order[a]], out[order[b]]
+		sa, sb := StyleFor(ta.Tag).Severity, StyleFor(tb.Tag).Severity
+		if sa != sb {
+			return sa > sb
+		}
+		if ta.File != tb.File {
+			return ta.File < tb.File
+		}
+		return ta.Line < tb.Line
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	cache := make(map[string]introducedResult)
+	for _, i := range order {
+		if ctx.Err() != nil {
+			break
+		}
+		t := &out[i]
+		key := t.File + "\x00" + t.Tag + "\x00" + t.Text
+		res, seen := cache[key]
+		if !seen {
+			res = lookupIntroduced(ctx, dir, *t, timeout, git)
+			cache[key] = res
+		}
+		if res.ok {
+			at := res.at
+			t.IntroducedAt = &at
+			t.IntroducedBy = res.by
+			t.IntroducedCommit = res.commit
+		}
+	}
+	return out
+}
+
+// lookupIntroduced runs the pickaxe search for a single item.
+func lookupIntroduced(ctx context.Context, dir string, t Todo, timeout time.Duration, git GitRunner) introducedResult {
+	needle := strings.TrimSpace(t.Text)
+	if needle == "" {
+		needle = t.Tag
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, err := git.Run(cctx, dir, "log", "--reverse", "--format=%H%x09%aI%x09%an", "-S"+needle, "--", normalizePath(t.File))
+	if err != nil {
+		return introducedResult{}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			continue
+		}
+		return introducedResult{at: at.UTC(), by: parts[2], commit: parts[0], ok: true}
+	}
+	return introducedResult{}
+}
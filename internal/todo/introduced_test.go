@@ -0,0 +1,154 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedGit returns canned output keyed by the -S needle and records calls.
+type scriptedGit struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	block   map[string]bool
+	calls   []string
+}
+
+func (g *scriptedGit) Run(ctx context.Context, _ string, args ...string) (string, error) {
+	var needle string
+	for _, a := range args {
+		if strings.HasPrefix(a, "-S") {
+			needle = strings.TrimPrefix(a, "-S")
+		}
+	}
+	g.mu.Lock()
+	g.calls = append(g.calls, needle)
+	g.mu.Unlock()
+	if g.block[needle] {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	return g.outputs[needle], nil
+}
+
+func TestTraceIntroduced_RecordsEarliestCommit(t *testing.T) {
+	git := &scriptedGit{outputs: map[string]string{
+		"handle errors": "aaa111\t2019-03-01T10:00:00+01:00\tAda\nbbb222\t2021-01-01T00:00:00Z\tBob\n",
+	}}
+	items := []Todo{{Location: Location{File: "a.go", Line: 3}, Tag: "TODO", Text: "handle errors"}}
+	got := TraceIntroduced(context.Background(), ".", items, IntroducedOptions{}, git)
+	if got[0].IntroducedAt == nil {
+		t.Fatalf("expected introduction date, got %#v", got[0])
+	}
+	want := time.Date(2019, 3, 1, 9, 0, 0, 0, time.UTC)
+	if !got[0].IntroducedAt.Equal(want) || got[0].IntroducedBy != "Ada" || got[0].IntroducedCommit != "aaa111" {
+		t.Fatalf("unexpected trace: %v %q %q", got[0].IntroducedAt, got[0].IntroducedBy, got[0].IntroducedCommit)
+	}
+	if items[0].IntroducedAt != nil {
+		t.Fatal("input items must not be mutated")
+	}
+}
+
+func TestTraceIntroduced_LimitPrefersSeverity(t *testing.T) {
+	out := "c\t2020-01-01T00:00:00Z\tX\n"
+	git := &scriptedGit{outputs: map[string]string{"note": out, "todo": out, "bug": out}}
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "NOTE", Text: "note"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "todo"},
+		{Location: Location{File: "a.go", Line: 3}, Tag: "BUG", Text: "bug"},
+	}
+	got := TraceIntroduced(context.Background(), ".", items, IntroducedOptions{Limit: 2}, git)
+	if got[2].IntroducedAt == nil || got[1].IntroducedAt == nil {
+		t.Fatalf("BUG and TODO should be traced first: %#v", got)
+	}
+	if got[0].IntroducedAt != nil {
+		t.Fatalf("NOTE should be skipped by the limit: %#v", got[0])
+	}
+}
+
+func TestTraceIntroduced_TimeoutAndCache(t *testing.T) {
+	git := &scriptedGit{
+		outputs: map[string]string{"dup": "c\t2020-01-01T00:00:00Z\tX\n"},
+		block:   map[string]bool{"slow": true},
+	}
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "dup"},
+		{Location: Location{File: "a.go", Line: 9}, Tag: "TODO", Text: "dup"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "TODO", Text: "slow"},
+	}
+	got := TraceIntroduced(context.Background(), ".", items, IntroducedOptions{Timeout: 10 * time.Millisecond}, git)
+	if got[2].IntroducedAt != nil {
+		t.Fatalf("timed out lookup should leave item untouched: %#v", got[2])
+	}
+	if got[0].IntroducedAt == nil || got[1].IntroducedAt == nil {
+		t.Fatalf("duplicate items should both be traced: %#v", got)
+	}
+	if len(git.calls) != 2 {
+		t.Fatalf("expected identical items to share one lookup, calls: %v", git.calls)
+	}
+}
+
+func TestGenerateMarkdownReport_OldestDebtSection(t *testing.T) {
+	older := time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 5, 6, 0, 0, 0, 0, time.UTC)
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "new", IntroducedAt: &newer, IntroducedBy: "Bob"},
+		{Location: Location{File: "b.go", Line: 2}, Tag: "BUG", Text: "old", IntroducedAt: &older, IntroducedBy: "Ada"},
+		{Location: Location{File: "c.go", Line: 3}, Tag: "NOTE", Text: "untraced"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{Now: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	oldRow := strings.Index(out, "| 2018-01-02 | 5y | Ada | b.go | 2 | BUG: old |")
+	newRow := strings.Index(out, "| 2022-05-06 | 8mo | Bob | a.go | 1 | TODO: new |")
+	if oldRow < 0 || newRow < 0 || oldRow > newRow {
+		t.Fatalf("oldest debt section missing or misordered:\n%s", out)
+	}
+	if strings.Index(out, "## Oldest debt") > strings.Index(out, "## Todos") {
+		t.Fatalf("oldest debt should precede the full table:\n%s", out)
+	}
+}
+
+func TestBuildReportData_AgeRange(t *testing.T) {
+	older := time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 5, 6, 0, 0, 0, 0, time.UTC)
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "new", IntroducedAt: &newer},
+		{Location: Location{File: "b.go", Line: 2}, Tag: "BUG", Text: "old", IntroducedAt: &older},
+		{Location: Location{File: "c.go", Line: 3}, Tag: "TODO", Text: "same day", IntroducedAt: &newer},
+		{Location: Location{File: "d.go", Line: 4}, Tag: "NOTE", Text: "untraced"},
+	}
+	opts := ReportOptions{Now: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)}
+	s := buildReportData(items, opts).Summary
+	if s.Oldest == nil || s.Oldest.File != "b.go" || s.Oldest.Age != "5y" {
+		t.Errorf("Oldest = %+v", s.Oldest)
+	}
+	if s.Newest == nil || s.Newest.File != "a.go" || s.Newest.Age != "8mo" {
+		t.Errorf("Newest = %+v, want the first of the newest items", s.Newest)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(items, "ignored.html", mdMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Oldest: b.go:2, 5y old (2018-01-02); newest: a.go:1, 8mo old (2022-05-06)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("HTML report lacks %q", want)
+	}
+
+	s = buildReportData(items[3:], opts).Summary
+	if s.Oldest != nil || s.Newest != nil {
+		t.Errorf("undated items should leave the range unset: %+v, %+v", s.Oldest, s.Newest)
+	}
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(items[3:], "ignored.html", mdMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Oldest:") {
+		t.Error("HTML report shows an age range without dates")
+	}
+}
@@ -0,0 +1,289 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Match is a marker found on a single line by Scanner.MatchLine.
+type Match struct {
+	// Tag is the matched marker, upper-cased; Text its trimmed description.
+	Tag  string
+	Text string
+	// Offset is the byte offset of the tag within the line and Column its
+	// 1-based rune offset, as in Todo.Column.
+	Offset int
+	Column int
+	// Assignee is the owner named right after the tag, as in
+	// "TODO(alice): ..."; empty when there is none.
+	Assignee string
+	// Refs lists the ticket references mentioned in Text.
+	Refs []string
+	// Findings lists the LintRules the item breaks, when checked.
+	Findings []LintFinding
+}
+
+// MatchLine matches a single line with the Scanner's tag pattern, as a scan
+// of a file holding just that line would, and returns its first item. Under
+// RespectIgnoreComments, a line carrying a todototum:ignore,
+// todototum:disable or todototum:enable directive yields no match. Text is
+// cleaned with SanitizeText and redacted under Redact. Detectors, which
+// need surrounding lines, do not apply. MatchLine does no I/O and is cheap
+// enough to run on every keystroke.
+func (s *Scanner) MatchLine(line string) (Match, bool) {
+	m, ok := s.match(line)
+	if !ok {
+		return Match{}, false
+	}
+	if s.opts.RespectIgnoreComments {
+		for _, d := range []string{ignoreDirective, disableDirective, enableDirective} {
+			if strings.Contains(line, d) {
+				return Match{}, false
+			}
+		}
+	}
+	m.Text = s.opts.itemText(m.Text)
+	m.Assignee = Assignee(m.Text)
+	m.Refs = ExtractRefs(m.Text)
+	return m, true
+}
+
+// match finds the first item on line, as MatchLine reports it.
+func (s *Scanner) match(line string) (Match, bool) {
+	ms := s.matchAll(line)
+	if len(ms) == 0 {
+		return Match{}, false
+	}
+	return ms[0].Match, true
+}
+
+// tagMatch is a Match with the byte offset just past its tag.
+type tagMatch struct {
+	Match
+	end int
+}
+
+// matchAll finds the items on line: the first tag, then unless
+// FirstMatchOnly each later tag written as a marker (see
+// ScanOptions.FirstMatchOnly). An item's text runs from after its tag and
+// colon to the next marker, without the separators before it, or to the end
+// of the line. It is the matching step shared by scans and MatchLine.
+func (s *Scanner) matchAll(line string) []tagMatch {
+	if !s.mayMatch(line) {
+		return nil
+	}
+	idx := findTag(s.opts.re, line)
+	if idx == nil {
+		return nil
+	}
+	// starts holds each item's tag start and end, and text offset.
+	starts := [][3]int{{idx[2], idx[3], idx[1]}}
+	if idx[4] >= 0 {
+		starts[0][2] = idx[4]
+	}
+	if !s.opts.FirstMatchOnly {
+		for _, loc := range s.opts.marker.FindAllStringSubmatchIndex(line, -1) {
+			if loc[2] < starts[0][2] || !decide(line, loc[2], loc[3]) {
+				continue
+			}
+			text := loc[3]
+			if line[text] == ':' {
+				text++
+			}
+			starts = append(starts, [3]int{loc[2], loc[3], text})
+		}
+	}
+	ms := make([]tagMatch, len(starts))
+	for i, st := range starts {
+		end := len(line)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		text := strings.TrimSpace(line[st[2]:end])
+		if end < len(line) {
+			text = strings.TrimRight(text, itemSeparators)
+		}
+		ms[i] = tagMatch{Match{
+			Tag:    s.opts.canonicalTag(strings.ToUpper(line[st[0]:st[1]])),
+			Text:   text,
+			Offset: st[0],
+			Column: utf8.RuneCountInString(line[:st[0]]) + 1,
+		}, st[1]}
+	}
+	return ms
+}
+
+// findTag returns the submatch indices of the first match of re in line
+// whose tag, submatch 1, decide accepts, or nil.
+func findTag(re *regexp.Regexp, line string) []int {
+	for off := 0; off < len(line); {
+		idx := re.FindStringSubmatchIndex(line[off:])
+		if idx == nil {
+			return nil
+		}
+		for i := range idx {
+			if idx[i] >= 0 {
+				idx[i] += off
+			}
+		}
+		if decide(line, idx[2], idx[3]) {
+			return idx
+		}
+		off = idx[3]
+	}
+	return nil
+}
+
+// urlSpan matches a URL with a scheme, up to the first character that
+// usually ends one in prose or markup.
+var urlSpan = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s<>"'()\[\]` + "`" + `]*`)
+
+// decide reports whether the tag at line[start:end], found by a tag pattern,
+// is a marker rather than part of a path, URL or identifier such as
+// "GET /todos/{id}", "app.todo", "http://x/todo:1" or "Fußnote" (the
+// pattern's \b only knows ASCII letters). It rejects a tag
+//   - preceded by a letter or '_', by a '/' other than a "//" comment
+//     opener, or by a '.' or '-' that follows a letter or digit;
+//   - followed by a letter, by a '/' after an optional colon, or by a '.'
+//     and a letter or digit, as in "todo.txt";
+//   - inside a URL.
+//
+// Markers next to other punctuation, as in "//TODO:", "(TODO)", "--TODO" or
+// "TODO.", are kept.
+func decide(line string, start, end int) bool {
+	if start > 0 {
+		prev, size := utf8.DecodeLastRuneInString(line[:start])
+		before := start - size
+		switch {
+		case unicode.IsLetter(prev) || prev == '_':
+			return false
+		case prev == '/':
+			if before == 0 || line[before-1] != '/' {
+				return false
+			}
+		case prev == '.' || prev == '-':
+			if p, _ := utf8.DecodeLastRuneInString(line[:before]); before > 0 && isAlnum(p) {
+				return false
+			}
+		}
+	}
+	after := end
+	if after < len(line) && line[after] == ':' {
+		after++
+	}
+	if after < len(line) {
+		next, size := utf8.DecodeRuneInString(line[after:])
+		switch {
+		case next == '/' || (after == end && unicode.IsLetter(next)):
+			return false
+		case next == '.':
+			if n, _ := utf8.DecodeRuneInString(line[after+size:]); after+size < len(line) && isAlnum(n) {
+				return false
+			}
+		}
+	}
+	if strings.Contains(line, "://") {
+		for _, span := range urlSpan.FindAllStringIndex(line, -1) {
+			if start >= span[0] && start < span[1] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isAlnum reports whether r is a letter or a digit.
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// itemSeparators are trimmed from the end of an item's text when another
+// item follows on the line, as in "TODO: a — FIXME: b" or
+// "TODO: a // FIXME: b".
+const itemSeparators = " \t-–—;,|/#*"
+
+// mayMatch is a cheap prefilter for match: it reports false only when line,
+// all ASCII, contains none of the tags in any case. Lines with other bytes
+// go to the pattern, whose case folding also maps e.g. 'K' (Kelvin) to 'k'.
+func (s *Scanner) mayMatch(line string) bool {
+	for i := 0; i < len(line); i++ {
+		if line[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+	for _, tag := range s.tags {
+		if containsFoldASCII(line, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFoldASCII reports whether s contains lower, an all lower-case
+// string, ignoring ASCII case.
+func containsFoldASCII(s, lower string) bool {
+	if lower == "" {
+		return true
+	}
+	first := lower[0]
+	for i := 0; i+len(lower) <= len(s); i++ {
+		if s[i]|0x20 != first {
+			continue
+		}
+		j := 1
+		for j < len(lower) && asciiLower(s[i+j]) == lower[j] {
+			j++
+		}
+		if j == len(lower) {
+			return true
+		}
+	}
+	return false
+}
+
+// asciiLower lower-cases an ASCII letter and returns other bytes as is.
+func asciiLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// LintFinding is a lint rule an item breaks.
+type LintFinding struct {
+	// Rule is LintEmptyText or LintShortText.
+	Rule    string
+	Message string
+}
+
+// Lint rule names.
+const (
+	LintEmptyText = "empty-text"
+	LintShortText = "short-text"
+)
+
+// LintRules are the description checks of the scan command's policy flags.
+// The zero value checks nothing.
+type LintRules struct {
+	// MinTextLength is the fewest runes a description may have, as with
+	// --min-text-length; 0 disables the check.
+	MinTextLength int
+	// RequireText flags items without any description.
+	RequireText bool
+}
+
+// Check returns the rules text, an item's description, breaks. An empty
+// description breaks RequireText only, not MinTextLength as well.
+func (r LintRules) Check(text string) []LintFinding {
+	n := utf8.RuneCountInString(strings.TrimSpace(text))
+	switch {
+	case n == 0 && r.RequireText:
+		return []LintFinding{{Rule: LintEmptyText, Message: "description is empty"}}
+	case n < r.MinTextLength:
+		return []LintFinding{{Rule: LintShortText, Message: fmt.Sprintf("description is shorter than %d characters", r.MinTextLength)}}
+	}
+	return nil
+}
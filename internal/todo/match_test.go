@@ -0,0 +1,277 @@
+package todo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanner_MatchLine(t *testing.T) {
+	s, err := NewScanner(DefaultScanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := s.MatchLine(`	x := 1 // todo(bob): fix PROJ-12`)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	want := Match{Tag: "TODO", Text: "(bob): fix PROJ-12", Offset: 11, Column: 12, Assignee: "bob", Refs: []string{"PROJ-12"}}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("got %+v, want %+v", m, want)
+	}
+
+	for _, line := range []string{
+		"plain code",
+		`x := "TODO" // todototum:ignore`,
+		"// TODO: legacy below todototum:disable",
+		"// TODO: legacy above todototum:enable",
+		"todos := 3",
+	} {
+		if _, ok := s.MatchLine(line); ok {
+			t.Errorf("MatchLine(%q) matched", line)
+		}
+	}
+}
+
+func TestScanner_MatchLine_MultiByteOffsets(t *testing.T) {
+	s, _ := NewScanner(DefaultScanOptions())
+	line := "// héllo wörld FIXME: ünïcode"
+	m, ok := s.MatchLine(line)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if line[m.Offset:m.Offset+5] != "FIXME" {
+		t.Fatalf("Offset %d does not point at the tag", m.Offset)
+	}
+	if m.Column != 16 || m.Offset != 17 {
+		t.Fatalf("Column = %d, Offset = %d; want 16, 17", m.Column, m.Offset)
+	}
+}
+
+func TestScanner_MatchLine_AgreesWithScan(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Tags = []string{"HACK", "XXX"}
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		"# hack: pin the old API",
+		"// TODO: not a custom tag",
+		"// XXX key=" + strings.Repeat("a1", 20),
+		"const ü = 1 // XXX(ann) ABC-7",
+	}
+	items, err := s.ScanString("a.py", strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matched []Todo
+	for i, line := range lines {
+		if m, ok := s.MatchLine(line); ok {
+			matched = append(matched, Todo{Location: Location{File: "a.py", Line: i + 1, Column: m.Column}, Tag: m.Tag, Text: m.Text, Refs: m.Refs})
+		}
+	}
+	for i := range items {
+		items[i].Position = ""
+	}
+	if !reflect.DeepEqual(matched, items) {
+		t.Fatalf("MatchLine disagrees with scan:\n%+v\n%+v", matched, items)
+	}
+}
+
+func TestLintRules_Check(t *testing.T) {
+	r := LintRules{MinTextLength: 5, RequireText: true}
+	tests := map[string]string{"": LintEmptyText, "  ": LintEmptyText, "fix": LintShortText, "fix it": ""}
+	for text, want := range tests {
+		got := r.Check(text)
+		if want == "" {
+			if got != nil {
+				t.Errorf("Check(%q) = %v, want none", text, got)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0].Rule != want {
+			t.Errorf("Check(%q) = %v, want %s", text, got, want)
+		}
+	}
+	if got := (LintRules{}).Check(""); got != nil {
+		t.Errorf("zero rules flagged %v", got)
+	}
+}
+
+func TestAssignee(t *testing.T) {
+	for text, want := range map[string]string{"(alice): x": "alice", "( bob ) y": "bob", "no owner": "", "(unclosed": ""} {
+		if got := Assignee(text); got != want {
+			t.Errorf("Assignee(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestScanner_MatchLine_PrefilterAgreesWithPattern(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Tags = []string{"HACK", "todo"}
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"// HaCk: x", "// ToDo", "// HAC\u212A: kelvin sign", "hac k", "to_do", "", "@[TODO`"} {
+		_, got := s.MatchLine(line)
+		want := s.opts.re.MatchString(line)
+		if got != want {
+			t.Errorf("MatchLine(%q) = %v, pattern says %v", line, got, want)
+		}
+	}
+}
+
+func TestScanner_MultipleTagsPerLine(t *testing.T) {
+	s, err := NewScanner(DefaultScanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	type item struct {
+		Column    int
+		Tag, Text string
+	}
+	tests := []struct {
+		name, line string
+		want       []item
+	}{
+		{"two tags", "// TODO: handle error — FIXME: also leaking the fd", []item{{4, "TODO", "handle error"}, {25, "FIXME", "also leaking the fd"}}},
+		{"three tags", "# TODO: a; BUG(ann): b // NOTE: c", []item{{3, "TODO", "a"}, {12, "BUG", "(ann): b"}, {27, "NOTE", "c"}}},
+		{"adjacent tags", "// TODO: FIXME: leak", []item{{4, "TODO", ""}, {10, "FIXME", "leak"}}},
+		{"tag in prose", "// TODO: note the bug in the parser", []item{{4, "TODO", "note the bug in the parser"}}},
+		{"bare first tag", "// todo fixme: later", []item{{4, "TODO", ""}, {9, "FIXME", "later"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := s.ScanString("a.go", tc.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []item
+			for _, it := range items {
+				got = append(got, item{it.Column, it.Tag, it.Text})
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+
+	opts := DefaultScanOptions()
+	opts.FirstMatchOnly = true
+	first, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := first.ScanString("a.go", tests[0].line)
+	if err != nil || len(items) != 1 || items[0].Text != "handle error — FIXME: also leaking the fd" {
+		t.Fatalf("FirstMatchOnly: %+v, %v", items, err)
+	}
+}
+
+func TestScanner_MatchLine_PathsAndIdentifiers(t *testing.T) {
+	s, err := NewScanner(DefaultScanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, line string
+		// column of the item found, 0 for none.
+		column int
+	}{
+		// Endpoint docs and URLs.
+		{"plural route", "// registers GET /todos and POST /todos/{id}", 0},
+		{"singular route", "// handler for GET /todo/{id}", 0},
+		{"route with colon param", "// route: /api/v1/todo:id", 0},
+		{"url", "// served at https://api.example.com/todo/list", 0},
+		{"url ending in the tag", "// mirrors http://example.com/note", 0},
+		{"markdown link to url", "// see [the list](https://example.com/notes/todo)", 0},
+		{"markdown link to path", "// open [the list](/todo)", 0},
+		{"marker after a url", "// from http://x/todo:1 TODO: real one", 25},
+		{"url in the text", "// TODO: see https://example.com/todo", 4},
+		// Identifiers and file names.
+		{"dotted name", "// reads app.todo.yml", 0},
+		{"dashed name", "// renders the my-todo component", 0},
+		{"file name", "// writes results to todo.txt", 0},
+		{"german word", "// Siehe Fußnote 3", 0},
+		{"accented suffix", "// NOTEé", 0},
+		// Genuine markers next to punctuation.
+		{"tight line comment", "//TODO: tight", 3},
+		{"triple slash", "///TODO: doc", 4},
+		{"block comment", "/*TODO: block */", 3},
+		{"html comment", "<!--TODO: markup-->", 5},
+		{"sql comment", "--TODO: query", 3},
+		{"parenthesized", "// (TODO) check bounds", 5},
+		{"bracketed", "// [FIXME]: bracketed", 5},
+		{"quoted", "// 'NOTE' this", 5},
+		{"sentence end", "# See the BUG.", 11},
+		{"ellipsis", "# ...NOTE: later", 6},
+		{"owner", "// TODO(alice): x", 4},
+		{"colon then text", "// TODO:fix", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := s.MatchLine(tt.line)
+			if got := map[bool]int{true: m.Column}[ok]; got != tt.column {
+				t.Errorf("MatchLine(%q) = %+v, %v; want column %d", tt.line, m, ok, tt.column)
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		line string
+		tag  string
+		want bool
+	}{
+		{"todo/list", "todo", false},
+		{"todo", "todo", true},
+		{"x/todo", "todo", false},
+		{"//todo", "todo", true},
+		{"_todo", "todo", false},
+		{"-todo", "todo", true},
+		{"a-todo", "todo", false},
+		{".todo", "todo", true},
+		{"a.todo", "todo", false},
+		{"todo.", "todo", true},
+		{"todo.md", "todo", false},
+		{"todo:/x", "todo", false},
+		{"ftp://host/todo", "todo", false},
+	}
+	for _, tt := range tests {
+		start := strings.LastIndex(tt.line, tt.tag)
+		if got := decide(tt.line, start, start+len(tt.tag)); got != tt.want {
+			t.Errorf("decide(%q, %d) = %v, want %v", tt.line, start, got, tt.want)
+		}
+	}
+}
+
+func TestScanner_Aliases(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Tags = []string{"TODO", "FIXME"}
+	opts.Aliases = map[string]string{"HACK": "FIXME", "LATER": "TODO"}
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := s.ScanReader("a.go", strings.NewReader("// hack: a\n// TODO: b LATER: c\n// NOTE: d\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Tag+" "+it.Text)
+	}
+	want := []string{"FIXME a", "TODO b", "TODO c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("items = %q, want %q", got, want)
+	}
+
+	plain := DefaultScanOptions()
+	plain.Tags = opts.Tags
+	if plain.MatchKey() == opts.MatchKey() {
+		t.Error("aliases should change the match key")
+	}
+}
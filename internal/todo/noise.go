@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultNoiseWords are descriptions that say nothing beyond the tag, such
+// as "TODO: fix" or "TODO later".
+var DefaultNoiseWords = []string{"fix", "later", "remove", "cleanup", "wip"}
+
+// NoiseRules classify low-signal items: those whose description, stripped
+// of its assignee and ticket references, is shorter than MinTextLength runes
+// or is one of Words, compared case-insensitively. The zero value classifies
+// nothing.
+type NoiseRules struct {
+	MinTextLength int
+	Words         []string
+}
+
+// SignalText returns the part of an item's description that says what to
+// do: without a leading "(assignee):", ticket references (see ExtractRefs),
+// punctuation at either end and repeated whitespace.
+func SignalText(text string) string {
+	text = strings.TrimSpace(text)
+	if end := strings.IndexByte(text, ')'); strings.HasPrefix(text, "(") && end > 0 {
+		text = text[end+1:]
+	}
+	text = refURL.ReplaceAllString(text, " ")
+	text = refQualified.ReplaceAllString(text, " ")
+	text = refLocal.ReplaceAllString(text, " ")
+	text = refKey.ReplaceAllStringFunc(text, func(key string) string {
+		if prefix, _, _ := strings.Cut(key, "-"); notTicketKeys[prefix] {
+			return key
+		}
+		return " "
+	})
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.TrimFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IsNoise reports whether text, an item's description, is low-signal.
+func (r NoiseRules) IsNoise(text string) bool {
+	s := SignalText(text)
+	if utf8.RuneCountInString(s) < r.MinTextLength {
+		return true
+	}
+	for _, w := range r.Words {
+		if strings.EqualFold(s, strings.TrimSpace(w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitNoise separates the low-signal items from the rest, keeping the
+// order of both.
+func SplitNoise(items []Todo, r NoiseRules) (kept, noise []Todo) {
+	kept = make([]Todo, 0, len(items))
+	for _, t := range items {
+		if r.IsNoise(t.Text) {
+			noise = append(noise, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	return kept, noise
+}
@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSignalText(t *testing.T) {
+	for text, want := range map[string]string{
+		"fix":                                 "fix",
+		"  Fix.  ":                            "Fix",
+		"(alice): fix":                        "fix",
+		"(alice) later":                       "later",
+		"fix #123":                            "fix",
+		"remove, see PROJ-42":                 "remove, see",
+		"wip https://github.com/o/r/issues/7": "wip",
+		"cleanup owner/repo#9":                "cleanup",
+		"handle   UTF-8   input":              "handle UTF-8 input",
+		"":                                    "",
+	} {
+		if got := SignalText(text); got != want {
+			t.Errorf("SignalText(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestNoiseRules_IsNoise(t *testing.T) {
+	r := NoiseRules{MinTextLength: 5, Words: DefaultNoiseWords}
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"fix", true},
+		{"FIX", true},
+		{"Later.", true},
+		{"(bob): WIP #12", true},
+		{"cleanup", true},
+		{"cleanup the parser", false},
+		{"", true},
+		{"abcd", true},
+		{"abcde", false},
+		// Lengths count runes, not bytes.
+		{"äöüß", true},
+		{"äöüßé", false},
+		{"修复这个", true},
+		{"修复这个问题", false},
+	}
+	for _, tt := range tests {
+		if got := r.IsNoise(tt.text); got != tt.want {
+			t.Errorf("IsNoise(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	if (NoiseRules{}).IsNoise("fix") {
+		t.Error("the zero NoiseRules should classify nothing")
+	}
+	if !(NoiseRules{Words: []string{" Revisit "}}).IsNoise("revisit") {
+		t.Error("configured words should match case-insensitively")
+	}
+}
+
+func TestSplitNoise(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "fix"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "validate the config path"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "FIXME", Text: "later"},
+		{Location: Location{File: "b.go", Line: 5}, Tag: "NOTE", Text: "retries are capped at three"},
+	}
+	kept, noise := SplitNoise(items, NoiseRules{Words: DefaultNoiseWords})
+	if want := []Todo{items[1], items[3]}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept = %+v, want %+v", kept, want)
+	}
+	if want := []Todo{items[0], items[2]}; !reflect.DeepEqual(noise, want) {
+		t.Errorf("noise = %+v, want %+v", noise, want)
+	}
+}
@@ -0,0 +1,167 @@
+package todo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// orphanMinLen is the shortest identifier OrphanRefs considers; shorter
+// CamelCase words are too often ordinary names.
+const orphanMinLen = 6
+
+// identRef matches a word that may name a Go identifier, with the package
+// or value qualifier in front of it, if any.
+var identRef = regexp.MustCompile(`(?:\b([A-Za-z_][A-Za-z0-9_]*)\.)?\b([A-Za-z_][A-Za-z0-9_]*)\b`)
+
+// camelCaseWords are CamelCase product and technology names that are not
+// identifiers, lower-cased.
+var camelCaseWords = map[string]bool{
+	"javascript": true, "typescript": true, "coffeescript": true, "github": true, "gitlab": true,
+	"bitbucket": true, "postgresql": true, "mysql": true, "mongodb": true, "youtube": true,
+	"linkedin": true, "graphql": true, "openapi": true, "websocket": true, "websockets": true,
+	"webassembly": true, "devops": true, "nosql": true, "powershell": true, "dynamodb": true,
+	"cloudflare": true, "macos": true, "onedrive": true, "sharepoint": true, "wordpress": true,
+	"openssl": true, "openssh": true, "redhat": true, "sqlite": true, "elasticsearch": true,
+}
+
+// GoSymbols returns the top-level identifiers declared in the Go files at
+// paths, relative to dir: functions, methods, types, constants, variables,
+// and the fields and methods of top-level structs and interfaces. Files
+// that do not parse are skipped.
+func GoSymbols(dir string, paths []string) map[string]bool {
+	syms := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, p := range paths {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, p), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				syms[d.Name.Name] = true
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						syms[s.Name.Name] = true
+						addMemberSymbols(syms, s.Type)
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							syms[n.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return syms
+}
+
+// addMemberSymbols adds the field and method names of a struct or
+// interface type.
+func addMemberSymbols(syms map[string]bool, typ ast.Expr) {
+	var fields *ast.FieldList
+	switch t := typ.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	}
+	if fields == nil {
+		return
+	}
+	for _, f := range fields.List {
+		for _, n := range f.Names {
+			syms[n.Name] = true
+		}
+	}
+}
+
+// OrphanRefs returns the identifiers text mentions that syms does not
+// declare, sorted. Only words that look like exported Go identifiers are
+// considered: CamelCase, starting with an upper-case letter, at least six
+// characters long and not a known product name. References qualified by a
+// lower-case name, like os.ReadFile, are skipped, since they usually point
+// into another package.
+func OrphanRefs(text string, syms map[string]bool) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range identRef.FindAllStringSubmatch(text, -1) {
+		qual, name := m[1], m[2]
+		if qual != "" && unicode.IsLower(rune(qual[0])) {
+			continue
+		}
+		if !isCamelCase(name) || len(name) < orphanMinLen || camelCaseWords[strings.ToLower(name)] {
+			continue
+		}
+		if !syms[name] && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// isCamelCase reports whether s starts with an upper-case letter, has
+// another upper-case letter later on, and has at least one lower-case
+// letter, e.g. MigrateUsersV1 or HTTPServer but not Remove or TODO.
+func isCamelCase(s string) bool {
+	if s == "" || !unicode.IsUpper(rune(s[0])) {
+		return false
+	}
+	lower, upper := false, false
+	for _, r := range s[1:] {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		}
+	}
+	return lower && upper
+}
+
+// OrphanEnricher marks Go items whose text mentions an identifier that is
+// declared in none of the scanned Go files (see OrphanRefs), such as
+// "TODO: remove after MigrateUsersV1 is gone" once MigrateUsersV1 was
+// deleted. It is a heuristic: flagged items are only possibly obsolete.
+type OrphanEnricher struct {
+	// Dir is the directory item paths and Files are relative to.
+	Dir string
+	// Files lists every scanned Go file, whose declarations are the known
+	// symbols.
+	Files []string
+}
+
+// Name implements namedEnricher.
+func (OrphanEnricher) Name() string { return "orphans" }
+
+// Enrich implements Enricher.
+func (e OrphanEnricher) Enrich(items []Todo) ([]Todo, error) {
+	syms := GoSymbols(e.Dir, e.Files)
+	for i := range items {
+		if strings.EqualFold(filepath.Ext(items[i].File), ".go") {
+			items[i].Orphan = len(OrphanRefs(items[i].Text, syms)) > 0
+		}
+	}
+	return items, nil
+}
+
+// orphanItems returns the items marked Orphan, in their original order.
+func orphanItems(items []Todo) []Todo {
+	var out []Todo
+	for _, t := range items {
+		if t.Orphan {
+			out = append(out, t)
+		}
+	}
+	return out
+}
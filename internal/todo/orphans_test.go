@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrphanEnricher(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, dir, "users/migrate.go", `package users
+
+// TODO: remove after MigrateUsersV1 is gone
+func MigrateUsersV2() {}
+
+// FIXME: drop LegacyAccounts once billing moved
+type Store struct {
+	ArchiveMode bool
+}
+`)
+	mustWriteFile(t, dir, "users/store.go", `package users
+
+// TODO: fold into MigrateUsersV2 and Store.ArchiveMode
+// NOTE: see os.ReadFile, GitHub and the HTTPClient docs
+var LegacyAccounts = 1
+`)
+	mustWriteFile(t, dir, "broken.go", "package broken\nfunc (\n")
+
+	items, err := ScanDirWithOptions(dir, DefaultScanOptions(), OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"users/migrate.go", "users/store.go", "broken.go"}
+	items, err = Enrich(items, []Enricher{OrphanEnricher{Dir: dir, Files: files}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range items {
+		if it.Orphan {
+			got = append(got, it.Text)
+		}
+	}
+	// MigrateUsersV1 was deleted; HTTPClient is declared nowhere either.
+	want := []string{"remove after MigrateUsersV1 is gone", "see os.ReadFile, GitHub and the HTTPClient docs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("orphans = %q, want %q", got, want)
+	}
+
+	data := buildReportData(items, ReportOptions{})
+	if len(data.Orphans) != 2 {
+		t.Fatalf("report Orphans = %+v", data.Orphans)
+	}
+}
+
+func TestOrphanRefs(t *testing.T) {
+	syms := map[string]bool{"ParseConfig": true}
+	tests := map[string][]string{
+		"remove after MigrateUsersV1 is gone":   {"MigrateUsersV1"},
+		"use ParseConfig instead of LoadConfig": {"LoadConfig"},
+		"Remove this TODO hack":                 nil, // not CamelCase
+		"rename SetUp":                          nil, // too short
+		"port to TypeScript":                    nil, // product name
+		"call os.ReadFile and bytes.NewBuffer":  nil, // package-qualified
+		"Config.LegacyMode and OldName OldName": {"LegacyMode", "OldName"},
+		"see migrateUsers":                      nil, // unexported-looking
+	}
+	for text, want := range tests {
+		if got := OrphanRefs(text, syms); !reflect.DeepEqual(got, want) {
+			t.Errorf("OrphanRefs(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package todo
+
+import (
+	"path"
+	"sync"
+)
+
+// Override changes the matching settings of a scan for the files of one
+// directory and its subtree, as a nested config file does; see
+// ScanOptions.DirOverride. Unset fields keep the settings from above.
+type Override struct {
+	// Source names where the override comes from, e.g. its config file.
+	Source string
+	// Tags and Detect, when non-nil, replace ScanOptions.Tags and Detect.
+	Tags   []string
+	Detect []string
+	// FirstMatchOnly and RespectIgnoreComments, when non-nil, replace the
+	// ScanOptions fields of the same name.
+	FirstMatchOnly        *bool
+	RespectIgnoreComments *bool
+}
+
+// apply returns opts with the settings of o.
+func (o *Override) apply(opts ScanOptions) ScanOptions {
+	if o.Tags != nil {
+		opts.Tags = o.Tags
+		opts.re, opts.marker = nil, nil
+	}
+	if o.Detect != nil {
+		opts.Detect = o.Detect
+	}
+	if o.FirstMatchOnly != nil {
+		opts.FirstMatchOnly = *o.FirstMatchOnly
+	}
+	if o.RespectIgnoreComments != nil {
+		opts.RespectIgnoreComments = *o.RespectIgnoreComments
+	}
+	return opts
+}
+
+// dirOptions resolves the options of each directory of a scan from
+// ScanOptions.DirOverride, once per directory: a directory starts from the
+// options of its parent and applies its own override, so deeper overrides
+// win. It is safe for concurrent use.
+type dirOptions struct {
+	base ScanOptions
+	hook func(dir string) (*Override, error)
+
+	mu    sync.Mutex
+	byDir map[string]ScanOptions
+	// problems records the overrides that could not be applied; their
+	// subtree keeps the options from above.
+	problems []Problem
+}
+
+func newDirOptions(base ScanOptions) *dirOptions {
+	return &dirOptions{base: base, hook: base.DirOverride, byDir: make(map[string]ScanOptions)}
+}
+
+// forFile returns the options for the file at the slash-separated path
+// file, relative to the scan root.
+func (d *dirOptions) forFile(file string) ScanOptions {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.resolve(path.Dir(file))
+}
+
+// resolve returns the options of dir, resolving its parents first. d.mu
+// must be held.
+func (d *dirOptions) resolve(dir string) ScanOptions {
+	if opts, ok := d.byDir[dir]; ok {
+		return opts
+	}
+	opts := d.base
+	if dir != "." {
+		opts = d.resolve(path.Dir(dir))
+	}
+	o, err := d.hook(dir)
+	if err == nil && o != nil {
+		next := o.apply(opts)
+		if err = next.compileMatchers(); err == nil {
+			err = checkDetectors(next.Detect)
+		}
+		if err == nil {
+			opts = next
+		}
+	}
+	if err != nil {
+		source := dir
+		if o != nil && o.Source != "" {
+			source = o.Source
+		}
+		d.problems = append(d.problems, Problem{Path: source, Phase: PhaseConfig, Code: ProblemConfig, Message: err.Error()})
+	}
+	d.byDir[dir] = opts
+	return opts
+}
@@ -0,0 +1,75 @@
+package todo
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestScanDirDetailed_DirOverride(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "a.go", "// TODO: root\n// HACK: root\n")
+	mustWriteFile(t, tmp, "legacy/b.go", "// TODO: hopeless\n// FIXME: legacy\n")
+	mustWriteFile(t, tmp, "legacy/old/c.go", "// TODO: hopeless too\n")
+	mustWriteFile(t, tmp, "legacy/keep/d.go", "// TODO: restored\n// HACK: restored\n")
+	mustWriteFile(t, tmp, "broken/e.go", "// TODO: parent settings\n")
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	opts := DefaultScanOptions()
+	opts.Tags = []string{"TODO", "HACK"}
+	opts.DirOverride = func(dir string) (*Override, error) {
+		mu.Lock()
+		calls[dir]++
+		mu.Unlock()
+		switch dir {
+		case "legacy":
+			return &Override{Source: "legacy/.todototum.yml", Tags: []string{"FIXME", "BUG"}}, nil
+		case "legacy/keep":
+			return &Override{Tags: []string{"TODO", "HACK"}}, nil
+		case "broken":
+			return &Override{Source: "broken/.todototum.yml", Tags: []string{"not a tag"}}, nil
+		}
+		return nil, nil
+	}
+	res, err := ScanDirDetailed(tmp, opts, OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range res.Items {
+		got = append(got, it.File+" "+it.Tag)
+	}
+	sort.Strings(got)
+	want := []string{"a.go HACK", "a.go TODO", "broken/e.go TODO", "legacy/b.go FIXME", "legacy/keep/d.go HACK", "legacy/keep/d.go TODO"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("items = %q, want %q", got, want)
+	}
+	for dir, n := range calls {
+		if n != 1 {
+			t.Errorf("DirOverride(%q) called %d times, want once", dir, n)
+		}
+	}
+	if len(calls) != 5 {
+		t.Errorf("DirOverride called for %v, want the 5 directories", calls)
+	}
+	if len(res.Problems) != 1 || res.Problems[0].Path != "broken/.todototum.yml" || res.Problems[0].Code != ProblemConfig {
+		t.Errorf("problems = %+v, want the invalid broken override", res.Problems)
+	}
+
+	opts.DirOverride = func(dir string) (*Override, error) {
+		if dir == "legacy" {
+			return nil, errors.New("legacy/.todototum.yml: unreadable")
+		}
+		return nil, nil
+	}
+	res, err = ScanDirDetailed(tmp, opts, OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 7 || len(res.Problems) != 1 || res.Problems[0].Path != "legacy" || res.Problems[0].Phase != PhaseConfig {
+		t.Errorf("a failing override should keep the parent settings: %d items, problems %+v", len(res.Items), res.Problems)
+	}
+}
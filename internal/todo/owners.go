@@ -0,0 +1,136 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnownedReportName is the base name of the report holding items without
+// owners when reports are split by owner.
+const UnownedReportName = "unowned"
+
+// OwnerGroup is the items owned by one owner.
+type OwnerGroup struct {
+	Owner string
+	Items []Todo
+}
+
+// SplitByOwner groups items by their Owners, ordered by owner. An item with
+// several owners is put in each of their groups. Items without owners are
+// returned separately.
+func SplitByOwner(items []Todo) (groups []OwnerGroup, unowned []Todo) {
+	byOwner := make(map[string][]Todo)
+	for _, t := range items {
+		if len(t.Owners) == 0 {
+			unowned = append(unowned, t)
+			continue
+		}
+		seen := make(map[string]bool, len(t.Owners))
+		for _, o := range t.Owners {
+			if !seen[o] {
+				seen[o] = true
+				byOwner[o] = append(byOwner[o], t)
+			}
+		}
+	}
+	for o, its := range byOwner {
+		groups = append(groups, OwnerGroup{Owner: o, Items: its})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Owner < groups[j].Owner })
+	return groups, unowned
+}
+
+// OwnerFileName turns an owner handle into a file base name: the leading
+// '@' is dropped and anything but letters, digits, '.', '_' and '-' becomes
+// '-', so "@acme/team-platform" gives "acme-team-platform".
+func OwnerFileName(owner string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, strings.TrimPrefix(owner, "@"))
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "owner"
+	}
+	return name
+}
+
+// OwnerIndex summarizes a set of per-owner reports.
+type OwnerIndex struct {
+	// Total counts distinct items: one owned by several owners is listed in
+	// each of their reports but counted once here.
+	Total int `json:"total"`
+	// Shared is the number of items with more than one owner.
+	Shared  int           `json:"shared"`
+	Owners  []OwnerReport `json:"owners"`
+	Unowned *OwnerReport  `json:"unowned,omitempty"`
+}
+
+// OwnerReport is one entry of an OwnerIndex.
+type OwnerReport struct {
+	Owner string `json:"owner,omitempty"`
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
+// NewOwnerIndex builds the index of the reports split from items by
+// SplitByOwner, naming each report OwnerFileName(owner)+ext. Owners whose
+// names collide get a numeric suffix, and none may take the unowned report's
+// name.
+func NewOwnerIndex(items []Todo, groups []OwnerGroup, unowned []Todo, ext string) OwnerIndex {
+	idx := OwnerIndex{Total: len(items), Owners: []OwnerReport{}}
+	for _, t := range items {
+		if len(t.Owners) > 1 {
+			idx.Shared++
+		}
+	}
+	used := map[string]bool{UnownedReportName: true, "index": true}
+	for _, g := range groups {
+		base := OwnerFileName(g.Owner)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		idx.Owners = append(idx.Owners, OwnerReport{Owner: g.Owner, File: name + ext, Count: len(g.Items)})
+	}
+	if len(unowned) > 0 {
+		idx.Unowned = &OwnerReport{File: UnownedReportName + ext, Count: len(unowned)}
+	}
+	return idx
+}
+
+// GenerateOwnerIndexWithWriter writes idx to output as Markdown, or as JSON
+// when format is "json".
+func GenerateOwnerIndexWithWriter(idx OwnerIndex, output, format string, w FileWriter) error {
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+	if format == "json" {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(idx)
+	}
+	var b strings.Builder
+	b.WriteString("# todototum reports by owner\n\n")
+	b.WriteString(fmt.Sprintf("- Total: %d\n", idx.Total))
+	if idx.Shared > 0 {
+		b.WriteString(fmt.Sprintf("- Shared by several owners: %d (listed in each owner's report, counted once in the total)\n", idx.Shared))
+	}
+	b.WriteString("\n| Owner | Items | Report |\n|-------|------:|--------|\n")
+	for _, o := range idx.Owners {
+		b.WriteString(fmt.Sprintf("| %s | %d | %s |\n", EscapeMarkdown(o.Owner), o.Count, MarkdownLink(o.File, o.File)))
+	}
+	if idx.Unowned != nil {
+		b.WriteString(fmt.Sprintf("| _unowned_ | %d | %s |\n", idx.Unowned.Count, MarkdownLink(idx.Unowned.File, idx.Unowned.File)))
+	}
+	_, err = f.Write([]byte(b.String()))
+	return err
+}
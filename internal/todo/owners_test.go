@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSplitByOwner_MultipleOwners(t *testing.T) {
+	shared := Todo{Location: Location{File: "internal/todo/scan.go"}, Tag: "TODO", Owners: []string{"@acme/platform", "@acme/reports"}}
+	items := []Todo{
+		shared,
+		{Location: Location{File: "internal/config/config.go"}, Tag: "FIXME", Owners: []string{"@acme/platform"}},
+		{Location: Location{File: "gen/types.go"}, Tag: "NOTE"},
+	}
+	groups, unowned := SplitByOwner(items)
+	if len(groups) != 2 || groups[0].Owner != "@acme/platform" || groups[1].Owner != "@acme/reports" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if len(groups[0].Items) != 2 || len(groups[1].Items) != 1 || groups[1].Items[0].File != shared.File {
+		t.Fatalf("the shared item should be in both groups: %+v", groups)
+	}
+	if len(unowned) != 1 {
+		t.Fatalf("unowned = %+v", unowned)
+	}
+
+	idx := NewOwnerIndex(items, groups, unowned, ".md")
+	// Per-owner counts add up to 3 but the shared item is counted once.
+	if idx.Total != 3 || idx.Shared != 1 {
+		t.Fatalf("total/shared = %d/%d, want 3/1", idx.Total, idx.Shared)
+	}
+	if idx.Owners[0].File != "acme-platform.md" || idx.Owners[0].Count != 2 || idx.Owners[1].File != "acme-reports.md" {
+		t.Fatalf("unexpected owner entries: %+v", idx.Owners)
+	}
+	if idx.Unowned == nil || idx.Unowned.File != "unowned.md" || idx.Unowned.Count != 1 {
+		t.Fatalf("unexpected unowned entry: %+v", idx.Unowned)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateOwnerIndexWithWriter(idx, "index.md", "md", mdMockFileWriter{buf: &buf}); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	for _, want := range []string{"- Total: 3", "Shared by several owners: 1", "| @acme/platform | 2 | [acme-platform.md](acme-platform.md) |", "| _unowned_ | 1 | [unowned.md](unowned.md) |"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, buf.String())
+		}
+	}
+	buf.Reset()
+	if err := GenerateOwnerIndexWithWriter(idx, "index.json", "json", mdMockFileWriter{buf: &buf}); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	var parsed OwnerIndex
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil || parsed.Total != 3 || len(parsed.Owners) != 2 {
+		t.Fatalf("unexpected json index %s: %v", buf.String(), err)
+	}
+}
+
+func TestOwnerFileName(t *testing.T) {
+	cases := map[string]string{
+		"@acme/team-platform": "acme-team-platform",
+		"@alice":              "alice",
+		"dev@example.com":     "dev-example.com",
+		"@../../etc":          "etc",
+		"@":                   "owner",
+	}
+	for in, want := range cases {
+		if got := OwnerFileName(in); got != want {
+			t.Errorf("OwnerFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewOwnerIndex_Collisions(t *testing.T) {
+	groups := []OwnerGroup{{Owner: "@a/b"}, {Owner: "@a.b"}, {Owner: "@a-b"}, {Owner: "@unowned"}}
+	idx := NewOwnerIndex(nil, groups, nil, ".json")
+	var files []string
+	for _, o := range idx.Owners {
+		files = append(files, o.File)
+	}
+	if got := strings.Join(files, ","); got != "a-b.json,a.b.json,a-b-2.json,unowned-2.json" {
+		t.Fatalf("files = %s", got)
+	}
+	if idx.Unowned != nil {
+		t.Fatalf("no unowned report expected")
+	}
+}
@@ -0,0 +1,139 @@
+package todo
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// planTopN caps the extension and largest-file lists of a Plan.
+const planTopN = 10
+
+// SkipReasonResumed marks files a Plan leaves out because ScanOptions.Done
+// lists them.
+const SkipReasonResumed = "resumed"
+
+// Plan describes what a scan of a tree would read, from the walk alone.
+type Plan struct {
+	// Files and Bytes count the files that would be read and their total
+	// size; Dirs counts the directories holding them.
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	Dirs  int   `json:"dirs"`
+	// Skipped counts the excluded entries by reason, in reason order.
+	Skipped []PlanSkip `json:"skipped"`
+	// Extensions lists the most common extensions among Files, most files
+	// first; files without one are counted under "".
+	Extensions []PlanExtension `json:"extensions"`
+	// Largest lists the biggest files that would be read, biggest first.
+	Largest []PlanFile `json:"largest"`
+}
+
+// PlanSkip counts the directories and files excluded for one reason.
+type PlanSkip struct {
+	Reason string `json:"reason"`
+	Dirs   int    `json:"dirs"`
+	Files  int    `json:"files"`
+}
+
+// PlanExtension counts the files with one extension.
+type PlanExtension struct {
+	Ext   string `json:"ext"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// PlanFile is a file that would be read, with its size.
+type PlanFile struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// PlanDir walks root with the gating of ScanDirDetailed under opts and
+// reports what a scan would read, without opening any file: sizes come from
+// the directory entries. Files skipped for a generated-code header are not
+// known until read, so they count as files to read.
+func PlanDir(root string, opts ScanOptions) (Plan, error) {
+	only, err := ParseAllowlist(opts.Only)
+	if err != nil {
+		return Plan{}, err
+	}
+	skips := make(map[string]*PlanSkip)
+	count := func(reason string, dir bool) {
+		s := skips[reason]
+		if s == nil {
+			s = &PlanSkip{Reason: reason}
+			skips[reason] = s
+		}
+		if dir {
+			s.Dirs++
+		} else {
+			s.Files++
+		}
+	}
+	var plan Plan
+	dirs := make(map[string]bool)
+	exts := make(map[string]*PlanExtension)
+	var files []PlanFile
+	var links *linkSet
+	if opts.DedupHardLinks {
+		links = newLinkSet()
+	}
+	stats, err := walkFiles(root, opts, only, links, func(r SkipRecord) {
+		count(r.Reason, r.Dir)
+	}, func(shown, _, _ string, d fs.DirEntry) {
+		var size int64
+		if info, err := d.Info(); err == nil {
+			size = info.Size()
+		}
+		plan.Files++
+		plan.Bytes += size
+		dirs[path.Dir(filepath.ToSlash(shown))] = true
+		ext := strings.ToLower(filepath.Ext(shown))
+		e := exts[ext]
+		if e == nil {
+			e = &PlanExtension{Ext: ext}
+			exts[ext] = e
+		}
+		e.Files++
+		e.Bytes += size
+		files = append(files, PlanFile{Path: shown, Bytes: size})
+	})
+	if err != nil {
+		return Plan{}, err
+	}
+	for i := 0; i < stats.Resumed; i++ {
+		count(SkipReasonResumed, false)
+	}
+	plan.Dirs = len(dirs)
+
+	plan.Skipped = make([]PlanSkip, 0, len(skips))
+	for _, s := range skips {
+		plan.Skipped = append(plan.Skipped, *s)
+	}
+	sort.Slice(plan.Skipped, func(i, j int) bool { return plan.Skipped[i].Reason < plan.Skipped[j].Reason })
+
+	plan.Extensions = make([]PlanExtension, 0, len(exts))
+	for _, e := range exts {
+		plan.Extensions = append(plan.Extensions, *e)
+	}
+	sort.Slice(plan.Extensions, func(i, j int) bool {
+		a, b := plan.Extensions[i], plan.Extensions[j]
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		return a.Ext < b.Ext
+	})
+	plan.Extensions = plan.Extensions[:min(planTopN, len(plan.Extensions))]
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Bytes != files[j].Bytes {
+			return files[i].Bytes > files[j].Bytes
+		}
+		return files[i].Path < files[j].Path
+	})
+	plan.Largest = append([]PlanFile{}, files[:min(planTopN, len(files))]...)
+	return plan, nil
+}
@@ -0,0 +1,98 @@
+package todo
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// openLog records the files opened through it, relative to root.
+type openLog struct {
+	root   string
+	opened []string
+}
+
+func (r *openLog) Open(name string) (io.ReadCloser, error) {
+	r.opened = append(r.opened, name)
+	return OSFileReader{}.Open(filepath.Join(r.root, name))
+}
+
+func TestPlanDir(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "*.tmp\nsrc/build/\n")
+	mustWriteFile(t, root, "src/a.go", "// TODO: a\n")
+	mustWriteFile(t, root, "src/b.go", strings.Repeat("x", 100))
+	mustWriteFile(t, root, "src/c.py", "# TODO: c\n")
+	mustWriteFile(t, root, "src/x.tmp", "scratch")
+	mustWriteFile(t, root, "src/build/out.go", "// TODO: built\n")
+	mustWriteFile(t, root, "src/vendor/v.go", "// TODO: vendored\n")
+	mustWriteFile(t, root, "docs/readme.md", "TODO: docs\n")
+
+	opts := DefaultScanOptions()
+	opts.IgnoreDirs = append(opts.IgnoreDirs, "vendor")
+	opts.Only = []string{"src/**", ".gitignore"}
+	plan, err := PlanDir(root, opts)
+	if err != nil {
+		t.Fatalf("PlanDir: %v", err)
+	}
+
+	// .gitignore, a.go, b.go and c.py.
+	if plan.Files != 4 || plan.Dirs != 2 {
+		t.Errorf("files, dirs = %d, %d, want 4, 2", plan.Files, plan.Dirs)
+	}
+	if want := int64(len("*.tmp\nsrc/build/\n") + len("// TODO: a\n") + 100 + len("# TODO: c\n")); plan.Bytes != want {
+		t.Errorf("bytes = %d, want %d", plan.Bytes, want)
+	}
+	wantSkipped := []PlanSkip{
+		{Reason: SkipReasonGitIgnore, Dirs: 1, Files: 1},
+		{Reason: SkipReasonIgnoreFlag, Dirs: 1},
+		{Reason: SkipReasonAllowlist, Dirs: 1},
+		{Reason: SkipReasonVCS, Dirs: 1},
+	}
+	if !reflect.DeepEqual(plan.Skipped, wantSkipped) {
+		t.Errorf("skipped = %+v, want %+v", plan.Skipped, wantSkipped)
+	}
+	if len(plan.Extensions) != 3 || plan.Extensions[0] != (PlanExtension{Ext: ".go", Files: 2, Bytes: 111}) {
+		t.Errorf("extensions = %+v", plan.Extensions)
+	}
+	if len(plan.Largest) != 4 || plan.Largest[0] != (PlanFile{Path: "src/b.go", Bytes: 100}) {
+		t.Errorf("largest = %+v", plan.Largest)
+	}
+
+	// A scan under the same options reads exactly the planned files.
+	r := &openLog{root: root}
+	res, err := ScanDirDetailed(root, opts, r)
+	if err != nil {
+		t.Fatalf("ScanDirDetailed: %v", err)
+	}
+	if len(r.opened) != plan.Files || res.Stats.Opened != plan.Files {
+		t.Errorf("scan opened %v, plan has %d files", r.opened, plan.Files)
+	}
+}
+
+func TestPlanDir_ResumedAndTopN(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < planTopN+2; i++ {
+		mustWriteFile(t, root, "f"+string(rune('a'+i))+".ext"+string(rune('a'+i)), strings.Repeat("x", i+1))
+	}
+	opts := DefaultScanOptions()
+	opts.Done = map[string]bool{"fa.exta": true}
+	plan, err := PlanDir(root, opts)
+	if err != nil {
+		t.Fatalf("PlanDir: %v", err)
+	}
+	if plan.Files != planTopN+1 {
+		t.Errorf("files = %d, want %d", plan.Files, planTopN+1)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0] != (PlanSkip{Reason: SkipReasonResumed, Files: 1}) {
+		t.Errorf("skipped = %+v", plan.Skipped)
+	}
+	if len(plan.Extensions) != planTopN || len(plan.Largest) != planTopN {
+		t.Fatalf("extensions, largest = %d, %d, want %d each", len(plan.Extensions), len(plan.Largest), planTopN)
+	}
+	if got := plan.Largest[0].Path; got != "fl.extl" {
+		t.Errorf("largest = %q, want fl.extl", got)
+	}
+}
@@ -0,0 +1,59 @@
+package todo
+
+import (
+	"bufio"
+	"errors"
+)
+
+// Phases of a file scan in which a Problem can occur.
+const (
+	PhaseOpen = "open"
+	PhaseRead = "read"
+	// PhaseConfig is resolving the settings of a directory; see
+	// ScanOptions.DirOverride.
+	PhaseConfig = "config"
+)
+
+// Stable Problem codes.
+const (
+	// ProblemOpen: the file could not be opened.
+	ProblemOpen = "E_OPEN"
+	// ProblemRead: reading the file failed partway; its items are dropped.
+	ProblemRead = "E_READ"
+	// ProblemTooLarge: a line is longer than the scanner's buffer.
+	ProblemTooLarge = "E_TOO_LARGE"
+	// ProblemConfig: a directory's override is invalid; its subtree is
+	// scanned with the settings from above.
+	ProblemConfig = "E_CONFIG"
+)
+
+// Problem is a file a scan could not match, with why.
+type Problem struct {
+	Path    string `json:"path"`
+	Phase   string `json:"phase"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// fileError records the phase in which scanning a file failed.
+type fileError struct {
+	phase string
+	err   error
+}
+
+func (e fileError) Error() string { return e.err.Error() }
+
+func (e fileError) Unwrap() error { return e.err }
+
+// newProblem describes the failure err of the file at path.
+func newProblem(path string, err error) Problem {
+	p := Problem{Path: path, Phase: PhaseRead, Code: ProblemRead, Message: err.Error()}
+	var fe fileError
+	if errors.As(err, &fe) && fe.phase == PhaseOpen {
+		p.Phase, p.Code = PhaseOpen, ProblemOpen
+	}
+	if errors.Is(err, bufio.ErrTooLong) {
+		p.Code = ProblemTooLarge
+	}
+	return p
+}
@@ -0,0 +1,95 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// problemReader fails to open "locked.go" and fails partway through reading
+// "flaky.go"; other files are served from files.
+type problemReader struct{ files map[string]string }
+
+func (r problemReader) Open(name string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(name, "locked.go"):
+		return nil, os.ErrPermission
+	case strings.HasSuffix(name, "flaky.go"):
+		return io.NopCloser(&failingReader{data: "// TODO: partial\n"}), nil
+	}
+	for suffix, content := range r.files {
+		if strings.HasSuffix(name, suffix) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+	}
+	return nil, errors.New("unexpected file " + name)
+}
+
+func TestScanDirDetailed_Problems(t *testing.T) {
+	root := t.TempDir()
+	long := "// TODO: " + strings.Repeat("x", 70*1024) + "\n"
+	files := map[string]string{"ok.go": "// TODO: fine\n", "huge.go": long}
+	for _, name := range []string{"ok.go", "huge.go", "locked.go", "flaky.go"} {
+		mustWriteFile(t, root, name, "x")
+	}
+	res, err := ScanDirDetailed(root, DefaultScanOptions(), problemReader{files: files})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed: %v", err)
+	}
+	if len(res.Items) != 1 || res.Items[0].File != "ok.go" {
+		t.Fatalf("unexpected items: %+v", res.Items)
+	}
+	var got [][3]string
+	for _, p := range res.Problems {
+		got = append(got, [3]string{p.Path, p.Phase, p.Code})
+		if p.Message == "" {
+			t.Errorf("%s: empty message", p.Path)
+		}
+	}
+	want := [][3]string{
+		{"flaky.go", PhaseRead, ProblemRead},
+		{"huge.go", PhaseRead, ProblemTooLarge},
+		{"locked.go", PhaseOpen, ProblemOpen},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("problems = %v, want %v", got, want)
+	}
+
+	opts := ReportOptions{Problems: res.Problems}
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(res.Items, "r.json", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Summary  Summary   `json:"summary"`
+		Problems []Problem `json:"problems"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Summary.ProblemCount != 3 || !reflect.DeepEqual(body.Problems, res.Problems) {
+		t.Fatalf("unexpected problems in JSON: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(res.Items, "r.html", mockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, `id="problems-banner"`) || !strings.Contains(html, "3 file(s) could not be scanned") || !strings.Contains(html, `id="problems"`) {
+		t.Fatalf("missing problems banner or appendix")
+	}
+
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(res.Items, "r.html", mockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "problems-banner\"") {
+		t.Fatalf("banner shown without problems")
+	}
+}
@@ -0,0 +1,345 @@
+package todo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Protocol methods served by ProtocolServer.
+const (
+	MethodScanFile = "scanFile"
+	MethodScanDir  = "scanDir"
+	MethodSummary  = "summary"
+	MethodShutdown = "shutdown"
+)
+
+// Error codes of ProtocolError.
+const (
+	// ProtocolParseError: the request line is not a JSON object.
+	ProtocolParseError = "E_PARSE"
+	// ProtocolUnknownMethod: the method is not one of the Method constants.
+	ProtocolUnknownMethod = "E_METHOD"
+	// ProtocolInvalidParams: the params do not fit the method.
+	ProtocolInvalidParams = "E_PARAMS"
+	// ProtocolScanFailed: the scan itself failed, e.g. a missing root.
+	ProtocolScanFailed = "E_SCAN"
+	// ProtocolShuttingDown: the request arrived after shutdown.
+	ProtocolShuttingDown = "E_SHUTDOWN"
+)
+
+// ProtocolRequest is one line read by ProtocolServer.Serve. ID is echoed
+// back unchanged in the response and may be any JSON value; clients use it to
+// pair responses, which can arrive out of order, with their requests.
+type ProtocolRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// ProtocolResponse is one line written by ProtocolServer.Serve: exactly one
+// of Result and Error is set.
+type ProtocolResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result any             `json:"result,omitempty"`
+	Error  *ProtocolError  `json:"error,omitempty"`
+}
+
+// ProtocolError is the error of a failed request.
+type ProtocolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// ScanFileParams are the params of scanFile. Content is scanned as the
+// file at Path, e.g. an editor's unsaved buffer; when omitted, the file is
+// read from disk. Path also selects the comment syntax, by extension.
+type ScanFileParams struct {
+	Path    string  `json:"path"`
+	Content *string `json:"content,omitempty"`
+}
+
+// ScanDirParams are the params of scanDir and summary. An empty Root is the
+// server's Root.
+type ScanDirParams struct {
+	Root string `json:"root,omitempty"`
+}
+
+// ScanFileResult is the result of scanFile.
+type ScanFileResult struct {
+	Items []Todo `json:"items"`
+}
+
+// ScanDirResult is the result of scanDir.
+type ScanDirResult struct {
+	Items    []Todo    `json:"items"`
+	Problems []Problem `json:"problems,omitempty"`
+	Stats    ScanStats `json:"stats"`
+}
+
+// ProtocolServer answers scan requests from a long-lived client, such as an
+// editor integration, over a line-delimited JSON protocol: each line read is
+// a ProtocolRequest and each line written a ProtocolResponse. Requests are
+// handled concurrently, so a quick scanFile is not held up by a scanDir of a
+// large tree; directory scans are cached in a ScanSession per root and only
+// redone when the tree changed.
+type ProtocolServer struct {
+	// Root is the directory of scanDir and summary requests without one.
+	Root   string
+	Opts   ScanOptions
+	Reader FileReader
+	// OnScan, when set, is called with the cleaned root and the result each
+	// time a directory request actually scans a tree, not when it reuses a
+	// cached scan. It runs on the scanning goroutine before the waiting
+	// requests are answered, and may be called concurrently for distinct
+	// roots.
+	OnScan func(root string, res ScanResult)
+
+	mu       sync.Mutex
+	sessions map[string]*ScanSession
+}
+
+// NewProtocolServer returns a server scanning with opts through reader,
+// defaulting to root for directory requests.
+func NewProtocolServer(root string, opts ScanOptions, reader FileReader) *ProtocolServer {
+	return &ProtocolServer{Root: root, Opts: opts, Reader: reader}
+}
+
+// Serve reads requests from r and writes their responses to w until r is
+// exhausted, a shutdown request arrives or ctx is done. Each request runs on
+// its own goroutine with a context canceled when ctx is; responses are
+// written whole, one per line, in the order they complete. A line that is
+// not a request gets an E_PARSE error response and serving goes on. Serve
+// waits for the requests in flight before returning, and answers shutdown
+// last. It returns the error reading r or writing w, if any.
+func (p *ProtocolServer) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner, err := NewScanner(p.Opts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wmu      sync.Mutex
+		writeErr error
+	)
+	write := func(resp ProtocolResponse) {
+		if resp.ID == nil {
+			resp.ID = json.RawMessage("null")
+		}
+		line, err := json.Marshal(resp)
+		if err != nil {
+			line, _ = json.Marshal(ProtocolResponse{ID: resp.ID, Error: &ProtocolError{Code: ProtocolScanFailed, Message: err.Error()}})
+		}
+		wmu.Lock()
+		defer wmu.Unlock()
+		if writeErr == nil {
+			_, writeErr = w.Write(append(line, '\n'))
+		}
+	}
+
+	// Lines are read on their own goroutine so that a done ctx stops Serve
+	// even while r blocks.
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		shutdown *ProtocolRequest
+		rerr     error
+	)
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case rerr = <-readErr:
+			break loop
+		case line := <-lines:
+			var req ProtocolRequest
+			if err := json.Unmarshal(line, &req); err != nil {
+				write(ProtocolResponse{ID: requestID(line), Error: &ProtocolError{Code: ProtocolParseError, Message: err.Error()}})
+				continue
+			}
+			if req.Method == MethodShutdown {
+				shutdown = &req
+				break loop
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := p.handle(ctx, scanner, req)
+				resp := ProtocolResponse{ID: req.ID, Result: result}
+				if err != nil {
+					var perr *ProtocolError
+					if !errors.As(err, &perr) {
+						perr = &ProtocolError{Code: ProtocolScanFailed, Message: err.Error()}
+					}
+					resp = ProtocolResponse{ID: req.ID, Error: perr}
+				}
+				write(resp)
+			}()
+		}
+	}
+	wg.Wait()
+	if shutdown != nil {
+		write(ProtocolResponse{ID: shutdown.ID, Result: struct{}{}})
+	}
+	wmu.Lock()
+	defer wmu.Unlock()
+	if writeErr != nil {
+		return writeErr
+	}
+	return rerr
+}
+
+// requestID returns the id of a request line that did not decode as a whole,
+// when at least its id can be read, so that the client can still pair the
+// error with its request.
+func requestID(line []byte) json.RawMessage {
+	var partial struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(line, &partial) != nil {
+		return nil
+	}
+	return partial.ID
+}
+
+// handle runs req and returns its result.
+func (p *ProtocolServer) handle(ctx context.Context, scanner *Scanner, req ProtocolRequest) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &ProtocolError{Code: ProtocolShuttingDown, Message: err.Error()}
+	}
+	switch req.Method {
+	case MethodScanFile:
+		var params ScanFileParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Path == "" {
+			return nil, &ProtocolError{Code: ProtocolInvalidParams, Message: "scanFile requires a path"}
+		}
+		var content io.Reader
+		if params.Content != nil {
+			content = strings.NewReader(*params.Content)
+		} else {
+			f, err := p.Reader.Open(params.Path)
+			if err != nil {
+				return nil, err
+			}
+			defer SafeClose(f, params.Path)
+			content = f
+		}
+		res, err := scanner.ScanReaderAs(params.Path, "", content)
+		if err != nil {
+			return nil, err
+		}
+		return ScanFileResult{Items: nonNil(res.Items)}, nil
+	case MethodScanDir, MethodSummary:
+		var params ScanDirParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		res, err := p.session(params.Root).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if req.Method == MethodScanDir {
+			return ScanDirResult{Items: nonNil(res.Items), Problems: res.Problems, Stats: res.Stats}, nil
+		}
+		byTag := make(map[string]int)
+		for _, t := range res.Items {
+			byTag[t.Tag]++
+		}
+		return Summary{Total: len(res.Items), ByTag: byTag, ProblemCount: len(res.Problems)}, nil
+	case "":
+		return nil, &ProtocolError{Code: ProtocolInvalidParams, Message: "request has no method"}
+	}
+	return nil, &ProtocolError{Code: ProtocolUnknownMethod, Message: fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+// decodeParams decodes raw into params; absent params leave params zero.
+func decodeParams(raw json.RawMessage, params any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, params); err != nil {
+		return &ProtocolError{Code: ProtocolInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// session returns the session of root, creating it on first use.
+func (p *ProtocolServer) session(root string) *ScanSession {
+	if root == "" {
+		root = p.Root
+	}
+	root = filepath.Clean(root)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sessions == nil {
+		p.sessions = make(map[string]*ScanSession)
+	}
+	s, ok := p.sessions[root]
+	if !ok {
+		s = NewScanSession(root, p.Opts, p.Reader)
+		if p.OnScan != nil {
+			s.onScan = func(res ScanResult) { p.OnScan(root, res) }
+		}
+		p.sessions[root] = s
+	}
+	return s
+}
+
+// Invalidate drops the cached scan of every root, so the next scanDir or
+// summary request rescans even when the tree looks unchanged, e.g. after
+// SIGHUP.
+func (p *ProtocolServer) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.sessions {
+		s.Invalidate()
+	}
+}
+
+// nonNil returns items, or an empty slice for nil, so that results always
+// carry an items array.
+func nonNil(items []Todo) []Todo {
+	if items == nil {
+		return []Todo{}
+	}
+	return items
+}
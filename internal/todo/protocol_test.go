@@ -0,0 +1,210 @@
+package todo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveLines runs one Serve over the request lines and returns the responses
+// keyed by their raw id.
+func serveLines(t *testing.T, p *ProtocolServer, lines ...string) map[string]ProtocolResponse {
+	t.Helper()
+	var out strings.Builder
+	if err := p.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]ProtocolResponse)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp struct {
+			ID     json.RawMessage
+			Result json.RawMessage
+			Error  *ProtocolError
+		}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response %q: %v", line, err)
+		}
+		r := ProtocolResponse{ID: resp.ID, Error: resp.Error}
+		if resp.Result != nil {
+			r.Result = string(resp.Result)
+		}
+		got[string(resp.ID)] = r
+	}
+	return got
+}
+
+func TestProtocolServer_Requests(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "a.go", "// TODO: on disk\n")
+	mustWriteFile(t, tmp, "sub/b.py", "# FIXME: python\n# BUG: also\n")
+	p := NewProtocolServer(tmp, DefaultScanOptions(), OSFileReader{})
+	abs := func(rel string) string { return strings.ReplaceAll(tmp+"/"+rel, `\`, `\\`) }
+
+	tests := []struct {
+		name    string
+		request string
+		id      string
+		// result lists substrings of the result; code is the error code
+		// expected instead.
+		result []string
+		code   string
+	}{
+		{"unsaved buffer", `{"id":1,"method":"scanFile","params":{"path":"x.go","content":"x := 1 // TODO: unsaved\n"}}`, "1",
+			[]string{`"File":"x.go"`, `"Tag":"TODO"`, `"Text":"unsaved"`, `"Position":"trailing"`}, ""},
+		{"file from disk", `{"id":"disk","method":"scanFile","params":{"path":"` + abs("a.go") + `"}}`, `"disk"`,
+			[]string{`"Text":"on disk"`}, ""},
+		{"no items", `{"id":2,"method":"scanFile","params":{"path":"x.go","content":"package x\n"}}`, "2",
+			[]string{`"items":[]`}, ""},
+		{"directory", `{"id":3,"method":"scanDir"}`, "3",
+			[]string{`"Text":"on disk"`, `"Text":"python"`, `"Text":"also"`, `"opened":2`}, ""},
+		{"summary", `{"id":4,"method":"summary","params":{"root":"` + abs("sub") + `"}}`, "4",
+			[]string{`"total":2`, `"FIXME":1`, `"BUG":1`}, ""},
+		{"malformed", `{"id":5,"method":`, "null", nil, ProtocolParseError},
+		{"wrong params", `{"id":6,"method":"scanFile","params":{"path":7}}`, "6", nil, ProtocolInvalidParams},
+		{"missing path", `{"id":7,"method":"scanFile","params":{}}`, "7", nil, ProtocolInvalidParams},
+		{"unknown method", `{"id":8,"method":"scanRepo"}`, "8", nil, ProtocolUnknownMethod},
+		{"missing root", `{"id":9,"method":"scanDir","params":{"root":"` + abs("nope") + `"}}`, "9", nil, ProtocolScanFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serveLines(t, p, tt.request)
+			resp, ok := got[tt.id]
+			if !ok || len(got) != 1 {
+				t.Fatalf("responses = %+v, want one with id %s", got, tt.id)
+			}
+			if tt.code != "" {
+				if resp.Error == nil || resp.Error.Code != tt.code || resp.Result != nil {
+					t.Fatalf("response = %+v, want error %s", resp, tt.code)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("error %v", resp.Error)
+			}
+			for _, s := range tt.result {
+				if !strings.Contains(resp.Result.(string), s) {
+					t.Errorf("result lacks %s: %s", s, resp.Result)
+				}
+			}
+		})
+	}
+}
+
+func TestProtocolServer_KeepsServingAndShutsDown(t *testing.T) {
+	p := NewProtocolServer(t.TempDir(), DefaultScanOptions(), OSFileReader{})
+	got := serveLines(t, p,
+		`not json`,
+		`{"id":1,"method":"scanFile","params":{"path":"a.go","content":"// NOTE: n"}}`,
+		`{"id":2,"method":"shutdown"}`,
+		`{"id":3,"method":"scanFile","params":{"path":"a.go","content":"// NOTE: n"}}`,
+	)
+	if len(got) != 3 || got["null"].Error == nil || got["1"].Error != nil || got["2"].Result != "{}" {
+		t.Fatalf("responses = %+v", got)
+	}
+	if _, ok := got["3"]; ok {
+		t.Error("request after shutdown was answered")
+	}
+}
+
+// slowReader blocks every Open until release is closed.
+type slowReader struct {
+	release chan struct{}
+}
+
+func (r slowReader) Open(name string) (io.ReadCloser, error) {
+	<-r.release
+	return OSFileReader{}.Open(name)
+}
+
+func TestProtocolServer_ScanFileNotBlockedByScanDir(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "a.go", "// TODO: slow\n")
+	reader := slowReader{release: make(chan struct{})}
+	p := NewProtocolServer(tmp, DefaultScanOptions(), reader)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- p.Serve(context.Background(), inR, outW) }()
+	responses := bufio.NewScanner(outR)
+	next := func() ProtocolResponse {
+		t.Helper()
+		if !responses.Scan() {
+			t.Fatal("no response")
+		}
+		var resp ProtocolResponse
+		if err := json.Unmarshal(responses.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	_, _ = io.WriteString(inW, `{"id":"dir","method":"scanDir"}`+"\n")
+	_, _ = io.WriteString(inW, `{"id":"file","method":"scanFile","params":{"path":"b.go","content":"// TODO: quick"}}`+"\n")
+	if resp := next(); string(resp.ID) != `"file"` {
+		t.Fatalf("first response = %s, want the scanFile one while scanDir is stuck", resp.ID)
+	}
+	close(reader.release)
+	if resp := next(); string(resp.ID) != `"dir"` || resp.Error != nil {
+		t.Fatalf("second response = %+v", resp)
+	}
+	_ = inW.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return at end of input")
+	}
+}
+
+func TestProtocolServer_Invalidate(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "a.go", "// TODO: x\n")
+	r := &gatedReader{root: tmp}
+	p := NewProtocolServer(tmp, DefaultScanOptions(), r)
+	p.Invalidate() // no session yet
+
+	serveLines(t, p, `{"id":1,"method":"scanDir"}`, `{"id":2,"method":"shutdown"}`)
+	serveLines(t, p, `{"id":1,"method":"summary"}`, `{"id":2,"method":"shutdown"}`)
+	if n := r.opens.Load(); n != 1 {
+		t.Fatalf("opens = %d before Invalidate, want the cached scan reused", n)
+	}
+	p.Invalidate()
+	got := serveLines(t, p, `{"id":1,"method":"summary"}`, `{"id":2,"method":"shutdown"}`)
+	if n := r.opens.Load(); n != 2 {
+		t.Fatalf("opens = %d after Invalidate, want a rescan", n)
+	}
+	if res, _ := got["1"].Result.(string); !strings.Contains(res, `"total":1`) {
+		t.Errorf("summary = %+v", got["1"])
+	}
+}
+
+func TestProtocolServer_OnScan(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "a.go", "// TODO: x\n")
+	p := NewProtocolServer(tmp, DefaultScanOptions(), OSFileReader{})
+	var roots []string
+	p.OnScan = func(root string, res ScanResult) {
+		roots = append(roots, root)
+		if len(res.Items) != 1 {
+			t.Errorf("OnScan items = %d, want 1", len(res.Items))
+		}
+	}
+
+	serveLines(t, p, `{"id":1,"method":"scanDir"}`, `{"id":2,"method":"shutdown"}`)
+	serveLines(t, p, `{"id":1,"method":"summary"}`, `{"id":2,"method":"shutdown"}`)
+	if len(roots) != 1 {
+		t.Fatalf("OnScan calls = %d, want none for the cached scan", len(roots))
+	}
+	p.Invalidate()
+	serveLines(t, p, `{"id":1,"method":"summary"}`, `{"id":2,"method":"shutdown"}`)
+	if len(roots) != 2 || roots[1] != tmp {
+		t.Fatalf("OnScan roots = %v, want a second call for %s", roots, tmp)
+	}
+}
@@ -0,0 +1,207 @@
+package todo
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Scanner matches items in file contents with a fixed set of options. It is
+// what the directory scan runs on every file, available for content that is
+// already in memory. Only the options affecting a single file apply: Tags,
+// Aliases, FirstMatchOnly, RespectIgnoreComments, SkipGenerated,
+// GeneratedHeader, IncludeRawLine, RawBytes, Detect, DebugPatterns and
+// CommentSyntax.
+// A Scanner is safe for concurrent use.
+type Scanner struct {
+	opts ScanOptions
+	// tags holds the lower-cased tags for prefilter.
+	tags []string
+}
+
+// NewScanner returns a Scanner for opts, or an error if opts.Tags holds an
+// invalid tag or opts.Detect an unknown detector.
+func NewScanner(opts ScanOptions) (*Scanner, error) {
+	if err := opts.compileMatchers(); err != nil {
+		return nil, err
+	}
+	if err := checkDetectors(opts.Detect); err != nil {
+		return nil, err
+	}
+	tags := opts.matchTags()
+	if len(tags) == 0 {
+		tags = DefaultTags
+	}
+	lower := make([]string, len(tags))
+	for i, t := range tags {
+		lower[i] = strings.ToLower(strings.TrimSpace(t))
+	}
+	return &Scanner{opts: opts, tags: lower}, nil
+}
+
+// ScanReader scans r line by line and returns its items, labeled with name
+// as their File. name also selects the comment syntax used to classify
+// positions, by extension. A read error is returned without any items.
+func (s *Scanner) ScanReader(name string, r io.Reader) ([]Todo, error) {
+	todos, _, err := s.scan(name, r)
+	return todos, err
+}
+
+// fileCounts describes what a scan read from one file.
+type fileCounts struct {
+	lines    int
+	redacted int
+}
+
+// scan is ScanReader that also counts what it read.
+func (s *Scanner) scan(name string, r io.Reader) ([]Todo, fileCounts, error) {
+	opts := s.opts
+	var todos []Todo
+	introducers := opts.commentIntroducers(name)
+	var conflicts *conflictTracker
+	if detectorEnabled(opts.Detect, DetectConflicts) {
+		conflicts = &conflictTracker{}
+	}
+	var debug []*regexp.Regexp
+	if detectorEnabled(opts.Detect, DetectDebug) {
+		debug = opts.debugMatchers(name)
+	}
+	detected := false
+	header := opts.GeneratedHeader
+	if header == nil {
+		header = DefaultGeneratedHeader
+	}
+	sc := bufio.NewScanner(r)
+	lineNum := 0
+	ignoreNext := false
+	disabled := 0 // depth of open todototum:disable regions
+	for sc.Scan() {
+		lineNum++
+		line := sc.Text()
+		if opts.SkipGenerated && lineNum <= generatedHeaderLines && header.MatchString(line) {
+			return nil, fileCounts{lines: lineNum}, generatedHeaderError{line: lineNum, header: strings.TrimSpace(line)}
+		}
+		if conflicts != nil {
+			// Markers are tracked on every line, ignored ones included, so
+			// a block is recognized as a whole.
+			if t, ok := conflicts.line(lineNum, conflictMarker(line)); ok {
+				t.File = name
+				todos = append(todos, t)
+				detected = true
+			}
+		}
+		ms := s.matchAll(line)
+		matched := len(ms) > 0
+		if opts.RespectIgnoreComments {
+			// Directive lines themselves are never reported.
+			if strings.Contains(line, disableDirective) {
+				disabled++
+				continue
+			}
+			if strings.Contains(line, enableDirective) {
+				if disabled > 0 {
+					disabled--
+				}
+				continue
+			}
+			if disabled > 0 {
+				ignoreNext = false
+				continue
+			}
+			ignored := ignoreNext || strings.Contains(line, ignoreDirective)
+			// A directive on a line without a match of its own covers the next line.
+			ignoreNext = !matched && strings.Contains(line, ignoreDirective)
+			if ignored {
+				continue
+			}
+		}
+		for _, m := range ms {
+			todos = append(todos, Todo{
+				Location: Location{File: name, Line: lineNum, Column: m.Column},
+				Tag:      m.Tag,
+				Text:     m.Text,
+				Position: classifyPosition(line, m.Offset, introducers),
+			})
+			t := &todos[len(todos)-1]
+			t.Refs = ExtractRefs(t.Text)
+			if opts.IncludeRawLine {
+				t.RawLine = line
+			}
+		}
+		for _, re := range debug {
+			if loc := re.FindStringIndex(line); loc != nil {
+				t := Todo{Location: Location{File: name, Line: lineNum, Column: utf8.RuneCountInString(line[:loc[0]]) + 1}, Tag: TagDebug, Text: strings.TrimSpace(line)}
+				if opts.IncludeRawLine {
+					t.RawLine = line
+				}
+				todos = append(todos, t)
+				break
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fileCounts{lines: lineNum}, err
+	}
+	if detected {
+		// Conflict items are found at the end of their block.
+		sort.SliceStable(todos, func(i, j int) bool { return todos[i].Line < todos[j].Line })
+	}
+	opts.sanitizeItems(todos)
+	return todos, fileCounts{lines: lineNum, redacted: opts.redactItems(todos)}, nil
+}
+
+// StdinName labels the items of content read from standard input.
+const StdinName = "<stdin>"
+
+// ScanReaderAs scans r like ScanReader, for content whose name does not tell
+// its language, such as piped input. format is a file extension, with or
+// without its dot ("go" or ".go"), that selects the comment syntax and debug
+// patterns; an empty format matches tags without any language knowledge.
+// Items are labeled with name. Content with a generated-code header, under
+// SkipGenerated, yields no items and counts as skipped.
+func (s *Scanner) ScanReaderAs(name, format string, r io.Reader) (ScanResult, error) {
+	start := time.Now()
+	ext := strings.ToLower(strings.TrimSpace(format))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	todos, counts, err := s.scan(name+ext, r)
+	stats := ScanStats{Walked: 1, Opened: 1, Lines: counts.lines, Redacted: counts.redacted}
+	var gen generatedHeaderError
+	if errors.As(err, &gen) {
+		stats.Skipped = 1
+		err = nil
+	}
+	if err != nil {
+		return ScanResult{}, err
+	}
+	for i := range todos {
+		todos[i].File = name
+	}
+	stats.Duration = time.Since(start)
+	return ScanResult{Items: todos, Stats: stats}, nil
+}
+
+// ScanString is ScanReader for content held in a string.
+func (s *Scanner) ScanString(name, content string) ([]Todo, error) {
+	return s.ScanReader(name, strings.NewReader(content))
+}
+
+// ScanReader scans r with DefaultScanOptions. See Scanner.ScanReader.
+func ScanReader(name string, r io.Reader) ([]Todo, error) {
+	s, err := NewScanner(DefaultScanOptions())
+	if err != nil {
+		return nil, err
+	}
+	return s.ScanReader(name, r)
+}
+
+// ScanString scans content with DefaultScanOptions. See Scanner.ScanReader.
+func ScanString(name, content string) ([]Todo, error) {
+	return ScanReader(name, strings.NewReader(content))
+}
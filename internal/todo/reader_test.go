@@ -0,0 +1,118 @@
+package todo
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failingReader yields data and then fails.
+type failingReader struct {
+	data string
+	done bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, errors.New("disk on fire")
+	}
+	r.done = true
+	return copy(p, r.data), nil
+}
+
+func TestScanString_MatchesDirectoryScan(t *testing.T) {
+	content := "package x\n// TODO: first\nx := 1 // FIXME(#12): second"
+	items, err := ScanString("pkg/x.go", content)
+	if err != nil {
+		t.Fatalf("ScanString: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %+v", items)
+	}
+	last := items[1]
+	if last.File != "pkg/x.go" || last.Line != 3 || last.Tag != "FIXME" || last.Text != "(#12): second" || last.Position != PositionTrailing {
+		t.Fatalf("unexpected item without trailing newline: %+v", last)
+	}
+	if len(last.Refs) != 1 || last.Refs[0] != "#12" {
+		t.Fatalf("refs not extracted: %+v", last.Refs)
+	}
+
+	// The directory scan produces the same items for the same content.
+	fromDir, lines, err := scanFileWithReader("pkg/x.go", mockFileReader{files: map[string]string{"pkg/x.go": content}}, DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("scanFileWithReader: %v", err)
+	}
+	if want := strings.Count(content, "\n") + 1; lines.lines != want {
+		t.Fatalf("lines = %d, want %d", lines.lines, want)
+	}
+	if len(fromDir) != len(items) || fromDir[1].Column != last.Column {
+		t.Fatalf("directory scan disagrees: %+v vs %+v", fromDir, items)
+	}
+}
+
+func TestScanReader_EmptyAndErrors(t *testing.T) {
+	items, err := ScanReader("empty.go", strings.NewReader(""))
+	if err != nil || len(items) != 0 {
+		t.Fatalf("empty reader = %+v, %v", items, err)
+	}
+
+	items, err = ScanReader("broken.go", &failingReader{data: "// TODO: partial\n"})
+	if err == nil || items != nil {
+		t.Fatalf("expected error and no items, got %+v, %v", items, err)
+	}
+	if errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected EOF error")
+	}
+}
+
+func TestScanner_AppliesOptions(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Tags = []string{"HACK"}
+	opts.IncludeRawLine = true
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	items, err := s.ScanString("a.py", "# TODO: not a tag here\n# HACK: yes\n# HACK: no todototum:ignore\n")
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(items) != 1 || items[0].Tag != "HACK" || items[0].RawLine != "# HACK: yes" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if _, err := NewScanner(ScanOptions{Tags: []string{"not a tag"}}); err == nil {
+		t.Fatalf("expected invalid tag error")
+	}
+}
+
+func TestScanner_ScanReaderAs(t *testing.T) {
+	opts := DefaultScanOptions()
+	opts.Detect = []string{DetectDebug}
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "x := f() // TODO: trailing\nfmt.Println(\"DEBUG\", x)\n"
+	for _, format := range []string{"go", ".GO"} {
+		res, err := s.ScanReaderAs(StdinName, format, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Items) != 2 || res.Items[0].File != StdinName || res.Items[0].Position != PositionTrailing || res.Items[1].Tag != TagDebug {
+			t.Fatalf("format %q: unexpected items %+v", format, res.Items)
+		}
+		if res.Stats.Lines != 2 || res.Stats.Opened != 1 {
+			t.Fatalf("format %q: unexpected stats %+v", format, res.Stats)
+		}
+	}
+
+	// Without a format only tags are matched.
+	res, err := s.ScanReaderAs(StdinName, "", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 1 || res.Items[0].Position != "" {
+		t.Fatalf("unexpected items without a format: %+v", res.Items)
+	}
+}
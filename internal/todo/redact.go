@@ -0,0 +1,107 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactedText replaces the secrets removed from captured text.
+const RedactedText = "[REDACTED]"
+
+// redactRule is a pattern whose matches are redacted. When the pattern has a
+// group named "secret" only that group is replaced, so the surrounding words
+// stay readable. check, when set, must accept the replaced text.
+type redactRule struct {
+	re    *regexp.Regexp
+	check func(string) bool
+}
+
+// redactRules match common credential shapes: AWS access key IDs, long hex
+// or base64 tokens right after a word like key, token, secret or password,
+// and PEM private key headers. Tokens must contain a digit, so long words
+// and identifiers are left alone; '-' is not part of a token, so UUIDs are
+// too.
+var redactRules = []redactRule{
+	{re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{
+		re:    regexp.MustCompile(`(?i)\b(?:api[_-]?key|access[_-]?key|keys?|tokens?|secrets?|password|passwd|pwd)\b["']?\s*[:=]?\s*["']?(?P<secret>[A-Za-z0-9+/_]{32,}={0,2})`),
+		check: func(s string) bool { return strings.ContainsAny(s, "0123456789") },
+	},
+	{re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// ParseRedactPattern compiles an extra redaction pattern. As with the
+// built-in ones, a group named "secret" limits what is replaced, e.g.
+// `internal_id=(?P<secret>\d+)`.
+func ParseRedactPattern(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("redact pattern %q: %w", expr, err)
+	}
+	return re, nil
+}
+
+// Redact replaces secrets in text with RedactedText, using the built-in
+// patterns and then extra. It returns the redacted text and the number of
+// replacements.
+func Redact(text string, extra []*regexp.Regexp) (string, int) {
+	total := 0
+	for _, r := range redactRules {
+		var n int
+		text, n = redactMatches(text, r)
+		total += n
+	}
+	for _, re := range extra {
+		var n int
+		text, n = redactMatches(text, redactRule{re: re})
+		total += n
+	}
+	return text, total
+}
+
+// redactMatches replaces the matches of r in text.
+func redactMatches(text string, r redactRule) (string, int) {
+	group := r.re.SubexpIndex("secret")
+	var b strings.Builder
+	last, n := 0, 0
+	for _, m := range r.re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		if group > 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		if start == end || (r.check != nil && !r.check(text[start:end])) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(RedactedText)
+		last = end
+		n++
+	}
+	if n == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+// redactItems redacts the Text, RawLine and RawBytes of items in place under
+// opts and returns the number of replacements. RawBytes holds the same line
+// as RawLine before sanitizing, so its replacements are not counted again.
+func (o ScanOptions) redactItems(items []Todo) int {
+	if !o.Redact {
+		return 0
+	}
+	total := 0
+	for i := range items {
+		var n, m int
+		items[i].Text, n = Redact(items[i].Text, o.RedactPatterns)
+		items[i].RawLine, m = Redact(items[i].RawLine, o.RedactPatterns)
+		if items[i].RawBytes != nil {
+			raw, _ := Redact(string(items[i].RawBytes), o.RedactPatterns)
+			items[i].RawBytes = []byte(raw)
+		}
+		total += n + m
+	}
+	return total
+}
@@ -0,0 +1,52 @@
+package todo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Ticket reference forms recognized in item text. Each yields a canonical
+// reference: "#123" (an issue in the current repository),
+// "owner/name#123" (an issue in another repository) or "PROJ-123"
+// (a Jira-style key).
+var (
+	refURL       = regexp.MustCompile(`https?://github\.com/([\w.-]+/[\w.-]+)/(?:issues|pull)/(\d+)`)
+	refQualified = regexp.MustCompile(`(?:^|[^\w/.-])([\w.-]+/[\w.-]+)#(\d+)\b`)
+	refLocal     = regexp.MustCompile(`(?:^|[^\w/#&])(?:#|GH-)(\d+)\b`)
+	refKey       = regexp.MustCompile(`\b([A-Z][A-Z0-9]+-\d+)\b`)
+)
+
+// notTicketKeys are prefixes of common identifiers shaped like Jira keys.
+var notTicketKeys = map[string]bool{"UTF": true, "SHA": true, "ISO": true, "RFC": true, "CVE": true, "GH": true}
+
+// ExtractRefs returns the ticket references mentioned in text without
+// duplicates: links first, then qualified, local and Jira-style references.
+func ExtractRefs(text string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	// URLs are consumed first so their path segments are not read again as
+	// other reference forms.
+	for _, m := range refURL.FindAllStringSubmatch(text, -1) {
+		add(m[1] + "#" + m[2])
+	}
+	rest := refURL.ReplaceAllString(text, " ")
+	for _, m := range refQualified.FindAllStringSubmatch(rest, -1) {
+		add(m[1] + "#" + m[2])
+	}
+	rest = refQualified.ReplaceAllString(rest, " ")
+	for _, m := range refLocal.FindAllStringSubmatch(rest, -1) {
+		add("#" + m[1])
+	}
+	for _, m := range refKey.FindAllStringSubmatch(rest, -1) {
+		if prefix, _, _ := strings.Cut(m[1], "-"); !notTicketKeys[prefix] {
+			add(m[1])
+		}
+	}
+	return refs
+}
@@ -0,0 +1,36 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractRefs(t *testing.T) {
+	cases := map[string][]string{
+		"remove once #12 ships":                         {"#12"},
+		"see GH-7 and #7":                               {"#7"},
+		"blocked on acme/api#34":                        {"acme/api#34"},
+		"https://github.com/acme/web/issues/9 then #10": {"acme/web#9", "#10"},
+		"https://github.com/acme/web/pull/11":           {"acme/web#11"},
+		"tracked in PROJ-123, also PROJ-123":            {"PROJ-123"},
+		"colour &#123; and anchor a#1 are not refs":     nil,
+		"no references here":                            nil,
+		"UTF-8 is not a ticket key but ABC-1 is":        {"ABC-1"},
+	}
+	for text, want := range cases {
+		if got := ExtractRefs(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("ExtractRefs(%q) = %#v, want %#v", text, got, want)
+		}
+	}
+}
+
+func TestScanFileWithReader_PopulatesRefs(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{"a.go": "// TODO(#42): drop shim\n// NOTE: nothing\n"}}
+	todos, _, err := scanFileWithReader("a.go", mock, DefaultScanOptions())
+	if err != nil || len(todos) != 2 {
+		t.Fatalf("unexpected result: %v %#v", err, todos)
+	}
+	if !reflect.DeepEqual(todos[0].Refs, []string{"#42"}) || todos[1].Refs != nil {
+		t.Fatalf("unexpected refs: %#v / %#v", todos[0].Refs, todos[1].Refs)
+	}
+}
@@ -1,21 +1,57 @@
 package todo
 
 import (
+	"bufio"
+	"bytes"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
 	"math"
+	"net/url"
 	"os"
+	"path"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Summary holds aggregate statistics.
 type Summary struct {
 	Total int            `json:"total"`
 	ByTag map[string]int `json:"byTag"`
+	// ByPosition splits counts into own-line and trailing comments for items
+	// whose comment syntax is known.
+	ByPosition map[string]int `json:"byPosition,omitempty"`
+	// LinesScanned and PerThousandLines are set when the number of lines
+	// scanned is known (see ReportOptions.LinesScanned).
+	LinesScanned     int     `json:"linesScanned,omitempty"`
+	PerThousandLines float64 `json:"perThousandLines,omitempty"`
+	// ProblemCount is the number of files that could not be scanned.
+	ProblemCount int `json:"problemCount,omitempty"`
+	// Noise counts the low-signal items listed apart from the others; they
+	// are not part of Total.
+	Noise int `json:"noise,omitempty"`
+	// Oldest and Newest are the items introduced first and last, set when
+	// introduction dates were traced (see AgeRange).
+	Oldest *DebtItem `json:"oldest,omitempty"`
+	Newest *DebtItem `json:"newest,omitempty"`
+}
+
+// FormatDensity renders the items-per-lines figure of s in lang, e.g.
+// "2.50 todos per 1000 lines (1200 lines scanned)".
+func FormatDensity(s Summary, lang string) string {
+	return fmt.Sprintf(Message(lang, "density"), s.PerThousandLines, s.LinesScanned)
+}
+
+// PerThousandLines returns the number of items per 1000 lines, rounded to
+// two decimals, or 0 when lines is not positive.
+func PerThousandLines(items, lines int) float64 {
+	if lines <= 0 {
+		return 0
+	}
+	return math.Round(float64(items)*1000*100/float64(lines)) / 100
 }
 
 // TagStat provides a stable, presentation-friendly view of per-tag counts.
@@ -25,13 +61,183 @@ type TagStat struct {
 	Percent float64 `json:"percent"`
 }
 
+// ExtStat counts the items in files with one extension.
+type ExtStat struct {
+	// Ext is the lower-cased file extension with its dot, e.g. ".go";
+	// empty for files without one.
+	Ext     string  `json:"ext"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
 // ReportData feeds data into the HTML and JSON report templates.
 type ReportData struct {
 	Todos    []Todo    `json:"todos"`
 	Summary  Summary   `json:"summary"`
 	TagStats []TagStat `json:"tagStats"`
+	// ExtStats counts items by file extension, most items first.
+	ExtStats []ExtStat `json:"extStats"`
+	// OldestDebt lists the longest-standing items when history was traced.
+	OldestDebt []DebtItem `json:"oldestDebt,omitempty"`
+	// Persistent lists escalated items, longest-running first.
+	Persistent []Todo `json:"persistent,omitempty"`
+	// Orphans lists the possibly obsolete items; see OrphanEnricher.
+	Orphans []Todo `json:"orphans,omitempty"`
+	// Noise lists the low-signal items left out of Todos; see SplitNoise.
+	Noise    []Todo          `json:"noise,omitempty"`
+	Metadata *ReportMetadata `json:"metadata,omitempty"`
+	// BurnDown compares this run with the previous one when history is kept.
+	BurnDown *BurnDown `json:"burnDown,omitempty"`
+	// Files groups Todos by file for the nested JSON shape; it is only
+	// populated when ReportOptions.JSONShape is JSONShapeNested.
+	Files map[string][]Todo `json:"files,omitempty"`
+	// ExtraColumns names the Todo.Extra keys shown as columns in HTML.
+	ExtraColumns []string `json:"-"`
+	// Problems lists the files the scan could not open or read.
+	Problems []Problem `json:"problems,omitempty"`
+
+	// opts are the options the data was built with, for the built-in
+	// reporters.
+	opts ReportOptions
+}
+
+// sample returns the sample the report's items come from, or nil for a
+// full scan.
+func (d ReportData) sample() *Sample {
+	if d.Metadata == nil {
+		return nil
+	}
+	return d.Metadata.Sample
 }
 
+// DebtItem is an item with a known introduction date and its humanized age
+// (see HumanizeAge).
+type DebtItem struct {
+	Todo
+	Age string `json:"age"`
+}
+
+// ReportMetadata carries optional details about how the scan was run.
+type ReportMetadata struct {
+	Audit *AuditLog `json:"audit,omitempty"`
+	// FilteredByPattern counts items dropped by FilterItems.
+	FilteredByPattern int `json:"filteredByPattern,omitempty"`
+	// Sample is set when the items come from a sampled scan.
+	Sample *Sample `json:"sample,omitempty"`
+}
+
+// ReportOptions tunes report generation. The zero value produces the default
+// report for every format.
+type ReportOptions struct {
+	// Audit, when non-nil, is embedded in the report metadata and rendered as
+	// a collapsed "Exclusions" appendix in HTML and Markdown.
+	Audit *AuditLog
+	// MarkdownBadges decorates the Markdown report: MarkdownBadgesEmoji adds
+	// per-tag emoji and a "Top issues" section, MarkdownBadgesShields also
+	// renders summary counts as img.shields.io badges. Empty keeps plain output.
+	MarkdownBadges string
+	// FilteredByPattern, when positive, is recorded in the report metadata.
+	FilteredByPattern int
+	// Now is the reference time for item ages (time.Now() when zero).
+	Now time.Time
+	// MarkdownCompact renders Markdown as a short summary for CI pages:
+	// totals, a per-tag table, the top files and a collapsed item table.
+	MarkdownCompact bool
+	// MarkdownMaxRows caps the item table of compact Markdown
+	// (DefaultMarkdownMaxRows if <= 0).
+	MarkdownMaxRows int
+	// JSONShape selects the JSON layout: JSONShapeFlat (the default when
+	// empty) lists items under "todos", JSONShapeNested groups them by file
+	// under "files".
+	JSONShape string
+	// BurnDown, when non-nil, is rendered as a callout in HTML, a section in
+	// Markdown and a field in JSON. See ComputeBurnDown.
+	BurnDown *BurnDown
+	// HTMLStreamThreshold is the item count above which HTML reports are
+	// written in batches of rows (DefaultHTMLStreamThreshold if 0; negative
+	// never streams).
+	HTMLStreamThreshold int
+	// RelativeRoot shows File paths without the directory prefix they all
+	// share (see CommonDir). Only the rendered paths change.
+	RelativeRoot bool
+	// Links adds a file:// URI and an optional editor link to every item of
+	// the JSON and JSONL reports, used by the HTML report to link item files.
+	// Links are built from the paths as scanned, before RelativeRoot.
+	Links ItemLinks
+	// Enrichers run over the items, in order, before the report is built.
+	// See Enrich.
+	Enrichers []Enricher
+	// ExtraColumns names the Todo.Extra keys rendered as additional columns
+	// of the HTML and Markdown item tables, in this order. JSON reports
+	// always carry the whole Extra map.
+	ExtraColumns []string
+	// LinesScanned is the number of lines the items were found in (see
+	// ScanStats.Lines). When positive, reports show items per 1000 lines.
+	LinesScanned int
+	// Problems, from ScanResult.Problems, is listed in JSON and flagged by
+	// a banner in HTML.
+	Problems []Problem
+	// RawText keeps item descriptions as scanned instead of prefixing them
+	// with the tag ("TAG: text"), e.g. for JSON consumers that read Tag on
+	// its own.
+	RawText bool
+	// Noise holds low-signal items split off the report's items (see
+	// SplitNoise). HTML and Markdown list them in a collapsed "Low-signal
+	// items" section and JSON under "noise"; they are counted in
+	// Summary.Noise, not Summary.Total.
+	Noise []Todo
+	// SamplePercent, when between 1 and 99, marks the items as coming from a
+	// scan sampled at that percentage (see ScanOptions.SamplePercent): the
+	// report metadata and headers carry the estimated totals of a full scan.
+	SamplePercent int
+	// Badge configures the SVG badge report (see GenerateBadgeWithWriter).
+	Badge BadgeOptions
+	// Lang is the language of the headings, labels and column headers of
+	// the HTML and Markdown reports (DefaultLang when empty; see Languages).
+	// Tags and item texts are never translated.
+	Lang string
+}
+
+// DefaultHTMLStreamThreshold is the default ReportOptions.HTMLStreamThreshold.
+const DefaultHTMLStreamThreshold = 10000
+
+// htmlRowBatch is the number of rows rendered between flushes when an HTML
+// report is streamed.
+const htmlRowBatch = 1000
+
+// streamHTML reports whether an HTML report of n items is streamed.
+func (o ReportOptions) streamHTML(n int) bool {
+	threshold := o.HTMLStreamThreshold
+	if threshold == 0 {
+		threshold = DefaultHTMLStreamThreshold
+	}
+	return threshold > 0 && n > threshold
+}
+
+// JSON report shapes accepted by ReportOptions.JSONShape.
+const (
+	JSONShapeFlat   = "flat"
+	JSONShapeNested = "nested"
+)
+
+// DefaultMarkdownMaxRows caps the item table of compact Markdown.
+const DefaultMarkdownMaxRows = 100
+
+// Markdown badge styles accepted by ReportOptions.MarkdownBadges.
+const (
+	MarkdownBadgesEmoji   = "emoji"
+	MarkdownBadgesShields = "shields"
+)
+
+// topIssuesLimit caps the "Top issues" section of badge-decorated Markdown.
+const topIssuesLimit = 10
+
+// topFilesLimit caps the "Top files" section of compact Markdown.
+const topFilesLimit = 10
+
+// oldestDebtLimit caps the "Oldest debt" section.
+const oldestDebtLimit = 10
+
 // FileWriter allows injecting file writers for testing or alternate outputs.
 type FileWriter interface {
 	Create(name string) (io.WriteCloser, error)
@@ -47,44 +253,62 @@ func (OSFileWriter) Create(name string) (io.WriteCloser, error) {
 
 // GenerateHTMLReport writes an HTML report to the given output path using the
 // default OS-backed writer. This is the production entry point.
-func GenerateHTMLReport(items []Todo, output string) error {
-	return GenerateHTMLReportWithWriter(items, output, OSFileWriter{})
+func GenerateHTMLReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateHTMLReportWithWriter(items, output, OSFileWriter{}, opts)
 }
 
 // GenerateJSONReport writes a JSON report to the given output path using the
 // default OS-backed writer. Suitable for CI consumption.
-func GenerateJSONReport(items []Todo, output string) error {
-	return GenerateJSONReportWithWriter(items, output, OSFileWriter{})
+func GenerateJSONReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateJSONReportWithWriter(items, output, OSFileWriter{}, opts)
 }
 
 // Create is a top-level convenience wrapper for HTML report generation.
 // It uses the real OS writer and defaults for production usage.
 func Create(items []Todo, output string) error {
-	return GenerateHTMLReport(items, output)
+	return GenerateHTMLReport(items, output, ReportOptions{})
 }
 
 // buildReportData constructs Summary and returns a sorted copy of items.
-func buildReportData(items []Todo) ReportData {
+func buildReportData(items []Todo, opts ReportOptions) ReportData {
 	counts := make(map[string]int)
-	cp := make([]Todo, len(items))
+	var positions map[string]int
+	// Noise items share the fingerprints and path trimming of the others.
+	cp := make([]Todo, len(items)+len(opts.Noise))
 	copy(cp, items)
+	copy(cp[len(items):], opts.Noise)
+	setFingerprints(cp)
+	for i := range cp {
+		opts.Links.Apply(&cp[i])
+	}
+	if opts.RelativeRoot {
+		trimCommonDir(cp)
+	}
+	noise := cp[len(items):]
+	cp = cp[:len(items):len(items)]
+	if !opts.RawText {
+		for i := range noise {
+			noise[i].Text = taggedText(noise[i])
+		}
+	}
+	sortTodos(noise)
+	exts := make(map[string]int)
 	for i := range cp {
 		// Aggregate counts by tag
 		counts[cp[i].Tag]++
+		exts[strings.ToLower(path.Ext(normalizePath(cp[i].File)))]++
+		if cp[i].Position != "" {
+			if positions == nil {
+				positions = make(map[string]int)
+			}
+			positions[cp[i].Position]++
+		}
 		// Enrich text to include the tag keyword for clearer reports
-		if cp[i].Text == "" {
-			cp[i].Text = cp[i].Tag
-		} else {
-			cp[i].Text = cp[i].Tag + ": " + cp[i].Text
+		if !opts.RawText {
+			cp[i].Text = taggedText(cp[i])
 		}
 	}
-	// Stable ordering for todos: by file, then line
-	sort.Slice(cp, func(i, j int) bool {
-		if cp[i].File == cp[j].File {
-			return cp[i].Line < cp[j].Line
-		}
-		return cp[i].File < cp[j].File
-	})
+	sortTodos(cp)
 	// Build TagStats in alphabetical order with percentages rounded to one decimal place.
 	keys := make([]string, 0, len(counts))
 	for k := range counts {
@@ -102,93 +326,641 @@ func buildReportData(items []Todo) ReportData {
 		}
 		stats = append(stats, TagStat{Tag: k, Count: c, Percent: pct})
 	}
-	return ReportData{
-		Todos:    cp,
-		Summary:  Summary{Total: total, ByTag: counts},
-		TagStats: stats,
+	data := ReportData{
+		Todos:      cp,
+		Summary:    Summary{Total: total, ByTag: counts, ByPosition: positions},
+		TagStats:   stats,
+		ExtStats:   extStats(exts, total),
+		OldestDebt: oldestDebt(cp, opts.now()),
+		Persistent: persistentItems(cp),
+		Orphans:    orphanItems(cp),
+		BurnDown:   opts.BurnDown,
+
+		ExtraColumns: opts.ExtraColumns,
+		opts:         opts,
+	}
+	data.Summary.Oldest, data.Summary.Newest = AgeRange(cp, opts.now())
+	if len(noise) > 0 {
+		data.Noise = noise
+		data.Summary.Noise = len(noise)
+	}
+	if len(opts.Problems) > 0 {
+		data.Problems = opts.Problems
+		data.Summary.ProblemCount = len(opts.Problems)
+	}
+	if opts.LinesScanned > 0 {
+		data.Summary.LinesScanned = opts.LinesScanned
+		data.Summary.PerThousandLines = PerThousandLines(total, opts.LinesScanned)
+	}
+	if opts.JSONShape == JSONShapeNested {
+		data.Files = make(map[string][]Todo)
+		for _, t := range cp {
+			data.Files[t.File] = append(data.Files[t.File], t)
+		}
+	}
+	var sample *Sample
+	if opts.SamplePercent > 0 && opts.SamplePercent < 100 {
+		sample = NewSample(cp, opts.SamplePercent)
+	}
+	if opts.Audit != nil || opts.FilteredByPattern > 0 || sample != nil {
+		data.Metadata = &ReportMetadata{Audit: opts.Audit, FilteredByPattern: opts.FilteredByPattern, Sample: sample}
+	}
+	return data
+}
+
+// taggedText returns the text reports show for t: its description prefixed
+// with the tag, or the tag alone.
+func taggedText(t Todo) string {
+	if t.Text == "" {
+		return t.Tag
 	}
+	return t.Tag + ": " + t.Text
+}
+
+// extStats turns per-extension counts of total items into ExtStats, most
+// items first and by extension on ties, with percentages rounded to one
+// decimal place.
+func extStats(counts map[string]int, total int) []ExtStat {
+	stats := make([]ExtStat, 0, len(counts))
+	for ext, c := range counts {
+		stats = append(stats, ExtStat{Ext: ext, Count: c, Percent: math.Round((float64(c)*100.0/float64(total))*10) / 10})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Ext < stats[j].Ext
+	})
+	return stats
+}
+
+// sortTodos orders items by file, line, then column: the order every report
+// uses. The sort is stable, so items sharing a position keep their scan order.
+func sortTodos(items []Todo) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].File != items[j].File {
+			return items[i].File < items[j].File
+		}
+		if items[i].Line != items[j].Line {
+			return items[i].Line < items[j].Line
+		}
+		return items[i].Column < items[j].Column
+	})
+}
+
+// CommonDir returns the longest directory prefix, ending in '/', shared by
+// the File of every item, or "" when there is none. Only whole path segments
+// count: "src/app/a.go" and "src/api/b.go" share "src/".
+func CommonDir(items []Todo) string {
+	if len(items) == 0 {
+		return ""
+	}
+	prefix := path.Dir(items[0].File)
+	for _, t := range items[1:] {
+		for prefix != "." && prefix != "/" && !strings.HasPrefix(t.File, prefix+"/") {
+			prefix = path.Dir(prefix)
+		}
+	}
+	switch prefix {
+	case ".":
+		return ""
+	case "/":
+		return "/"
+	}
+	return prefix + "/"
+}
+
+// TrimCommonDir returns a copy of items with CommonDir removed from each File.
+func TrimCommonDir(items []Todo) []Todo {
+	cp := make([]Todo, len(items))
+	copy(cp, items)
+	trimCommonDir(cp)
+	return cp
+}
+
+// trimCommonDir removes CommonDir from the File of items in place.
+func trimCommonDir(items []Todo) {
+	prefix := CommonDir(items)
+	if prefix == "" {
+		return
+	}
+	for i := range items {
+		items[i].File = strings.TrimPrefix(items[i].File, prefix)
+	}
+}
+
+// oldestDebt returns up to oldestDebtLimit items with a known introduction
+// date, oldest first, with their age at now. Items are expected in file/line
+// order for stable ties.
+func oldestDebt(items []Todo, now time.Time) []DebtItem {
+	var dated []DebtItem
+	for _, t := range items {
+		if age, ok := ItemAge(t, now); ok {
+			dated = append(dated, DebtItem{Todo: t, Age: HumanizeAge(age)})
+		}
+	}
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].IntroducedAt.Before(*dated[j].IntroducedAt)
+	})
+	if len(dated) > oldestDebtLimit {
+		dated = dated[:oldestDebtLimit]
+	}
+	return dated
+}
+
+// AgeRange returns the items of items introduced first and last, with
+// their ages at now, or nils when no item has a known introduction date.
+// Ties go to the item listed first.
+func AgeRange(items []Todo, now time.Time) (oldest, newest *DebtItem) {
+	for _, t := range items {
+		age, ok := ItemAge(t, now)
+		if !ok {
+			continue
+		}
+		if oldest == nil || t.IntroducedAt.Before(*oldest.IntroducedAt) {
+			oldest = &DebtItem{Todo: t, Age: HumanizeAge(age)}
+		}
+		if newest == nil || t.IntroducedAt.After(*newest.IntroducedAt) {
+			newest = &DebtItem{Todo: t, Age: HumanizeAge(age)}
+		}
+	}
+	return oldest, newest
+}
+
+// now returns the reference time for item ages.
+func (o ReportOptions) now() time.Time {
+	if o.Now.IsZero() {
+		return time.Now()
+	}
+	return o.Now
 }
 
 // GenerateHTMLReportWithWriter allows dependency injection of writers for testing.
-func GenerateHTMLReportWithWriter(items []Todo, output string, w FileWriter) error {
-	data := buildReportData(items)
+func GenerateHTMLReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return GenerateReportWithWriter("html", items, output, w, opts)
+}
 
-	tmpl, candidates, err := parseReportTemplate()
+// renderHTMLReport is the html Reporter.
+func renderHTMLReport(data ReportData, w io.Writer) error {
+	tmpl, candidates, err := parseReportTemplate(data.ExtraColumns, data.opts.Lang)
 	if err != nil {
 		return fmt.Errorf("could not find report.html template in: %v", candidates)
 	}
+	return renderHTML(tmpl, data, w, data.opts)
+}
 
-	f, err := w.Create(output)
-	if err != nil {
-		return err
-	}
-	defer SafeClose(f, output)
+// writeHTMLReport renders data with tmpl to output, created through w.
+func writeHTMLReport(tmpl *template.Template, data ReportData, output string, w FileWriter, opts ReportOptions) error {
+	f := &lazyFile{w: w, name: output}
+	return f.close(renderHTML(tmpl, data, f, opts))
+}
 
-	return tmpl.Execute(f, data)
+// renderHTML renders data with tmpl to w. The document is rendered into
+// memory first and only written once that succeeds, so a template error
+// never leaves a partial file behind. Streamed reports are too large for
+// that; see streamHTMLReport.
+func renderHTML(tmpl *template.Template, data ReportData, w io.Writer, opts ReportOptions) error {
+	if opts.streamHTML(len(data.Todos)) {
+		if err := streamHTMLReport(w, tmpl, data); err != nil {
+			return fmt.Errorf("render HTML report: %w", err)
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("render HTML report: %w", err)
+	}
+	_, err := buf.WriteTo(w)
+	return err
 }
 
-// GenerateJSONReportWithWriter allows dependency injection of writers for testing.
-func GenerateJSONReportWithWriter(items []Todo, output string, w FileWriter) error {
-	data := buildReportData(items)
-	f, err := w.Create(output)
-	if err != nil {
+// streamHTMLReport writes the same document as the report template's main
+// composition, but renders the rows through a buffer flushed every
+// htmlRowBatch rows, so the writer sees bounded chunks however many items
+// there are. The head is rendered whole before anything is written, so an
+// error in the summary sections still leaves w untouched.
+func streamHTMLReport(w io.Writer, tmpl *template.Template, data ReportData) error {
+	var head bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&head, "head", data); err != nil {
 		return err
 	}
-	defer SafeClose(f, output)
-	enc := json.NewEncoder(f)
+	bw := bufio.NewWriter(w)
+	if _, err := head.WriteTo(bw); err != nil {
+		return err
+	}
+	for i, t := range data.Todos {
+		if err := tmpl.ExecuteTemplate(bw, "row", t); err != nil {
+			return err
+		}
+		if (i+1)%htmlRowBatch == 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tmpl.ExecuteTemplate(bw, "foot", data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// GenerateJSONReportWithWriter allows dependency injection of writers for testing.
+func GenerateJSONReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return GenerateReportWithWriter("json", items, output, w, opts)
+}
+
+// renderJSONReport is the json Reporter.
+func renderJSONReport(data ReportData, w io.Writer) error {
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
+	if data.opts.JSONShape == JSONShapeNested {
+		// The outer nil Todos shadows the embedded field, dropping the flat
+		// list from the output; the outer Files keeps an empty map in it.
+		return enc.Encode(struct {
+			Todos []Todo            `json:"todos,omitempty"`
+			Files map[string][]Todo `json:"files"`
+			ReportData
+		}{Files: data.Files, ReportData: data})
+	}
 	return enc.Encode(data)
 }
 
 // GenerateMarkdownReport writes a Markdown report to the given output path using the
 // default OS-backed writer.
-func GenerateMarkdownReport(items []Todo, output string) error {
-	return GenerateMarkdownReportWithWriter(items, output, OSFileWriter{})
+func GenerateMarkdownReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateMarkdownReportWithWriter(items, output, OSFileWriter{}, opts)
 }
 
 // GenerateMarkdownReportWithWriter allows dependency injection of writers for testing.
-func GenerateMarkdownReportWithWriter(items []Todo, output string, w FileWriter) error {
-	data := buildReportData(items)
-	f, err := w.Create(output)
+func GenerateMarkdownReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return GenerateReportWithWriter("md", items, output, w, opts)
+}
+
+// renderMarkdownReport is the md Reporter.
+func renderMarkdownReport(data ReportData, w io.Writer) error {
+	_, err := io.WriteString(w, renderMarkdown(data, data.opts))
+	return err
+}
+
+// AppendMarkdownSummary appends a compact Markdown summary of items to the
+// file at path, creating it if needed. Existing content is kept, so several
+// writers can share the file, as with GitHub's $GITHUB_STEP_SUMMARY.
+func AppendMarkdownSummary(items []Todo, path string, opts ReportOptions) error {
+	opts.MarkdownCompact = true
+	items, err := Enrich(items, opts.Enrichers)
 	if err != nil {
 		return err
 	}
-	defer SafeClose(f, output)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, renderMarkdown(buildReportData(items, opts), opts)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
 
+// renderMarkdown renders the Markdown report, or its compact summary form
+// when opts.MarkdownCompact is set.
+func renderMarkdown(data ReportData, opts ReportOptions) string {
+	badges := opts.MarkdownBadges
+	compact := opts.MarkdownCompact
+	lang := opts.Lang
+	msg := func(key string) string { return Message(lang, key) }
 	var b strings.Builder
 	// Title
-	b.WriteString("# todototum report\n\n")
+	if compact {
+		b.WriteString("## " + msg("summary.title") + "\n\n")
+	} else {
+		b.WriteString("# " + msg("report.title") + "\n\n")
+		b.WriteString("## " + msg("summary") + "\n\n")
+	}
 	// Summary
-	b.WriteString("## Summary\n\n")
-	b.WriteString(fmt.Sprintf("- Total: %d\n", data.Summary.Total))
-	// Stable list of tags using TagStats (already sorted)
-	if len(data.TagStats) > 0 {
-		for _, ts := range data.TagStats {
-			b.WriteString(fmt.Sprintf("- %s: %d (%.1f%%)\n", ts.Tag, ts.Count, ts.Percent))
+	switch {
+	case badges == MarkdownBadgesShields:
+		writeMarkdownShields(&b, data)
+	case compact:
+		b.WriteString(fmt.Sprintf("**%s: %d**\n\n", msg("total"), data.Summary.Total))
+		if len(data.TagStats) > 0 {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", msg("col.tag"), msg("col.count"), msg("col.share")))
+			b.WriteString("|-----|------:|------:|\n")
+			for _, ts := range data.TagStats {
+				b.WriteString(fmt.Sprintf("| %s | %d | %.1f%% |\n", markdownTag(ts.Tag, badges), ts.Count, ts.Percent))
+			}
+		}
+	default:
+		b.WriteString(fmt.Sprintf("- %s: %d\n", msg("total"), data.Summary.Total))
+		// Stable list of tags using TagStats (already sorted)
+		if len(data.TagStats) > 0 {
+			for _, ts := range data.TagStats {
+				b.WriteString(fmt.Sprintf("- %s: %d (%.1f%%)\n", markdownTag(ts.Tag, badges), ts.Count, ts.Percent))
+			}
 		}
 	}
+	if len(data.Summary.ByPosition) > 0 {
+		b.WriteString("- " + FormatPositionSplit(data.Summary.ByPosition, lang) + "\n")
+	}
+	if data.Summary.LinesScanned > 0 {
+		b.WriteString("- " + FormatDensity(data.Summary, lang) + "\n")
+	}
+	sample := data.sample()
+	if sample != nil {
+		b.WriteString("- " + FormatEstimate(sample, lang) + "\n")
+	}
 	b.WriteString("\n")
+	if compact {
+		writeMarkdownTopFiles(&b, data.Todos, lang)
+		maxRows := opts.MarkdownMaxRows
+		if maxRows <= 0 {
+			maxRows = DefaultMarkdownMaxRows
+		}
+		if len(data.Todos) > 0 {
+			label := msg("all-items")
+			if sample != nil {
+				label = msg("sampled-items")
+			}
+			b.WriteString(fmt.Sprintf("<details>\n<summary>%s (%d)</summary>\n\n", label, len(data.Todos)))
+			writeMarkdownTodos(&b, data.Todos[:min(maxRows, len(data.Todos))], badges, data.ExtraColumns, lang)
+			if n := len(data.Todos) - maxRows; n > 0 {
+				b.WriteString("\n_" + fmt.Sprintf(msg("more-not-shown"), n) + "_\n")
+			}
+			b.WriteString("\n</details>\n\n")
+		}
+		return b.String()
+	}
+	if data.BurnDown != nil {
+		writeMarkdownBurnDown(&b, data.BurnDown, lang)
+	}
+	if badges != "" {
+		writeMarkdownTopIssues(&b, data.Todos, badges, lang)
+	}
+	if len(data.OldestDebt) > 0 {
+		b.WriteString("## " + msg("oldest-debt") + "\n\n")
+		b.WriteString(markdownHeader(lang, "introduced", "age", "by", "file", "line", "text"))
+		b.WriteString("|------------|----:|----|------|-----:|------|\n")
+		for _, t := range data.OldestDebt {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d | %s |\n", t.IntroducedAt.Format("2006-01-02"), t.Age, EscapeMarkdown(t.IntroducedBy), EscapeMarkdown(t.File), t.Line, EscapeMarkdown(t.Text)))
+		}
+		b.WriteString("\n")
+	}
+	if len(data.Persistent) > 0 {
+		b.WriteString("## " + msg("persistent") + "\n\n")
+		b.WriteString(markdownHeader(lang, "runs", "file", "line", "text"))
+		b.WriteString("|-----:|------|-----:|------|\n")
+		for _, t := range data.Persistent {
+			b.WriteString(fmt.Sprintf("| %d | %s | %d | %s |\n", t.SeenInRuns, EscapeMarkdown(t.File), t.Line, EscapeMarkdown(t.Text)))
+		}
+		b.WriteString("\n")
+	}
+	if len(data.Orphans) > 0 {
+		b.WriteString("## " + msg("obsolete") + "\n\n")
+		b.WriteString(msg("obsolete.note") + "\n\n")
+		b.WriteString(markdownHeader(lang, "file", "line", "text"))
+		b.WriteString("|------|-----:|------|\n")
+		for _, t := range data.Orphans {
+			b.WriteString(fmt.Sprintf("| %s | %d | %s |\n", EscapeMarkdown(t.File), t.Line, EscapeMarkdown(t.Text)))
+		}
+		b.WriteString("\n")
+	}
 	// Todos table
-	b.WriteString("## Todos\n\n")
-	b.WriteString("| File | Line | Tag | Text |\n")
-	b.WriteString("|------|------:|-----:|------|\n")
-	for _, t := range data.Todos {
-		// Text already includes the tag prefix (via buildReportData)
-		b.WriteString(fmt.Sprintf("| %s | %d | %s | %s |\n", t.File, t.Line, t.Tag, t.Text))
+	if sample != nil {
+		b.WriteString("## " + fmt.Sprintf(msg("todos.sampled"), sample.Percent) + "\n\n")
+	} else {
+		b.WriteString("## " + msg("todos") + "\n\n")
+	}
+	writeMarkdownTodos(&b, data.Todos, badges, data.ExtraColumns, lang)
+	if len(data.Noise) > 0 {
+		b.WriteString(fmt.Sprintf("\n<details>\n<summary>%s (%d)</summary>\n\n", msg("low-signal"), len(data.Noise)))
+		writeMarkdownTodos(&b, data.Noise, badges, data.ExtraColumns, lang)
+		b.WriteString("\n</details>\n")
 	}
+	if data.Metadata != nil && data.Metadata.Audit != nil {
+		writeMarkdownExclusions(&b, data.Metadata.Audit, lang)
+	}
+	return b.String()
+}
 
-	_, err = io.WriteString(f, b.String())
-	return err
+// writeMarkdownBurnDown writes the change since the previous run and the
+// projections.
+func writeMarkdownBurnDown(b *strings.Builder, bd *BurnDown, lang string) {
+	b.WriteString("## " + Message(lang, "burn-down") + "\n\n")
+	b.WriteString(fmt.Sprintf(Message(lang, "burn-down.since"), bd.Baseline.Format("2006-01-02")) + " ")
+	b.WriteString(fmt.Sprintf(Message(lang, "burn-down.changes"), bd.Resolved, bd.Added, bd.Net, bd.BaselineTotal, bd.Current) + "\n\n")
+	for _, p := range bd.Projections {
+		b.WriteString("- " + p.Sentence(lang) + "\n")
+	}
+	b.WriteString("\n")
+}
+
+// markdownHeader returns the header row of a Markdown table whose columns
+// are the "col." messages named by keys, in lang.
+func markdownHeader(lang string, keys ...string) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for _, k := range keys {
+		b.WriteString(" " + Message(lang, "col."+k) + " |")
+	}
+	b.WriteString("\n")
+	return b.String()
 }
 
-//go:embed templates/report.html
+// writeMarkdownTodos writes the item table, with a column per Extra key in
+// cols.
+func writeMarkdownTodos(b *strings.Builder, todos []Todo, badges string, cols []string, lang string) {
+	b.WriteString(strings.TrimSuffix(markdownHeader(lang, "file", "line", "tag", "text"), "\n"))
+	for _, c := range cols {
+		b.WriteString(" " + EscapeMarkdown(c) + " |")
+	}
+	b.WriteString("\n|------|------:|-----:|------|")
+	b.WriteString(strings.Repeat("------|", len(cols)))
+	b.WriteString("\n")
+	for _, t := range todos {
+		// Text includes the tag prefix unless RawText (via buildReportData)
+		b.WriteString(fmt.Sprintf("| %s | %d | %s | %s |", EscapeMarkdown(t.File), t.Line, markdownTag(t.Tag, badges), EscapeMarkdown(t.Text)))
+		for _, v := range t.ExtraValues(cols) {
+			b.WriteString(" " + EscapeMarkdown(v) + " |")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeMarkdownTopFiles writes the files with the most items, up to
+// topFilesLimit, most items first and by name among equals.
+func writeMarkdownTopFiles(b *strings.Builder, todos []Todo, lang string) {
+	if len(todos) == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	var files []string
+	for _, t := range todos {
+		if counts[t.File] == 0 {
+			files = append(files, t.File)
+		}
+		counts[t.File]++
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if counts[files[i]] != counts[files[j]] {
+			return counts[files[i]] > counts[files[j]]
+		}
+		return files[i] < files[j]
+	})
+	if len(files) > topFilesLimit {
+		files = files[:topFilesLimit]
+	}
+	b.WriteString("### " + Message(lang, "top-files") + "\n\n")
+	b.WriteString(markdownHeader(lang, "file", "items"))
+	b.WriteString("|------|------:|\n")
+	for _, f := range files {
+		b.WriteString(fmt.Sprintf("| %s | %d |\n", EscapeMarkdown(f), counts[f]))
+	}
+	b.WriteString("\n")
+}
+
+// FormatPositionSplit renders own-line/trailing counts in lang, e.g.
+// "own-line: 120, trailing: 37".
+func FormatPositionSplit(byPosition map[string]int, lang string) string {
+	return fmt.Sprintf("%s: %d, %s: %d", Message(lang, PositionOwnLine), byPosition[PositionOwnLine], Message(lang, PositionTrailing), byPosition[PositionTrailing])
+}
+
+// markdownTag decorates tag with its emoji when badges are enabled.
+func markdownTag(tag, badges string) string {
+	if badges == "" {
+		return tag
+	}
+	return StyleFor(tag).Emoji + " " + tag
+}
+
+// markdownEscaper backslash-escapes the characters that would end a table
+// cell, start a code span or open raw HTML, and the "](" that would turn a
+// bracketed phrase into a link or image; a lone bracket, as in the
+// [REDACTED] marker, is left readable. Line breaks, which would end a table
+// row, become spaces.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "](", `\](`, "`", "\\`", "<", `\<`, ">", `\>`,
+	"\r\n", " ", "\n", " ", "\r", " ")
+
+// EscapeMarkdown escapes s for a Markdown table cell or list item, so that
+// file paths and comment text render as written and cannot break the table
+// or inject links and HTML.
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownCode renders s as a code span, delimited by more backticks than
+// it contains in a row so that none of them can end the span early.
+func markdownCode(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+	longest := longestRun(s, '`')
+	if longest == 0 {
+		return "`" + s + "`"
+	}
+	fence := strings.Repeat("`", longest+1)
+	return fence + " " + s + " " + fence
+}
+
+// MarkdownLink returns a Markdown link labeled label to target, a URL or a
+// relative path. The target is parsed and re-encoded with net/url, so special
+// characters are percent-encoded; a target with a scheme other than http or
+// https, such as javascript:, or that does not parse yields the escaped label
+// without a link.
+func MarkdownLink(label, target string) string {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return EscapeMarkdown(label)
+	}
+	return "[" + EscapeMarkdown(label) + "](" + strings.NewReplacer("(", "%28", ")", "%29").Replace(u.String()) + ")"
+}
+
+// writeMarkdownShields renders summary counts as img.shields.io badges.
+func writeMarkdownShields(b *strings.Builder, data ReportData) {
+	b.WriteString(shieldsBadge("total", data.Summary.Total, "blue"))
+	for _, ts := range data.TagStats {
+		b.WriteString(" ")
+		b.WriteString(shieldsBadge(ts.Tag, ts.Count, StyleFor(ts.Tag).Color))
+	}
+	b.WriteString("\n")
+}
+
+// shieldsBadge builds a static shields.io badge image for label/count.
+func shieldsBadge(label string, count int, color string) string {
+	// shields uses '-' as a separator, so literal dashes and underscores are doubled.
+	esc := strings.NewReplacer("-", "--", "_", "__", " ", "_").Replace(label)
+	return fmt.Sprintf("![%s: %d](https://img.shields.io/badge/%s-%d-%s)", label, count, url.PathEscape(esc), count, color)
+}
+
+// writeMarkdownTopIssues lists the most severe items ahead of the full table.
+// Items keep their file/line order within the same severity.
+func writeMarkdownTopIssues(b *strings.Builder, todos []Todo, badges, lang string) {
+	if len(todos) == 0 {
+		return
+	}
+	top := make([]Todo, len(todos))
+	copy(top, todos)
+	sort.SliceStable(top, func(i, j int) bool {
+		return StyleFor(top[i].Tag).Severity > StyleFor(top[j].Tag).Severity
+	})
+	if len(top) > topIssuesLimit {
+		top = top[:topIssuesLimit]
+	}
+	b.WriteString("## " + Message(lang, "top-issues") + "\n\n")
+	for _, t := range top {
+		b.WriteString(fmt.Sprintf("- %s %s %s\n", markdownTag(t.Tag, badges), markdownCode(fmt.Sprintf("%s:%d", t.File, t.Line)), EscapeMarkdown(t.Text)))
+	}
+	b.WriteString("\n")
+}
+
+// writeMarkdownExclusions renders the audit log as a collapsed appendix.
+func writeMarkdownExclusions(b *strings.Builder, audit *AuditLog, lang string) {
+	b.WriteString("\n<details>\n")
+	b.WriteString(fmt.Sprintf("<summary>%s (%d)</summary>\n\n", Message(lang, "exclusions"), len(audit.Entries)))
+	b.WriteString(markdownHeader(lang, "path", "type", "reason", "rule"))
+	b.WriteString("|------|------|--------|------|\n")
+	for _, e := range audit.Entries {
+		kind := Message(lang, "kind.file")
+		if e.Dir {
+			kind = Message(lang, "kind.dir")
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", EscapeMarkdown(e.Path), kind, e.Reason, EscapeMarkdown(e.Rule)))
+	}
+	if audit.Truncated {
+		b.WriteString("\n_" + fmt.Sprintf(Message(lang, "truncated"), audit.Limit) + "_\n")
+	}
+	b.WriteString("\n</details>\n")
+}
+
+//go:embed templates/report.html templates/diff.html
 var templatesFS embed.FS
 
-// parseReportTemplate parses the embedded HTML template.
+// parseReportTemplate parses the embedded HTML template, with its headings
+// and labels in lang.
 // The template is compiled into the binary via Go's //go:embed. No filesystem
 // lookup or overrides are performed.
-func parseReportTemplate() (*template.Template, []string, error) {
-	if tmpl, err := template.ParseFS(templatesFS, "templates/report.html"); err == nil {
+func parseReportTemplate(cols []string, lang string) (*template.Template, []string, error) {
+	if lang == "" {
+		lang = DefaultLang
+	}
+	funcs := template.FuncMap{
+		"extraValues": func(t Todo) []string { return t.ExtraValues(cols) },
+		"msg":         func(key string) string { return Message(lang, key) },
+		"lang":        func() string { return lang },
+		"lower":       strings.ToLower,
+		// Item links are built by ItemLinks from escaped paths, so their
+		// file: and editor schemes are trusted; a script scheme set through
+		// the Go API is still handed to the template as a plain string, which
+		// html/template neutralizes.
+		"href": func(t Todo) any {
+			u := t.URI
+			if t.EditorURI != "" {
+				u = t.EditorURI
+			}
+			if hasScriptScheme(u) {
+				return u
+			}
+			return template.URL(u)
+		},
+	}
+	if tmpl, err := template.New("report.html").Funcs(funcs).ParseFS(templatesFS, "templates/report.html"); err == nil {
 		return tmpl, []string{"embedded:templates/report.html"}, nil
 	}
 	return nil, []string{"embedded:templates/report.html"}, fmt.Errorf("template not found")
@@ -0,0 +1,143 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default BadgeOptions thresholds: up to DefaultBadgeGreen items is green,
+// up to DefaultBadgeYellow yellow, more red.
+const (
+	DefaultBadgeGreen  = 10
+	DefaultBadgeYellow = 50
+)
+
+// Badge colors of the shields.io palette.
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeRed    = "#e05d44"
+)
+
+// BadgeOptions tunes the SVG badge of GenerateBadgeWithWriter.
+type BadgeOptions struct {
+	// Label is the left-hand text; empty uses the lowercased Tag, or
+	// "todos".
+	Label string
+	// Tag, when set, counts only the items with this tag (case-insensitive)
+	// instead of all of them.
+	Tag string
+	// Green and Yellow are the highest counts shown green and yellow; larger
+	// counts are red. When both are zero, DefaultBadgeGreen and
+	// DefaultBadgeYellow apply.
+	Green, Yellow int
+}
+
+// label returns the badge's left-hand text.
+func (o BadgeOptions) label() string {
+	switch {
+	case o.Label != "":
+		return o.Label
+	case o.Tag != "":
+		return strings.ToLower(o.Tag)
+	}
+	return "todos"
+}
+
+// BadgeColor returns the badge color for count: green up to the green
+// threshold, yellow up to the yellow one, red above.
+func (o BadgeOptions) BadgeColor(count int) string {
+	green, yellow := o.Green, o.Yellow
+	if green == 0 && yellow == 0 {
+		green, yellow = DefaultBadgeGreen, DefaultBadgeYellow
+	}
+	switch {
+	case count <= green:
+		return badgeGreen
+	case count <= yellow:
+		return badgeYellow
+	}
+	return badgeRed
+}
+
+// GenerateBadgeReport writes an SVG badge of the item count to the given
+// output path using the default OS-backed writer.
+func GenerateBadgeReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateBadgeWithWriter(items, output, OSFileWriter{}, opts)
+}
+
+// GenerateBadgeWithWriter writes a self-contained SVG badge in the shields.io
+// flat style, labeled per opts.Badge, showing the number of items (or of
+// items with opts.Badge.Tag) on a background colored by the thresholds.
+func GenerateBadgeWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	b := opts.Badge
+	count := 0
+	for _, t := range items {
+		if b.Tag == "" || strings.EqualFold(t.Tag, b.Tag) {
+			count++
+		}
+	}
+	svg := RenderBadge(b.label(), strconv.Itoa(count), b.BadgeColor(count))
+
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+	_, err = f.Write([]byte(svg))
+	return err
+}
+
+// RenderBadge returns a flat-style SVG badge with label on the left and
+// value on the right on a color background. Each half is sized to its text
+// with badgeTextWidth, so long values do not overflow.
+func RenderBadge(label, value, color string) string {
+	lw, vw := badgeTextWidth(label)+10, badgeTextWidth(value)+10
+	total := lw + vw
+	l, v := xmlEscape(label), xmlEscape(value)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, total, l, v)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, l, v)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, total)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`, lw, lw, vw, xmlEscape(color), total)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, t := range []struct {
+		x    float64
+		text string
+	}{{float64(lw) / 2, l}, {float64(lw) + float64(vw)/2, v}} {
+		fmt.Fprintf(&b, `<text x="%g" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%g" y="14">%s</text>`, t.x, t.text, t.x, t.text)
+	}
+	b.WriteString("</g></svg>\n")
+	return b.String()
+}
+
+// badgeTextWidth approximates the width in pixels of s in 11px Verdana:
+// narrow glyphs such as i, l and punctuation are about 4px, wide ones such
+// as m and W 10px, other capitals 8px and the rest, digits included, 7px.
+func badgeTextWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("fijlrtI.,:;!|'()[] ", r):
+			w += 4
+		case strings.ContainsRune("mwMW@%", r):
+			w += 10
+		case r >= 'A' && r <= 'Z':
+			w += 8
+		default:
+			w += 7
+		}
+	}
+	return w
+}
+
+// xmlEscape escapes s for XML text and attribute values.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
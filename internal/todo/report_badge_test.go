@@ -0,0 +1,107 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parsedBadge is the part of a badge SVG the tests look at.
+type parsedBadge struct {
+	Width int    `xml:"width,attr"`
+	Title string `xml:"title"`
+	Rects []struct {
+		X     int    `xml:"x,attr"`
+		Width int    `xml:"width,attr"`
+		Fill  string `xml:"fill,attr"`
+	} `xml:"g>rect"`
+	Texts []string `xml:"g>text"`
+}
+
+func renderBadge(t *testing.T, items []Todo, opts BadgeOptions) parsedBadge {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := GenerateBadgeWithWriter(items, "badge.svg", mockFileWriter{buf: &buf}, ReportOptions{Badge: opts}); err != nil {
+		t.Fatal(err)
+	}
+	var b parsedBadge
+	if err := xml.Unmarshal(buf.Bytes(), &b); err != nil {
+		t.Fatalf("badge is not valid XML: %v\n%s", err, buf.String())
+	}
+	return b
+}
+
+func todosOf(n int, tag string) []Todo {
+	items := make([]Todo, n)
+	for i := range items {
+		items[i] = Todo{Location: Location{File: "a.go", Line: i + 1}, Tag: tag}
+	}
+	return items
+}
+
+func TestGenerateBadge_CountAndLabel(t *testing.T) {
+	items := append(todosOf(3, "TODO"), todosOf(2, "FIXME")...)
+	b := renderBadge(t, items, BadgeOptions{})
+	if b.Title != "todos: 5" || len(b.Texts) != 4 || b.Texts[1] != "todos" || b.Texts[3] != "5" {
+		t.Fatalf("badge = %+v", b)
+	}
+	b = renderBadge(t, items, BadgeOptions{Tag: "fixme"})
+	if b.Title != "fixme: 2" {
+		t.Errorf("--badge-tag title = %q", b.Title)
+	}
+	b = renderBadge(t, items, BadgeOptions{Tag: "FIXME", Label: "debt <&>"})
+	if b.Title != "debt <&>: 2" {
+		t.Errorf("custom label title = %q", b.Title)
+	}
+}
+
+func TestGenerateBadge_ColorBands(t *testing.T) {
+	opts := BadgeOptions{Green: 2, Yellow: 4}
+	for n, want := range map[int]string{0: badgeGreen, 2: badgeGreen, 3: badgeYellow, 4: badgeYellow, 5: badgeRed, 40: badgeRed} {
+		b := renderBadge(t, todosOf(n, "TODO"), opts)
+		if len(b.Rects) != 3 || b.Rects[1].Fill != want {
+			t.Errorf("%d items: rects %+v, want value fill %s", n, b.Rects, want)
+		}
+	}
+	// Green only at zero.
+	if got := (BadgeOptions{Green: 0, Yellow: 3}).BadgeColor(1); got != badgeYellow {
+		t.Errorf("BadgeColor(1) = %s, want yellow", got)
+	}
+	defaults := BadgeOptions{}
+	for n, want := range map[int]string{DefaultBadgeGreen: badgeGreen, DefaultBadgeGreen + 1: badgeYellow, DefaultBadgeYellow + 1: badgeRed} {
+		if got := defaults.BadgeColor(n); got != want {
+			t.Errorf("default BadgeColor(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestGenerateBadge_WidthsFitText(t *testing.T) {
+	short := renderBadge(t, nil, BadgeOptions{Label: "todos"})
+	long := renderBadge(t, nil, BadgeOptions{Label: "technical debt markers"})
+	if long.Width <= short.Width || long.Rects[0].Width <= short.Rects[0].Width {
+		t.Errorf("longer label did not widen the badge: %d vs %d", long.Width, short.Width)
+	}
+
+	small := renderBadge(t, todosOf(7, "TODO"), BadgeOptions{})
+	big := renderBadge(t, todosOf(12345, "TODO"), BadgeOptions{})
+	if big.Rects[1].Width <= small.Rects[1].Width {
+		t.Errorf("longer value did not widen its half: %d vs %d", big.Rects[1].Width, small.Rects[1].Width)
+	}
+	for _, b := range []parsedBadge{short, long, small, big} {
+		label, value := b.Rects[0], b.Rects[1]
+		if value.X != label.Width || label.Width+value.Width != b.Width {
+			t.Errorf("halves do not tile the badge: %+v, width %d", b.Rects, b.Width)
+		}
+		if w := badgeTextWidth(b.Texts[3]); w >= value.Width {
+			t.Errorf("value %q (%dpx) overflows its %dpx half", b.Texts[3], w, value.Width)
+		}
+	}
+	if n, _ := strconv.Atoi(big.Texts[3]); n != 12345 {
+		t.Errorf("value = %q", big.Texts[3])
+	}
+	if !strings.HasPrefix(RenderBadge("a", "1", badgeGreen), "<svg ") {
+		t.Error("badge should start with the svg element")
+	}
+}
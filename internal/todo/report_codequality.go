@@ -0,0 +1,103 @@
+package todo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+)
+
+// codeQualityIssue is one entry of a GitLab Code Quality report.
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+// codeQualityLocation points an issue at a file line.
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+// codeQualityLines is the line range of an issue; items cover one line.
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// CodeQualitySeverity maps tag to a GitLab Code Quality severity by its
+// TagStyle severity: CONFLICT is a blocker, BUG critical, FIXME and DEBUG
+// major, NOTE info, and TODO and unknown tags minor.
+func CodeQualitySeverity(tag string) string {
+	switch StyleFor(tag).Severity {
+	case 5:
+		return "blocker"
+	case 4:
+		return "critical"
+	case 3:
+		return "major"
+	case 1:
+		return "info"
+	}
+	return "minor"
+}
+
+// CodeQualityFingerprint identifies t in Code Quality reports: the SHA-256 of
+// its File, Line, Tag and Text. GitLab compares fingerprints between the
+// source and target branch, so an item keeps its fingerprint as long as none
+// of these change; an item that moves to another line shows up as one issue
+// fixed and one introduced.
+func CodeQualityFingerprint(t Todo) string {
+	h := sha256.New()
+	for _, s := range []string{t.File, strconv.Itoa(t.Line), t.Tag, t.Text} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GenerateCodeQualityReport writes a GitLab Code Quality report to the given
+// output path using the default OS-backed writer.
+func GenerateCodeQualityReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateCodeQualityReportWithWriter(items, output, OSFileWriter{}, opts)
+}
+
+// GenerateCodeQualityReportWithWriter allows dependency injection of writers
+// for testing. The report is a JSON array of issues in file/line order.
+func GenerateCodeQualityReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+
+	items, err = Enrich(items, opts.Enrichers)
+	if err != nil {
+		return err
+	}
+	cp := make([]Todo, len(items))
+	copy(cp, items)
+	if opts.RelativeRoot {
+		trimCommonDir(cp)
+	}
+	sortTodos(cp)
+	issues := make([]codeQualityIssue, 0, len(cp))
+	for _, t := range cp {
+		desc := t.Tag
+		if t.Text != "" {
+			desc += ": " + t.Text
+		}
+		issues = append(issues, codeQualityIssue{
+			Description: desc,
+			CheckName:   "todototum/" + t.Tag,
+			Fingerprint: CodeQualityFingerprint(t),
+			Severity:    CodeQualitySeverity(t.Tag),
+			Location:    codeQualityLocation{Path: t.File, Lines: codeQualityLines{Begin: t.Line}},
+		})
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
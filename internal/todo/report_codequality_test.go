@@ -0,0 +1,80 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateCodeQualityReport_WithWriter(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "b.go", Line: 4}, Tag: "NOTE", Text: "fine"},
+		{Location: Location{File: "a.go", Line: 9}, Tag: "BUG", Text: "off by one"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateCodeQualityReportWithWriter(items, "gl.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var issues []struct {
+		Description string `json:"description"`
+		CheckName   string `json:"check_name"`
+		Fingerprint string `json:"fingerprint"`
+		Severity    string `json:"severity"`
+		Location    struct {
+			Path  string `json:"path"`
+			Lines struct {
+				Begin int `json:"begin"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if len(issues) != 2 {
+		t.Fatalf("want 2 issues, got %d", len(issues))
+	}
+	bug := issues[0]
+	if bug.Description != "BUG: off by one" || bug.CheckName != "todototum/BUG" || bug.Severity != "critical" ||
+		bug.Location.Path != "a.go" || bug.Location.Lines.Begin != 9 || bug.Fingerprint != CodeQualityFingerprint(items[1]) {
+		t.Fatalf("unexpected issue: %+v", bug)
+	}
+	if issues[1].Severity != "info" {
+		t.Fatalf("NOTE severity = %q, want info", issues[1].Severity)
+	}
+
+	// An empty scan is still a valid report.
+	buf.Reset()
+	if err := GenerateCodeQualityReportWithWriter(nil, "gl.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Fatalf("empty report = %q", buf.String())
+	}
+}
+
+func TestCodeQualityFingerprint_Stable(t *testing.T) {
+	a := Todo{Location: Location{File: "a.go", Line: 3}, Tag: "TODO", Text: "x"}
+	if CodeQualityFingerprint(a) != CodeQualityFingerprint(a) || len(CodeQualityFingerprint(a)) != 64 {
+		t.Fatal("fingerprint is not deterministic")
+	}
+	for _, b := range []Todo{
+		{Location: Location{File: "b.go", Line: 3}, Tag: "TODO", Text: "x"},
+		{Location: Location{File: "a.go", Line: 4}, Tag: "TODO", Text: "x"},
+		{Location: Location{File: "a.go", Line: 3}, Tag: "FIXME", Text: "x"},
+		{Location: Location{File: "a.go", Line: 3}, Tag: "TODO", Text: "y"},
+		// field boundaries matter
+		{Location: Location{File: "a.go3", Line: 0}, Tag: "TODO", Text: "x"},
+	} {
+		if CodeQualityFingerprint(a) == CodeQualityFingerprint(b) {
+			t.Errorf("%+v collides with %+v", b, a)
+		}
+	}
+}
+
+func TestCodeQualitySeverity(t *testing.T) {
+	for tag, want := range map[string]string{TagConflict: "blocker", "BUG": "critical", "FIXME": "major", "TODO": "minor", "HACK": "minor", "NOTE": "info"} {
+		if got := CodeQualitySeverity(tag); got != want {
+			t.Errorf("CodeQualitySeverity(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
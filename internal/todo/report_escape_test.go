@@ -0,0 +1,142 @@
+package todo
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// hostileItems carry markup, quotes, ampersands, pipes, brackets and a
+// javascript: URL in every field a report prints.
+func hostileItems() []Todo {
+	return []Todo{
+		{
+			Location: Location{File: `x/<script>alert(1)</script>".go`, Line: 1},
+			Tag:      "TODO",
+			Text:     `<img src=x onerror=alert(1)> & "quoted" 'single' | pipe [click](javascript:alert(1)) ` + "`code`",
+			RawLine:  `// TODO: </pre><script>alert(2)</script>`,
+			Symbol:   `f"><script>alert(3)</script>`,
+			Extra:    map[string]string{"owner": `<b onmouseover="alert(4)">me</b> | [x](javascript:alert(5))`},
+		},
+		{Location: Location{File: "a|b.go", Line: 2}, Tag: "FIXME", Text: "tail \\| [REDACTED]\nsecond line"},
+	}
+}
+
+// javascriptAttr finds a javascript: URL in an attribute of rendered HTML.
+var javascriptAttr = regexp.MustCompile(`(?i)(href|src|action)\s*=\s*["']?\s*javascript:`)
+
+func TestReports_HTMLEscapesHostileValues(t *testing.T) {
+	items := hostileItems()
+	var buf bytes.Buffer
+	opts := ReportOptions{ExtraColumns: []string{"owner"}}
+	if err := GenerateHTMLReportWithWriter(items, "r.html", mockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+	buf.Reset()
+	d := DiffItems(nil, items)
+	if err := GenerateDiffHTMLReportWithWriter(d, `old"<x>.json`, "new.json", "d.html", mockFileWriter{buf: &buf}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, html := range map[string]string{"report": report, "diff": buf.String()} {
+		for _, raw := range []string{"<script>alert", "<img src", "<b onmouseover", `".go"`, `"><script`, "</pre><script>", `old"<x>`} {
+			if strings.Contains(html, raw) {
+				t.Errorf("%s: unescaped %q", name, raw)
+			}
+		}
+		if m := javascriptAttr.FindString(html); m != "" {
+			t.Errorf("%s: javascript: URL in an attribute: %q", name, m)
+		}
+		if !strings.Contains(html, "&lt;img src=x onerror=alert(1)&gt; &amp; &#34;quoted&#34;") {
+			t.Errorf("%s: text is not escaped as expected", name)
+		}
+	}
+	if !strings.Contains(report, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Error("file path is not escaped as expected")
+	}
+}
+
+func TestReports_MarkdownEscapesHostileValues(t *testing.T) {
+	var buf bytes.Buffer
+	opts := ReportOptions{ExtraColumns: []string{"owner"}, MarkdownBadges: MarkdownBadgesEmoji}
+	if err := GenerateMarkdownReportWithWriter(hostileItems(), "r.md", mdMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	md := buf.String()
+
+	// Every row of the item table keeps its five cells: the only pipes not
+	// escaped are the cell borders.
+	unescapedPipe := regexp.MustCompile(`(^|[^\\])\|`)
+	rows := 0
+	for _, line := range strings.Split(md, "\n") {
+		if !strings.HasPrefix(line, "| ") || strings.HasPrefix(line, "| File") {
+			continue
+		}
+		rows++
+		if n := len(unescapedPipe.FindAllString(strings.ReplaceAll(line, `\\`, ""), -1)); n != 6 {
+			t.Errorf("row has %d cell borders, want 6: %s", n, line)
+		}
+	}
+	if rows != 2 {
+		t.Fatalf("%d item rows, want 2:\n%s", rows, md)
+	}
+	unescaped := regexp.MustCompile("(^|[^\\\\])(<|\\]\\(|`)")
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(line, "| ") && unescaped.MatchString(strings.ReplaceAll(line, `\\`, "")) {
+			t.Errorf("unescaped markup in row: %s", line)
+		}
+	}
+	for _, escaped := range []string{`x/\<script\>alert(1)\</script\>".go`, `a\|b.go`, `tail \\\| [REDACTED] second line`, `[click\](javascript:alert(1))`, "\\`code\\`"} {
+		if !strings.Contains(md, escaped) {
+			t.Errorf("lacks %q in:\n%s", escaped, md)
+		}
+	}
+	// Top issues show the location as a code span, where markup is inert.
+	want := "- 📝 TODO `x/<script>alert(1)</script>\".go:1` TODO: \\<img src=x onerror=alert(1)\\> & \"quoted\" 'single' \\| pipe [click\\](javascript:alert(1)) \\`code\\`\n"
+	if !strings.Contains(md, want) {
+		t.Errorf("top issues lack %q:\n%s", want, md)
+	}
+}
+
+func TestMarkdownLink(t *testing.T) {
+	tests := []struct {
+		label, target, want string
+	}{
+		{"team.md", "@org/team.md", "[team.md](@org/team.md)"},
+		{"a b", "dir/a b (1).md", "[a b](dir/a%20b%20%281%29.md)"},
+		{"#12", "https://github.com/o/r/issues/12?q=(x)", "[#12](https://github.com/o/r/issues/12?q=%28x%29)"},
+		{"x", "javascript:alert(1)", "x"},
+		{"x", "JavaScript:alert(1)", "x"},
+		{"x", "data:text/html,<script>", "x"},
+		{"[x](y)", "%zz", `[x\](y)`},
+	}
+	for _, tt := range tests {
+		if got := MarkdownLink(tt.label, tt.target); got != tt.want {
+			t.Errorf("MarkdownLink(%q, %q) = %q, want %q", tt.label, tt.target, got, tt.want)
+		}
+	}
+	if got := markdownCode("a`b``c"); got != "``` a`b``c ```" {
+		t.Errorf("markdownCode = %q", got)
+	}
+}
+
+func TestReports_HTMLRefusesScriptEditorScheme(t *testing.T) {
+	for _, tmpl := range []string{"javascript:alert(1)//{path}", " JAVASCRIPT:alert(1)//{path}", "data:text/html,{path}", "vbscript:x//{path}"} {
+		if _, err := ParseEditorScheme(tmpl); err == nil {
+			t.Errorf("ParseEditorScheme(%q) accepted a script scheme", tmpl)
+		}
+	}
+
+	// EditorURI set through the Go API bypasses ParseEditorScheme, so the
+	// report must not trust it either.
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x", URI: "file:///src/a.go", EditorURI: "javascript:alert(1)//a.go"}}
+	var buf bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(items, "r.html", mockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if m := javascriptAttr.FindString(buf.String()); m != "" {
+		t.Errorf("javascript: URL in an attribute: %q", m)
+	}
+}
@@ -0,0 +1,81 @@
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// Annotation levels of GitHub Actions workflow commands.
+const (
+	AnnotationError   = "error"
+	AnnotationWarning = "warning"
+	AnnotationNotice  = "notice"
+)
+
+// AnnotationLevel returns the workflow command level for tag: error for
+// severe tags such as BUG and FIXME, notice for NOTE, warning otherwise.
+func AnnotationLevel(tag string) string {
+	switch sev := StyleFor(tag).Severity; {
+	case sev >= StyleFor("FIXME").Severity:
+		return AnnotationError
+	case sev == StyleFor("NOTE").Severity:
+		return AnnotationNotice
+	}
+	return AnnotationWarning
+}
+
+// GenerateGitHubReport writes GitHub Actions annotations to the given output
+// path using the default OS-backed writer.
+func GenerateGitHubReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateGitHubReportWithWriter(items, output, OSFileWriter{}, opts)
+}
+
+// GenerateGitHubReportWithWriter allows dependency injection of writers for
+// testing. Each item becomes one workflow command, e.g.
+// "::warning file=a.go,line=3,col=4::TODO: text", which the Actions runner
+// shows inline on the pull request diff. Items are in file/line order.
+func GenerateGitHubReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+
+	items, err = Enrich(items, opts.Enrichers)
+	if err != nil {
+		return err
+	}
+	cp := make([]Todo, len(items))
+	copy(cp, items)
+	if opts.RelativeRoot {
+		trimCommonDir(cp)
+	}
+	sortTodos(cp)
+	bw := bufio.NewWriter(f)
+	for _, t := range cp {
+		msg := t.Tag
+		if t.Text != "" {
+			msg += ": " + t.Text
+		}
+		props := fmt.Sprintf("file=%s,line=%d", escapeAnnotationProperty(t.File), t.Line)
+		if t.Column > 0 {
+			props += fmt.Sprintf(",col=%d", t.Column)
+		}
+		if _, err := fmt.Fprintf(bw, "::%s %s::%s\n", AnnotationLevel(t.Tag), props, escapeAnnotationData(msg)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// escapeAnnotationData escapes a workflow command message.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a workflow command property value, which
+// also may not contain ':' or ','.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
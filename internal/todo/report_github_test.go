@@ -0,0 +1,39 @@
+package todo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnnotationLevel(t *testing.T) {
+	for tag, want := range map[string]string{
+		"BUG":       AnnotationError,
+		"fixme":     AnnotationError,
+		TagConflict: AnnotationError,
+		"TODO":      AnnotationWarning,
+		"HACK":      AnnotationWarning,
+		"NOTE":      AnnotationNotice,
+	} {
+		if got := AnnotationLevel(tag); got != want {
+			t.Errorf("AnnotationLevel(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestGenerateGitHubReport_WithWriter(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "b.go", Line: 4}, Tag: "NOTE", Text: "fine"},
+		{Location: Location{File: "a.go", Line: 9, Column: 5}, Tag: "FIXME", Text: "100% broken\nreally"},
+		{Location: Location{File: "dir,x/a:b.go", Line: 1}, Tag: "TODO"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateGitHubReportWithWriter(items, "-", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "::error file=a.go,line=9,col=5::FIXME: 100%25 broken%0Areally\n" +
+		"::notice file=b.go,line=4::NOTE: fine\n" +
+		"::warning file=dir%2Cx/a%3Ab.go,line=1::TODO\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"html/template"
 	"io"
 	"os"
 	"path/filepath"
@@ -44,10 +45,10 @@ func (errOnWriteFileWriter) Create(_ string) (io.WriteCloser, error) { return er
 
 func TestReport_GenerateHTML(t *testing.T) {
 	t.Run("success with writer buffer", func(t *testing.T) {
-		items := []Todo{{File: "a.go", Line: 1, Tag: "TODO", Text: "x"}, {File: "b.go", Line: 2, Tag: "FIXME", Text: "y"}}
+		items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}, {Location: Location{File: "b.go", Line: 2}, Tag: "FIXME", Text: "y"}}
 		var buf bytes.Buffer
 		writer := mockFileWriter{buf: &buf}
-		if err := GenerateHTMLReportWithWriter(items, "ignored.html", writer); err != nil {
+		if err := GenerateHTMLReportWithWriter(items, "ignored.html", writer, ReportOptions{}); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 		out := buf.String()
@@ -62,10 +63,28 @@ func TestReport_GenerateHTML(t *testing.T) {
 		}
 	})
 
+	t.Run("raw line rendered only when captured", func(t *testing.T) {
+		items := []Todo{
+			{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x", RawLine: "\tcall(a < b) // TODO: x"},
+			{Location: Location{File: "b.go", Line: 2}, Tag: "NOTE", Text: "y"},
+		}
+		var buf bytes.Buffer
+		if err := GenerateHTMLReportWithWriter(items, "ignored.html", mockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, `<pre class="raw-line">	call(a &lt; b) // TODO: x</pre>`) {
+			t.Errorf("expected escaped raw line, got: %s", out)
+		}
+		if strings.Count(out, `<pre class="raw-line">`) != 1 {
+			t.Errorf("expected a single raw line block")
+		}
+	})
+
 	t.Run("embedded template always available (no missing template error)", func(t *testing.T) {
 		var buf bytes.Buffer
 		writer := mockFileWriter{buf: &buf}
-		if err := GenerateHTMLReportWithWriter(nil, "ignored.html", writer); err != nil {
+		if err := GenerateHTMLReportWithWriter(nil, "ignored.html", writer, ReportOptions{}); err != nil {
 			t.Fatalf("did not expect error with embedded template, got: %v", err)
 		}
 		if buf.Len() == 0 {
@@ -81,24 +100,24 @@ func TestReport_GenerateHTML(t *testing.T) {
 		origWD, _ := os.Getwd()
 		t.Cleanup(func() { _ = os.Chdir(origWD) })
 		_ = os.Chdir(tmp)
-		items := []Todo{{File: "x.go", Line: 1, Tag: "BUG", Text: "fail"}}
-		if err := GenerateHTMLReportWithWriter(items, "ignored.html", badFileWriter{}); err == nil {
+		items := []Todo{{Location: Location{File: "x.go", Line: 1}, Tag: "BUG", Text: "fail"}}
+		if err := GenerateHTMLReportWithWriter(items, "ignored.html", badFileWriter{}, ReportOptions{}); err == nil {
 			t.Fatal("expected create error")
 		}
 	})
 
 	t.Run("execute error from writer surfaces", func(t *testing.T) {
-		items := []Todo{{File: "a.go", Line: 1, Tag: "TODO", Text: "x"}}
-		if err := GenerateHTMLReportWithWriter(items, "ignored.html", errOnWriteFileWriter{}); err == nil {
+		items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}}
+		if err := GenerateHTMLReportWithWriter(items, "ignored.html", errOnWriteFileWriter{}, ReportOptions{}); err == nil {
 			t.Fatalf("expected error from writer during Execute, got nil")
 		}
 	})
 
 	t.Run("sorts by file then line", func(t *testing.T) {
-		items := []Todo{{File: "same.go", Line: 20, Tag: "TODO", Text: "later"}, {File: "a.go", Line: 5, Tag: "BUG", Text: "first by file"}, {File: "same.go", Line: 10, Tag: "FIXME", Text: "should come before line 20"}}
+		items := []Todo{{Location: Location{File: "same.go", Line: 20}, Tag: "TODO", Text: "later"}, {Location: Location{File: "a.go", Line: 5}, Tag: "BUG", Text: "first by file"}, {Location: Location{File: "same.go", Line: 10}, Tag: "FIXME", Text: "should come before line 20"}}
 		var buf bytes.Buffer
 		mw := mockFileWriter{buf: &buf}
-		if err := GenerateHTMLReportWithWriter(items, "ignored.html", mw); err != nil {
+		if err := GenerateHTMLReportWithWriter(items, "ignored.html", mw, ReportOptions{}); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 		out := buf.String()
@@ -119,7 +138,7 @@ func TestReport_GenerateHTML(t *testing.T) {
 
 	t.Run("Create wrapper writes file", func(t *testing.T) {
 		tmp := t.TempDir()
-		items := []Todo{{File: "x.go", Line: 1, Tag: "NOTE", Text: "ok"}}
+		items := []Todo{{Location: Location{File: "x.go", Line: 1}, Tag: "NOTE", Text: "ok"}}
 		out := filepath.Join(tmp, "report.html")
 		if err := Create(items, out); err != nil {
 			t.Fatalf("Create returned error: %v", err)
@@ -134,11 +153,11 @@ func TestReport_GenerateHTML(t *testing.T) {
 // merged from report_builddata_test.go
 func TestBuildReportData_EnrichesTextAndComputesPercents(t *testing.T) {
 	items := []Todo{
-		{File: "b.go", Line: 10, Tag: "FIXME", Text: "fix it"},
-		{File: "a.go", Line: 2, Tag: "TODO", Text: "do it"},
-		{File: "a.go", Line: 1, Tag: "TODO", Text: ""}, // empty text should become just tag
+		{Location: Location{File: "b.go", Line: 10}, Tag: "FIXME", Text: "fix it"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "do it"},
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: ""}, // empty text should become just tag
 	}
-	data := buildReportData(items)
+	data := buildReportData(items, ReportOptions{})
 
 	// Todos must be sorted by file then line
 	if len(data.Todos) != 3 {
@@ -178,3 +197,88 @@ func TestBuildReportData_EnrichesTextAndComputesPercents(t *testing.T) {
 		t.Fatalf("unexpected percent sum: %v", sum)
 	}
 }
+
+func htmlFixture(n int) []Todo {
+	tags := []string{"TODO", "FIXME", "BUG", "NOTE"}
+	items := make([]Todo, n)
+	for i := range items {
+		items[i] = Todo{Location: Location{File: fmt.Sprintf("pkg%d/file%d.go", i%7, i), Line: i + 1}, Tag: tags[i%len(tags)], Text: fmt.Sprintf("item <%d> & \"quoted\"", i)}
+	}
+	items[1].RawLine = "\tif a < b { // FIXME: item"
+	return items
+}
+
+func TestGenerateHTML_StreamedMatchesSinglePass(t *testing.T) {
+	items := htmlFixture(25)
+	audit := &AuditLog{Entries: []SkipRecord{{Path: "vendor", Dir: true, Reason: SkipReasonIgnoreFlag, Rule: "--ignore vendor"}}}
+	var single, streamed bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(items, "r.html", mockFileWriter{buf: &single}, ReportOptions{Audit: audit}); err != nil {
+		t.Fatalf("single pass: %v", err)
+	}
+	if err := GenerateHTMLReportWithWriter(items, "r.html", mockFileWriter{buf: &streamed}, ReportOptions{Audit: audit, HTMLStreamThreshold: 10}); err != nil {
+		t.Fatalf("streamed: %v", err)
+	}
+	if !bytes.Equal(single.Bytes(), streamed.Bytes()) {
+		t.Fatalf("streamed output differs from single pass:\n--- single\n%s\n--- streamed\n%s", single.String(), streamed.String())
+	}
+	if !strings.HasPrefix(single.String(), "<!doctype html>") || strings.Count(single.String(), `<tr data-file=`) != 25 {
+		t.Fatalf("unexpected document:\n%s", single.String())
+	}
+}
+
+// maxWriteRecorder records the largest single write it receives.
+type maxWriteRecorder struct{ max, total int }
+
+func (m *maxWriteRecorder) Write(p []byte) (int, error) {
+	m.max = max(m.max, len(p))
+	m.total += len(p)
+	return len(p), nil
+}
+func (m *maxWriteRecorder) Close() error { return nil }
+
+type recorderFileWriter struct{ w *maxWriteRecorder }
+
+func (r recorderFileWriter) Create(string) (io.WriteCloser, error) { return r.w, nil }
+
+func TestGenerateHTML_StreamedWritesAreBounded(t *testing.T) {
+	rec := &maxWriteRecorder{}
+	if err := GenerateHTMLReportWithWriter(htmlFixture(5000), "r.html", recorderFileWriter{rec}, ReportOptions{HTMLStreamThreshold: 100}); err != nil {
+		t.Fatalf("streamed: %v", err)
+	}
+	// Rows reach the writer in flushed batches, never as one document.
+	if rec.max > 64*1024 || rec.total < 10*rec.max {
+		t.Fatalf("largest write %d bytes of %d total", rec.max, rec.total)
+	}
+}
+
+func BenchmarkGenerateHTML_Streamed50k(b *testing.B) {
+	items := htmlFixture(50000)
+	w := recorderFileWriter{&maxWriteRecorder{}}
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := GenerateHTMLReportWithWriter(items, "r.html", w, ReportOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestWriteHTMLReport_RenderErrorCreatesNoFile(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}, {Location: Location{File: "b.go", Line: 2}, Tag: "BUG", Text: "y"}}
+	data := buildReportData(items, ReportOptions{})
+	for name, tc := range map[string]struct {
+		tmpl string
+		opts ReportOptions
+	}{
+		// Fails on a row, after the opening markup rendered fine.
+		"buffered": {`<html>{{range .Todos}}{{.Missing}}{{end}}</html>`, ReportOptions{}},
+		"streamed": {`{{define "head"}}<html>{{.Missing}}{{end}}`, ReportOptions{HTMLStreamThreshold: 1}},
+	} {
+		tmpl := template.Must(template.New("report.html").Parse(tc.tmpl))
+		// badFileWriter fails on Create, so any other error means the
+		// template failed before output was created.
+		err := writeHTMLReport(tmpl, data, "report.html", badFileWriter{}, tc.opts)
+		if err == nil || !strings.Contains(err.Error(), "render HTML report") {
+			t.Errorf("%s: err = %v, want a render error before creating the file", name, err)
+		}
+	}
+}
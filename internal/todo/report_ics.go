@@ -0,0 +1,112 @@
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// icsLineLimit is the longest content line RFC 5545 allows, in octets,
+// excluding the CRLF.
+const icsLineLimit = 75
+
+// ICSPriority maps tag to a VTODO PRIORITY by its TagStyle severity, from 1
+// (highest) for CONFLICT to 9 for NOTE. Unknown tags yield 0, undefined.
+func ICSPriority(tag string) int {
+	switch StyleFor(tag).Severity {
+	case 5:
+		return 1
+	case 4:
+		return 2
+	case 3:
+		return 3
+	case 2:
+		return 5
+	case 1:
+		return 9
+	}
+	return 0
+}
+
+// GenerateICSReport writes an iCalendar document of VTODO entries to the
+// given output path using the default OS-backed writer.
+func GenerateICSReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateICSReportWithWriter(items, output, OSFileWriter{}, opts)
+}
+
+// GenerateICSReportWithWriter allows dependency injection of writers for
+// testing. Each item becomes one VTODO whose UID is derived from its
+// Fingerprint, so importing a newer report into a task app updates the
+// entries of an older one instead of duplicating them. Items are in
+// file/line order.
+func GenerateICSReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+
+	items, err = Enrich(items, opts.Enrichers)
+	if err != nil {
+		return err
+	}
+	cp := make([]Todo, len(items))
+	copy(cp, items)
+	setFingerprints(cp)
+	if opts.RelativeRoot {
+		trimCommonDir(cp)
+	}
+	sortTodos(cp)
+	stamp := opts.now().UTC().Format("20060102T150405Z")
+
+	bw := bufio.NewWriter(f)
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:-//todototum//todototum//EN")
+	for _, t := range cp {
+		summary := t.Tag
+		if t.Text != "" {
+			summary += ": " + t.Text
+		}
+		writeICSLine(bw, "BEGIN:VTODO")
+		writeICSLine(bw, "UID:"+t.Fingerprint+"@todototum")
+		writeICSLine(bw, "DTSTAMP:"+stamp)
+		writeICSLine(bw, "SUMMARY:"+escapeICSText(summary))
+		writeICSLine(bw, "DESCRIPTION:"+escapeICSText(fmt.Sprintf("%s:%d\n%s", t.File, t.Line, summary)))
+		writeICSLine(bw, "CATEGORIES:"+escapeICSText(t.Tag))
+		if p := ICSPriority(t.Tag); p > 0 {
+			writeICSLine(bw, fmt.Sprintf("PRIORITY:%d", p))
+		}
+		writeICSLine(bw, "STATUS:NEEDS-ACTION")
+		writeICSLine(bw, "END:VTODO")
+	}
+	writeICSLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escapeICSText escapes an RFC 5545 TEXT value: backslashes, semicolons,
+// commas and newlines.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line with CRLF endings, folded so no line
+// exceeds icsLineLimit octets: continuation lines start with a space and
+// multi-byte characters are never split. bw's error surfaces on Flush.
+func writeICSLine(bw *bufio.Writer, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		_, _ = bw.WriteString(line[:cut])
+		_, _ = bw.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts toward the limit of continuation lines.
+		limit = icsLineLimit - 1
+	}
+	_, _ = bw.WriteString(line)
+	_, _ = bw.WriteString("\r\n")
+}
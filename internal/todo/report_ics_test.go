@@ -0,0 +1,116 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func generateICS(t *testing.T, items []Todo, opts ReportOptions) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := GenerateICSReportWithWriter(items, "todos.ics", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatalf("GenerateICSReportWithWriter: %v", err)
+	}
+	return buf.String()
+}
+
+// unfoldICS joins folded content lines back together.
+func unfoldICS(s string) []string {
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n ", ""), "\r\n"), "\r\n")
+}
+
+func TestGenerateICSReport(t *testing.T) {
+	at := time.Date(2024, 6, 15, 3, 12, 0, 0, time.UTC)
+	items := []Todo{
+		{Location: Location{File: "b.go", Line: 9}, Tag: "NOTE", Text: "see a, b; c"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "BUG", Text: `crash on C:\tmp`},
+	}
+	out := generateICS(t, items, ReportOptions{Now: at})
+
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") || !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Fatalf("lines must end with CRLF:\n%q", out)
+	}
+	lines := unfoldICS(out)
+	want := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//todototum//todototum//EN",
+		"BEGIN:VTODO",
+		"UID:" + Fingerprint(items[1]) + "@todototum",
+		"DTSTAMP:20240615T031200Z",
+		`SUMMARY:BUG: crash on C:\\tmp`,
+		`DESCRIPTION:a.go:2\nBUG: crash on C:\\tmp`,
+		"CATEGORIES:BUG",
+		"PRIORITY:2",
+		"STATUS:NEEDS-ACTION",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:" + Fingerprint(items[0]) + "@todototum",
+		"DTSTAMP:20240615T031200Z",
+		`SUMMARY:NOTE: see a\, b\; c`,
+		`DESCRIPTION:b.go:9\nNOTE: see a\, b\; c`,
+		"CATEGORIES:NOTE",
+		"PRIORITY:9",
+		"STATUS:NEEDS-ACTION",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected calendar:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGenerateICSReport_FoldsLongLines(t *testing.T) {
+	text := strings.Repeat("é", 30) + strings.Repeat("x", 100)
+	out := generateICS(t, []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "CUSTOM", Text: text}}, ReportOptions{})
+	raw := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	folded := 0
+	for _, l := range raw {
+		if len(l) > icsLineLimit {
+			t.Errorf("line of %d octets: %q", len(l), l)
+		}
+		if strings.HasPrefix(l, " ") {
+			folded++
+			if strings.HasPrefix(l[1:], "\xa9") {
+				t.Errorf("fold split a multi-byte character: %q", l)
+			}
+		}
+	}
+	if folded < 2 {
+		t.Errorf("expected the summary and description to be folded:\n%s", out)
+	}
+	var summary string
+	for _, l := range unfoldICS(out) {
+		if strings.HasPrefix(l, "SUMMARY:") {
+			summary = l
+		}
+		if strings.HasPrefix(l, "PRIORITY:") {
+			t.Errorf("unknown tags should have no priority: %q", l)
+		}
+	}
+	if summary != "SUMMARY:CUSTOM: "+text {
+		t.Errorf("unfolded summary = %q", summary)
+	}
+}
+
+func TestGenerateICSReport_StableUIDs(t *testing.T) {
+	first := []Todo{{Location: Location{File: "src/a.go", Line: 3}, Tag: "TODO", Text: "one"}, {Location: Location{File: "src/b.go", Line: 1}, Tag: "FIXME", Text: "two"}}
+	// Lines shift and the order changes between runs.
+	second := []Todo{{Location: Location{File: "src/b.go", Line: 7}, Tag: "FIXME", Text: "two"}, {Location: Location{File: "src/a.go", Line: 40}, Tag: "TODO", Text: "one"}}
+	uids := func(out string) []string {
+		var ids []string
+		for _, l := range unfoldICS(out) {
+			if strings.HasPrefix(l, "UID:") {
+				ids = append(ids, l)
+			}
+		}
+		return ids
+	}
+	a := uids(generateICS(t, first, ReportOptions{}))
+	b := uids(generateICS(t, second, ReportOptions{RelativeRoot: true}))
+	if len(a) != 2 || strings.Join(a, ",") != strings.Join(b, ",") {
+		t.Fatalf("UIDs changed across runs: %v vs %v", a, b)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -37,13 +40,13 @@ func (jsonErrOnWriteFileWriter) Create(_ string) (io.WriteCloser, error) {
 
 func TestGenerateJSONReport_WithWriter_Success(t *testing.T) {
 	items := []Todo{
-		{File: "b.go", Line: 10, Tag: "FIXME", Text: "second"},
-		{File: "a.go", Line: 2, Tag: "TODO", Text: "first"},
-		{File: "a.go", Line: 20, Tag: "BUG", Text: "third"},
+		{Location: Location{File: "b.go", Line: 10}, Tag: "FIXME", Text: "second"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "first"},
+		{Location: Location{File: "a.go", Line: 20}, Tag: "BUG", Text: "third"},
 	}
 	var buf bytes.Buffer
 	mw := jsonMockFileWriter{buf: &buf}
-	if err := GenerateJSONReportWithWriter(items, "ignored.json", mw); err != nil {
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", mw, ReportOptions{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	var got struct {
@@ -75,15 +78,271 @@ func TestGenerateJSONReport_WithWriter_Success(t *testing.T) {
 }
 
 func TestGenerateJSONReport_WithWriter_CreateError(t *testing.T) {
-	items := []Todo{{File: "x.go", Line: 1, Tag: "TODO", Text: "x"}}
-	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonBadFileWriter{}); err == nil {
+	items := []Todo{{Location: Location{File: "x.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonBadFileWriter{}, ReportOptions{}); err == nil {
 		t.Fatal("expected error from Create")
 	}
 }
 
 func TestGenerateJSONReport_WithWriter_WriteError(t *testing.T) {
-	items := []Todo{{File: "x.go", Line: 1, Tag: "TODO", Text: "x"}}
-	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonErrOnWriteFileWriter{}); err == nil {
+	items := []Todo{{Location: Location{File: "x.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonErrOnWriteFileWriter{}, ReportOptions{}); err == nil {
 		t.Fatal("expected error from writer during json.Encode")
 	}
 }
+
+func TestBuildReportData_SameLineOrderIsDeterministic(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 5, Column: 20}, Tag: "FIXME", Text: "second"},
+		{Location: Location{File: "a.go", Line: 5}, Tag: "NOTE", Text: "no column, first seen"},
+		{Location: Location{File: "a.go", Line: 5}, Tag: "BUG", Text: "no column, second seen"},
+		{Location: Location{File: "a.go", Line: 5, Column: 3}, Tag: "TODO", Text: "first"},
+	}
+	want := []string{"NOTE", "BUG", "TODO", "FIXME"}
+	for run := 0; run < 20; run++ {
+		data := buildReportData(items, ReportOptions{})
+		for i, tag := range want {
+			if data.Todos[i].Tag != tag {
+				t.Fatalf("run %d: position %d = %s, want %s (order %v)", run, i, data.Todos[i].Tag, tag, data.Todos)
+			}
+		}
+	}
+}
+
+func TestGenerateJSONReport_WithWriter_NestedShape(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "b.go", Line: 10}, Tag: "FIXME", Text: "second"},
+		{Location: Location{File: "a.go", Line: 7}, Tag: "TODO", Text: "later"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "BUG", Text: "first"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonMockFileWriter{buf: &buf}, ReportOptions{JSONShape: JSONShapeNested}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if _, ok := parsed["todos"]; ok {
+		t.Fatalf("nested shape should not include the flat todos list:\n%s", buf.String())
+	}
+	var body struct {
+		Files   map[string][]Todo `json:"files"`
+		Summary Summary           `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	a := body.Files["a.go"]
+	if len(body.Files) != 2 || len(a) != 2 || a[0].Line != 2 || a[1].Line != 7 || len(body.Files["b.go"]) != 1 {
+		t.Fatalf("unexpected files: %+v", body.Files)
+	}
+	if body.Summary.Total != 3 {
+		t.Fatalf("summary total = %d, want 3", body.Summary.Total)
+	}
+
+	// The flat shape stays the default and has no files key.
+	buf.Reset()
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed = nil
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if _, ok := parsed["files"]; ok {
+		t.Fatalf("flat shape should not include files")
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	cases := []struct {
+		files []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"src/app/a.go"}, "src/app/"},
+		{[]string{"src/app/a.go", "src/api/b.go"}, "src/"},
+		{[]string{"src/a.go", "srcs/b.go"}, ""},
+		{[]string{"a.go", "src/b.go"}, ""},
+		{[]string{"/home/me/x/a.go", "/home/me/y/b.go"}, "/home/me/"},
+		{[]string{"/a.go", "/b/c.go"}, "/"},
+	}
+	for _, tc := range cases {
+		items := make([]Todo, len(tc.files))
+		for i, f := range tc.files {
+			items[i].File = f
+		}
+		if got := CommonDir(items); got != tc.want {
+			t.Errorf("CommonDir(%v) = %q, want %q", tc.files, got, tc.want)
+		}
+	}
+}
+
+func TestGenerateJSONReport_RelativeRoot(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "deep/tree/src/a.go", Line: 1}, Tag: "TODO", Text: "x"},
+		{Location: Location{File: "deep/tree/pkg/b.go", Line: 2}, Tag: "FIXME", Text: "y"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "ignored.json", jsonMockFileWriter{buf: &buf}, ReportOptions{RelativeRoot: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var body struct {
+		Todos []Todo `json:"todos"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Todos) != 2 || body.Todos[0].File != "pkg/b.go" || body.Todos[1].File != "src/a.go" {
+		t.Fatalf("unexpected files: %+v", body.Todos)
+	}
+	if body.Todos[1].Fingerprint != Fingerprint(items[0]) {
+		t.Fatalf("fingerprint of the trimmed item = %q, want the untrimmed %q", body.Todos[1].Fingerprint, Fingerprint(items[0]))
+	}
+	if items[0].File != "deep/tree/src/a.go" || items[0].Fingerprint != "" {
+		t.Fatalf("input items were modified: %+v", items)
+	}
+}
+
+func TestBuildReportData_ExtStats(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go"}, Tag: "TODO"},
+		{Location: Location{File: "pkg/b.GO"}, Tag: "BUG"},
+		{Location: Location{File: "c.py"}, Tag: "TODO"},
+		{Location: Location{File: "Makefile"}, Tag: "NOTE"},
+		{Location: Location{File: "d.go"}, Tag: "FIXME"},
+		{Location: Location{File: "v1.2/e.py"}, Tag: "TODO"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		ExtStats []ExtStat `json:"extStats"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	want := []ExtStat{{".go", 3, 50}, {".py", 2, 33.3}, {"", 1, 16.7}}
+	if !reflect.DeepEqual(rep.ExtStats, want) {
+		t.Fatalf("extStats = %+v, want %+v", rep.ExtStats, want)
+	}
+
+	var html bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(items, "r.html", jsonMockFileWriter{buf: &html}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `id="by-extension"`) || !strings.Contains(html.String(), "<td>(none)</td>") {
+		t.Fatalf("HTML report lacks the extension table")
+	}
+}
+
+func TestGenerateReports_Noise(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "validate the input"}}
+	noise := []Todo{
+		{Location: Location{File: "b.go", Line: 9}, Tag: "TODO", Text: "later"},
+		{Location: Location{File: "a.go", Line: 3}, Tag: "FIXME", Text: "fix"},
+	}
+	opts := ReportOptions{Noise: noise}
+
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Todos   []Todo  `json:"todos"`
+		Noise   []Todo  `json:"noise"`
+		Summary Summary `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Summary.Total != 1 || rep.Summary.Noise != 2 || len(rep.Todos) != 1 {
+		t.Fatalf("summary = %+v with %d todos, want total 1 and noise 2", rep.Summary, len(rep.Todos))
+	}
+	if rep.Summary.ByTag["FIXME"] != 0 {
+		t.Fatalf("noise counted by tag: %v", rep.Summary.ByTag)
+	}
+	if len(rep.Noise) != 2 || rep.Noise[0].Text != "FIXME: fix" || rep.Noise[1].File != "b.go" || rep.Noise[0].Fingerprint == "" {
+		t.Fatalf("noise = %+v, want sorted, tagged and fingerprinted items", rep.Noise)
+	}
+
+	buf.Reset()
+	if err := GenerateMarkdownReportWithWriter(items, "r.md", mdMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if md := buf.String(); !strings.Contains(md, "<summary>Low-signal items (2)</summary>") || !strings.Contains(md, "| b.go | 9 | TODO | TODO: later |") {
+		t.Fatalf("Markdown report lacks the low-signal section:\n%s", md)
+	}
+
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(items, "r.html", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if html := buf.String(); !strings.Contains(html, `id="low-signal"`) || !strings.Contains(html, "TODO: later") {
+		t.Fatalf("HTML report lacks the low-signal section")
+	}
+}
+
+func TestGenerateJSONReport_LeanItems(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 3}, Tag: "TODO", Text: "wire up retries"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "NOTE"},
+	}
+	for _, raw := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, ReportOptions{RawText: raw}); err != nil {
+			t.Fatal(err)
+		}
+		var rep struct {
+			Todos []map[string]any `json:"todos"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+			t.Fatal(err)
+		}
+		wantText := []any{"TODO: wire up retries", "NOTE"}
+		if raw {
+			wantText = []any{"wire up retries", ""}
+		}
+		for i, item := range rep.Todos {
+			keys := make([]string, 0, len(item))
+			for k := range item {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			// Required fields always serialize; unused optional ones never do.
+			if want := []string{"File", "Fingerprint", "Line", "Tag", "Text"}; !reflect.DeepEqual(keys, want) {
+				t.Errorf("raw=%v: item %d has fields %v, want %v", raw, i, keys, want)
+			}
+			if item["Text"] != wantText[i] {
+				t.Errorf("raw=%v: item %d Text = %q, want %q", raw, i, item["Text"], wantText[i])
+			}
+		}
+	}
+}
+
+func TestGenerateJSONReport_NoItems(t *testing.T) {
+	for _, shape := range []string{JSONShapeFlat, JSONShapeNested} {
+		var buf bytes.Buffer
+		if err := GenerateJSONReportWithWriter(nil, "r.json", jsonMockFileWriter{buf: &buf}, ReportOptions{JSONShape: shape}); err != nil {
+			t.Fatal(err)
+		}
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+			t.Fatalf("%s: empty report is not valid JSON: %v\n%s", shape, err, buf.String())
+		}
+		var summary Summary
+		if err := json.Unmarshal(raw["summary"], &summary); err != nil || summary.Total != 0 {
+			t.Errorf("%s: summary = %s", shape, raw["summary"])
+		}
+		// Consumers iterate over the items without a null check.
+		list, want := "todos", "[]"
+		if shape == JSONShapeNested {
+			list, want = "files", "{}"
+		}
+		if got := string(raw[list]); got != want {
+			t.Errorf("%s: %s = %s, want %s", shape, list, got, want)
+		}
+	}
+}
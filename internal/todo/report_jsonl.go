@@ -0,0 +1,122 @@
+package todo
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// jsonlTodo is the compact per-item record of a JSONL report.
+type jsonlTodo struct {
+	Type             string            `json:"type"`
+	File             string            `json:"file"`
+	Line             int               `json:"line"`
+	Column           int               `json:"column,omitempty"`
+	Tag              string            `json:"tag"`
+	Text             string            `json:"text"`
+	Position         string            `json:"position,omitempty"`
+	RawLine          string            `json:"rawLine,omitempty"`
+	RawBytes         []byte            `json:"rawBytes,omitempty"`
+	IntroducedAt     *time.Time        `json:"introducedAt,omitempty"`
+	IntroducedBy     string            `json:"introducedBy,omitempty"`
+	IntroducedCommit string            `json:"introducedCommit,omitempty"`
+	Extra            map[string]string `json:"extra,omitempty"`
+	Fingerprint      string            `json:"fingerprint,omitempty"`
+	URI              string            `json:"uri,omitempty"`
+	EditorURI        string            `json:"editorUri,omitempty"`
+}
+
+// jsonlSummary is the trailer record closing a JSONL report.
+type jsonlSummary struct {
+	Type  string         `json:"type"`
+	Total int            `json:"total"`
+	ByTag map[string]int `json:"byTag"`
+}
+
+// JSONLStream writes newline-delimited JSON: one compact object per todo
+// followed by a summary trailer whose "type" is "summary". It is safe for
+// concurrent use, so scan workers can emit items as they are found.
+type JSONLStream struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	counts map[string]int
+	total  int
+}
+
+// NewJSONLStream returns a stream writing to w.
+func NewJSONLStream(w io.Writer) *JSONLStream {
+	return &JSONLStream{enc: json.NewEncoder(w), counts: make(map[string]int)}
+}
+
+// Write emits a single item record. Text is written as captured, without the
+// tag prefix used by the document reports, since the tag has its own field.
+func (s *JSONLStream) Write(t Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total++
+	s.counts[t.Tag]++
+	return s.enc.Encode(jsonlTodo{
+		Type:             "todo",
+		File:             t.File,
+		Line:             t.Line,
+		Column:           t.Column,
+		Tag:              t.Tag,
+		Text:             t.Text,
+		Position:         t.Position,
+		RawLine:          t.RawLine,
+		RawBytes:         t.RawBytes,
+		IntroducedAt:     t.IntroducedAt,
+		IntroducedBy:     t.IntroducedBy,
+		IntroducedCommit: t.IntroducedCommit,
+		Extra:            t.Extra,
+		Fingerprint:      fingerprint(t),
+		URI:              t.URI,
+		EditorURI:        t.EditorURI,
+	})
+}
+
+// Close writes the summary trailer covering every item written so far.
+func (s *JSONLStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(jsonlSummary{Type: "summary", Total: s.total, ByTag: s.counts})
+}
+
+// GenerateJSONLReport writes a JSONL report to the given output path using the
+// default OS-backed writer.
+func GenerateJSONLReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateJSONLReportWithWriter(items, output, OSFileWriter{}, opts)
+}
+
+// GenerateJSONLReportWithWriter allows dependency injection of writers for testing.
+// Items are written in the same file/line order as the other reports.
+func GenerateJSONLReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+
+	items, err = Enrich(items, opts.Enrichers)
+	if err != nil {
+		return err
+	}
+	cp := make([]Todo, len(items))
+	copy(cp, items)
+	setFingerprints(cp)
+	for i := range cp {
+		opts.Links.Apply(&cp[i])
+	}
+	if opts.RelativeRoot {
+		trimCommonDir(cp)
+	}
+	sortTodos(cp)
+	s := NewJSONLStream(f)
+	for _, t := range cp {
+		if err := s.Write(t); err != nil {
+			return err
+		}
+	}
+	return s.Close()
+}
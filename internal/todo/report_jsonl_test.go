@@ -0,0 +1,90 @@
+package todo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGenerateJSONLReport_WithWriter_LinesAndSummary(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "b.go", Line: 10}, Tag: "FIXME", Text: "second"},
+		{Location: Location{File: "a.go", Line: 2, Column: 4}, Tag: "TODO", Text: "first", Position: PositionOwnLine},
+		{Location: Location{File: "a.go", Line: 20}, Tag: "TODO", Text: "third"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateJSONLReportWithWriter(items, "ignored.jsonl", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want 4 lines (3 items + summary), got %d:\n%s", len(lines), buf.String())
+	}
+	wantFiles := []string{"a.go", "a.go", "b.go"}
+	for i, line := range lines[:3] {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", i+1, err, line)
+		}
+		if rec["type"] != "todo" || rec["file"] != wantFiles[i] {
+			t.Fatalf("line %d = %s, want todo in %s", i+1, line, wantFiles[i])
+		}
+	}
+	if !strings.Contains(lines[0], `"column":4`) || !strings.Contains(lines[0], `"text":"first"`) || !strings.Contains(lines[0], `"position":"own-line"`) {
+		t.Fatalf("first record missing fields: %s", lines[0])
+	}
+
+	var sum struct {
+		Type  string         `json:"type"`
+		Total int            `json:"total"`
+		ByTag map[string]int `json:"byTag"`
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &sum); err != nil {
+		t.Fatalf("summary is not JSON: %v", err)
+	}
+	if sum.Type != "summary" || sum.Total != 3 || sum.ByTag["TODO"] != 2 || sum.ByTag["FIXME"] != 1 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+}
+
+func TestJSONLStream_ConcurrentWrites(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewJSONLStream(&buf)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = s.Write(Todo{Location: Location{File: "f.go", Line: i + 1}, Tag: "NOTE", Text: "n"})
+		}(i)
+	}
+	wg.Wait()
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	n := 0
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		if !json.Valid(sc.Bytes()) {
+			t.Fatalf("interleaved or invalid line: %s", sc.Text())
+		}
+		n++
+	}
+	if n != 51 {
+		t.Fatalf("want 51 lines, got %d", n)
+	}
+}
+
+func TestGenerateJSONLReport_WithWriter_Errors(t *testing.T) {
+	if err := GenerateJSONLReportWithWriter(nil, "x", jsonBadFileWriter{}, ReportOptions{}); err == nil {
+		t.Fatalf("expected create error")
+	}
+	if err := GenerateJSONLReportWithWriter(nil, "x", jsonErrOnWriteFileWriter{}, ReportOptions{}); err == nil {
+		t.Fatalf("expected write error")
+	}
+}
@@ -3,7 +3,10 @@ package todo
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -39,13 +42,13 @@ func (mdErrOnWriteFileWriter) Create(_ string) (io.WriteCloser, error) {
 
 func TestGenerateMarkdownReport_WithWriter_Success(t *testing.T) {
 	items := []Todo{
-		{File: "b.go", Line: 10, Tag: "FIXME", Text: "second"},
-		{File: "a.go", Line: 2, Tag: "TODO", Text: "first"},
-		{File: "a.go", Line: 20, Tag: "BUG", Text: "third"},
+		{Location: Location{File: "b.go", Line: 10}, Tag: "FIXME", Text: "second"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "first"},
+		{Location: Location{File: "a.go", Line: 20}, Tag: "BUG", Text: "third"},
 	}
 	var buf bytes.Buffer
 	mw := mdMockFileWriter{buf: &buf}
-	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mw); err != nil {
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mw, ReportOptions{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	out := buf.String()
@@ -62,15 +65,231 @@ func TestGenerateMarkdownReport_WithWriter_Success(t *testing.T) {
 }
 
 func TestGenerateMarkdownReport_WithWriter_CreateError(t *testing.T) {
-	items := []Todo{{File: "x.go", Line: 1, Tag: "TODO", Text: "x"}}
-	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdBadFileWriter{}); err == nil {
+	items := []Todo{{Location: Location{File: "x.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdBadFileWriter{}, ReportOptions{}); err == nil {
 		t.Fatal("expected error from Create")
 	}
 }
 
 func TestGenerateMarkdownReport_WithWriter_WriteError(t *testing.T) {
-	items := []Todo{{File: "x.go", Line: 1, Tag: "TODO", Text: "x"}}
-	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdErrOnWriteFileWriter{}); err == nil {
+	items := []Todo{{Location: Location{File: "x.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdErrOnWriteFileWriter{}, ReportOptions{}); err == nil {
 		t.Fatal("expected error from writer during markdown write")
 	}
 }
+
+func TestGenerateMarkdownReport_WithWriter_ExclusionsAppendix(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	audit := &AuditLog{
+		Entries:   []SkipRecord{{Path: "node_modules", Dir: true, Reason: SkipReasonGitIgnore, Rule: ".gitignore:3: node_modules/"}},
+		Limit:     1,
+		Truncated: true,
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{Audit: audit}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, must := range []string{"<summary>Exclusions (1)</summary>", "| node_modules | dir | gitignore | .gitignore:3: node_modules/ |", "Truncated after 1 entries"} {
+		if !strings.Contains(out, must) {
+			t.Fatalf("markdown missing %q:\n%s", must, out)
+		}
+	}
+
+	buf.Reset()
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "Exclusions") {
+		t.Fatalf("exclusions appendix should only render when auditing: %s", buf.String())
+	}
+}
+
+func TestGenerateMarkdownReport_WithWriter_DefaultOutputStable(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "b.go", Line: 10}, Tag: "FIXME", Text: "second"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "first"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "# todototum report\n\n" +
+		"## Summary\n\n" +
+		"- Total: 2\n" +
+		"- FIXME: 1 (50.0%)\n" +
+		"- TODO: 1 (50.0%)\n\n" +
+		"## Todos\n\n" +
+		"| File | Line | Tag | Text |\n" +
+		"|------|------:|-----:|------|\n" +
+		"| a.go | 2 | TODO | TODO: first |\n" +
+		"| b.go | 10 | FIXME | FIXME: second |\n"
+	if buf.String() != want {
+		t.Fatalf("default markdown changed:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestGenerateMarkdownReport_WithWriter_EmojiBadges(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "NOTE", Text: "n"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "t"},
+		{Location: Location{File: "b.go", Line: 3}, Tag: "BUG", Text: "b"},
+		{Location: Location{File: "c.go", Line: 4}, Tag: "FIXME", Text: "f"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{MarkdownBadges: MarkdownBadgesEmoji}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, must := range []string{"🐛 BUG", "🔧 FIXME", "📝 TODO", "💡 NOTE", "| b.go | 3 | 🐛 BUG | BUG: b |"} {
+		if !strings.Contains(out, must) {
+			t.Fatalf("missing %q in:\n%s", must, out)
+		}
+	}
+	if strings.Contains(out, "img.shields.io") {
+		t.Fatalf("emoji mode must not reference external images:\n%s", out)
+	}
+	summary := strings.Index(out, "## Summary")
+	top := strings.Index(out, "## Top issues")
+	table := strings.Index(out, "## Todos")
+	if summary >= top || top >= table {
+		t.Fatalf("unexpected section order (summary %d, top %d, table %d):\n%s", summary, top, table, out)
+	}
+	// Top issues are ordered by severity
+	section := out[top:table]
+	bug := strings.Index(section, "BUG")
+	fixme := strings.Index(section, "FIXME")
+	todoIdx := strings.Index(section, "TODO")
+	note := strings.Index(section, "NOTE")
+	if bug >= fixme || fixme >= todoIdx || todoIdx >= note {
+		t.Fatalf("top issues not severity-sorted:\n%s", section)
+	}
+}
+
+func TestGenerateMarkdownReport_WithWriter_TopIssuesCapped(t *testing.T) {
+	var items []Todo
+	for i := 1; i <= 15; i++ {
+		items = append(items, Todo{Location: Location{File: "a.go", Line: i}, Tag: "BUG", Text: "x"})
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{MarkdownBadges: MarkdownBadgesEmoji}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	section := out[strings.Index(out, "## Top issues"):strings.Index(out, "## Todos")]
+	if got := strings.Count(section, "\n- "); got != 10 {
+		t.Fatalf("expected 10 top issues, got %d:\n%s", got, section)
+	}
+}
+
+func TestGenerateMarkdownReport_WithWriter_ShieldsBadges(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "x"}}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{MarkdownBadges: MarkdownBadgesShields}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, must := range []string{"![total: 1](https://img.shields.io/badge/total-1-blue)", "![TODO: 1](https://img.shields.io/badge/TODO-1-yellow)"} {
+		if !strings.Contains(out, must) {
+			t.Fatalf("missing %q in:\n%s", must, out)
+		}
+	}
+}
+
+func TestGenerateMarkdownReport_PersistentSection(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "BUG", Text: "old", SeenInRuns: 7, Escalated: true},
+		{Location: Location{File: "b.go", Line: 2}, Tag: "TODO", Text: "new", SeenInRuns: 1},
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "x.md", mdMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "## Persistent items\n") || !strings.Contains(got, "| 7 | a.go | 1 | BUG: old |") {
+		t.Fatalf("missing persistent section:\n%s", got)
+	}
+	if strings.Contains(got, "| 1 | b.go |") {
+		t.Fatalf("non-escalated item listed as persistent:\n%s", got)
+	}
+}
+
+func TestAppendMarkdownSummary_CompactAndAppends(t *testing.T) {
+	var items []Todo
+	for i := 1; i <= 12; i++ {
+		items = append(items, Todo{Location: Location{File: fmt.Sprintf("f%02d.go", i%11), Line: i}, Tag: "TODO", Text: "t"})
+	}
+	items = append(items, Todo{Location: Location{File: "f01.go", Line: 99}, Tag: "BUG", Text: "b"})
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("from another step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendMarkdownSummary(items, path, ReportOptions{MarkdownMaxRows: 5}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, must := range []string{
+		"from another step\n## todototum summary",
+		"**Total: 13**",
+		"| BUG | 1 | 7.7% |",
+		"### Top files",
+		"| f01.go | 3 |",
+		"<summary>All items (13)</summary>",
+		"_8 more not shown._",
+	} {
+		if !strings.Contains(out, must) {
+			t.Fatalf("summary missing %q:\n%s", must, out)
+		}
+	}
+	if strings.Contains(out, "# todototum report") || strings.Contains(out, "## Todos") {
+		t.Fatalf("compact summary should not contain full report sections:\n%s", out)
+	}
+	if rows := strings.Count(out, "| TODO: t |") + strings.Count(out, "| BUG: b |"); rows != 5 {
+		t.Fatalf("item rows = %d, want 5", rows)
+	}
+	if top := strings.Count(out[strings.Index(out, "### Top files"):strings.Index(out, "<details>")], ".go |"); top != 10 {
+		t.Fatalf("top files rows = %d, want 10", top)
+	}
+}
+
+func TestGenerateMarkdownReport_Density(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "first"},
+		{Location: Location{File: "a.go", Line: 9}, Tag: "BUG", Text: "second"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "TODO", Text: "third"},
+	}
+	var buf bytes.Buffer
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{LinesScanned: 1200}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "- 2.50 todos per 1000 lines (1200 lines scanned)\n") {
+		t.Fatalf("missing density:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := GenerateMarkdownReportWithWriter(items, "ignored.md", mdMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "per 1000 lines") {
+		t.Fatalf("density shown without a line count:\n%s", buf.String())
+	}
+}
+
+func TestPerThousandLines(t *testing.T) {
+	for _, tc := range []struct {
+		items, lines int
+		want         float64
+	}{
+		{3, 1200, 2.5},
+		{1, 3000, 0.33},
+		{5, 0, 0},
+	} {
+		if got := PerThousandLines(tc.items, tc.lines); got != tc.want {
+			t.Errorf("PerThousandLines(%d, %d) = %v, want %v", tc.items, tc.lines, got, tc.want)
+		}
+	}
+}
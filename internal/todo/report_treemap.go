@@ -0,0 +1,91 @@
+package todo
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// TreemapNode is a directory or file in a treemap report. Count is the
+// number of items at or below the node, so with d3 the leaves carry the
+// area: d3.hierarchy(root).sum(d => d.children ? 0 : d.count).
+type TreemapNode struct {
+	Name string `json:"name"`
+	// Path is the slash-separated path of the node; the root has none.
+	Path     string         `json:"path,omitempty"`
+	Count    int            `json:"count"`
+	Children []*TreemapNode `json:"children,omitempty"`
+}
+
+// BuildTreemap groups items into a tree by their File, split on "/". Every
+// node sums the counts of its children; siblings are ordered by count,
+// largest first, then by name.
+func BuildTreemap(items []Todo) *TreemapNode {
+	root := &TreemapNode{Name: "."}
+	index := map[string]*TreemapNode{"": root}
+	for _, t := range items {
+		node := root
+		node.Count++
+		var p string
+		for _, seg := range strings.Split(strings.Trim(t.File, "/"), "/") {
+			if seg == "" || seg == "." {
+				continue
+			}
+			if p == "" {
+				p = seg
+			} else {
+				p += "/" + seg
+			}
+			child, ok := index[p]
+			if !ok {
+				child = &TreemapNode{Name: seg, Path: p}
+				index[p] = child
+				node.Children = append(node.Children, child)
+			}
+			child.Count++
+			node = child
+		}
+	}
+	sortTreemap(root)
+	return root
+}
+
+// sortTreemap orders the children of n recursively.
+func sortTreemap(n *TreemapNode) {
+	sort.Slice(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+	for _, c := range n.Children {
+		sortTreemap(c)
+	}
+}
+
+// GenerateTreemapReport writes a treemap report to the given output path
+// using the default OS-backed writer.
+func GenerateTreemapReport(items []Todo, output string, opts ReportOptions) error {
+	return GenerateTreemapReportWithWriter(items, output, OSFileWriter{}, opts)
+}
+
+// GenerateTreemapReportWithWriter allows dependency injection of writers for
+// testing. The report is the indented JSON of BuildTreemap.
+func GenerateTreemapReportWithWriter(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	f, err := w.Create(output)
+	if err != nil {
+		return err
+	}
+	defer SafeClose(f, output)
+	items, err = Enrich(items, opts.Enrichers)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if opts.RelativeRoot {
+		items = TrimCommonDir(items)
+	}
+	return enc.Encode(BuildTreemap(items))
+}
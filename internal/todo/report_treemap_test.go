@@ -0,0 +1,58 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildTreemap(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "cmd/scan.go"}, Tag: "TODO"},
+		{Location: Location{File: "internal/todo/scan.go"}, Tag: "TODO"},
+		{Location: Location{File: "internal/todo/scan.go"}, Tag: "FIXME"},
+		{Location: Location{File: "internal/todo/report.go"}, Tag: "BUG"},
+		{Location: Location{File: "internal/config/config.go"}, Tag: "NOTE"},
+		{Location: Location{File: "main.go"}, Tag: "TODO"},
+	}
+	root := BuildTreemap(items)
+	if root.Name != "." || root.Count != 6 || len(root.Children) != 3 {
+		t.Fatalf("unexpected root: %+v", root)
+	}
+	internal := root.Children[0]
+	if internal.Path != "internal" || internal.Count != 4 {
+		t.Fatalf("largest child should be internal with 4 items, got %+v", internal)
+	}
+	// Ties are broken by name: cmd before main.go.
+	if root.Children[1].Name != "cmd" || root.Children[2].Name != "main.go" {
+		t.Fatalf("unexpected order: %s, %s", root.Children[1].Name, root.Children[2].Name)
+	}
+	todoDir := internal.Children[0]
+	if todoDir.Path != "internal/todo" || todoDir.Count != 3 {
+		t.Fatalf("unexpected internal/todo node: %+v", todoDir)
+	}
+	scan := todoDir.Children[0]
+	if scan.Path != "internal/todo/scan.go" || scan.Count != 2 || scan.Children != nil {
+		t.Fatalf("unexpected leaf: %+v", scan)
+	}
+}
+
+func TestGenerateTreemapReport(t *testing.T) {
+	var buf bytes.Buffer
+	items := []Todo{{Location: Location{File: "a/b.go"}, Tag: "TODO"}, {Location: Location{File: "a/c.go"}, Tag: "TODO"}}
+	if err := GenerateTreemapReportWithWriter(items, "treemap.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got TreemapNode
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if got.Count != 2 || len(got.Children) != 1 || got.Children[0].Name != "a" || len(got.Children[0].Children) != 2 {
+		t.Fatalf("unexpected tree: %s", buf.String())
+	}
+
+	empty := BuildTreemap(nil)
+	if empty.Count != 0 || empty.Children != nil {
+		t.Fatalf("unexpected empty tree: %+v", empty)
+	}
+}
@@ -0,0 +1,106 @@
+package todo
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Reporter renders a report of data to w. It should render into memory
+// what may fail before writing, as the output file is only created by the
+// first write (see GenerateReportWithWriter).
+type Reporter func(data ReportData, w io.Writer) error
+
+var (
+	reportersMu sync.RWMutex
+	reporters   = make(map[string]Reporter)
+)
+
+func init() {
+	RegisterReporter("html", renderHTMLReport)
+	RegisterReporter("json", renderJSONReport)
+	RegisterReporter("md", renderMarkdownReport)
+}
+
+// RegisterReporter makes fn the report format name, for
+// GenerateReportWithWriter and the --report flag. It is meant to be called
+// from init functions of programs embedding todototum, and panics when
+// name is empty or already registered, or fn is nil.
+func RegisterReporter(name string, fn Reporter) {
+	reportersMu.Lock()
+	defer reportersMu.Unlock()
+	if name == "" || fn == nil {
+		panic("todo: RegisterReporter needs a name and a function")
+	}
+	if _, dup := reporters[name]; dup {
+		panic("todo: RegisterReporter called twice for report format " + name)
+	}
+	reporters[name] = fn
+}
+
+// LookupReporter returns the Reporter registered as name.
+func LookupReporter(name string) (Reporter, bool) {
+	reportersMu.RLock()
+	defer reportersMu.RUnlock()
+	fn, ok := reporters[name]
+	return fn, ok
+}
+
+// Reporters returns the names of the registered report formats, sorted.
+func Reporters() []string {
+	reportersMu.RLock()
+	defer reportersMu.RUnlock()
+	return sortedKeys(reporters)
+}
+
+// GenerateReportWithWriter writes the report of format, a registered
+// Reporter, of items to output through w. Items go through opts.Enrichers
+// first. output is created on the Reporter's first write, or once it
+// returns when it wrote nothing, so a Reporter failing early leaves no
+// file behind.
+func GenerateReportWithWriter(format string, items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	fn, ok := LookupReporter(format)
+	if !ok {
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+	items, err := Enrich(items, opts.Enrichers)
+	if err != nil {
+		return err
+	}
+	f := &lazyFile{w: w, name: output}
+	return f.close(fn(buildReportData(items, opts), f))
+}
+
+// lazyFile creates its file on the first write.
+type lazyFile struct {
+	w    FileWriter
+	name string
+	f    io.WriteCloser
+}
+
+func (l *lazyFile) Write(p []byte) (int, error) {
+	if l.f == nil {
+		f, err := l.w.Create(l.name)
+		if err != nil {
+			return 0, err
+		}
+		l.f = f
+	}
+	return l.f.Write(p)
+}
+
+// close finishes a write that ended with err: without an error, an empty
+// file is still created when nothing was written. It returns err, or the
+// error creating the file.
+func (l *lazyFile) close(err error) error {
+	if l.f == nil {
+		if err != nil {
+			return err
+		}
+		if l.f, err = l.w.Create(l.name); err != nil {
+			return err
+		}
+	}
+	SafeClose(l.f, l.name)
+	return err
+}
@@ -0,0 +1,81 @@
+package todo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+// countingCreates is a FileWriter recording how many files it created.
+type countingCreates struct {
+	buf     *bytes.Buffer
+	created *int
+}
+
+func (c countingCreates) Create(_ string) (io.WriteCloser, error) {
+	*c.created++
+	return nopWriteCloser{c.buf}, nil
+}
+
+func init() {
+	RegisterReporter("test-tags", func(data ReportData, w io.Writer) error {
+		for _, s := range data.TagStats {
+			if _, err := fmt.Fprintf(w, "%s=%d\n", s.Tag, s.Count); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	RegisterReporter("test-fail", func(ReportData, io.Writer) error { return errors.New("boom") })
+}
+
+func TestGenerateReportWithWriter_Registered(t *testing.T) {
+	items := []Todo{{Location: Location{File: "a.go", Line: 1}, Tag: "TODO"}, {Location: Location{File: "a.go", Line: 2}, Tag: "BUG"}, {Location: Location{File: "b.go", Line: 1}, Tag: "TODO"}}
+	var buf bytes.Buffer
+	created := 0
+	w := countingCreates{&buf, &created}
+	if err := GenerateReportWithWriter("test-tags", items, "r.txt", w, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "BUG=1\nTODO=2\n" || created != 1 {
+		t.Fatalf("report = %q, %d files created", got, created)
+	}
+
+	// A failing reporter that wrote nothing leaves no file behind.
+	created = 0
+	if err := GenerateReportWithWriter("test-fail", items, "r.txt", w, ReportOptions{}); err == nil || err.Error() != "boom" || created != 0 {
+		t.Fatalf("err = %v, %d files created", err, created)
+	}
+	// A reporter writing nothing still yields an (empty) file.
+	if err := GenerateReportWithWriter("test-tags", nil, "r.txt", w, ReportOptions{}); err != nil || created != 1 {
+		t.Fatalf("err = %v, %d files created", err, created)
+	}
+	if err := GenerateReportWithWriter("nope", items, "r.txt", w, ReportOptions{}); err == nil {
+		t.Fatal("an unknown format should fail")
+	}
+}
+
+func TestRegisterReporter(t *testing.T) {
+	names := Reporters()
+	for _, want := range []string{"html", "json", "md", "test-tags"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("Reporters() = %v, lacks %s", names, want)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Reporters() = %v, not sorted", names)
+	}
+	for name, fn := range map[string]Reporter{"json": renderJSONReport, "": renderJSONReport, "x-nil": nil} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterReporter(%q) should panic", name)
+				}
+			}()
+			RegisterReporter(name, fn)
+		}()
+	}
+}
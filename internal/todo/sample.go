@@ -0,0 +1,63 @@
+package todo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// Sample summarizes a sampled scan (see ScanOptions.SamplePercent) for
+// reports: the items found, and the totals a full scan would be expected to
+// find, scaled by EstimateTotal.
+type Sample struct {
+	Percent        int            `json:"percent"`
+	Scanned        int            `json:"scanned"`
+	EstimatedTotal int            `json:"estimatedTotal"`
+	EstimatedByTag map[string]int `json:"estimatedByTag"`
+}
+
+// InSample reports whether the file at relPath, relative to the scan root,
+// is among the percent of files a sampled scan reads. The choice hashes the
+// slash-separated path, so the same files are sampled on every run and
+// platform. percent is clamped to 0–100.
+func InSample(relPath string, percent int) bool {
+	if percent >= 100 {
+		return true
+	}
+	if percent <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(normalizePath(relPath)))
+	return int(h.Sum32()%100) < percent
+}
+
+// EstimateTotal scales count, found in a percent sample, to the whole tree,
+// rounded to the nearest whole item. Counts from a full scan, or from an
+// invalid percent, are returned unchanged.
+func EstimateTotal(count, percent int) int {
+	if percent <= 0 || percent >= 100 {
+		return count
+	}
+	return int(math.Round(float64(count) * 100 / float64(percent)))
+}
+
+// NewSample estimates the totals of a full scan from the items of a percent
+// sample.
+func NewSample(items []Todo, percent int) *Sample {
+	s := &Sample{Percent: percent, Scanned: len(items), EstimatedTotal: EstimateTotal(len(items), percent), EstimatedByTag: make(map[string]int)}
+	counts := make(map[string]int)
+	for _, t := range items {
+		counts[t.Tag]++
+	}
+	for tag, n := range counts {
+		s.EstimatedByTag[tag] = EstimateTotal(n, percent)
+	}
+	return s
+}
+
+// FormatEstimate renders the estimated total of s in lang, e.g.
+// "Estimated total: 480 (sampled at 25%, 120 found)".
+func FormatEstimate(s *Sample, lang string) string {
+	return fmt.Sprintf(Message(lang, "estimate"), s.EstimatedTotal, s.Percent, s.Scanned)
+}
@@ -0,0 +1,170 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInSample(t *testing.T) {
+	paths := make([]string, 1000)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("pkg%d/file%d.go", i%37, i)
+	}
+	for _, p := range paths {
+		if InSample(p, 0) || !InSample(p, 100) {
+			t.Fatalf("%s: 0%% should sample nothing and 100%% everything", p)
+		}
+		// A larger sample contains every smaller one.
+		if InSample(p, 10) && !InSample(p, 50) {
+			t.Fatalf("%s: in the 10%% sample but not in the 50%% one", p)
+		}
+	}
+	// The hash is part of the contract: changing it would change which files
+	// every existing sample covers.
+	for p, bucket := range map[string]int{"main.go": 44, "cmd/scan.go": 31, "internal/todo/report.go": 8} {
+		if !InSample(p, bucket+1) || InSample(p, bucket) {
+			t.Errorf("%s should first be sampled at %d%%", p, bucket+1)
+		}
+	}
+
+	n := 0
+	for _, p := range paths {
+		if InSample(p, 25) {
+			n++
+		}
+	}
+	if n < 200 || n > 300 {
+		t.Errorf("25%% sample picked %d of %d paths", n, len(paths))
+	}
+}
+
+func TestEstimateTotal(t *testing.T) {
+	tests := []struct {
+		count, percent, want int
+	}{
+		{120, 25, 480},
+		{0, 25, 0},
+		{1, 3, 33},
+		{2, 3, 67},
+		{7, 1, 700},
+		{7, 99, 7},
+		{7, 100, 7},
+		{7, 0, 7},
+		{7, -5, 7},
+	}
+	for _, tt := range tests {
+		if got := EstimateTotal(tt.count, tt.percent); got != tt.want {
+			t.Errorf("EstimateTotal(%d, %d) = %d, want %d", tt.count, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestNewSample(t *testing.T) {
+	items := []Todo{{Tag: "TODO"}, {Tag: "TODO"}, {Tag: "BUG"}}
+	got := NewSample(items, 20)
+	want := &Sample{Percent: 20, Scanned: 3, EstimatedTotal: 15, EstimatedByTag: map[string]int{"TODO": 10, "BUG": 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NewSample = %+v, want %+v", got, want)
+	}
+	if s := FormatEstimate(got, DefaultLang); s != "Estimated total: 15 (sampled at 20%, 3 found)" {
+		t.Errorf("FormatEstimate = %q", s)
+	}
+}
+
+func TestReports_Sample(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "first"},
+		{Location: Location{File: "b.go", Line: 1}, Tag: "BUG", Text: "second"},
+	}
+	opts := ReportOptions{SamplePercent: 25}
+
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	var rep ReportData
+	if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	want := &Sample{Percent: 25, Scanned: 2, EstimatedTotal: 8, EstimatedByTag: map[string]int{"TODO": 4, "BUG": 4}}
+	if rep.Metadata == nil || !reflect.DeepEqual(rep.Metadata.Sample, want) {
+		t.Fatalf("metadata = %+v, want sample %+v", rep.Metadata, want)
+	}
+	if rep.Summary.Total != 2 || len(rep.Todos) != 2 {
+		t.Errorf("summary and items should count what was scanned: %+v", rep.Summary)
+	}
+
+	buf.Reset()
+	if err := GenerateMarkdownReportWithWriter(items, "r.md", mdMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"- Estimated total: 8 (sampled at 25%, 2 found)\n", "## Todos (sample of 25% of files)\n"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("markdown lacks %q:\n%s", s, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(items, "r.html", mockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `id="sample"`) || !strings.Contains(buf.String(), "Estimated total: 8 (sampled at 25%)") {
+		t.Errorf("HTML lacks the sample banner")
+	}
+
+	// Full scans carry no sample.
+	buf.Reset()
+	if err := GenerateMarkdownReportWithWriter(items, "r.md", mdMockFileWriter{buf: &buf}, ReportOptions{SamplePercent: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "sample") {
+		t.Errorf("100%% sample is a full scan:\n%s", buf.String())
+	}
+}
+
+func TestScanDirDetailed_SamplePercent(t *testing.T) {
+	tmp := t.TempDir()
+	for i := range 40 {
+		mustWriteFile(t, tmp, fmt.Sprintf("f%02d.go", i), "// TODO: x\n")
+	}
+	scan := func(percent int) []string {
+		t.Helper()
+		opts := DefaultScanOptions()
+		opts.SamplePercent = percent
+		opts.Audit = true
+		res, err := ScanDirDetailed(tmp, opts, OSFileReader{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, it := range res.Items {
+			files = append(files, it.File)
+			if percent > 0 && !InSample(it.File, percent) {
+				t.Errorf("%s scanned outside the %d%% sample", it.File, percent)
+			}
+		}
+		for _, e := range res.Audit.Entries {
+			if e.Reason != SkipReasonSample || e.Rule != fmt.Sprintf("--sample %d", percent) {
+				t.Errorf("audit entry %+v", e)
+			}
+		}
+		if len(files)+res.Stats.Skipped != 40 {
+			t.Errorf("%d scanned + %d skipped, want 40 files", len(files), res.Stats.Skipped)
+		}
+		return files
+	}
+	first := scan(30)
+	if len(first) == 0 || len(first) == 40 {
+		t.Fatalf("30%% sample scanned %d of 40 files", len(first))
+	}
+	if again := scan(30); !reflect.DeepEqual(again, first) {
+		t.Errorf("samples differ between runs: %v vs %v", first, again)
+	}
+	if len(scan(0)) != 40 || len(scan(100)) != 40 {
+		t.Error("0 and 100 should scan every file")
+	}
+}
@@ -0,0 +1,124 @@
+package todo
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SanitizeText makes captured text safe to print and render: each run of
+// invalid UTF-8 becomes one U+FFFD, ANSI escape sequences (CSI such as
+// ESC[31m, OSC, and two-byte escapes) are removed, and the remaining C0 and
+// C1 control characters and DEL are dropped, except tab. Text that needs
+// none of this is returned as is.
+func SanitizeText(s string) string {
+	if cleanText(s) {
+		return s
+	}
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == 0x1b:
+			i += escapeLen(s[i:])
+			continue
+		case r == 0x9b: // C1 CSI, the single-character form of ESC [
+			i += size + csiLen(s[i+size:])
+			continue
+		case r != '\t' && (r < 0x20 || r == 0x7f || (r >= 0x80 && r <= 0x9f)):
+			// Dropped.
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// cleanText reports whether s is valid UTF-8 without control characters
+// other than tab.
+func cleanText(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			// Only the slow path tells C1 controls and invalid bytes apart.
+			return utf8.ValidString(s[i:]) && !strings.ContainsFunc(s[i:], func(r rune) bool { return r >= 0x80 && r <= 0x9f })
+		}
+		if (c < 0x20 && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeLen returns the length of the escape sequence at the start of s,
+// which begins with ESC. An unterminated sequence runs to the end of s.
+func escapeLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		return 2 + csiLen(s[2:])
+	case ']', 'P', '_', '^', 'X':
+		// OSC and other string sequences end with BEL or ST (ESC \).
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	if s[1] < utf8.RuneSelf {
+		return 2
+	}
+	return 1
+}
+
+// csiLen returns the length of the parameters, intermediates and final
+// byte of a control sequence.
+func csiLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x40 && c <= 0x7e {
+			return i + 1
+		} else if c < 0x20 || c > 0x3f {
+			// Not a parameter or intermediate byte: a malformed sequence
+			// ends here.
+			return i
+		}
+	}
+	return len(s)
+}
+
+// sanitizeItems applies SanitizeText to the Text and RawLine of items in
+// place. With RawBytes, items whose source line changed keep its original
+// bytes in Todo.RawBytes.
+func (o ScanOptions) sanitizeItems(items []Todo) {
+	for i := range items {
+		t := &items[i]
+		t.Text = SanitizeText(t.Text)
+		if t.RawLine == "" {
+			continue
+		}
+		if clean := SanitizeText(t.RawLine); clean != t.RawLine {
+			if o.RawBytes {
+				t.RawBytes = []byte(t.RawLine)
+			}
+			t.RawLine = clean
+		}
+	}
+}
+
+// itemText returns text, as matched on a source line, the way a scan under
+// o reports it in Todo.Text: sanitized, then redacted.
+func (o ScanOptions) itemText(text string) string {
+	text = SanitizeText(text)
+	if o.Redact {
+		text, _ = Redact(text, o.RedactPatterns)
+	}
+	return text
+}
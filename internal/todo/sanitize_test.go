@@ -0,0 +1,86 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeText(t *testing.T) {
+	cases := map[string]string{
+		"plain text":                    "plain text",
+		"tab\tkept":                     "tab\tkept",
+		"caf\xe9 cr\xe8me":              "caf\uFFFD cr\uFFFDme",
+		"two\xff\xfebad":                "two\uFFFDbad",
+		"\x1b[31mred\x1b[0m text":       "red text",
+		"\x1b[1;38;5;196mbold":          "bold",
+		"title\x1b]0;pwned\x07 set":     "title set",
+		"st\x1b]8;;http://x\x1b\\link":  "stlink",
+		"nul\x00byte and bell\x07":      "nulbyte and bell",
+		"cr\rreturn\x7f":                "crreturn",
+		"c1 \u009b31mcsi and \u0085nel": "c1 csi and nel",
+		"dangling \x1b":                 "dangling ",
+		"\x1b[":                         "",
+		"unicode ✓ stays":               "unicode ✓ stays",
+	}
+	for in, want := range cases {
+		if got := SanitizeText(in); got != want {
+			t.Errorf("SanitizeText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeText_CleanInputIsNotCopied(t *testing.T) {
+	if n := testing.AllocsPerRun(100, func() { _ = SanitizeText("fine — ünïcode\ttext") }); n != 0 {
+		t.Errorf("allocs = %v, want 0", n)
+	}
+}
+
+func TestScanner_SanitizesCapturedText(t *testing.T) {
+	src := "// TODO: caf\xe9 latin-1\n" +
+		"fmt.Println(\"\x1b[31m\") // FIXME: \x1b[31mred\x1b[0m alert\n" +
+		"// NOTE: nul\x00 inside\n"
+	opts := DefaultScanOptions()
+	opts.IncludeRawLine = true
+	opts.RawBytes = true
+	s, err := NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := s.ScanReader("x.go", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ScanReader: %v", err)
+	}
+	want := []string{"caf\uFFFD latin-1", "red alert", "nul inside"}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v", items)
+	}
+	for i, it := range items {
+		if it.Text != want[i] {
+			t.Errorf("item %d text = %q, want %q", i, it.Text, want[i])
+		}
+		if strings.ContainsAny(it.RawLine, "\x1b\x00") || !utf8.ValidString(it.RawLine) {
+			t.Errorf("item %d raw line not sanitized: %q", i, it.RawLine)
+		}
+	}
+	if got := string(items[1].RawBytes); got != "fmt.Println(\"\x1b[31m\") // FIXME: \x1b[31mred\x1b[0m alert" {
+		t.Errorf("raw bytes = %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(buf.Bytes()) || !json.Valid(buf.Bytes()) {
+		t.Fatalf("report is not valid UTF-8 JSON:\n%s", buf.String())
+	}
+	var rep struct{ Todos []Todo }
+	if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rep.Todos[1].RawBytes, items[1].RawBytes) {
+		t.Errorf("raw bytes did not survive a JSON round trip: %q", rep.Todos[1].RawBytes)
+	}
+}
@@ -1,27 +1,488 @@
 package todo
 
 import (
-	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Todo represents a single annotated task found in source files.
 // Fields are intentionally simple to support plain table and HTML rendering.
 type Todo struct {
-	File string
-	Line int
+	// Location is where the item was found; its File, Line and Column are
+	// promoted, and JSON keeps them at the top level of the item.
+	Location
 	Tag  string
 	Text string
+	// Position is PositionOwnLine or PositionTrailing; empty when the file's
+	// comment syntax is unknown.
+	Position string `json:",omitempty"`
+	// IntroducedAt, IntroducedBy and IntroducedCommit describe the earliest
+	// commit that added the item, when traced via TraceIntroduced.
+	IntroducedAt     *time.Time `json:",omitempty"`
+	IntroducedBy     string     `json:",omitempty"`
+	IntroducedCommit string     `json:",omitempty"`
+	// Refs lists the ticket references mentioned in Text (see ExtractRefs).
+	Refs []string `json:",omitempty"`
+	// Owners lists the code owners of File, when assigned from a
+	// CODEOWNERS file (see CodeOwners.Assign).
+	Owners []string `json:",omitempty"`
+	// RawLine is the entire source line the item was found on, including
+	// indentation and surrounding code; set only when
+	// ScanOptions.IncludeRawLine is on.
+	RawLine string `json:",omitempty"`
+	// SeenInRuns counts the consecutive history runs, including the current
+	// one, that reported the item; zero when no history was applied.
+	SeenInRuns int `json:",omitempty"`
+	// Escalated marks a BUG or FIXME that persisted past the escalation
+	// threshold. See ApplyHistory.
+	Escalated bool `json:",omitempty"`
+	// Extra holds metadata attached by an Enricher. Reports render the keys
+	// named by ReportOptions.ExtraColumns as extra columns.
+	Extra map[string]string `json:"extra,omitempty"`
+	// Orphan marks a Go item mentioning an identifier declared nowhere in
+	// the scanned tree; see OrphanEnricher.
+	Orphan bool `json:",omitempty"`
+	// Symbol names the Go declaration enclosing the item, e.g.
+	// "(*Server).handleLogin"; see GoSymbolEnricher.
+	Symbol string `json:",omitempty"`
+	// Fingerprint is a stable ID of the item, set by reports (see
+	// Fingerprint).
+	Fingerprint string `json:",omitempty"`
+	// RawBytes is the source line as read, before SanitizeText, when
+	// ScanOptions.RawBytes is on and sanitizing changed it. Secrets are
+	// redacted as in RawLine. JSON encodes it as base64.
+	RawBytes []byte `json:",omitempty"`
+	// AlsoAt lists the other paths of File when it has several hard links
+	// scanned once (see ScanOptions.DedupHardLinks), in path order.
+	AlsoAt []string `json:",omitempty"`
+	// URI is the file:// URI of the item's file and EditorURI its editor
+	// link, set by reports from ReportOptions.Links (see ItemLinks).
+	URI       string `json:"uri,omitempty"`
+	EditorURI string `json:"editorUri,omitempty"`
 }
 
+// Location identifies a position in a scanned file. Its JSON keys match
+// those Todo has always had for these fields.
+type Location struct {
+	File string
+	Line int
+	// Column is the 1-based rune offset of the tag within the line; zero when unknown.
+	Column int `json:",omitempty"`
+}
+
+// BackupDirName is the directory, below the root of a rewrite, where
+// commands editing files in place mirror the originals. Scans skip it.
+const BackupDirName = ".todototum-backup"
+
+// DefaultTags are the markers matched when ScanOptions.Tags is empty.
+var DefaultTags = []string{"TODO", "FIXME", "BUG", "NOTE"}
+
 // pattern matches TODO-like markers, case-insensitively, capturing tag and text.
 var pattern = regexp.MustCompile(`(?i)\b(TODO|FIXME|BUG|NOTE)\b:?(.+)?`)
 
+// validTag restricts custom tags to word-like markers.
+var validTag = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// markerPattern matches the default tags written as markers, followed by a
+// colon or an owner, as in "FIXME:" or "BUG(alice)".
+var markerPattern = regexp.MustCompile(`(?i)\b(TODO|FIXME|BUG|NOTE)[:(]`)
+
+// TagPattern returns the matcher for the given tags, built like pattern.
+// Empty tags yield the default pattern.
+func TagPattern(tags []string) (*regexp.Regexp, error) {
+	if len(tags) == 0 {
+		return pattern, nil
+	}
+	alts, err := tagAlternation(tags)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(`(?i)\b(` + alts + `)\b:?(.+)?`)
+}
+
+// MarkerPattern returns the matcher for the given tags written as markers,
+// built like markerPattern, which empty tags yield. A scan ends an item's
+// text where such a marker follows the tag on the same line.
+func MarkerPattern(tags []string) (*regexp.Regexp, error) {
+	if len(tags) == 0 {
+		return markerPattern, nil
+	}
+	alts, err := tagAlternation(tags)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(`(?i)\b(` + alts + `)[:(]`)
+}
+
+// tagAlternation validates tags and joins them, quoted, into a regular
+// expression alternation.
+func tagAlternation(tags []string) (string, error) {
+	alts := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if !validTag.MatchString(t) {
+			return "", fmt.Errorf("invalid tag %q: tags must start with a letter and contain only letters, digits, '_' or '-'", t)
+		}
+		alts = append(alts, regexp.QuoteMeta(t))
+	}
+	return strings.Join(alts, "|"), nil
+}
+
+// ignoreDirective marks a line (or the line below it) as not to be reported,
+// e.g. `x := "TODO" // todototum:ignore`.
+const ignoreDirective = "todototum:ignore"
+
+// disableDirective and enableDirective bracket a region whose matches are not
+// reported. Regions nest; an unterminated region extends to end of file.
+const (
+	disableDirective = "todototum:disable"
+	enableDirective  = "todototum:enable"
+)
+
+// DefaultGeneratedHeader matches the standard Go generated-code header,
+// e.g. `// Code generated by protoc-gen-go. DO NOT EDIT.`.
+var DefaultGeneratedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedHeaderLines is how many leading lines are checked for a
+// generated-code header.
+const generatedHeaderLines = 10
+
+// generatedHeaderError is returned by scanFileWithReader for files skipped
+// because of a generated-code header.
+type generatedHeaderError struct {
+	line   int
+	header string
+}
+
+func (e generatedHeaderError) Error() string {
+	return fmt.Sprintf("generated-code header at line %d", e.line)
+}
+
+// rule describes the header for audit records.
+func (e generatedHeaderError) rule() string {
+	return fmt.Sprintf("header:%d: %s", e.line, e.header)
+}
+
+// ScanOptions tunes the directory walk and per-file matching.
+// DefaultScanOptions returns the settings used by ScanDir.
+type ScanOptions struct {
+	// IgnoreDirs lists directory names skipped during the walk. An entry
+	// containing a slash, e.g. "src/generated", is a path relative to the
+	// scanned root and skips only that directory.
+	IgnoreDirs []string
+	// IgnoreDirsFoldCase matches IgnoreDirs regardless of case, so "vendor"
+	// also skips "Vendor", as on case-insensitive filesystems.
+	IgnoreDirsFoldCase bool
+	// RespectIgnoreComments drops matches on lines carrying an inline
+	// todototum:ignore directive, or directly below a directive-only line,
+	// and inside todototum:disable ... todototum:enable regions.
+	RespectIgnoreComments bool
+	// Audit records every skipped directory and file, with the responsible
+	// rule, into ScanResult.Audit.
+	Audit bool
+	// AuditLimit caps the number of audit entries (DefaultAuditLimit if <= 0).
+	AuditLimit int
+	// SkipGenerated skips files marked linguist-generated in .gitattributes
+	// and files with a generated-code header in their first lines.
+	SkipGenerated bool
+	// GeneratedHeader recognizes generated-code header lines for
+	// SkipGenerated (DefaultGeneratedHeader if nil).
+	GeneratedHeader *regexp.Regexp
+	// OnItem, when set, is called for every item as soon as a worker finds
+	// it, before the scan completes. Calls are serialized but arrive in no
+	// particular order.
+	OnItem func(Todo)
+	// NormalizePaths reports file paths with forward slashes on every OS,
+	// so reports are byte-identical across platforms.
+	NormalizePaths bool
+	// IncludeRawLine records each item's full source line in Todo.RawLine.
+	IncludeRawLine bool
+	// RawBytes, with IncludeRawLine, also keeps the original bytes of raw
+	// lines that sanitizing changed (see Todo.RawBytes).
+	RawBytes bool
+	// Tags lists the markers to match (DefaultTags if empty).
+	Tags []string
+	// Aliases maps other spellings, upper-cased, to the tag they stand for,
+	// e.g. "HACK" to "FIXME". Aliases match like Tags, in any case, and
+	// their items report the tag.
+	Aliases map[string]string
+	// DirOverride, when set, is called with the slash-separated path,
+	// relative to the root, of every directory holding files to scan and of
+	// each directory above it up to the root ("."), once per directory. The
+	// Override it returns, if any, applies to the directory's subtree on top
+	// of those of its parents. An error, or an invalid override, is reported
+	// as a ProblemConfig and leaves the subtree with its parent's settings.
+	DirOverride func(dir string) (*Override, error)
+	// FirstMatchOnly reports only the first tag of each line, with the rest
+	// of the line as its text, as scans did before lines could hold several
+	// items. By default a line yields one item per tag: the first tag
+	// anywhere, and each later one written as a marker, "FIXME:" or
+	// "FIXME(owner)", which also ends the text of the item before it.
+	FirstMatchOnly bool
+	// SkipSubmodules skips nested repositories (submodules and other
+	// directories with their own .git) instead of scanning them with their
+	// own ignore rules.
+	SkipSubmodules bool
+	// Done lists files, by reported path, that an earlier interrupted scan
+	// already covered (see Checkpoint). They are walked but not opened.
+	Done map[string]bool
+	// ModifiedSince, when set, skips files last modified before it, by
+	// their modification time on disk.
+	ModifiedSince time.Time
+	// SamplePercent, when between 1 and 99, reads only the files InSample
+	// picks for it; other values read every file.
+	SamplePercent int
+	// OnFileScanned, when set, is called after each file is scanned with its
+	// items, which may be none. Calls are serialized. A Checkpointer uses it
+	// to record progress.
+	OnFileScanned func(file string, items []Todo)
+	// Files, when non-nil, lists the files to scan, by path relative to
+	// the root, in place of walking it, as TrackedFiles returns them.
+	// .gitignore rules and nested repositories do not apply; the other
+	// file rules do, and IgnoreDirs matches the directories holding a file.
+	// Listed paths that are not regular files are left out.
+	Files []string
+	// Only, when non-empty, restricts the scan to paths matching these
+	// patterns (see Allowlist). Directories that cannot contain a match are
+	// not walked; .gitignore rules still apply within the allowed paths.
+	Only []string
+	// Detect lists detectors run alongside tag matching (see Detectors):
+	// DetectConflicts reports complete merge conflict blocks as CONFLICT
+	// items and DetectDebug reports debug statements as DEBUG items.
+	Detect []string
+	// DebugPatterns adds expressions, keyed by lowercase file extension
+	// with its dot, to the built-in DetectDebug patterns.
+	DebugPatterns map[string][]*regexp.Regexp
+	// CommentSyntax maps lowercase file extensions, with their dot, to the
+	// comment introducers used to classify Todo.Position, replacing the
+	// built-in ones (see CommentSyntax) for the extensions it lists.
+	CommentSyntax map[string][]string
+	// Redact replaces credentials in Text and RawLine with RedactedText
+	// before items leave the scanner (see Redact). RedactPatterns adds to
+	// the built-in patterns.
+	Redact         bool
+	RedactPatterns []*regexp.Regexp
+	// DedupHardLinks scans a file with several hard links in the tree once,
+	// where the platform tells links apart (Unix): its items are reported
+	// under the lexicographically first path, with the others in
+	// Todo.AlsoAt, and the other links are skipped as SkipReasonHardLink.
+	// OnItem and OnFileScanned see the path the file was read from.
+	DedupHardLinks bool
+
+	// re and marker cache the compiled tag and marker matchers for the
+	// duration of a scan.
+	re     *regexp.Regexp
+	marker *regexp.Regexp
+}
+
+// MatchKey hashes the settings of o that decide which items a file yields
+// and what they hold: tags, detectors and their patterns, comment syntaxes,
+// ignore directives, raw lines, redaction and path normalization. Results
+// kept across runs, such as checkpoints, are only reused under the same key,
+// so changing --tags or a pattern rescans instead of reporting stale items.
+// Walk settings are not part of it; they decide which files are read, not
+// what a file yields.
+func (o ScanOptions) MatchKey() string {
+	h := sha256.New()
+	field := func(name string, values ...string) {
+		fmt.Fprintf(h, "%s=%d\x00%s\x00", name, len(values), strings.Join(values, "\x00"))
+	}
+	tags := o.Tags
+	if len(tags) == 0 {
+		tags = DefaultTags
+	}
+	upper := make([]string, len(tags))
+	for i, t := range tags {
+		upper[i] = strings.ToUpper(strings.TrimSpace(t))
+	}
+	sort.Strings(upper)
+	field("tags", upper...)
+	aliases := make([]string, 0, len(o.Aliases))
+	for _, alias := range sortedKeys(o.Aliases) {
+		aliases = append(aliases, alias+"="+o.Aliases[alias])
+	}
+	field("aliases", aliases...)
+	detect := append([]string(nil), o.Detect...)
+	sort.Strings(detect)
+	field("detect", detect...)
+	for _, ext := range sortedKeys(o.DebugPatterns) {
+		exprs := make([]string, len(o.DebugPatterns[ext]))
+		for i, re := range o.DebugPatterns[ext] {
+			exprs[i] = re.String()
+		}
+		field("debug "+ext, exprs...)
+	}
+	for _, ext := range sortedKeys(o.CommentSyntax) {
+		field("syntax "+ext, o.CommentSyntax[ext]...)
+	}
+	redact := make([]string, len(o.RedactPatterns))
+	for i, re := range o.RedactPatterns {
+		redact[i] = re.String()
+	}
+	field("redact", redact...)
+	field("flags", fmt.Sprint(o.RespectIgnoreComments, o.IncludeRawLine, o.RawBytes, o.Redact, o.NormalizePaths, o.FirstMatchOnly))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// TreeMatchKey is MatchKey for a scan of root. When DirOverride changes
+// the options of a directory, e.g. through a nested config file, the key
+// also covers the options that directory resolves to, so results kept
+// across runs are not reused once an override changed. It walks root like
+// a scan, without opening files; without DirOverride it is MatchKey.
+func (o ScanOptions) TreeMatchKey(root string) (string, error) {
+	key := o.MatchKey()
+	if o.DirOverride == nil {
+		return key, nil
+	}
+	only, err := ParseAllowlist(o.Only)
+	if err != nil {
+		return "", err
+	}
+	dirs := newDirOptions(o)
+	seen := make(map[string]bool)
+	var changed []string
+	_, err = walkFiles(root, o, only, nil, func(SkipRecord) {}, func(shown, _, _ string, _ fs.DirEntry) {
+		file := normalizePath(shown)
+		dir := path.Dir(file)
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		if k := dirs.forFile(file).MatchKey(); k != key {
+			changed = append(changed, dir+"="+k)
+		}
+	})
+	if err != nil || len(changed) == 0 {
+		return key, err
+	}
+	sort.Strings(changed)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", key, strings.Join(changed, "\x00"))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matcher returns the compiled tag pattern for o.
+func (o ScanOptions) matcher() (*regexp.Regexp, error) {
+	if o.re != nil {
+		return o.re, nil
+	}
+	return TagPattern(o.matchTags())
+}
+
+// matchTags returns the spellings the tag pattern of o matches: Tags, or
+// DefaultTags if empty, and the keys of Aliases.
+func (o ScanOptions) matchTags() []string {
+	if len(o.Aliases) == 0 {
+		return o.Tags
+	}
+	tags := o.Tags
+	if len(tags) == 0 {
+		tags = DefaultTags
+	}
+	return append(append([]string(nil), tags...), sortedKeys(o.Aliases)...)
+}
+
+// canonicalTag returns the tag that tag, upper-cased, stands for: its
+// Aliases entry if any, or tag itself.
+func (o ScanOptions) canonicalTag(tag string) string {
+	if t, ok := o.Aliases[tag]; ok {
+		return t
+	}
+	return tag
+}
+
+// compileMatchers compiles and caches the tag and marker patterns of o.
+func (o *ScanOptions) compileMatchers() error {
+	re, err := o.matcher()
+	if err != nil {
+		return err
+	}
+	if o.marker == nil {
+		if o.marker, err = MarkerPattern(o.matchTags()); err != nil {
+			return err
+		}
+	}
+	o.re = re
+	return nil
+}
+
+// ScanResult carries the items found by a scan along with optional details
+// about how the walk went.
+type ScanResult struct {
+	Items []Todo
+	Stats ScanStats
+	// Audit lists exclusions when ScanOptions.Audit is set; nil otherwise.
+	Audit *AuditLog
+	// Problems lists the files that could not be opened or read, by path.
+	Problems []Problem
+}
+
+// ScanStats counts the work done by a scan.
+type ScanStats struct {
+	// Walked is the number of files visited by the walk.
+	Walked int `json:"walked"`
+	// Opened is the number of files successfully opened for matching.
+	Opened int `json:"opened"`
+	// Skipped is the number of directories and files excluded by ignore rules
+	// or generated-code detection.
+	Skipped int `json:"skipped"`
+	// Resumed is the number of files not rescanned because ScanOptions.Done
+	// listed them.
+	Resumed int `json:"resumed,omitempty"`
+	// Lines is the number of lines read from the files scanned, excluding
+	// files skipped as generated.
+	Lines int `json:"lines"`
+	// Redacted counts the secrets replaced in captured text.
+	Redacted int `json:"redacted,omitempty"`
+	// HardLinks is the number of files not scanned because they are hard
+	// links to a file already scanned.
+	HardLinks int           `json:"hardLinks,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// countingReader wraps a FileReader and counts successful opens.
+type countingReader struct {
+	FileReader
+	opened *atomic.Int64
+}
+
+func (c countingReader) Open(name string) (io.ReadCloser, error) {
+	rc, err := c.FileReader.Open(name)
+	if err == nil {
+		c.opened.Add(1)
+	}
+	return rc, err
+}
+
+// DefaultScanOptions returns the options used by ScanDir and ScanDirWithReader.
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{RespectIgnoreComments: true, NormalizePaths: true, Redact: true, DedupHardLinks: true}
+}
+
 // ScanDir walks a directory tree using the real OS reader and collects todos.
 func ScanDir(root string, ignoreDirs []string) ([]Todo, error) {
 	return ScanDirWithReader(root, ignoreDirs, OSFileReader{})
@@ -30,15 +491,43 @@ func ScanDir(root string, ignoreDirs []string) ([]Todo, error) {
 // ScanDirWithReader is like ScanDir but allows injection of a custom FileReader
 // for testing or alternate backends. Behavior and output are identical.
 func ScanDirWithReader(root string, ignoreDirs []string, reader FileReader) ([]Todo, error) {
-	// Prepare ignore set
-	skip := make(map[string]bool)
-	for _, d := range ignoreDirs {
-		skip[strings.TrimSpace(d)] = true
+	opts := DefaultScanOptions()
+	opts.IgnoreDirs = ignoreDirs
+	return ScanDirWithOptions(root, opts, reader)
+}
+
+// ScanDirWithOptions is like ScanDirWithReader but takes explicit ScanOptions.
+func ScanDirWithOptions(root string, opts ScanOptions, reader FileReader) ([]Todo, error) {
+	res, err := ScanDirDetailed(root, opts, reader)
+	return res.Items, err
+}
+
+// ScanDirDetailed is like ScanDirWithOptions but returns the full ScanResult.
+func ScanDirDetailed(root string, opts ScanOptions, reader FileReader) (ScanResult, error) {
+	start := time.Now()
+	if err := opts.compileMatchers(); err != nil {
+		return ScanResult{}, err
+	}
+	if err := checkDetectors(opts.Detect); err != nil {
+		return ScanResult{}, err
+	}
+	only, err := ParseAllowlist(opts.Only)
+	if err != nil {
+		return ScanResult{}, err
 	}
+	var dirs *dirOptions
+	if opts.DirOverride != nil {
+		dirs = newDirOptions(opts)
+	}
+	var opened atomic.Int64
+	// Decide how files are opened before wrapping the reader for counting.
+	_, osReader := reader.(OSFileReader)
+	reader = countingReader{FileReader: reader, opened: &opened}
 
-	// Determine repo root and load .gitignore rules if available.
-	repoRoot := findRepoRoot(root)
-	gi, _ := loadGitIgnore(repoRoot)
+	var audit *auditCollector
+	if opts.Audit {
+		audit = newAuditCollector(opts.AuditLimit)
+	}
 
 	// Bounded worker pool to scan files in parallel.
 	type fileJob struct {
@@ -49,6 +538,9 @@ func ScanDirWithReader(root string, ignoreDirs []string, reader FileReader) ([]T
 	jobs := make(chan fileJob, 64)
 	var todos []Todo
 	var mu sync.Mutex
+	var headerSkips atomic.Int64
+	var lines, redacted atomic.Int64
+	var problems []Problem
 
 	workers := runtime.NumCPU()
 	if workers < 2 {
@@ -61,94 +553,393 @@ func ScanDirWithReader(root string, ignoreDirs []string, reader FileReader) ([]T
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				fileTodos, err := scanFileWithReader(job.open, reader)
-				if err == nil && len(fileTodos) > 0 {
-					for i := range fileTodos {
-						fileTodos[i].File = job.rel
+				fileOpts := opts
+				if dirs != nil {
+					fileOpts = dirs.forFile(normalizePath(job.rel))
+				}
+				fileTodos, counts, err := scanFileWithReader(job.open, reader, fileOpts)
+				var gen generatedHeaderError
+				if errors.As(err, &gen) {
+					headerSkips.Add(1)
+					if audit != nil {
+						audit.add(SkipRecord{Path: job.rel, Reason: SkipReasonGenerated, Rule: gen.rule()})
 					}
+					continue
+				}
+				if err != nil {
 					mu.Lock()
-					todos = append(todos, fileTodos...)
+					problems = append(problems, newProblem(job.rel, err))
 					mu.Unlock()
+					continue
+				}
+				lines.Add(int64(counts.lines))
+				redacted.Add(int64(counts.redacted))
+				for i := range fileTodos {
+					fileTodos[i].File = job.rel
+				}
+				mu.Lock()
+				todos = append(todos, fileTodos...)
+				if opts.OnItem != nil {
+					for _, t := range fileTodos {
+						opts.OnItem(t)
+					}
 				}
+				if opts.OnFileScanned != nil {
+					opts.OnFileScanned(job.rel, fileTodos)
+				}
+				mu.Unlock()
 			}
 		}()
 	}
 
+	var links *linkSet
+	if opts.DedupHardLinks {
+		links = newLinkSet()
+	}
 	// Walk directory and dispatch files to workers.
+	stats, err := walkFiles(root, opts, only, links, func(r SkipRecord) {
+		if audit != nil {
+			audit.add(r)
+		}
+	}, func(shown, relPath, path string, _ fs.DirEntry) {
+		// Use full path when reading real files; relative for mocks.
+		openPath := relPath
+		if osReader {
+			openPath = path
+		}
+		jobs <- fileJob{rel: shown, open: openPath}
+	})
+	close(jobs)
+	wg.Wait()
+
+	stats.Opened = int(opened.Load())
+	stats.Skipped += int(headerSkips.Load())
+	stats.Lines = int(lines.Load())
+	stats.Redacted = int(redacted.Load())
+	stats.Duration = time.Since(start)
+	if links != nil {
+		applyHardLinks(todos, links)
+	}
+	if dirs != nil {
+		problems = append(problems, dirs.problems...)
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	res := ScanResult{Items: todos, Stats: stats, Problems: problems}
+	if audit != nil {
+		res.Audit = audit.result()
+	}
+	return res, err
+}
+
+// walkFiles walks the tree at root with the scan's gating: .git metadata,
+// Only, IgnoreDirs, .gitignore rules, nested repositories, linguist-generated
+// files, ModifiedSince, SamplePercent and Done. Files that resolve outside
+// root, through a symlink, are excluded too. Every excluded entry is
+// passed to skipped; every file to read is passed to visit with its reported
+// path, its path relative to root, its walked path and its entry. No file is
+// opened for its content. The returned stats count walked, skipped and
+// resumed entries. With opts.Files, the listed files are gated instead of
+// the walked ones. With links, a file already visited through another hard
+// link is skipped and counted in HardLinks.
+func walkFiles(root string, opts ScanOptions, only Allowlist, links *linkSet, skipped func(SkipRecord), visit func(shown, relPath, path string, d fs.DirEntry)) (ScanStats, error) {
+	var stats ScanStats
+	// Prepare ignore sets: names match any directory's base name, paths
+	// its slash-separated path relative to root.
+	skipNames := make(map[string]bool)
+	skipPaths := make(map[string]bool)
+	fold := func(s string) string {
+		if opts.IgnoreDirsFoldCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	for _, d := range opts.IgnoreDirs {
+		d = fold(normalizePath(strings.TrimSpace(d)))
+		if strings.Contains(d, "/") {
+			skipPaths[strings.Trim(path.Clean(d), "/")] = true
+		} else {
+			skipNames[d] = true
+		}
+	}
+	// ignoredBy returns the --ignore entry matching a directory, if any.
+	ignoredBy := func(name, relPath string) (string, bool) {
+		if skipNames[fold(name)] {
+			return name, true
+		}
+		rel := normalizePath(relPath)
+		return rel, skipPaths[fold(rel)]
+	}
+	// dirIgnoredBy returns the --ignore entry matching any directory
+	// containing the file at relPath, if any.
+	dirIgnoredBy := func(relPath string) (string, bool) {
+		for dir := filepath.Dir(relPath); dir != "."; dir = filepath.Dir(dir) {
+			if entry, ok := ignoredBy(filepath.Base(dir), dir); ok {
+				return entry, true
+			}
+		}
+		return "", false
+	}
+
+	// Determine repo root and load .gitignore rules if available. Nested
+	// repositories found during the walk push their own scope, so their
+	// rules apply within their subtree instead of the outer repository's.
+	scopes := []repoScope{loadRepoScope(findRepoRoot(root), opts.SkipGenerated)}
+	scopeFor := func(path string) repoScope {
+		for len(scopes) > 1 && !withinDir(path, scopes[len(scopes)-1].root) {
+			scopes = scopes[:len(scopes)-1]
+		}
+		return scopes[len(scopes)-1]
+	}
+
+	// realRoot is root with symlinks resolved, the boundary no file may
+	// resolve outside of.
+	realRoot := resolvedPath(root)
+
+	// gateFile applies the rules for files, from Only to Done, to one file
+	// of the scope and visits it unless a rule excludes it.
+	gateFile := func(shown, relPath, path string, d fs.DirEntry, scope repoScope) {
+		stats.Walked++
+		gi, ga, repoRoot := scope.gi, scope.ga, scope.root
+
+		// The walk itself stays within root, so only symlinks can lead out
+		// of it; listed files may also go through symlinked directories.
+		if opts.Files != nil || d.Type()&fs.ModeSymlink != 0 {
+			if target := resolvedPath(path); !withinDir(target, realRoot) {
+				skipped(SkipRecord{Path: shown, Reason: SkipReasonOutsideRoot, Rule: "resolves to " + target})
+				stats.Skipped++
+				return
+			}
+		}
+		if !only.Allows(normalizePath(relPath)) {
+			skipped(SkipRecord{Path: shown, Reason: SkipReasonAllowlist, Rule: "--only"})
+			stats.Skipped++
+			return
+		}
+		// Check .gitignore rules for files
+		if gi != nil {
+			relRepo, _ := filepath.Rel(repoRoot, path)
+			if rule, ok := gi.matchRule(relRepo, false); ok {
+				skipped(SkipRecord{Path: shown, Reason: SkipReasonGitIgnore, Rule: rule.origin()})
+				stats.Skipped++
+				return
+			}
+		}
+		if ga != nil {
+			relRepo, _ := filepath.Rel(repoRoot, path)
+			if rule, ok := ga.generated(relRepo); ok {
+				skipped(SkipRecord{Path: shown, Reason: SkipReasonGenerated, Rule: rule})
+				stats.Skipped++
+				return
+			}
+		}
+
+		if !opts.ModifiedSince.IsZero() {
+			// Entries that cannot be stat'ed, as with in-memory readers,
+			// are kept.
+			if info, err := d.Info(); err == nil && info.ModTime().Before(opts.ModifiedSince) {
+				skipped(SkipRecord{Path: shown, Reason: SkipReasonModified, Rule: "--modified-within"})
+				stats.Skipped++
+				return
+			}
+		}
+
+		if opts.SamplePercent > 0 && !InSample(relPath, opts.SamplePercent) {
+			skipped(SkipRecord{Path: shown, Reason: SkipReasonSample, Rule: fmt.Sprintf("--sample %d", opts.SamplePercent)})
+			stats.Skipped++
+			return
+		}
+
+		if links != nil {
+			if first, ok := links.add(shown, d); ok {
+				skipped(SkipRecord{Path: shown, Reason: SkipReasonHardLink, Rule: "same file as " + first})
+				stats.HardLinks++
+				return
+			}
+		}
+
+		if opts.Done[shown] {
+			stats.Resumed++
+			return
+		}
+
+		visit(shown, relPath, path, d)
+	}
+
+	if opts.Files != nil {
+		// The listed files replace the walk. .gitignore rules and nested
+		// repositories do not apply: the list already reflects them.
+		scope := scopes[0]
+		scope.gi = nil
+		for _, name := range opts.Files {
+			relPath := filepath.Clean(filepath.FromSlash(name))
+			path := filepath.Join(root, relPath)
+			shown := relPath
+			if opts.NormalizePaths {
+				shown = normalizePath(relPath)
+			}
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				// Deleted files and submodules have no content to scan.
+				continue
+			}
+			if entry, ok := dirIgnoredBy(relPath); ok {
+				skipped(SkipRecord{Path: shown, Reason: SkipReasonIgnoreFlag, Rule: "--ignore " + entry})
+				stats.Skipped++
+				continue
+			}
+			gateFile(shown, relPath, path, fs.FileInfoToDirEntry(info), scope)
+		}
+		return stats, nil
+	}
+
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
+			if path == root {
+				// Nothing can be walked, e.g. root does not exist.
+				return err
+			}
 			// Ignore traversal errors for individual entries; continue walking.
 			return nil
 		}
+		// Normalize to relative path for nicer display and stable output.
+		relPath, _ := filepath.Rel(root, path)
+		// shown is the path reported in results; files are opened by relPath.
+		shown := relPath
+		if opts.NormalizePaths {
+			shown = normalizePath(relPath)
+		}
+
+		scope := scopeFor(path)
+		gi, repoRoot := scope.gi, scope.root
+
+		if d.Name() == ".git" && !d.IsDir() {
+			// The .git file of a worktree or submodule is VCS metadata too.
+			return nil
+		}
 		if d.IsDir() {
 			// Always skip VCS metadata directories
 			if d.Name() == ".git" {
+				skipped(SkipRecord{Path: shown, Dir: true, Reason: SkipReasonVCS, Rule: ".git"})
+				stats.Skipped++
+				return filepath.SkipDir
+			}
+			// Backed-up originals would report every rewritten item twice
+			if d.Name() == BackupDirName {
+				skipped(SkipRecord{Path: shown, Dir: true, Reason: SkipReasonBackup, Rule: BackupDirName})
+				stats.Skipped++
+				return filepath.SkipDir
+			}
+			// Prune directories outside the allowlist without walking them
+			if path != root && !only.CanContain(normalizePath(relPath)) {
+				skipped(SkipRecord{Path: shown, Dir: true, Reason: SkipReasonAllowlist, Rule: "--only"})
+				stats.Skipped++
 				return filepath.SkipDir
 			}
 			// Skip by explicit directory name
-			if skip[d.Name()] {
+			if entry, ok := ignoredBy(d.Name(), relPath); ok {
+				skipped(SkipRecord{Path: shown, Dir: true, Reason: SkipReasonIgnoreFlag, Rule: "--ignore " + entry})
+				stats.Skipped++
 				return filepath.SkipDir
 			}
 			// Skip by .gitignore rules when inside a git repo
 			if gi != nil {
 				relRepo, _ := filepath.Rel(repoRoot, path)
-				if gi.match(relRepo, true) {
+				if rule, ok := gi.matchRule(relRepo, true); ok {
+					skipped(SkipRecord{Path: shown, Dir: true, Reason: SkipReasonGitIgnore, Rule: rule.origin()})
+					stats.Skipped++
 					return filepath.SkipDir
 				}
 			}
+			if path != root && isRepoRoot(path) {
+				if opts.SkipSubmodules {
+					skipped(SkipRecord{Path: shown, Dir: true, Reason: SkipReasonSubmodule, Rule: "--skip-submodules"})
+					stats.Skipped++
+					return filepath.SkipDir
+				}
+				scopes = append(scopes, loadRepoScope(path, opts.SkipGenerated))
+			}
 			return nil
 		}
+		gateFile(shown, relPath, path, d, scope)
+		return nil
+	})
 
-		// Normalize to relative path for nicer display and stable output.
-		relPath, _ := filepath.Rel(root, path)
-
-		// Check .gitignore rules for files
-		if gi != nil {
-			relRepo, _ := filepath.Rel(repoRoot, path)
-			if gi.match(relRepo, false) {
-				return nil
-			}
-		}
+	return stats, err
+}
 
-		// Use full path when reading real files; relative for mocks.
-		openPath := relPath
-		if _, ok := reader.(OSFileReader); ok {
-			openPath = path
-		}
+// repoScope holds the ignore rules of one repository in the walk.
+type repoScope struct {
+	root string
+	gi   *gitIgnore
+	ga   *gitAttributes
+}
 
-		jobs <- fileJob{rel: relPath, open: openPath}
-		return nil
-	})
+// loadRepoScope loads the .gitignore, and with attrs the .gitattributes, of
+// the repository at root.
+func loadRepoScope(root string, attrs bool) repoScope {
+	sc := repoScope{root: root}
+	sc.gi, _ = loadGitIgnore(root)
+	if attrs {
+		sc.ga, _ = loadGitAttributes(root)
+	}
+	return sc
+}
 
-	close(jobs)
-	wg.Wait()
+// withinDir reports whether path is dir or lies below it.
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
-	return todos, err
+// resolvedPath returns the absolute path of path with symlinks resolved. A
+// path that cannot be resolved, such as a dangling link, is returned absolute
+// but unresolved, so that opening it reports the problem.
+func resolvedPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
 }
 
 // scanFileWithReader scans a single file using the provided reader.
-// It returns any matching TODO-like items found line by line.
-func scanFileWithReader(path string, reader FileReader) ([]Todo, error) {
+// It returns any matching TODO-like items found line by line, and counts of
+// what was read.
+func scanFileWithReader(path string, reader FileReader, opts ScanOptions) ([]Todo, fileCounts, error) {
 	f, err := reader.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, fileCounts{}, fileError{phase: PhaseOpen, err: err}
 	}
 	defer SafeClose(f, path)
 
-	var todos []Todo
-	sc := bufio.NewScanner(f)
-	lineNum := 0
-	for sc.Scan() {
-		lineNum++
-		line := sc.Text()
-		if m := pattern.FindStringSubmatch(line); m != nil {
-			todos = append(todos, Todo{
-				File: path,
-				Line: lineNum,
-				Tag:  strings.ToUpper(m[1]),
-				Text: strings.TrimSpace(m[2]),
-			})
-		}
-	}
-	return todos, sc.Err()
+	s, err := NewScanner(opts)
+	if err != nil {
+		return nil, fileCounts{}, err
+	}
+	todos, counts, err := s.scan(path, f)
+	if err != nil {
+		var gen generatedHeaderError
+		if !errors.As(err, &gen) {
+			err = fileError{phase: PhaseRead, err: err}
+		}
+	}
+	return todos, counts, err
+}
+
+// submatches converts a FindStringSubmatchIndex result into the strings
+// FindStringSubmatch would have returned, so callers get both views from a
+// single regexp evaluation. Unmatched groups yield "".
+func submatches(s string, idx []int) []string {
+	if idx == nil {
+		return nil
+	}
+	out := make([]string, len(idx)/2)
+	for i := range out {
+		if idx[2*i] >= 0 {
+			out[i] = s[idx[2*i]:idx[2*i+1]]
+		}
+	}
+	return out
 }
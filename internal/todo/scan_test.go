@@ -1,11 +1,16 @@
 package todo
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 // --- helper mocks ---
@@ -27,7 +32,7 @@ func (m mockFileReader) Open(name string) (io.ReadCloser, error) {
 // --- tests ---
 
 func TestScanFileWithReader_OpenError_OSReader(t *testing.T) {
-	if _, err := scanFileWithReader("/definitely/not/here.go", OSFileReader{}); err == nil {
+	if _, _, err := scanFileWithReader("/definitely/not/here.go", OSFileReader{}, DefaultScanOptions()); err == nil {
 		t.Fatal("expected error opening missing file")
 	}
 }
@@ -89,9 +94,230 @@ func TestScanDirWithReader_FindsAndIgnores(t *testing.T) {
 	}
 }
 
+func TestScanDirWithOptions_IgnoreDirsFoldCase(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "main.go", "// TODO: kept\n")
+	mustWriteFile(t, tmp, "Vendor/a.go", "// TODO: upper\n")
+	mustWriteFile(t, tmp, "VENDOR/b.go", "// TODO: shouting\n")
+	mustWriteFile(t, tmp, "src/vendor/c.go", "// TODO: lower\n")
+
+	scan := func(fold bool, ignore ...string) []string {
+		t.Helper()
+		opts := DefaultScanOptions()
+		opts.IgnoreDirs = ignore
+		opts.IgnoreDirsFoldCase = fold
+		items, err := ScanDirWithOptions(tmp, opts, OSFileReader{})
+		if err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		var texts []string
+		for _, it := range items {
+			texts = append(texts, it.Text)
+		}
+		sort.Strings(texts)
+		return texts
+	}
+
+	if got, want := scan(false, "vendor"), []string{"kept", "shouting", "upper"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("case-sensitive = %v, want %v", got, want)
+	}
+	if got, want := scan(true, "vendor"), []string{"kept"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("case-insensitive = %v, want %v", got, want)
+	}
+	if got, want := scan(true, "VenDor"), []string{"kept"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mixed-case ignore list = %v, want %v", got, want)
+	}
+}
+
+func TestScanDirDetailed_ModifiedSince(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "fresh.go", "// TODO: fresh\n")
+	stale := mustWriteFile(t, tmp, "pkg/stale.go", "// TODO: stale\n")
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultScanOptions()
+	opts.Audit = true
+	opts.ModifiedSince = time.Now().Add(-7 * 24 * time.Hour)
+	res, err := ScanDirDetailed(tmp, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(res.Items) != 1 || res.Items[0].Text != "fresh" {
+		t.Fatalf("items = %+v, want only the fresh file", res.Items)
+	}
+	if want := []SkipRecord{{Path: "pkg/stale.go", Reason: SkipReasonModified, Rule: "--modified-within"}}; !reflect.DeepEqual(res.Audit.Entries, want) {
+		t.Errorf("audit = %+v, want %+v", res.Audit.Entries, want)
+	}
+	if res.Stats.Skipped != 1 {
+		t.Errorf("skipped = %d, want 1", res.Stats.Skipped)
+	}
+
+	// Readers that do not read from disk are still gated by the walked files.
+	mock := mockFileReader{files: map[string]string{"fresh.go": "// FIXME: mocked\n", "stale.go": "// FIXME: mocked\n"}}
+	if items, err := ScanDirWithOptions(tmp, opts, mock); err != nil || len(items) != 1 || items[0].File != "fresh.go" {
+		t.Fatalf("mock reader items = %+v, %v", items, err)
+	}
+}
+
+func TestScanDirDetailed_Files(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "*.gen.go\n")
+	mustWriteFile(t, root, "main.go", "// TODO: tracked\n")
+	mustWriteFile(t, root, "forced.gen.go", "// TODO: tracked despite .gitignore\n")
+	mustWriteFile(t, root, "build/out.go", "// TODO: untracked artifact\n")
+	mustWriteFile(t, root, "vendor/lib.go", "// TODO: tracked but ignored\n")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultScanOptions()
+	opts.Audit = true
+	opts.IgnoreDirs = []string{"vendor"}
+	opts.Files = []string{"main.go", "forced.gen.go", "vendor/lib.go", "deleted.go", "sub"}
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, it := range res.Items {
+		files = append(files, it.File)
+	}
+	sort.Strings(files)
+	if want := []string{"forced.gen.go", "main.go"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("scanned %v, want %v", files, want)
+	}
+	if want := []SkipRecord{{Path: "vendor/lib.go", Reason: SkipReasonIgnoreFlag, Rule: "--ignore vendor"}}; !reflect.DeepEqual(res.Audit.Entries, want) {
+		t.Errorf("audit = %+v, want %+v", res.Audit.Entries, want)
+	}
+	if res.Stats.Walked != 2 || res.Stats.Skipped != 1 {
+		t.Errorf("stats = %+v, want 2 walked and 1 skipped", res.Stats)
+	}
+
+	opts.Files = []string{}
+	if res, err := ScanDirDetailed(root, opts, OSFileReader{}); err != nil || len(res.Items) != 0 {
+		t.Errorf("an empty list should scan nothing: %+v, %v", res.Items, err)
+	}
+}
+
+func TestScanDirDetailed_SkipsBackups(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "main.go", "// TODO(ada): rewritten\n")
+	mustWriteFile(t, root, BackupDirName+"/20240615T031200Z/main.go", "// TODO: original\n")
+	opts := DefaultScanOptions()
+	opts.Audit = true
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 1 || res.Items[0].File != "main.go" {
+		t.Fatalf("items = %+v, want main.go only", res.Items)
+	}
+	if e := res.Audit.Entries; len(e) != 1 || e[0].Reason != SkipReasonBackup {
+		t.Fatalf("audit = %+v", e)
+	}
+}
+
+func TestScanDirDetailed_SkipsSymlinksOutsideRoot(t *testing.T) {
+	outside := t.TempDir()
+	mustWriteFile(t, outside, "secret.go", "// TODO: outside the root\n")
+	root := t.TempDir()
+	mustWriteFile(t, root, "main.go", "// TODO: inside\n")
+	mustWriteFile(t, root, "real/lib.go", "// TODO: through a link\n")
+	for link, target := range map[string]string{
+		"escape.go": filepath.Join(outside, "secret.go"),
+		"alias.go":  filepath.Join(root, "main.go"),
+		"linked":    filepath.Join(root, "real"),
+		"away":      outside,
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	opts := DefaultScanOptions()
+	opts.Audit = true
+	scan := func() []string {
+		t.Helper()
+		res, err := ScanDirDetailed(root, opts, OSFileReader{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, it := range res.Items {
+			files = append(files, it.File)
+		}
+		sort.Strings(files)
+		for _, e := range res.Audit.Entries {
+			if e.Reason == SkipReasonOutsideRoot && !strings.HasPrefix(e.Rule, "resolves to "+resolvedPath(outside)) {
+				t.Errorf("audit entry %+v", e)
+			}
+		}
+		return files
+	}
+	if got, want := scan(), []string{"alias.go", "main.go", "real/lib.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walk scanned %v, want %v", got, want)
+	}
+
+	// Listed files may also go through a symlinked directory or "..".
+	opts.Files = []string{"main.go", "escape.go", "linked/lib.go", "away/secret.go", "../" + filepath.Base(outside) + "/secret.go"}
+	if got, want := scan(), []string{"linked/lib.go", "main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listed files scanned %v, want %v", got, want)
+	}
+}
+
+func TestScanDirDetailed_IgnorePaths(t *testing.T) {
+	tmp := t.TempDir()
+	mustWriteFile(t, tmp, "main.go", "// TODO: kept\n")
+	mustWriteFile(t, tmp, "src/generated/a.go", "// TODO: generated\n")
+	mustWriteFile(t, tmp, "src/generated/deep/b.go", "// TODO: deep\n")
+	mustWriteFile(t, tmp, "lib/generated/c.go", "// TODO: other generated\n")
+	mustWriteFile(t, tmp, "Docs/Internal/d.go", "// TODO: docs\n")
+
+	scan := func(fold bool, ignore ...string) ([]string, *AuditLog) {
+		t.Helper()
+		opts := DefaultScanOptions()
+		opts.IgnoreDirs = ignore
+		opts.IgnoreDirsFoldCase = fold
+		opts.Audit = true
+		res, err := ScanDirDetailed(tmp, opts, OSFileReader{})
+		if err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		var texts []string
+		for _, it := range res.Items {
+			texts = append(texts, it.Text)
+		}
+		sort.Strings(texts)
+		return texts, res.Audit
+	}
+
+	got, audit := scan(false, "src/generated")
+	if want := []string{"docs", "kept", "other generated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("path entry = %v, want %v", got, want)
+	}
+	if len(audit.Entries) != 1 || audit.Entries[0].Rule != "--ignore src/generated" {
+		t.Errorf("audit = %+v, want the path entry as rule", audit.Entries)
+	}
+	if got, _ := scan(false, "generated"); !reflect.DeepEqual(got, []string{"docs", "kept"}) {
+		t.Errorf("name entry = %v, want every generated dir skipped", got)
+	}
+	if got, _ := scan(false, "./src/generated/", "/Docs/Internal"); !reflect.DeepEqual(got, []string{"kept", "other generated"}) {
+		t.Errorf("paths with ./ and slashes = %v", got)
+	}
+	if got, _ := scan(false, "docs/internal"); len(got) != 5 {
+		t.Errorf("path entries should be case-sensitive by default, got %v", got)
+	}
+	if got, _ := scan(true, "docs/internal"); !reflect.DeepEqual(got, []string{"deep", "generated", "kept", "other generated"}) {
+		t.Errorf("case-insensitive path entry = %v", got)
+	}
+}
+
 func TestScanFileWithReader_OpenError(t *testing.T) {
 	mock := mockFileReader{files: map[string]string{}}
-	if _, err := scanFileWithReader("nope.go", mock); err == nil {
+	if _, _, err := scanFileWithReader("nope.go", mock, DefaultScanOptions()); err == nil {
 		t.Fatal("expected error for missing file")
 	}
 }
@@ -288,3 +514,443 @@ func TestFindRepoRoot_ClimbsToGitDir(t *testing.T) {
 		t.Fatalf("findRepoRoot: got %q want %q", got, want)
 	}
 }
+
+func TestRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "repo/.git/HEAD", "ref: refs/heads/main\n")
+	sub := filepath.Join(root, "repo", "pkg", "deep")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := RepoRoot(sub); !ok || got != filepath.Join(root, "repo") {
+		t.Fatalf("RepoRoot = %q, %v", got, ok)
+	}
+	if _, ok := RepoRoot(root); ok {
+		t.Fatal("RepoRoot found a repository above a plain directory")
+	}
+}
+
+// --- inline ignore directive tests ---
+
+func TestScanFileWithReader_IgnoreDirective_SameLine(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{
+		"a.go": "// TODO: keep\nx := \"TODO\" // todototum:ignore\n// FIXME: keep too\n",
+	}}
+	todos, _, err := scanFileWithReader("a.go", mock, DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(todos) != 2 || todos[0].Line != 1 || todos[1].Line != 3 {
+		t.Fatalf("expected lines 1 and 3 only, got %#v", todos)
+	}
+}
+
+func TestScanFileWithReader_IgnoreDirective_PreviousLine(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{
+		"a.go": "// todototum:ignore\n// TODO: silenced\n// TODO: reported\n",
+	}}
+	todos, _, err := scanFileWithReader("a.go", mock, DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(todos) != 1 || todos[0].Line != 3 {
+		t.Fatalf("expected only line 3, got %#v", todos)
+	}
+}
+
+func TestScanFileWithReader_IgnoreDirective_Disabled(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{
+		"a.go": "// todototum:ignore\n// TODO: one\n// TODO: two // todototum:ignore\n",
+	}}
+	opts := DefaultScanOptions()
+	opts.RespectIgnoreComments = false
+	todos, _, err := scanFileWithReader("a.go", mock, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(todos) != 2 {
+		t.Fatalf("expected directives to be ignored when disabled, got %#v", todos)
+	}
+}
+
+// --- audit tests ---
+
+func TestScanDirDetailed_AuditRecordsGitIgnoreRuleLine(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "# build output\n*.tmp\nnode_modules/\n")
+	mustWriteFile(t, root, "node_modules/lib/a.go", "// TODO: ignored\n")
+	mustWriteFile(t, root, "x.tmp", "// TODO: ignored\n")
+	mustWriteFile(t, root, "vendor/v.go", "// TODO: ignored\n")
+	mustWriteFile(t, root, "src/b.go", "// TODO: kept\n")
+
+	opts := DefaultScanOptions()
+	opts.IgnoreDirs = []string{"vendor"}
+	opts.Audit = true
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed error: %v", err)
+	}
+	if len(res.Items) != 1 {
+		t.Fatalf("expected 1 item, got %#v", res.Items)
+	}
+	if res.Audit == nil {
+		t.Fatal("expected audit log when auditing is on")
+	}
+	want := map[string]SkipRecord{
+		"node_modules": {Path: "node_modules", Dir: true, Reason: SkipReasonGitIgnore, Rule: ".gitignore:3: node_modules/"},
+		"x.tmp":        {Path: "x.tmp", Reason: SkipReasonGitIgnore, Rule: ".gitignore:2: *.tmp"},
+		"vendor":       {Path: "vendor", Dir: true, Reason: SkipReasonIgnoreFlag, Rule: "--ignore vendor"},
+		".git":         {Path: ".git", Dir: true, Reason: SkipReasonVCS, Rule: ".git"},
+	}
+	for _, e := range res.Audit.Entries {
+		w, ok := want[e.Path]
+		if !ok {
+			t.Fatalf("unexpected audit entry: %#v", e)
+		}
+		if e != w {
+			t.Fatalf("audit entry mismatch: got %#v want %#v", e, w)
+		}
+		delete(want, e.Path)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing audit entries: %#v", want)
+	}
+	if res.Audit.Truncated {
+		t.Fatal("audit should not be truncated below the limit")
+	}
+}
+
+func TestScanDirDetailed_AuditCapAndTruncation(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "*.tmp\n")
+	for _, n := range []string{"a.tmp", "b.tmp", "c.tmp", "d.tmp"} {
+		mustWriteFile(t, root, n, "x\n")
+	}
+	opts := DefaultScanOptions()
+	opts.Audit = true
+	opts.AuditLimit = 2
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed error: %v", err)
+	}
+	if len(res.Audit.Entries) != 2 || res.Audit.Limit != 2 || !res.Audit.Truncated {
+		t.Fatalf("expected 2 entries with truncated marker, got %#v", res.Audit)
+	}
+}
+
+func TestScanDirDetailed_NoAuditByDefault(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "*.tmp\n")
+	mustWriteFile(t, root, "a.tmp", "x\n")
+	res, err := ScanDirDetailed(root, DefaultScanOptions(), OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed error: %v", err)
+	}
+	if res.Audit != nil {
+		t.Fatalf("audit log should be nil when auditing is off")
+	}
+}
+
+// --- block suppression tests ---
+
+func TestScanFileWithReader_DisableEnableRegions(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		lines   []int
+	}{
+		{
+			name:    "simple region",
+			content: "// TODO: a\n// todototum:disable\n// TODO: b\n// todototum:enable\n// TODO: c\n",
+			lines:   []int{1, 5},
+		},
+		{
+			name:    "nested regions need matching enables",
+			content: "// todototum:disable\n// todototum:disable\n// TODO: a\n// todototum:enable\n// TODO: b\n// todototum:enable\n// TODO: c\n",
+			lines:   []int{7},
+		},
+		{
+			name:    "stray enable is harmless",
+			content: "// todototum:enable\n// TODO: a\n// todototum:disable\n// TODO: b\n// todototum:enable\n// todototum:enable\n// TODO: c\n",
+			lines:   []int{2, 7},
+		},
+		{
+			name:    "overlapping ignore directive inside region",
+			content: "// todototum:disable\n// todototum:ignore\n// todototum:enable\n// TODO: a\n",
+			lines:   []int{4},
+		},
+		{
+			name:    "unterminated disable extends to EOF",
+			content: "// TODO: a\n// todototum:disable\n// TODO: b\n\n// FIXME: c",
+			lines:   []int{1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mock := mockFileReader{files: map[string]string{"a.go": c.content}}
+			todos, _, err := scanFileWithReader("a.go", mock, DefaultScanOptions())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []int
+			for _, td := range todos {
+				got = append(got, td.Line)
+			}
+			if len(got) != len(c.lines) {
+				t.Fatalf("got lines %v, want %v", got, c.lines)
+			}
+			for i := range got {
+				if got[i] != c.lines[i] {
+					t.Fatalf("got lines %v, want %v", got, c.lines)
+				}
+			}
+		})
+	}
+}
+
+func TestScanFileWithReader_DisableIgnoredWhenDirectivesOff(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{"a.go": "// todototum:disable\n// TODO: a\n"}}
+	opts := DefaultScanOptions()
+	opts.RespectIgnoreComments = false
+	todos, _, err := scanFileWithReader("a.go", mock, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(todos) != 1 {
+		t.Fatalf("expected region to be ignored when directives are off, got %#v", todos)
+	}
+}
+
+// --- location tests ---
+
+func TestScanFileWithReader_RecordsColumnAndLocation(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{"a.go": "x := 1 // TODO: check\n\t// FIXME: tabbed\nπ // NOTE: runes\n"}}
+	todos, _, err := scanFileWithReader("a.go", mock, DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Location{{File: "a.go", Line: 1, Column: 11}, {File: "a.go", Line: 2, Column: 5}, {File: "a.go", Line: 3, Column: 6}}
+	if len(todos) != len(want) {
+		t.Fatalf("expected %d todos, got %#v", len(want), todos)
+	}
+	for i, w := range want {
+		if got := todos[i].Location; got != w {
+			t.Fatalf("location %d: got %#v want %#v", i, got, w)
+		}
+	}
+	b, err := json.Marshal(todos[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), `{"File":"a.go","Line":1,"Column":11,"Tag":"TODO"`) {
+		t.Errorf("embedded location changed the JSON shape: %s", b)
+	}
+}
+
+// --- stats tests ---
+
+func TestScanDirDetailed_Stats(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "*.tmp\n")
+	mustWriteFile(t, root, "a.go", "// TODO: a\n")
+	mustWriteFile(t, root, "b.go", "package b\n")
+	mustWriteFile(t, root, "c.tmp", "// TODO: ignored\n")
+	mustWriteFile(t, root, "vendor/v.go", "// TODO: ignored\n")
+
+	opts := DefaultScanOptions()
+	opts.IgnoreDirs = []string{"vendor"}
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed error: %v", err)
+	}
+	// walked: a.go, b.go, c.tmp, .gitignore; opened: all but c.tmp;
+	// skipped: .git, vendor, c.tmp
+	if res.Stats.Walked != 4 || res.Stats.Opened != 3 || res.Stats.Skipped != 3 {
+		t.Fatalf("unexpected stats: %+v", res.Stats)
+	}
+	// one line each in a.go, b.go and .gitignore
+	if res.Stats.Lines != 3 {
+		t.Fatalf("lines = %d, want 3", res.Stats.Lines)
+	}
+	if res.Stats.Duration <= 0 {
+		t.Fatalf("expected a positive duration, got %v", res.Stats.Duration)
+	}
+}
+
+func TestScanDirDetailed_StatsCountFailedOpens(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "known.go", "x")
+	mustWriteFile(t, root, "unknown.go", "x")
+	mock := mockFileReader{files: map[string]string{"known.go": "// TODO: a"}}
+	res, err := ScanDirDetailed(root, DefaultScanOptions(), mock)
+	if err != nil {
+		t.Fatalf("ScanDirDetailed error: %v", err)
+	}
+	if res.Stats.Walked != 2 || res.Stats.Opened != 1 {
+		t.Fatalf("unexpected stats: %+v", res.Stats)
+	}
+}
+
+func TestScanDir_CustomTags(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "main.go", "// hack: workaround\n// TODO: later\n// XXX: tricky\n")
+
+	opts := DefaultScanOptions()
+	opts.Tags = []string{"HACK", "XXX"}
+	items, err := ScanDirWithOptions(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("want 2 items, got %+v", items)
+	}
+	tagsFound := map[string]string{}
+	for _, it := range items {
+		tagsFound[it.Tag] = it.Text
+	}
+	if tagsFound["HACK"] != "workaround" || tagsFound["XXX"] != "tricky" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	opts.Tags = []string{"NOT A TAG"}
+	if _, err := ScanDirWithOptions(root, opts, OSFileReader{}); err == nil {
+		t.Fatalf("expected invalid tag error")
+	}
+}
+
+func TestNormalizeSeparator_IdenticalJSONAcrossSeparators(t *testing.T) {
+	render := func(items []Todo) string {
+		var buf bytes.Buffer
+		if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		return buf.String()
+	}
+	windows := []Todo{{Location: Location{File: `pkg\sub\a.go`, Line: 1}, Tag: "TODO", Text: "x"}, {Location: Location{File: `main.go`, Line: 2}, Tag: "BUG", Text: "y"}}
+	unix := []Todo{{Location: Location{File: "pkg/sub/a.go", Line: 1}, Tag: "TODO", Text: "x"}, {Location: Location{File: "main.go", Line: 2}, Tag: "BUG", Text: "y"}}
+	for i := range windows {
+		windows[i].File = normalizeSeparator(windows[i].File, '\\')
+		unix[i].File = normalizeSeparator(unix[i].File, '/')
+	}
+	if a, b := render(windows), render(unix); a != b {
+		t.Fatalf("JSON differs across separators:\n%s\nvs\n%s", a, b)
+	}
+}
+
+func TestScanDir_NormalizePathsInResults(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, filepath.Join("pkg", "sub", "a.go"), "// TODO: nested\n")
+	items, err := ScanDirWithOptions(root, DefaultScanOptions(), OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(items) != 1 || items[0].File != "pkg/sub/a.go" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+}
+
+func TestScanFileWithReader_RawLineOnlyWhenRequested(t *testing.T) {
+	mock := mockFileReader{files: map[string]string{"a.go": "\tif ok { retry() } // TODO: back off\n"}}
+	todos, _, err := scanFileWithReader("a.go", mock, DefaultScanOptions())
+	if err != nil || len(todos) != 1 {
+		t.Fatalf("unexpected result: %v %#v", err, todos)
+	}
+	if todos[0].RawLine != "" {
+		t.Fatalf("RawLine should be empty by default, got %q", todos[0].RawLine)
+	}
+
+	opts := DefaultScanOptions()
+	opts.IncludeRawLine = true
+	todos, _, err = scanFileWithReader("a.go", mock, opts)
+	if err != nil || len(todos) != 1 {
+		t.Fatalf("unexpected result: %v %#v", err, todos)
+	}
+	if want := "\tif ok { retry() } // TODO: back off"; todos[0].RawLine != want {
+		t.Fatalf("RawLine = %q, want %q", todos[0].RawLine, want)
+	}
+}
+
+func scannedFiles(t *testing.T, root string, opts ScanOptions) []string {
+	t.Helper()
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed: %v", err)
+	}
+	files := make([]string, 0, len(res.Items))
+	for _, it := range res.Items {
+		files = append(files, it.File)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestScanDir_WorktreeGitFileMarksRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, ".git", "gitdir: /elsewhere/.git/worktrees/wt\n")
+	mustWriteFile(t, root, ".gitignore", "build/\n")
+	mustWriteFile(t, root, "build/out.go", "// TODO: ignored\n")
+	mustWriteFile(t, root, "src/a.go", "// TODO: kept\n")
+
+	// Scanning a subdirectory still finds the worktree root and its rules.
+	if got := findRepoRoot(filepath.Join(root, "src")); got != root {
+		t.Fatalf("findRepoRoot = %q, want %q", got, root)
+	}
+	if got := scannedFiles(t, root, DefaultScanOptions()); !reflect.DeepEqual(got, []string{"src/a.go"}) {
+		t.Fatalf("files = %v", got)
+	}
+	// A .git file without a gitdir line is not a repository marker.
+	other := t.TempDir()
+	mustWriteFile(t, other, ".git", "not a pointer\n")
+	if isRepoRoot(other) {
+		t.Fatalf("plain .git file should not mark a repo root")
+	}
+}
+
+func TestScanDir_NestedRepoUsesOwnIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	makeGitRepo(t, root, "*.tmp.go\n")
+	mustWriteFile(t, root, "a.tmp.go", "// TODO: ignored by parent\n")
+	mustWriteFile(t, root, "main.go", "// TODO: parent\n")
+	// A submodule: its own .git file and .gitignore; the parent's rules do
+	// not apply inside it.
+	mustWriteFile(t, root, "libs/sub/.git", "gitdir: ../../.git/modules/sub\n")
+	mustWriteFile(t, root, "libs/sub/.gitignore", "gen/\n")
+	mustWriteFile(t, root, "libs/sub/b.tmp.go", "// TODO: kept in submodule\n")
+	mustWriteFile(t, root, "libs/sub/gen/c.go", "// TODO: ignored by submodule\n")
+	// The submodule's rules end at its boundary.
+	mustWriteFile(t, root, "libs/gen/d.go", "// TODO: outside submodule\n")
+
+	got := scannedFiles(t, root, DefaultScanOptions())
+	want := []string{"libs/gen/d.go", "libs/sub/b.tmp.go", "main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+
+	opts := DefaultScanOptions()
+	opts.SkipSubmodules = true
+	opts.Audit = true
+	res, err := ScanDirDetailed(root, opts, OSFileReader{})
+	if err != nil {
+		t.Fatalf("ScanDirDetailed: %v", err)
+	}
+	for _, it := range res.Items {
+		if strings.HasPrefix(it.File, "libs/sub/") {
+			t.Fatalf("submodule item reported with SkipSubmodules: %+v", it)
+		}
+	}
+	found := false
+	for _, e := range res.Audit.Entries {
+		if e.Path == "libs/sub" && e.Reason == SkipReasonSubmodule {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected submodule audit entry, got %+v", res.Audit.Entries)
+	}
+}
+
+func TestScanDirDetailed_MissingRoot(t *testing.T) {
+	if _, err := ScanDirDetailed(filepath.Join(t.TempDir(), "missing"), DefaultScanOptions(), OSFileReader{}); err == nil {
+		t.Fatal("expected an error for a missing root")
+	}
+}
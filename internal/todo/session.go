@@ -0,0 +1,204 @@
+package todo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ScanSession shares one scan of a tree between callers that need the same
+// data moments apart, such as a report written in several formats. It keeps
+// the last result with the tree signature it was scanned at, and rescans only
+// when the signature changed or Invalidate was called. A session is bound to
+// the root, options and reader it was created with; it is safe for concurrent
+// use.
+type ScanSession struct {
+	root   string
+	opts   ScanOptions
+	reader FileReader
+	// onScan, when set, is passed each fresh scan; see ProtocolServer.OnScan.
+	onScan func(ScanResult)
+
+	mu     sync.Mutex
+	cached *ScanResult
+	sig    string
+	// gen counts Invalidate calls; a scan started before one is returned to
+	// its callers but not cached.
+	gen  int
+	call *sessionCall
+}
+
+// sessionCall is a signature check, and rescan if needed, in progress.
+// Callers arriving meanwhile wait on done instead of starting their own.
+type sessionCall struct {
+	done chan struct{}
+	res  ScanResult
+	err  error
+}
+
+// NewScanSession returns a session scanning root with opts through reader.
+// Nothing is scanned until the first Get.
+func NewScanSession(root string, opts ScanOptions, reader FileReader) *ScanSession {
+	return &ScanSession{root: root, opts: opts, reader: reader}
+}
+
+// Get returns the scan of the tree: the cached result when the tree
+// signature is unchanged since it was scanned, a fresh scan otherwise.
+// Concurrent calls share a single check and scan. ctx only bounds the wait
+// of this caller; a scan others are waiting for keeps running. Each caller
+// gets its own copy of the item and problem slices.
+func (s *ScanSession) Get(ctx context.Context) (ScanResult, error) {
+	s.mu.Lock()
+	c := s.call
+	if c == nil {
+		c = &sessionCall{done: make(chan struct{})}
+		s.call = c
+		go s.refresh(c, s.gen)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ScanResult{}, ctx.Err()
+	}
+	if c.err != nil {
+		return ScanResult{}, c.err
+	}
+	res := c.res
+	res.Items = slices.Clone(res.Items)
+	res.Problems = slices.Clone(res.Problems)
+	return res, nil
+}
+
+// Invalidate drops the cached result, so the next Get rescans even when the
+// tree looks unchanged. A scan already in progress still completes for its
+// callers but is not cached.
+func (s *ScanSession) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached = nil
+	s.sig = ""
+	s.gen++
+}
+
+// RefreshOn forces a rescan each time a signal arrives on signals, e.g.
+// SIGHUP from signal.Notify, until ctx is done. Each rescan is passed to
+// report when it completes, on RefreshOn's goroutine. Signals arriving
+// while a rescan is in flight are dropped rather than queued. RefreshOn
+// returns once ctx is done and no rescan it started is still being waited
+// for; report is not called for a rescan cut short by ctx.
+func (s *ScanSession) RefreshOn(ctx context.Context, signals <-chan os.Signal, report func(ScanResult, error)) {
+	type outcome struct {
+		res ScanResult
+		err error
+	}
+	done := make(chan outcome, 1)
+	busy := false
+	for {
+		select {
+		case <-ctx.Done():
+			if busy {
+				<-done
+			}
+			return
+		case <-signals:
+			if busy {
+				continue
+			}
+			busy = true
+			s.Invalidate()
+			go func() {
+				res, err := s.Get(ctx)
+				done <- outcome{res, err}
+			}()
+		case out := <-done:
+			busy = false
+			if ctx.Err() == nil {
+				report(out.res, out.err)
+			}
+		}
+	}
+}
+
+// CycleLine summarizes one rescan of a long-running session in a line, for
+// printing per cycle instead of a full summary, e.g.
+// "[+12m5s] re-scanned 1204 files in 120ms, 42 todos": the time since the
+// session started, the files read, how long the scan took and the items
+// found.
+func CycleLine(res ScanResult, sinceStart time.Duration) string {
+	return fmt.Sprintf("[+%s] re-scanned %d files in %s, %d todos",
+		sinceStart.Round(time.Second), res.Stats.Opened, res.Stats.Duration.Round(time.Millisecond), len(res.Items))
+}
+
+// refresh completes c: it reuses the cached result when the tree signature
+// matches and rescans otherwise. gen is the Invalidate count when c began.
+func (s *ScanSession) refresh(c *sessionCall, gen int) {
+	defer close(c.done)
+	sig, err := TreeSignature(s.root, s.opts)
+
+	s.mu.Lock()
+	if err == nil && s.cached != nil && s.sig == sig && s.gen == gen {
+		c.res = *s.cached
+		s.call = nil
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	var res ScanResult
+	if err == nil {
+		res, err = ScanDirDetailed(s.root, s.opts, s.reader)
+	}
+
+	s.mu.Lock()
+	s.call = nil
+	c.res, c.err = res, err
+	if err == nil && s.gen == gen {
+		s.cached, s.sig = &res, sig
+	}
+	s.mu.Unlock()
+	if err == nil && s.onScan != nil {
+		s.onScan(res)
+	}
+}
+
+// TreeSignature summarizes the files a scan of root with opts would read:
+// their paths, sizes and modification times, hashed with the matching
+// settings of opts (see ScanOptions.MatchKey). Adding, removing or editing
+// a file changes it, as does scanning for other tags. Only directories are
+// listed and files stat'ed; none is opened.
+func TreeSignature(root string, opts ScanOptions) (string, error) {
+	only, err := ParseAllowlist(opts.Only)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", opts.MatchKey())
+	var statErr error
+	_, err = walkFiles(root, opts, only, nil, func(SkipRecord) {}, func(_, relPath, _ string, d fs.DirEntry) {
+		info, err := d.Info()
+		if err != nil {
+			// Gone since it was listed; the scan would not find it either.
+			if statErr == nil && !errors.Is(err, fs.ErrNotExist) {
+				statErr = err
+			}
+			return
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", normalizePath(relPath), info.Size(), info.ModTime().UnixNano())
+	})
+	if err == nil {
+		err = statErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,284 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// gatedReader counts opens and, when release is set, holds every open until
+// release is closed, signalling entered first.
+type gatedReader struct {
+	root    string
+	opens   atomic.Int64
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (r *gatedReader) Open(name string) (io.ReadCloser, error) {
+	r.opens.Add(1)
+	if r.release != nil {
+		select {
+		case r.entered <- struct{}{}:
+		default:
+		}
+		<-r.release
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(r.root, name)
+	}
+	return os.Open(name)
+}
+
+func TestScanSession_CoalescesConcurrentGets(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: once\n")
+	r := &gatedReader{root: root, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	s := NewScanSession(root, DefaultScanOptions(), r)
+
+	const callers = 8
+	results := make([]ScanResult, callers)
+	errs := make([]error, callers)
+	var started, done sync.WaitGroup
+	get := func(i int) {
+		defer done.Done()
+		started.Done()
+		results[i], errs[i] = s.Get(context.Background())
+	}
+	started.Add(1)
+	done.Add(1)
+	go get(0)
+	<-r.entered // the first scan is now blocked in the reader
+	started.Add(callers - 1)
+	done.Add(callers - 1)
+	for i := 1; i < callers; i++ {
+		go get(i)
+	}
+	started.Wait()
+	close(r.release)
+	done.Wait()
+
+	for i := range callers {
+		if errs[i] != nil {
+			t.Fatalf("Get %d: %v", i, errs[i])
+		}
+		if len(results[i].Items) != 1 || results[i].Items[0].Text != "once" {
+			t.Fatalf("Get %d items = %+v", i, results[i].Items)
+		}
+	}
+	if n := r.opens.Load(); n != 1 {
+		t.Fatalf("file opened %d times, want one shared scan", n)
+	}
+	// Callers get their own slices.
+	results[0].Items[0].Text = "changed"
+	if results[1].Items[0].Text != "once" {
+		t.Fatal("results share their item slice")
+	}
+}
+
+func TestScanSession_RescansWhenTreeChanges(t *testing.T) {
+	root := t.TempDir()
+	p := mustWriteFile(t, root, "a.go", "// TODO: first\n")
+	r := &gatedReader{root: root}
+	s := NewScanSession(root, DefaultScanOptions(), r)
+	ctx := context.Background()
+
+	for range 2 {
+		if res, err := s.Get(ctx); err != nil || len(res.Items) != 1 {
+			t.Fatalf("Get = %+v, %v", res.Items, err)
+		}
+	}
+	if n := r.opens.Load(); n != 1 {
+		t.Fatalf("unchanged tree opened %d times, want the cached scan", n)
+	}
+
+	if err := os.WriteFile(p, []byte("// TODO: first\n// FIXME: second\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Coarse filesystem clocks may not move between the two writes.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(p, later, later); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Get(ctx)
+	if err != nil || len(res.Items) != 2 {
+		t.Fatalf("after edit Get = %+v, %v", res.Items, err)
+	}
+	if n := r.opens.Load(); n != 2 {
+		t.Fatalf("opens = %d, want a rescan after the edit", n)
+	}
+
+	mustWriteFile(t, root, "b.go", "// NOTE: new file\n")
+	if res, err := s.Get(ctx); err != nil || len(res.Items) != 3 {
+		t.Fatalf("after adding a file Get = %+v, %v", res.Items, err)
+	}
+}
+
+func TestScanSession_Invalidate(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: x\n")
+	r := &gatedReader{root: root}
+	s := NewScanSession(root, DefaultScanOptions(), r)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Invalidate()
+	if _, err := s.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := r.opens.Load(); n != 2 {
+		t.Fatalf("opens = %d, want a rescan after Invalidate", n)
+	}
+	if _, err := s.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := r.opens.Load(); n != 2 {
+		t.Fatalf("opens = %d, want the rescan to be cached", n)
+	}
+}
+
+func TestScanSession_GetHonorsContext(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: slow\n")
+	r := &gatedReader{root: root, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	s := NewScanSession(root, DefaultScanOptions(), r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := s.Get(ctx)
+		errc <- err
+	}()
+	<-r.entered
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// The abandoned scan still completes and serves the next caller.
+	close(r.release)
+	res, err := s.Get(context.Background())
+	if err != nil || len(res.Items) != 1 {
+		t.Fatalf("Get = %+v, %v", res.Items, err)
+	}
+}
+
+func TestTreeSignature_IgnoresSkippedDirs(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: x\n")
+	opts := DefaultScanOptions()
+	opts.IgnoreDirs = []string{"vendor"}
+	before, err := TreeSignature(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustWriteFile(t, root, "vendor/lib.go", "// TODO: ignored\n")
+	if after, err := TreeSignature(root, opts); err != nil || after != before {
+		t.Fatalf("signature changed for an ignored dir: %s -> %s (%v)", before, after, err)
+	}
+	mustWriteFile(t, root, "b.go", "")
+	if after, _ := TreeSignature(root, opts); after == before {
+		t.Fatal("signature did not change for a new file")
+	}
+}
+
+func TestScanSession_RefreshOn(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: x\n// BUG: y\n")
+	r := &gatedReader{root: root, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	s := NewScanSession(root, DefaultScanOptions(), r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal) // unbuffered: a send returns once RefreshOn took it
+	reports := make(chan int, 10)
+	stopped := make(chan struct{})
+	go func() {
+		s.RefreshOn(ctx, signals, func(res ScanResult, err error) {
+			if err != nil {
+				t.Errorf("rescan: %v", err)
+			}
+			reports <- len(res.Items)
+		})
+		close(stopped)
+	}()
+
+	signals <- syscall.SIGHUP
+	<-r.entered
+	// Repeated signals during the rescan are dropped.
+	for range 3 {
+		signals <- syscall.SIGHUP
+	}
+	close(r.release)
+	if n := <-reports; n != 2 {
+		t.Fatalf("report got %d items, want 2", n)
+	}
+	signals <- syscall.SIGHUP
+	if n := <-reports; n != 2 {
+		t.Fatalf("second report got %d items, want 2", n)
+	}
+	if n := r.opens.Load(); n != 2 {
+		t.Fatalf("opens = %d, want one rescan per accepted signal", n)
+	}
+
+	cancel()
+	<-stopped
+	select {
+	case n := <-reports:
+		t.Fatalf("unexpected extra report of %d items", n)
+	default:
+	}
+}
+
+func TestScanSession_RefreshOnShutdownMidRescan(t *testing.T) {
+	before := runtime.NumGoroutine()
+	root := t.TempDir()
+	mustWriteFile(t, root, "a.go", "// TODO: x\n")
+	r := &gatedReader{root: root, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	s := NewScanSession(root, DefaultScanOptions(), r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal)
+	stopped := make(chan struct{})
+	go func() {
+		s.RefreshOn(ctx, signals, func(ScanResult, error) { t.Error("report called after shutdown") })
+		close(stopped)
+	}()
+	signals <- syscall.SIGHUP
+	<-r.entered
+	cancel()
+	<-stopped
+
+	// The scan itself cannot be interrupted; once it finishes nothing of
+	// the session may be left running.
+	close(r.release)
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines: %d before, %d after shutdown", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCycleLine(t *testing.T) {
+	res := ScanResult{
+		Items: make([]Todo, 42),
+		Stats: ScanStats{Opened: 1204, Duration: 120*time.Millisecond + 400*time.Microsecond},
+	}
+	if got, want := CycleLine(res, 12*time.Minute+5*time.Second+300*time.Millisecond), "[+12m5s] re-scanned 1204 files in 120ms, 42 todos"; got != want {
+		t.Errorf("CycleLine = %q, want %q", got, want)
+	}
+	if got, want := CycleLine(ScanResult{}, 0), "[+0s] re-scanned 0 files in 0s, 0 todos"; got != want {
+		t.Errorf("CycleLine = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LineDrift is how many lines FindAtLine and Locate look above and below a
+// line for the item meant, so a location taken from an older revision still
+// resolves after small edits.
+const LineDrift = 5
+
+// MinFingerprintPrefix is the shortest prefix FindByFingerprint accepts, as
+// with git's short hashes.
+const MinFingerprintPrefix = 4
+
+// ErrNoItem is returned when no item matches a lookup.
+var ErrNoItem = errors.New("no matching item")
+
+// AmbiguousError is returned by FindByFingerprint when a prefix matches more
+// than one item.
+type AmbiguousError struct {
+	Prefix     string
+	Candidates []Todo
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "fingerprint prefix %q is ambiguous; candidates:", e.Prefix)
+	for _, t := range e.Candidates {
+		text := t.Text
+		if !strings.HasPrefix(text, t.Tag+":") {
+			// Items of JSON reports already carry the tag in their text.
+			text = taggedText(t)
+		}
+		fmt.Fprintf(&b, "\n  %s %s:%d %s", fingerprint(t), t.File, t.Line, text)
+	}
+	return b.String()
+}
+
+// FindByFingerprint returns the item of items whose fingerprint starts with
+// prefix, ignoring case. Like a short git hash, the prefix needs at least
+// MinFingerprintPrefix characters and must match a single item; otherwise an
+// *AmbiguousError lists the candidates.
+func FindByFingerprint(items []Todo, prefix string) (Todo, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < MinFingerprintPrefix {
+		return Todo{}, fmt.Errorf("fingerprint prefix %q is too short; give at least %d characters", prefix, MinFingerprintPrefix)
+	}
+	var found []Todo
+	for _, t := range items {
+		if strings.HasPrefix(fingerprint(t), prefix) {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Todo{}, fmt.Errorf("%w: fingerprint %s", ErrNoItem, prefix)
+	case 1:
+		return found[0], nil
+	}
+	return Todo{}, &AmbiguousError{Prefix: prefix, Candidates: found}
+}
+
+// FindAtLine returns the item of items at file:line, or else the nearest
+// item of file within LineDrift lines, the earlier one on a tie.
+func FindAtLine(items []Todo, file string, line int) (Todo, error) {
+	file = normalizePath(filepath.Clean(file))
+	best, bestDist := -1, LineDrift+1
+	for i, t := range items {
+		if normalizePath(filepath.Clean(t.File)) != file {
+			continue
+		}
+		d := abs(t.Line - line)
+		if d < bestDist || (d == bestDist && best >= 0 && t.Line < items[best].Line) {
+			best, bestDist = i, d
+		}
+	}
+	if best < 0 {
+		return Todo{}, fmt.Errorf("%w within %d lines of %s:%d", ErrNoItem, LineDrift, file, line)
+	}
+	return items[best], nil
+}
+
+// Locate finds t, e.g. an item of an older report, in current, a fresh scan
+// of its file: it returns the item with t's fingerprint nearest to t.Line,
+// within LineDrift lines. It reports false when the item moved further or
+// changed.
+func Locate(current []Todo, t Todo) (Todo, bool) {
+	want := fingerprint(t)
+	found, dist := -1, LineDrift+1
+	for i, c := range current {
+		if d := abs(c.Line - t.Line); d < dist && fingerprint(c) == want {
+			found, dist = i, d
+		}
+	}
+	if found < 0 {
+		return Todo{}, false
+	}
+	return current[found], true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Permalink returns the link to line of file, a slash-separated path from
+// the repository root, at commit of the repository browsed at repoURL, in
+// the /blob/<commit>/<path>#L<line> form GitHub, Gitea and GitLab accept.
+func Permalink(repoURL, commit, file string, line int) string {
+	segs := strings.Split(strings.TrimPrefix(normalizePath(file), "/"), "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.TrimSuffix(repoURL, "/") + "/blob/" + url.PathEscape(commit) + "/" + strings.Join(segs, "/") + "#L" + strconv.Itoa(line)
+}
+
+// SnippetOptions configures RenderSnippet.
+type SnippetOptions struct {
+	// Context is how many lines are shown above and below the item.
+	Context int
+	// Permalink links the item's location, e.g. from Permalink; without it
+	// the location is shown as code.
+	Permalink string
+}
+
+// RenderSnippet renders t as Markdown ready to paste into a review comment:
+// its tag in bold with its emoji, its text, its location and a fenced block
+// of src, the content of t.File, around t.Line with a comment arrow after
+// the item's line. The fence is longer than any run of backticks in the
+// block, so code containing fences cannot end it early.
+func RenderSnippet(t Todo, src []byte, opts SnippetOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s **%s** %s\n\n", StyleFor(t.Tag).Emoji, EscapeMarkdown(t.Tag), EscapeMarkdown(t.Text))
+	loc := fmt.Sprintf("%s:%d", normalizePath(t.File), t.Line)
+	if opts.Permalink != "" {
+		b.WriteString(MarkdownLink(loc, opts.Permalink))
+	} else {
+		b.WriteString(markdownCode(loc))
+	}
+	b.WriteString("\n")
+
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n"), "\n")
+	if t.Line < 1 || t.Line > len(lines) {
+		return b.String()
+	}
+	from, to := max(t.Line-opts.Context, 1), min(t.Line+opts.Context, len(lines))
+	block := make([]string, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		line := lines[n-1]
+		if n == t.Line {
+			line += "  " + arrowComment(t.File)
+		}
+		block = append(block, line)
+	}
+	body := strings.Join(block, "\n")
+	fence := strings.Repeat("`", max(3, longestRun(body, '`')+1))
+	lang := strings.TrimPrefix(strings.ToLower(filepath.Ext(t.File)), ".")
+	fmt.Fprintf(&b, "\n%s%s\n%s\n%s\n", fence, lang, body, fence)
+	return b.String()
+}
+
+// arrowComment returns an arrow written as a comment in the language of
+// file, or a bare arrow when its comment syntax is unknown.
+func arrowComment(file string) string {
+	intros := commentSyntax[strings.ToLower(filepath.Ext(file))]
+	if len(intros) == 0 {
+		return "<--"
+	}
+	switch intros[0] {
+	case "/*":
+		return "/* <-- */"
+	case "<!--":
+		return "<!-- <-- -->"
+	}
+	return intros[0] + " <--"
+}
+
+// longestRun returns the length of the longest run of r in s.
+func longestRun(s string, r rune) int {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c != r {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return longest
+}
@@ -0,0 +1,152 @@
+package todo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFindByFingerprint(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 1}, Tag: "TODO", Text: "one", Fingerprint: "abc123000000"},
+		{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "two", Fingerprint: "abc124000000"},
+		{Location: Location{File: "b.go", Line: 3}, Tag: "FIXME", Text: "three", Fingerprint: "def000000000"},
+	}
+	got, err := FindByFingerprint(items, "ABC123")
+	if err != nil || got.Text != "one" {
+		t.Fatalf("FindByFingerprint(ABC123) = %+v, %v", got, err)
+	}
+	if got, err := FindByFingerprint(items, "def000000000"); err != nil || got.Text != "three" {
+		t.Fatalf("full fingerprint = %+v, %v", got, err)
+	}
+
+	_, err = FindByFingerprint(items, "abc1")
+	var amb *AmbiguousError
+	if !errors.As(err, &amb) || len(amb.Candidates) != 2 {
+		t.Fatalf("err = %v, want an ambiguity between two items", err)
+	}
+	want := "fingerprint prefix \"abc1\" is ambiguous; candidates:\n  abc123000000 a.go:1 TODO: one\n  abc124000000 a.go:2 TODO: two"
+	if err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+
+	if _, err := FindByFingerprint(items, "abc"); err == nil || !strings.Contains(err.Error(), "too short") {
+		t.Errorf("short prefix: err = %v", err)
+	}
+	if _, err := FindByFingerprint(items, "ffff"); !errors.Is(err, ErrNoItem) {
+		t.Errorf("unknown prefix: err = %v, want ErrNoItem", err)
+	}
+
+	// Items read without a fingerprint are matched by the computed one.
+	plain := Todo{Location: Location{File: "c.go", Line: 1}, Tag: "TODO", Text: "computed"}
+	if got, err := FindByFingerprint([]Todo{plain}, Fingerprint(plain)[:6]); err != nil || got.Text != "computed" {
+		t.Errorf("computed fingerprint = %+v, %v", got, err)
+	}
+}
+
+func TestFindAtLine(t *testing.T) {
+	items := []Todo{
+		{Location: Location{File: "a.go", Line: 10}, Text: "ten"},
+		{Location: Location{File: "a.go", Line: 14}, Text: "fourteen"},
+		{Location: Location{File: "b.go", Line: 12}, Text: "other file"},
+	}
+	tests := []struct {
+		file string
+		line int
+		want string
+	}{
+		{"a.go", 10, "ten"},
+		{"./a.go", 14, "fourteen"},
+		{"a.go", 11, "ten"},
+		{"a.go", 12, "ten"}, // a tie goes to the earlier item
+		{"a.go", 13, "fourteen"},
+		{"a.go", 19, "fourteen"},
+		{"a.go", 5, "ten"},
+	}
+	for _, tt := range tests {
+		got, err := FindAtLine(items, tt.file, tt.line)
+		if err != nil || got.Text != tt.want {
+			t.Errorf("FindAtLine(%s:%d) = %q, %v; want %q", tt.file, tt.line, got.Text, err, tt.want)
+		}
+	}
+	for _, line := range []int{4, 20} {
+		if _, err := FindAtLine(items, "a.go", line); !errors.Is(err, ErrNoItem) {
+			t.Errorf("FindAtLine(a.go:%d) err = %v, want ErrNoItem beyond the drift", line, err)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	s, err := NewScanner(DefaultScanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reports keep the fingerprint of the scanned text next to a text
+	// prefixed with the tag.
+	reported := Todo{Location: Location{File: "a.go", Line: 2}, Tag: "TODO", Text: "TODO: move me"}
+	reported.Fingerprint = Fingerprint(Todo{Location: Location{File: "a.go"}, Tag: "TODO", Text: "move me"})
+	tests := []struct {
+		name, src string
+		want      int
+	}{
+		{"unchanged", "x\n// TODO: move me\n", 2},
+		{"shifted down", strings.Repeat("x\n", 5) + "// TODO: move me\n", 6},
+		{"nearest copy wins", "// TODO: move me\nx\nx\n// TODO: move me\n", 1},
+		{"too far", strings.Repeat("x\n", 7) + "// TODO: move me\n", 0},
+		{"edited", "x\n// TODO: moved me\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, err := s.ScanString("a.go", tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := Locate(current, reported); got.Line != tt.want || ok != (tt.want > 0) {
+				t.Errorf("Locate = line %d, %v; want %d", got.Line, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermalink(t *testing.T) {
+	got := Permalink("https://github.com/org/repo/", "abc123", "sub dir/a#b.go", 7)
+	if want := "https://github.com/org/repo/blob/abc123/sub%20dir/a%23b.go#L7"; got != want {
+		t.Errorf("Permalink = %s, want %s", got, want)
+	}
+}
+
+func TestRenderSnippet(t *testing.T) {
+	src := "package a\n\nfunc f() {\n\tx := 1\n\t// FIXME: handle *errors*\n\treturn\n}\n"
+	item := Todo{Location: Location{File: "a.go", Line: 5}, Tag: "FIXME", Text: "handle *errors*"}
+
+	got := RenderSnippet(item, []byte(src), SnippetOptions{Context: 3, Permalink: "https://example.com/blob/abc/a.go#L5"})
+	want := "🔧 **FIXME** handle *errors*\n\n" +
+		"[a.go:5](https://example.com/blob/abc/a.go#L5)\n\n" +
+		"```go\n" +
+		"\n" +
+		"func f() {\n" +
+		"\tx := 1\n" +
+		"\t// FIXME: handle *errors*  // <--\n" +
+		"\treturn\n" +
+		"}\n" +
+		"```\n"
+	if got != want {
+		t.Errorf("RenderSnippet =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderSnippet_FenceLongerThanBackticks(t *testing.T) {
+	src := "Example:\n```sh\nmake\n```\n<!-- TODO: document ``flags`` -->\n"
+	item := Todo{Location: Location{File: "README.md", Line: 5}, Tag: "TODO", Text: "document ``flags`` -->"}
+
+	got := RenderSnippet(item, []byte(src), SnippetOptions{Context: 1})
+	want := "📝 **TODO** document \\`\\`flags\\`\\` --\\>\n\n" +
+		"`README.md:5`\n\n" +
+		"````md\n" +
+		"```\n" +
+		"<!-- TODO: document ``flags`` -->  <--\n" +
+		"````\n"
+	if got != want {
+		t.Errorf("RenderSnippet =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package todo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// GoSymbolEnricher sets Todo.Symbol on items in Go files to the declaration
+// enclosing them (see EnclosingSymbol). Each file with items is parsed once;
+// files that do not parse leave their items without a symbol.
+type GoSymbolEnricher struct {
+	// Dir is the directory item paths are relative to.
+	Dir string
+}
+
+// Name implements namedEnricher.
+func (GoSymbolEnricher) Name() string { return "go-symbols" }
+
+// Enrich implements Enricher.
+func (e GoSymbolEnricher) Enrich(items []Todo) ([]Todo, error) {
+	byFile := make(map[string][]int)
+	for i, t := range items {
+		if strings.EqualFold(filepath.Ext(t.File), ".go") {
+			byFile[t.File] = append(byFile[t.File], i)
+		}
+	}
+	fset := token.NewFileSet()
+	for file, idx := range byFile {
+		f, err := parser.ParseFile(fset, filepath.Join(e.Dir, filepath.FromSlash(file)), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, i := range idx {
+			items[i].Symbol = EnclosingSymbol(fset, f, items[i].Line)
+		}
+	}
+	return items, nil
+}
+
+// EnclosingSymbol describes the top-level declaration of f spanning line,
+// including its doc comment: "handleLogin" for a function,
+// "(*Server).handleLogin" or "Server.String" for a method, "type Server",
+// and "pkg-level var config" or "pkg-level const MaxRetries" for values.
+// Lines in function literals belong to the function around them; lines in a
+// parenthesized block outside any of its specs give "pkg-level const block"
+// and the like. It returns "" for lines outside any declaration, such as
+// the package clause and imports.
+func EnclosingSymbol(fset *token.FileSet, f *ast.File, line int) string {
+	within := func(doc *ast.CommentGroup, from, to token.Pos) bool {
+		if doc != nil {
+			from = doc.Pos()
+		}
+		return fset.Position(from).Line <= line && line <= fset.Position(to).Line
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if within(d.Doc, d.Pos(), d.End()) {
+				return funcSymbol(d)
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT || !within(d.Doc, d.Pos(), d.End()) {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if name, ok := specSymbol(d.Tok, spec, within); ok {
+					return name
+				}
+			}
+			if len(d.Specs) == 1 {
+				name, _ := specSymbol(d.Tok, d.Specs[0], func(*ast.CommentGroup, token.Pos, token.Pos) bool { return true })
+				return name
+			}
+			if d.Tok == token.TYPE {
+				return "type block"
+			}
+			return "pkg-level " + d.Tok.String() + " block"
+		}
+	}
+	return ""
+}
+
+// funcSymbol names a function or method, with the receiver type of a
+// method, e.g. "(*Server).handleLogin".
+func funcSymbol(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	typ := d.Recv.List[0].Type
+	ptr := false
+	if star, ok := typ.(*ast.StarExpr); ok {
+		ptr, typ = true, star.X
+	}
+	// Drop type parameters: List[T] and Map[K, V] are List and Map.
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+	recv := "?"
+	if id, ok := typ.(*ast.Ident); ok {
+		recv = id.Name
+	}
+	if ptr {
+		return "(*" + recv + ")." + d.Name.Name
+	}
+	return recv + "." + d.Name.Name
+}
+
+// specSymbol names spec of a tok declaration when within accepts its lines,
+// doc and trailing comments included.
+func specSymbol(tok token.Token, spec ast.Spec, within func(*ast.CommentGroup, token.Pos, token.Pos) bool) (string, bool) {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		end := s.End()
+		if s.Comment != nil {
+			end = s.Comment.End()
+		}
+		if within(s.Doc, s.Pos(), end) {
+			return "type " + s.Name.Name, true
+		}
+	case *ast.ValueSpec:
+		end := s.End()
+		if s.Comment != nil {
+			end = s.Comment.End()
+		}
+		if within(s.Doc, s.Pos(), end) {
+			names := make([]string, len(s.Names))
+			for i, n := range s.Names {
+				names[i] = n.Name
+			}
+			return "pkg-level " + tok.String() + " " + strings.Join(names, ", "), true
+		}
+	}
+	return "", false
+}
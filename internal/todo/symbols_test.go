@@ -0,0 +1,108 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const symbolsFixture = `package server
+
+// TODO: drop the legacy import
+import "net/http"
+
+// Limits of the login handler.
+const (
+	// TODO: make configurable
+	MaxRetries = 3
+
+	// NOTE: in seconds
+	Timeout = 30 // FIXME: too long
+	// TODO: orphaned block comment
+)
+
+// TODO: read from the environment
+var config = map[string]string{}
+
+// Server serves logins.
+type Server struct {
+	mux *http.ServeMux // TODO: unexport
+}
+
+// handleLogin checks credentials.
+// TODO: rate limit
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	go func() {
+		// FIXME: leaks on shutdown
+	}()
+}
+
+func (s Server) String() string { return "server" } // NOTE: value receiver
+
+type List[T any] struct{ items []T }
+
+func (l *List[T]) Push(v T) {
+	// TODO: grow in chunks
+	l.items = append(l.items, v)
+}
+
+func main() {
+	// BUG: exits too early
+}
+`
+
+func TestGoSymbolEnricher(t *testing.T) {
+	root := t.TempDir()
+	mustWriteFile(t, root, "server/server.go", symbolsFixture)
+	mustWriteFile(t, root, "broken.go", "package broken\n\nfunc f( {\n\t// TODO: inside broken code\n}\n")
+	mustWriteFile(t, root, "notes.txt", "TODO: not Go\n")
+
+	res, err := ScanDirDetailed(root, DefaultScanOptions(), OSFileReader{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	items, err := Enrich(res.Items, []Enricher{GoSymbolEnricher{Dir: root}})
+	if err != nil {
+		t.Fatalf("enrich: %v", err)
+	}
+	want := map[string]string{
+		"drop the legacy import":    "",
+		"make configurable":         "pkg-level const MaxRetries",
+		"in seconds":                "pkg-level const Timeout",
+		"too long":                  "pkg-level const Timeout",
+		"orphaned block comment":    "pkg-level const block",
+		"read from the environment": "pkg-level var config",
+		"unexport":                  "type Server",
+		"rate limit":                "(*Server).handleLogin",
+		"leaks on shutdown":         "(*Server).handleLogin",
+		"value receiver":            "Server.String",
+		"grow in chunks":            "(*List).Push",
+		"exits too early":           "main",
+		"inside broken code":        "",
+		"not Go":                    "",
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for _, it := range items {
+		sym, ok := want[it.Text]
+		if !ok {
+			t.Errorf("unexpected item %q", it.Text)
+			continue
+		}
+		if it.Symbol != sym {
+			t.Errorf("%s:%d %q: symbol = %q, want %q", it.File, it.Line, it.Text, it.Symbol, sym)
+		}
+	}
+}
+
+func TestGenerateHTMLReport_Symbol(t *testing.T) {
+	items := []Todo{{Location: Location{File: "server.go", Line: 3}, Tag: "TODO", Text: "rate limit", Symbol: "(*Server).handleLogin"}}
+	var buf bytes.Buffer
+	if err := GenerateHTMLReportWithWriter(items, "r.html", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `server.go<span class="symbol">(*Server).handleLogin</span>`) {
+		t.Fatalf("HTML report lacks the symbol under the file cell")
+	}
+}
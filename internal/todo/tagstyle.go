@@ -0,0 +1,34 @@
+package todo
+
+import "strings"
+
+// TagStyle describes how a tag is ranked and decorated in reports.
+type TagStyle struct {
+	// Severity orders tags; higher values are more urgent.
+	Severity int
+	// Emoji is used by badge-decorated Markdown output.
+	Emoji string
+	// Color is a shields.io color name used for summary badges.
+	Color string
+}
+
+// tagStyles is the registry of known tags. Unknown tags fall back to
+// defaultTagStyle so custom markers still render.
+var tagStyles = map[string]TagStyle{
+	TagConflict: {Severity: 5, Emoji: "⚔️", Color: "critical"},
+	TagDebug:    {Severity: 3, Emoji: "🔍", Color: "orange"},
+	"BUG":       {Severity: 4, Emoji: "🐛", Color: "critical"},
+	"FIXME":     {Severity: 3, Emoji: "🔧", Color: "orange"},
+	"TODO":      {Severity: 2, Emoji: "📝", Color: "yellow"},
+	"NOTE":      {Severity: 1, Emoji: "💡", Color: "informational"},
+}
+
+var defaultTagStyle = TagStyle{Severity: 0, Emoji: "🏷️", Color: "lightgrey"}
+
+// StyleFor returns the registered style for tag (case-insensitive).
+func StyleFor(tag string) TagStyle {
+	if s, ok := tagStyles[strings.ToUpper(tag)]; ok {
+		return s
+	}
+	return defaultTagStyle
+}
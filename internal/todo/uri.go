@@ -0,0 +1,188 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileURI returns the file:// URI of the absolute path p, percent-encoding
+// each path segment. Windows paths keep their drive letter, as in
+// file:///C:/src/main.go, and UNC paths \\server\share\... name the server
+// as the host, as in file://server/share/....
+func FileURI(p string) string {
+	host, slashed := uriPath(p)
+	return "file://" + url.PathEscape(host) + escapeSegments(slashed)
+}
+
+// uriPath splits p into the host and forward-slash path of its URI.
+func uriPath(p string) (host, slashed string) {
+	switch {
+	case isUNCPath(p):
+		rest := strings.TrimLeft(strings.ReplaceAll(p, `\`, "/"), "/")
+		host, slashed, _ = strings.Cut(rest, "/")
+		return host, "/" + slashed
+	case isDrivePath(p):
+		return "", "/" + strings.ReplaceAll(p, `\`, "/")
+	}
+	return "", p
+}
+
+// isDrivePath reports whether p starts with a Windows drive letter, as in
+// C:\ or C:/.
+func isDrivePath(p string) bool {
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	c := p[0] | 0x20
+	return c >= 'a' && c <= 'z' && (len(p) == 2 || p[2] == '\\' || p[2] == '/')
+}
+
+// isUNCPath reports whether p is a Windows UNC path, \\server\share\...,
+// or the same with forward slashes.
+func isUNCPath(p string) bool {
+	return len(p) > 2 && (strings.HasPrefix(p, `\\`) || strings.HasPrefix(p, "//")) && p[2] != '\\' && p[2] != '/'
+}
+
+// escapeSegments percent-encodes every segment of the slash-separated p.
+func escapeSegments(p string) string {
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// EditorScheme is a parsed editor link template, such as
+// "vscode://file/{path}:{line}" or "idea://open?file={path}&line={line}".
+type EditorScheme struct {
+	parts []editorPart
+}
+
+// editorPart is literal text or, when placeholder is set, the {path} or
+// {line} placeholder named by text.
+type editorPart struct {
+	text        string
+	placeholder bool
+	// query marks a placeholder in the query string, escaped as a query
+	// value rather than a path.
+	query bool
+}
+
+// editorSchemePrefix matches the URI scheme a template must start with.
+var editorSchemePrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+
+// scriptSchemes are URI schemes that run code or inline a document when a
+// link is followed, so they never make an editor link.
+var scriptSchemes = map[string]bool{"javascript": true, "vbscript": true, "data": true}
+
+// hasScriptScheme reports whether uri starts with one of scriptSchemes, in
+// any case and ignoring leading whitespace, as browsers do.
+func hasScriptScheme(uri string) bool {
+	scheme, _, ok := strings.Cut(strings.TrimSpace(uri), ":")
+	return ok && scriptSchemes[strings.ToLower(scheme)]
+}
+
+// ParseEditorScheme parses an editor link template. {path} is replaced by
+// the absolute path of an item, with forward slashes, and {line} by its line
+// number. The template must start with a URI scheme other than javascript:,
+// vbscript: or data:, and contain {path}.
+func ParseEditorScheme(tmpl string) (*EditorScheme, error) {
+	if !editorSchemePrefix.MatchString(tmpl) {
+		return nil, fmt.Errorf("template %q does not start with a URI scheme, e.g. vscode:", tmpl)
+	}
+	if hasScriptScheme(tmpl) {
+		return nil, fmt.Errorf("template %q uses a script scheme; use an editor scheme, e.g. vscode:", tmpl)
+	}
+	var s EditorScheme
+	var hasPath, inQuery bool
+	rest := tmpl
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			s.parts = append(s.parts, editorPart{text: rest})
+			break
+		}
+		offset := len(tmpl) - len(rest) + open
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("unexpected \"}\" at offset %d of %q", offset, tmpl)
+		}
+		if open > 0 {
+			s.parts = append(s.parts, editorPart{text: rest[:open]})
+			inQuery = inQuery || strings.Contains(rest[:open], "?")
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] != '}' {
+			return nil, fmt.Errorf("unclosed \"{\" at offset %d of %q", offset, tmpl)
+		}
+		name := rest[open+1 : open+1+end]
+		switch name {
+		case "path":
+			hasPath = true
+		case "line":
+		default:
+			return nil, fmt.Errorf("unknown placeholder {%s} in %q; use {path} and {line}", name, tmpl)
+		}
+		s.parts = append(s.parts, editorPart{text: name, placeholder: true, query: inQuery})
+		rest = rest[open+1+end+1:]
+	}
+	if !hasPath {
+		return nil, errors.New("template has no {path} placeholder")
+	}
+	return &s, nil
+}
+
+// URI returns the editor link to line of the file at the absolute path p.
+// In the path of the link, a "/" right before {path} stands for the leading
+// slash of p, so "vscode://file/{path}" opens vscode://file/home/me/main.go.
+func (s *EditorScheme) URI(p string, line int) string {
+	slashed := p
+	if isDrivePath(p) || isUNCPath(p) {
+		slashed = strings.ReplaceAll(p, `\`, "/")
+	}
+	var b strings.Builder
+	for _, part := range s.parts {
+		switch {
+		case !part.placeholder:
+			b.WriteString(part.text)
+		case part.text == "line":
+			b.WriteString(strconv.Itoa(line))
+		case part.query:
+			// Slashes are valid in a query and keep the path readable.
+			b.WriteString(strings.ReplaceAll(url.QueryEscape(slashed), "%2F", "/"))
+		default:
+			v := slashed
+			if strings.HasSuffix(b.String(), "/") {
+				v = strings.TrimPrefix(v, "/")
+			}
+			b.WriteString(escapeSegments(v))
+		}
+	}
+	return b.String()
+}
+
+// ItemLinks adds file and editor links to items, for JSON consumers and
+// local HTML reports.
+type ItemLinks struct {
+	// Root is the absolute directory item paths are relative to. No links
+	// are added when it is empty.
+	Root string
+	// Editor, when non-nil, also sets Todo.EditorURI.
+	Editor *EditorScheme
+}
+
+// Apply sets the URI and, with an editor scheme, the EditorURI of t.
+func (l ItemLinks) Apply(t *Todo) {
+	if l.Root == "" {
+		return
+	}
+	p := filepath.Join(l.Root, filepath.FromSlash(t.File))
+	t.URI = FileURI(p)
+	if l.Editor != nil {
+		t.EditorURI = l.Editor.URI(p, t.Line)
+	}
+}
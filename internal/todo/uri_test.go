@@ -0,0 +1,141 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileURI(t *testing.T) {
+	cases := []struct{ path, want string }{
+		{"/home/me/src/main.go", "file:///home/me/src/main.go"},
+		{"/home/me/my project/main.go", "file:///home/me/my%20project/main.go"},
+		{"/tmp/issue#12/50%/a.go", "file:///tmp/issue%2312/50%25/a.go"},
+		{"/srv/café/naïve.go", "file:///srv/caf%C3%A9/na%C3%AFve.go"},
+		{"/tmp/a?b.go", "file:///tmp/a%3Fb.go"},
+		{`C:\Users\me\src\main.go`, "file:///C:/Users/me/src/main.go"},
+		{"c:/Program Files/x.go", "file:///c:/Program%20Files/x.go"},
+		{`\\server\share\dir\a b.go`, "file://server/share/dir/a%20b.go"},
+		{"//server/share/x.go", "file://server/share/x.go"},
+	}
+	for _, c := range cases {
+		if got := FileURI(c.path); got != c.want {
+			t.Errorf("FileURI(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestEditorScheme_URI(t *testing.T) {
+	cases := []struct {
+		tmpl, path string
+		want       string
+	}{
+		{"vscode://file/{path}:{line}", "/home/me/main.go", "vscode://file/home/me/main.go:12"},
+		{"vscode://file/{path}:{line}", "/home/me/my dir/#1.go", "vscode://file/home/me/my%20dir/%231.go:12"},
+		{"vscode://file/{path}:{line}", `C:\src\main.go`, "vscode://file/C:/src/main.go:12"},
+		{"idea://open?file={path}&line={line}", "/home/me/a b&c.go", "idea://open?file=/home/me/a+b%26c.go&line=12"},
+		{"idea://open?file={path}&line={line}", `C:\src\100%.go`, "idea://open?file=C%3A/src/100%25.go&line=12"},
+		{"subl://open?url=file://{path}&line={line}", "/x.go", "subl://open?url=file:///x.go&line=12"},
+		{"editor:{path}", `\\server\share\x.go`, "editor://server/share/x.go"},
+	}
+	for _, c := range cases {
+		s, err := ParseEditorScheme(c.tmpl)
+		if err != nil {
+			t.Fatalf("ParseEditorScheme(%q): %v", c.tmpl, err)
+		}
+		if got := s.URI(c.path, 12); got != c.want {
+			t.Errorf("%q with %q = %q, want %q", c.tmpl, c.path, got, c.want)
+		}
+	}
+}
+
+func TestParseEditorScheme_Malformed(t *testing.T) {
+	cases := map[string]string{
+		"":                            "does not start with a URI scheme",
+		"{path}:{line}":               "does not start with a URI scheme",
+		"vscode://file/{path":         `unclosed "{" at offset 14`,
+		"vscode://file/{pa{th}":       `unclosed "{" at offset 14`,
+		"vscode://file/{path}}":       `unexpected "}" at offset 20`,
+		"vscode://file/{file}:{line}": "unknown placeholder {file}",
+		"vscode://file/{}":            "unknown placeholder {}",
+		"vscode://open?line={line}":   "no {path} placeholder",
+		"javascript:alert(1)//{path}": "uses a script scheme",
+		"JavaScript:alert(1)//{path}": "uses a script scheme",
+		"vbscript:msgbox//{path}":     "uses a script scheme",
+		"data:text/html,{path}":       "uses a script scheme",
+	}
+	for tmpl, want := range cases {
+		_, err := ParseEditorScheme(tmpl)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseEditorScheme(%q) error = %v, want %q", tmpl, err, want)
+		}
+	}
+}
+
+func TestReports_ItemLinks(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "my repo")
+	editor, err := ParseEditorScheme("vscode://file/{path}:{line}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []Todo{{Location: Location{File: "pkg/a.go", Line: 3}, Tag: "TODO", Text: "link me"}}
+	opts := ReportOptions{Links: ItemLinks{Root: root, Editor: editor}, RelativeRoot: true}
+	abs := filepath.Join(root, "pkg", "a.go")
+	wantURI, wantEditor := FileURI(abs), editor.URI(abs, 3)
+	if !strings.Contains(wantURI, "my%20repo/pkg/a.go") {
+		t.Fatalf("uri = %s", wantURI)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Todos []struct {
+			File      string
+			URI       string `json:"uri"`
+			EditorURI string `json:"editorUri"`
+		} `json:"todos"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Todos) != 1 || doc.Todos[0].File != "a.go" || doc.Todos[0].URI != wantURI || doc.Todos[0].EditorURI != wantEditor {
+		t.Errorf("json items = %+v, want links to the untrimmed path", doc.Todos)
+	}
+
+	buf.Reset()
+	if err := GenerateJSONLReportWithWriter(items, "r.jsonl", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	first, _, _ := strings.Cut(buf.String(), "\n")
+	if !strings.Contains(first, `"uri":"`+wantURI+`"`) || !strings.Contains(first, `"editorUri":"`+wantEditor+`"`) {
+		t.Errorf("jsonl record = %s", first)
+	}
+
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(items, "r.html", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<a href="`+wantEditor+`">a.go</a>`) {
+		t.Errorf("HTML file cell does not link to the editor:\n%s", buf.String())
+	}
+	opts.Links.Editor = nil
+	buf.Reset()
+	if err := GenerateHTMLReportWithWriter(items, "r.html", jsonMockFileWriter{buf: &buf}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<a href="`+wantURI+`">a.go</a>`) {
+		t.Errorf("HTML file cell does not link to the file:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := GenerateJSONReportWithWriter(items, "r.json", jsonMockFileWriter{buf: &buf}, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `"uri"`) {
+		t.Errorf("links without a root:\n%s", buf.String())
+	}
+}
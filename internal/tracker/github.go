@@ -0,0 +1,175 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for the GitHub provider.
+const (
+	DefaultGitHubURL  = "https://api.github.com"
+	DefaultMaxRetries = 3
+	// maxRetryWait caps how long a rate-limited request waits before retrying.
+	maxRetryWait = time.Minute
+)
+
+// GitHub resolves references against GitHub Issues through the REST API.
+// Pull request numbers resolve too, since GitHub serves them as issues.
+type GitHub struct {
+	// Repo is the "owner/name" used for local references like "#12".
+	Repo string
+	// Token authenticates requests when set.
+	Token string
+	// BaseURL is the API root (DefaultGitHubURL if empty).
+	BaseURL string
+	// Client sends requests (http.DefaultClient if nil).
+	Client *http.Client
+	// MaxRetries bounds retries of rate-limited and failed requests
+	// (DefaultMaxRetries if <= 0).
+	MaxRetries int
+	// Sleep waits between retries, returning early when ctx is done
+	// (a timer-based wait if nil). Tests replace it to avoid real delays.
+	Sleep func(ctx context.Context, d time.Duration)
+}
+
+// Lookup implements Provider.
+func (g *GitHub) Lookup(ctx context.Context, ref string) (Ticket, error) {
+	repo, num, ok := g.split(ref)
+	if !ok {
+		return Ticket{}, fmt.Errorf("%w: %s", ErrUnsupportedRef, ref)
+	}
+	base := strings.TrimRight(g.BaseURL, "/")
+	if base == "" {
+		base = DefaultGitHubURL
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%s", base, repo, num)
+
+	retries := g.MaxRetries
+	if retries <= 0 {
+		retries = DefaultMaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		tk, wait, err := g.fetch(ctx, url)
+		if wait == 0 || attempt >= retries {
+			if err == nil {
+				tk.Ref = ref
+			}
+			return tk, err
+		}
+		g.sleep(ctx, wait)
+		if ctx.Err() != nil {
+			return Ticket{}, ctx.Err()
+		}
+	}
+}
+
+// split resolves ref to a repository and issue number.
+func (g *GitHub) split(ref string) (repo, num string, ok bool) {
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return "", "", false
+	}
+	repo, num = ref[:i], ref[i+1:]
+	if repo == "" {
+		repo = g.Repo
+	}
+	if repo == "" || !strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	if _, err := strconv.Atoi(num); err != nil {
+		return "", "", false
+	}
+	return repo, num, true
+}
+
+// fetch performs one request. A non-zero wait asks the caller to retry
+// after that long.
+func (g *GitHub) fetch(ctx context.Context, url string) (Ticket, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Ticket{}, 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return Ticket{}, 0, ctx.Err()
+		}
+		return Ticket{}, time.Second, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		var body struct {
+			State   string `json:"state"`
+			Title   string `json:"title"`
+			HTMLURL string `json:"html_url"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return Ticket{}, 0, fmt.Errorf("decode %s: %w", url, err)
+		}
+		return Ticket{State: body.State, Title: body.Title, URL: body.HTMLURL}, 0, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return Ticket{}, 0, ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden:
+		err := fmt.Errorf("GET %s: %s", url, resp.Status)
+		if wait, limited := rateLimitWait(resp.Header, time.Now()); limited {
+			return Ticket{}, wait, err
+		}
+		return Ticket{}, 0, err
+	case resp.StatusCode >= 500:
+		return Ticket{}, time.Second, fmt.Errorf("GET %s: %s", url, resp.Status)
+	default:
+		return Ticket{}, 0, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+}
+
+// rateLimitWait reads GitHub's rate-limit headers and reports how long to
+// wait before retrying, capped at maxRetryWait.
+func rateLimitWait(h http.Header, now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+	limited := false
+	if s := h.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil {
+			wait, limited = time.Duration(secs)*time.Second, true
+		}
+	} else if h.Get("X-RateLimit-Remaining") == "0" {
+		limited = true
+		if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait = time.Unix(reset, 0).Sub(now)
+		}
+	}
+	if !limited {
+		return 0, false
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return min(wait, maxRetryWait), true
+}
+
+func (g *GitHub) sleep(ctx context.Context, d time.Duration) {
+	if g.Sleep != nil {
+		g.Sleep(ctx, d)
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
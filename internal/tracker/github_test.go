@@ -0,0 +1,110 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cannedIssues serves canned issue responses for acme/app.
+func cannedIssues(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch r.URL.Path {
+		case "/repos/acme/app/issues/1":
+			_, _ = w.Write([]byte(`{"state":"open","title":"Still going","html_url":"https://github.com/acme/app/issues/1"}`))
+		case "/repos/acme/app/issues/2", "/repos/other/lib/issues/5":
+			_, _ = w.Write([]byte(`{"state":"closed","title":"Done","html_url":"https://github.com/acme/app/issues/2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestGitHub_Lookup_OpenClosedMissing(t *testing.T) {
+	srv, _ := cannedIssues(t)
+	gh := &GitHub{Repo: "acme/app", Token: "secret", BaseURL: srv.URL}
+	ctx := context.Background()
+
+	if tk, err := gh.Lookup(ctx, "#1"); err != nil || tk.State != StateOpen || tk.Ref != "#1" {
+		t.Fatalf("#1 = %+v, %v", tk, err)
+	}
+	if tk, err := gh.Lookup(ctx, "#2"); err != nil || tk.State != StateClosed || tk.Title != "Done" {
+		t.Fatalf("#2 = %+v, %v", tk, err)
+	}
+	if tk, err := gh.Lookup(ctx, "other/lib#5"); err != nil || tk.State != StateClosed {
+		t.Fatalf("other/lib#5 = %+v, %v", tk, err)
+	}
+	if _, err := gh.Lookup(ctx, "#404"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("#404 err = %v, want ErrNotFound", err)
+	}
+	if _, err := gh.Lookup(ctx, "PROJ-9"); !errors.Is(err, ErrUnsupportedRef) {
+		t.Fatalf("PROJ-9 err = %v, want ErrUnsupportedRef", err)
+	}
+	if _, err := (&GitHub{BaseURL: srv.URL}).Lookup(ctx, "#1"); !errors.Is(err, ErrUnsupportedRef) {
+		t.Fatalf("bare ref without --repo err = %v, want ErrUnsupportedRef", err)
+	}
+}
+
+func TestGitHub_Lookup_RetriesWhenRateLimited(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"state":"closed"}`))
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	gh := &GitHub{Repo: "acme/app", BaseURL: srv.URL, Sleep: func(_ context.Context, d time.Duration) { waits = append(waits, d) }}
+	tk, err := gh.Lookup(context.Background(), "#3")
+	if err != nil || tk.State != StateClosed {
+		t.Fatalf("lookup = %+v, %v", tk, err)
+	}
+	if calls.Load() != 3 || len(waits) != 2 {
+		t.Fatalf("calls = %d, waits = %v", calls.Load(), waits)
+	}
+
+	// Retries are bounded; the last error is returned.
+	calls.Store(-100)
+	gh.MaxRetries = 1
+	if _, err := gh.Lookup(context.Background(), "#3"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected rate limit error after retries, got %v", err)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+	h := http.Header{}
+	if _, limited := rateLimitWait(h, now); limited {
+		t.Fatalf("plain 403 should not be treated as rate limited")
+	}
+	h.Set("Retry-After", "7")
+	if d, _ := rateLimitWait(h, now); d != 7*time.Second {
+		t.Fatalf("Retry-After wait = %v", d)
+	}
+	h = http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1030"}}
+	if d, _ := rateLimitWait(h, now); d != 30*time.Second {
+		t.Fatalf("reset wait = %v", d)
+	}
+	h.Set("X-RateLimit-Reset", "999999")
+	if d, _ := rateLimitWait(h, now); d != maxRetryWait {
+		t.Fatalf("wait should be capped, got %v", d)
+	}
+}
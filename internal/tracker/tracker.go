@@ -0,0 +1,119 @@
+// Package tracker looks up the tickets referenced by todo items in an issue
+// tracker, to find items whose ticket is already closed.
+package tracker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// Ticket states reported by providers.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// ErrNotFound is returned by providers for tickets that do not exist.
+var ErrNotFound = errors.New("ticket not found")
+
+// ErrUnsupportedRef is returned by providers for references they cannot
+// resolve, such as a Jira key given to the GitHub provider.
+var ErrUnsupportedRef = errors.New("reference not supported by provider")
+
+// Ticket is the tracker's view of a referenced ticket.
+type Ticket struct {
+	Ref   string `json:"ref"`
+	State string `json:"state"`
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Provider resolves ticket references against an issue tracker.
+type Provider interface {
+	// Lookup fetches the ticket for a canonical reference (see
+	// todo.ExtractRefs).
+	Lookup(ctx context.Context, ref string) (Ticket, error)
+}
+
+// Group is a ticket together with every item referencing it.
+type Group struct {
+	Ticket    Ticket          `json:"ticket"`
+	Locations []todo.Location `json:"locations"`
+	// Reason explains why an unverifiable ticket could not be checked.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Result is the outcome of a cross-check.
+type Result struct {
+	// Closed lists tickets that are closed while items still reference them.
+	Closed []Group `json:"closed"`
+	// Unverifiable lists references that could not be fetched.
+	Unverifiable []Group `json:"unverifiable"`
+	// Open is the number of referenced tickets still open.
+	Open int `json:"open"`
+}
+
+// DefaultBatchSize bounds the number of lookups in flight at once.
+const DefaultBatchSize = 8
+
+// CrossCheck looks up every distinct reference in items with p, at most
+// batch (DefaultBatchSize if <= 0) at a time, and groups the item locations
+// by ticket. A failed lookup marks the ticket unverifiable rather than
+// failing the run; only cancellation of ctx returns an error.
+func CrossCheck(ctx context.Context, items []todo.Todo, p Provider, batch int) (Result, error) {
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	locations := make(map[string][]todo.Location)
+	var refs []string
+	for _, t := range items {
+		for _, ref := range t.Refs {
+			if _, ok := locations[ref]; !ok {
+				refs = append(refs, ref)
+			}
+			locations[ref] = append(locations[ref], t.Location)
+		}
+	}
+	sort.Strings(refs)
+
+	type lookup struct {
+		ticket Ticket
+		err    error
+	}
+	results := make([]lookup, len(refs))
+	for start := 0; start < len(refs); start += batch {
+		end := min(start+batch, len(refs))
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tk, err := p.Lookup(ctx, refs[i])
+				results[i] = lookup{ticket: tk, err: err}
+			}(i)
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	res := Result{Closed: []Group{}, Unverifiable: []Group{}}
+	for i, ref := range refs {
+		r := results[i]
+		switch {
+		case r.err != nil:
+			res.Unverifiable = append(res.Unverifiable, Group{Ticket: Ticket{Ref: ref}, Locations: locations[ref], Reason: r.err.Error()})
+		case r.ticket.State == StateClosed:
+			r.ticket.Ref = ref
+			res.Closed = append(res.Closed, Group{Ticket: r.ticket, Locations: locations[ref]})
+		default:
+			res.Open++
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,73 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// countingProvider answers from a fixed state map and counts lookups per ref.
+type countingProvider struct {
+	mu     sync.Mutex
+	states map[string]string
+	calls  map[string]int
+}
+
+func (p *countingProvider) Lookup(_ context.Context, ref string) (Ticket, error) {
+	p.mu.Lock()
+	p.calls[ref]++
+	p.mu.Unlock()
+	state, ok := p.states[ref]
+	if !ok {
+		return Ticket{}, ErrNotFound
+	}
+	return Ticket{Ref: ref, State: state}, nil
+}
+
+func TestCrossCheck_DeduplicatesRefsAndGroups(t *testing.T) {
+	var items []todo.Todo
+	for i := 0; i < 50; i++ {
+		items = append(items, todo.Todo{Location: todo.Location{File: fmt.Sprintf("f%02d.go", i), Line: i + 1}, Tag: "TODO", Refs: []string{"#1"}})
+	}
+	items = append(items,
+		todo.Todo{Location: todo.Location{File: "a.go", Line: 3}, Tag: "FIXME", Refs: []string{"#2", "#1"}},
+		todo.Todo{Location: todo.Location{File: "b.go", Line: 4}, Tag: "TODO", Refs: []string{"#9"}},
+		todo.Todo{Location: todo.Location{File: "c.go", Line: 5}, Tag: "TODO"},
+	)
+	p := &countingProvider{states: map[string]string{"#1": StateClosed, "#2": StateOpen}, calls: map[string]int{}}
+
+	res, err := CrossCheck(context.Background(), items, p, 2)
+	if err != nil {
+		t.Fatalf("crosscheck: %v", err)
+	}
+	for ref, n := range p.calls {
+		if n != 1 {
+			t.Errorf("%s looked up %d times, want once", ref, n)
+		}
+	}
+	if len(p.calls) != 3 {
+		t.Fatalf("lookups = %v, want #1, #2 and #9", p.calls)
+	}
+	if len(res.Closed) != 1 || res.Closed[0].Ticket.Ref != "#1" || len(res.Closed[0].Locations) != 51 {
+		t.Fatalf("unexpected closed groups: %+v", res.Closed)
+	}
+	if res.Open != 1 {
+		t.Fatalf("open = %d, want 1", res.Open)
+	}
+	if len(res.Unverifiable) != 1 || res.Unverifiable[0].Ticket.Ref != "#9" || res.Unverifiable[0].Reason != ErrNotFound.Error() {
+		t.Fatalf("unexpected unverifiable: %+v", res.Unverifiable)
+	}
+}
+
+func TestCrossCheck_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &countingProvider{states: map[string]string{}, calls: map[string]int{}}
+	items := []todo.Todo{{Location: todo.Location{File: "a.go", Line: 1}, Refs: []string{"#1"}}}
+	if _, err := CrossCheck(ctx, items, p, 0); err == nil {
+		t.Fatalf("expected context error")
+	}
+}
@@ -0,0 +1,102 @@
+package todototum_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/valerioTomassi/todototum/pkg/todototum"
+)
+
+func ExampleScanString() {
+	items, err := todototum.ScanString("main.go", "package main\n\n// TODO: handle errors\nfunc main() {} // FIXME: exit code")
+	if err != nil {
+		panic(err)
+	}
+	for _, t := range items {
+		fmt.Printf("%s:%d %s %s\n", t.File, t.Line, t.Tag, t.Text)
+	}
+	// Output:
+	// main.go:3 TODO handle errors
+	// main.go:4 FIXME exit code
+}
+
+func ExampleScanner_ScanReader() {
+	opts := todototum.DefaultScanOptions()
+	opts.Tags = []string{"HACK"}
+	s, err := todototum.NewScanner(opts)
+	if err != nil {
+		panic(err)
+	}
+	items, err := s.ScanReader("setup.py", strings.NewReader("# HACK: pin the old API\n# TODO: not matched\n"))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(items), items[0].Tag, items[0].Text)
+	// Output: 1 HACK pin the old API
+}
+
+// riskEnricher rates FIXMEs as high risk.
+type riskEnricher struct{}
+
+func (riskEnricher) Enrich(items []todototum.Todo) ([]todototum.Todo, error) {
+	for i := range items {
+		if items[i].Tag == "FIXME" {
+			items[i].Extra = map[string]string{"risk": "high"}
+		}
+	}
+	return items, nil
+}
+
+func ExampleEnrich() {
+	items, err := todototum.ScanString("main.go", "// TODO: docs\n// FIXME: race on close\n")
+	if err != nil {
+		panic(err)
+	}
+	items, err = todototum.Enrich(items, []todototum.Enricher{riskEnricher{}})
+	if err != nil {
+		panic(err)
+	}
+	for _, t := range items {
+		fmt.Printf("%s %q\n", t.Tag, t.Extra["risk"])
+	}
+	// Output:
+	// TODO ""
+	// FIXME "high"
+}
+
+// stdoutWriter writes every report to standard output.
+type stdoutWriter struct{}
+
+func (stdoutWriter) Create(string) (io.WriteCloser, error) { return nopCloser{os.Stdout}, nil }
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// Formats are registered once, from an init function, before any report is
+// generated.
+func init() {
+	todototum.RegisterReporter("csv", func(data todototum.ReportData, w io.Writer) error {
+		for _, t := range data.Todos {
+			if _, err := fmt.Fprintf(w, "%s,%d,%s\n", t.File, t.Line, t.Tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func ExampleRegisterReporter() {
+	items, err := todototum.ScanString("main.go", "// TODO: docs\n// FIXME: race on close\n")
+	if err != nil {
+		panic(err)
+	}
+	if err := todototum.GenerateReport("csv", items, "todos.csv", stdoutWriter{}, todototum.ReportOptions{}); err != nil {
+		panic(err)
+	}
+	// Output:
+	// main.go,1,TODO
+	// main.go,2,FIXME
+}
@@ -0,0 +1,47 @@
+package todototum_test
+
+import (
+	"testing"
+
+	"github.com/valerioTomassi/todototum/pkg/todototum"
+)
+
+func TestMatchLine(t *testing.T) {
+	m, ok := todototum.MatchLine("// FIXME: x", todototum.WithLintRules(todototum.LintRules{MinTextLength: 3}))
+	if !ok || m.Tag != "FIXME" || len(m.Findings) != 1 {
+		t.Fatalf("got %+v, %v", m, ok)
+	}
+	if m, ok := todototum.MatchLine("return nil"); ok || m != nil {
+		t.Fatalf("matched plain code: %+v", m)
+	}
+
+	opts := todototum.DefaultScanOptions()
+	opts.Tags = []string{"HACK"}
+	s, err := todototum.NewScanner(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := todototum.MatchLine("// TODO: x", todototum.WithScanner(s)); ok {
+		t.Fatal("custom scanner matched a default tag")
+	}
+	if m, ok := todototum.MatchLine("# HACK: y", todototum.WithScanner(s)); !ok || m.Tag != "HACK" || m.Findings != nil {
+		t.Fatalf("got %+v, %v", m, ok)
+	}
+}
+
+func BenchmarkMatchLine_NoMatch(b *testing.B) {
+	line := "\tif err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {"
+	b.ReportAllocs()
+	for b.Loop() {
+		todototum.MatchLine(line)
+	}
+}
+
+func BenchmarkMatchLine_Match(b *testing.B) {
+	line := "\treturn nil // TODO(alice): handle the PROJ-42 retry case"
+	lint := todototum.WithLintRules(todototum.LintRules{MinTextLength: 10})
+	b.ReportAllocs()
+	for b.Loop() {
+		todototum.MatchLine(line, lint)
+	}
+}
@@ -0,0 +1,208 @@
+// Package todototum is the public Go API of todototum: it scans directories
+// or in-memory content for TODO, FIXME, BUG and NOTE markers.
+//
+// The types are aliases of the implementation used by the CLI, so programs
+// embedding todototum get exactly the items the command reports.
+package todototum
+
+import (
+	"io"
+	"time"
+
+	"github.com/valerioTomassi/todototum/internal/todo"
+)
+
+// Todo is one matched marker.
+type Todo = todo.Todo
+
+// Location is the file, line and column embedded in a Todo.
+type Location = todo.Location
+
+// ScanOptions tunes a scan. Start from DefaultScanOptions.
+type ScanOptions = todo.ScanOptions
+
+// Scanner matches items in content with a fixed set of options.
+type Scanner = todo.Scanner
+
+// Override changes matching settings below one directory of a scan; see
+// ScanOptions.DirOverride.
+type Override = todo.Override
+
+// FilterRule drops items by text or path pattern; see ParseFilterRule.
+type FilterRule = todo.FilterRule
+
+// ReportOptions tunes report generation; the zero value gives the default
+// report.
+type ReportOptions = todo.ReportOptions
+
+// ReportData is what a report is built from: the items, their summary and
+// statistics.
+type ReportData = todo.ReportData
+
+// Reporter renders a report of ReportData; see RegisterReporter.
+type Reporter = todo.Reporter
+
+// Enricher attaches metadata to items before reports are built, e.g. fields
+// of Todo.Extra shown through ReportOptions.ExtraColumns.
+type Enricher = todo.Enricher
+
+// FileWriter creates report files; OSFileWriter writes to disk.
+type FileWriter = todo.FileWriter
+
+// OSFileWriter is the FileWriter backed by the local filesystem.
+type OSFileWriter = todo.OSFileWriter
+
+// Match is a marker found on a single line; see MatchLine.
+type Match = todo.Match
+
+// LintRules are description checks run by MatchLine with WithLintRules.
+type LintRules = todo.LintRules
+
+// LintFinding is a lint rule a matched item breaks.
+type LintFinding = todo.LintFinding
+
+// CompressingFileWriter wraps a FileWriter so every report written through
+// it is compressed, e.g. CompressingFileWriter{Compression: CompressGzip}.
+type CompressingFileWriter = todo.CompressingFileWriter
+
+// CompressGzip is the gzip compression of CompressingFileWriter.
+const CompressGzip = todo.CompressGzip
+
+// DefaultScanOptions returns the options the CLI uses by default.
+func DefaultScanOptions() ScanOptions { return todo.DefaultScanOptions() }
+
+// NewScanner returns a Scanner for opts.
+func NewScanner(opts ScanOptions) (*Scanner, error) { return todo.NewScanner(opts) }
+
+// ScanReader scans r with the default options, labeling items with name.
+func ScanReader(name string, r io.Reader) ([]Todo, error) { return todo.ScanReader(name, r) }
+
+// ScanString scans content with the default options, labeling items with name.
+func ScanString(name, content string) ([]Todo, error) { return todo.ScanString(name, content) }
+
+// ScanDir scans the directory tree at root with opts.
+func ScanDir(root string, opts ScanOptions) ([]Todo, error) {
+	return todo.ScanDirWithOptions(root, opts, todo.OSFileReader{})
+}
+
+// ScanResult is the outcome of a directory scan: items, stats and the files
+// that could not be read.
+type ScanResult = todo.ScanResult
+
+// ScanSession caches the scan of a directory tree for callers that need it
+// repeatedly, e.g. an HTTP handler; see NewScanSession.
+type ScanSession = todo.ScanSession
+
+// NewScanSession returns a session scanning root with opts. Its Get rescans
+// only when files under root were added, removed or modified since the last
+// scan, and concurrent calls share one scan.
+func NewScanSession(root string, opts ScanOptions) *ScanSession {
+	return todo.NewScanSession(root, opts, todo.OSFileReader{})
+}
+
+// CycleLine summarizes a rescan in one line prefixed with the time since
+// the session started, e.g. "[+12m5s] re-scanned 1204 files in 120ms, 42 todos".
+func CycleLine(res ScanResult, sinceStart time.Duration) string {
+	return todo.CycleLine(res, sinceStart)
+}
+
+// ParseFilterRule compiles a filter pattern; a "path:" prefix matches file
+// paths instead of item text.
+func ParseFilterRule(s string) (FilterRule, error) { return todo.ParseFilterRule(s) }
+
+// FilterItems returns the items matching none of rules and the number dropped.
+func FilterItems(items []Todo, rules []FilterRule) ([]Todo, int) {
+	return todo.FilterItems(items, rules)
+}
+
+// Fingerprint returns the stable ID reports give t: a hash of its file, tag
+// and text that ignores the line number.
+func Fingerprint(t Todo) string { return todo.Fingerprint(t) }
+
+// SanitizeText replaces invalid UTF-8 and removes ANSI escape sequences and
+// control characters other than tab, as scans do for captured text.
+func SanitizeText(s string) string { return todo.SanitizeText(s) }
+
+// Enrich runs enrichers over items in order. An error names the enricher
+// that failed.
+func Enrich(items []Todo, enrichers []Enricher) ([]Todo, error) {
+	return todo.Enrich(items, enrichers)
+}
+
+// GenerateHTMLReport writes an HTML report of items to output through w.
+func GenerateHTMLReport(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return todo.GenerateHTMLReportWithWriter(items, output, w, opts)
+}
+
+// GenerateJSONReport writes a JSON report of items to output through w.
+func GenerateJSONReport(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return todo.GenerateJSONReportWithWriter(items, output, w, opts)
+}
+
+// GenerateMarkdownReport writes a Markdown report of items to output through w.
+func GenerateMarkdownReport(items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return todo.GenerateMarkdownReportWithWriter(items, output, w, opts)
+}
+
+// RegisterReporter adds the report format name, rendered by fn. GenerateReport
+// and the todototum command's --report flag then accept it alongside the
+// built-in formats, which register the same way. Call it from an init
+// function; it panics when name is empty or taken, or fn is nil.
+func RegisterReporter(name string, fn Reporter) { todo.RegisterReporter(name, fn) }
+
+// Reporters returns the names of the registered report formats, sorted.
+func Reporters() []string { return todo.Reporters() }
+
+// GenerateReport writes the report of a registered format of items to
+// output through w.
+func GenerateReport(format string, items []Todo, output string, w FileWriter, opts ReportOptions) error {
+	return todo.GenerateReportWithWriter(format, items, output, w, opts)
+}
+
+// defaultScanner matches lines for MatchLine when no WithScanner option is
+// given. The default options are always valid.
+var defaultScanner, _ = todo.NewScanner(todo.DefaultScanOptions())
+
+// Option configures MatchLine.
+type Option func(*matchConfig)
+
+type matchConfig struct {
+	scanner *Scanner
+	lint    *LintRules
+}
+
+// WithScanner matches with s, e.g. one built for custom tags, instead of a
+// Scanner with DefaultScanOptions.
+func WithScanner(s *Scanner) Option {
+	return func(c *matchConfig) { c.scanner = s }
+}
+
+// WithLintRules checks the description of a match against r and records
+// the rules it breaks in Match.Findings.
+func WithLintRules(r LintRules) Option {
+	return func(c *matchConfig) { c.lint = &r }
+}
+
+// MatchLine reports whether line holds a marker, and which, exactly as a
+// scan would find it. It does no I/O, so editors can call it on every
+// keystroke. See Scanner.MatchLine.
+func MatchLine(line string, opts ...Option) (*Match, bool) {
+	s, lint := defaultScanner, (*LintRules)(nil)
+	if len(opts) > 0 {
+		c := matchConfig{scanner: s}
+		for _, o := range opts {
+			o(&c)
+		}
+		s, lint = c.scanner, c.lint
+	}
+	m, ok := s.MatchLine(line)
+	if !ok {
+		return nil, false
+	}
+	if lint != nil {
+		m.Findings = lint.Check(m.Text)
+	}
+	out := new(Match)
+	*out = m
+	return out, true
+}